@@ -0,0 +1,124 @@
+package agents
+
+import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
+	"context"
+	"core/ai"
+	"encoding/base64"
+	"model"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// buildUserMessage 构建本次的用户消息。没有附带图片时是普通文本消息；
+// 主模型支持图片时把图片放在多模态消息中，否则先由图像模型描述图片，把描述附加到文本中
+func (s *Service) buildUserMessage(ctx context.Context, agent *model.Agent, message string, inputs promptInputs) (*schema.Message, error) {
+	if len(inputs.AttachmentIDs) == 0 {
+		return schema.UserMessage(message), nil
+	}
+	images, err := s.loadImages(inputs.UserID, inputs.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	trigger, err := event.Trigger("getVisionModel", &shared.GetVisionModelRequest{
+		UserID:    agent.CreatorID,
+		Provider:  agent.ModelProvider,
+		ModelName: agent.ModelName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	visionModel := trigger.(*shared.VisionModel)
+	if visionModel.Native {
+		return imageMessage(message, images), nil
+	}
+	description, err := s.describeImages(ctx, visionModel, message, images)
+	if err != nil {
+		return nil, err
+	}
+	return schema.UserMessage(ai.BuildImageContextPrompt(message, len(images), description)), nil
+}
+
+// loadImages 按请求中的顺序读取图片，不存在或不属于该用户的附件返回错误
+func (s *Service) loadImages(userID uuid.UUID, ids []uuid.UUID) ([]*model.Attachment, error) {
+	if len(ids) > configs.GetConfig().Attachment.GetMaxImages() {
+		return nil, biz.ErrAttachmentLimit
+	}
+	if userID == uuid.Nil {
+		return nil, biz.ErrAttachmentNotFound
+	}
+	trigger, err := event.Trigger("getAttachmentsByIds", &shared.GetAttachmentsRequest{
+		UserID: userID,
+		IDs:    ids,
+	})
+	if err != nil {
+		logs.Errorf("getAttachmentsByIds error: %v", err)
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*model.Attachment)
+	for _, attachment := range trigger.([]*model.Attachment) {
+		byID[attachment.ID] = attachment
+	}
+	images := make([]*model.Attachment, 0, len(ids))
+	for _, id := range ids {
+		attachment, ok := byID[id]
+		if !ok {
+			return nil, biz.ErrAttachmentNotFound
+		}
+		if attachment.Kind != model.AttachmentImage {
+			return nil, biz.ErrAttachmentInvalid
+		}
+		images = append(images, attachment)
+	}
+	return images, nil
+}
+
+// imageMessage 文本和图片组成的多模态用户消息。
+// 图片使用 base64 内容而不是地址，Ollama 只支持 base64，附件的地址也需要登录才能访问
+func imageMessage(message string, images []*model.Attachment) *schema.Message {
+	parts := make([]schema.MessageInputPart, 0, len(images)+1)
+	if message != "" {
+		parts = append(parts, schema.MessageInputPart{
+			Type: schema.ChatMessagePartTypeText,
+			Text: message,
+		})
+	}
+	for _, image := range images {
+		data := base64.StdEncoding.EncodeToString(image.Data)
+		parts = append(parts, schema.MessageInputPart{
+			Type: schema.ChatMessagePartTypeImageURL,
+			Image: &schema.MessageInputImage{
+				MessagePartCommon: schema.MessagePartCommon{
+					Base64Data: &data,
+					MIMEType:   image.ContentType,
+				},
+			},
+		})
+	}
+	return &schema.Message{
+		Role:                  schema.User,
+		UserInputMultiContent: parts,
+	}
+}
+
+// describeImages 用图像模型描述图片，供不支持图片的主模型使用
+func (s *Service) describeImages(ctx context.Context, visionModel *shared.VisionModel, message string, images []*model.Attachment) (string, error) {
+	chatModel, err := shared.BuildChatModel(ctx, visionModel.ProviderConfig, visionModel.ModelName, nil)
+	if err != nil {
+		return "", err
+	}
+	msg, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(ai.ImageDescriptionPrompt),
+		imageMessage("用户的问题："+message, images),
+	})
+	if err != nil {
+		logs.Errorf("describe images with %s error: %v", visionModel.ModelName, err)
+		return "", err
+	}
+	return msg.Content, nil
+}
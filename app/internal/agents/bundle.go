@@ -0,0 +1,250 @@
+package agents
+
+import (
+	"app/shared"
+	"common/biz"
+	"context"
+	"errors"
+	"maps"
+	"model"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// exportAgent 导出agent的配置和工具定义，凭证、连接串以及其他用户共享的工具配置都会去掉
+func (s *Service) exportAgent(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req ExportAgentReq) (*AgentBundle, error) {
+	agent, err := s.getAgent(ctx, userID, agentId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version > 0 {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if agent, err = s.loadAgentVersion(ctx, agent, uint(req.Version)); err != nil {
+			if errors.Is(err, biz.ErrAgentVersionNotFound) {
+				return nil, err
+			}
+			logs.Errorf("load agent version error: %v", err)
+			return nil, errs.DBError
+		}
+	}
+	bundle := &AgentBundle{
+		BundleVersion: AgentBundleVersion,
+		ExportedAt:    time.Now(),
+		Agent: BundleAgent{
+			Name:               agent.Name,
+			Description:        agent.Description,
+			Icon:               agent.Icon,
+			SystemPrompt:       agent.SystemPrompt,
+			PromptFormat:       agent.PromptFormat,
+			Variables:          agent.Variables,
+			OutputSchema:       agent.OutputSchema,
+			OutputMaxAttempts:  agent.OutputMaxAttempts,
+			ModelProvider:      agent.ModelProvider,
+			ModelName:          agent.ModelName,
+			ModelParameters:    agent.ModelParameters,
+			OpeningDialogue:    agent.OpeningDialogue,
+			SuggestedQuestions: agent.SuggestedQuestions,
+		},
+		Tools: make([]BundleTool, 0, len(agent.Tools)),
+	}
+	for _, t := range agent.Tools {
+		bundle.Tools = append(bundle.Tools, newBundleTool(t, userID))
+	}
+	return bundle, nil
+}
+
+func newBundleTool(t *model.Tool, userID uuid.UUID) BundleTool {
+	bundleTool := BundleTool{
+		Name:             t.Name,
+		Description:      t.Description,
+		ToolType:         t.ToolType,
+		ParametersSchema: t.ParametersSchema,
+	}
+	// 安装的工具只导出名称，目标环境需要安装同名的工具
+	if t.CreatorID != userID {
+		return bundleTool
+	}
+	if t.McpConfig != nil {
+		mcpConfig := *t.McpConfig
+		mcpConfig.Credential = ""
+		// 地址和启动参数中经常带有 token，环境变量中通常是密钥，都只保留结构，导入时重新填写
+		mcpConfig.Url = ""
+		mcpConfig.Args = nil
+		mcpConfig.Env = blankValues(t.McpConfig.Env)
+		bundleTool.McpConfig = &mcpConfig
+	}
+	if t.HttpConfig != nil {
+		httpConfig := *t.HttpConfig
+		httpConfig.Credential = ""
+		// 请求头中可能直接写了密钥，只保留名称
+		httpConfig.Headers = blankValues(t.HttpConfig.Headers)
+		bundleTool.HttpConfig = &httpConfig
+	}
+	if t.DataSource != nil {
+		bundleTool.DataSource = &BundleDataSource{
+			Name: t.DataSource.Name,
+			Type: t.DataSource.Type,
+		}
+	}
+	return bundleTool
+}
+
+// blankValues 保留键，清空值
+func blankValues(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	blank := make(map[string]string, len(m))
+	for key := range m {
+		blank[key] = ""
+	}
+	return blank
+}
+
+// bundleToolInputs 按导出文件重建工具时需要用户填写的配置，导出时这些值已经被清空
+func bundleToolInputs(t BundleTool) []string {
+	var inputs []string
+	if c := t.McpConfig; c != nil {
+		if c.Type == model.McpTypeStdio {
+			inputs = append(inputs, "mcpConfig.args")
+		} else {
+			inputs = append(inputs, "mcpConfig.url")
+		}
+		for _, key := range slices.Sorted(maps.Keys(c.Env)) {
+			inputs = append(inputs, "mcpConfig.env."+key)
+		}
+		if c.AuthenticationRequired || c.CredentialType != "" {
+			inputs = append(inputs, "credential")
+		}
+	}
+	if c := t.HttpConfig; c != nil {
+		for _, key := range slices.Sorted(maps.Keys(c.Headers)) {
+			inputs = append(inputs, "httpConfig.headers."+key)
+		}
+		if c.AuthType != "" {
+			inputs = append(inputs, "credential")
+		}
+	}
+	if t.DataSource != nil {
+		inputs = append(inputs, "dataSourceId")
+	}
+	return inputs
+}
+
+// importAgent 按导出文件创建agent，工具按名称关联当前用户自己的和已安装的工具，
+// 找不到的工具和模型在结果中列出，agent仍然会创建为草稿，补齐后再发布
+func (s *Service) importAgent(ctx context.Context, userID uuid.UUID, bundle *AgentBundle, dryRun bool) (*ImportAgentResponse, error) {
+	if bundle.BundleVersion <= 0 || bundle.BundleVersion > AgentBundleVersion || strings.TrimSpace(bundle.Agent.Name) == "" {
+		return nil, biz.ErrAgentBundleInvalid
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp := &ImportAgentResponse{
+		DryRun: dryRun,
+		Tools:  make([]*ImportedTool, 0, len(bundle.Tools)),
+	}
+	if bundle.Agent.ModelProvider != "" {
+		_, err := s.getProviderConfig(ctx, model.LLMTypeChat, bundle.Agent.ModelProvider, bundle.Agent.ModelName)
+		if errors.Is(err, biz.ProviderConfigNotFound) {
+			resp.MissingProvider = bundle.Agent.ModelProvider + "/" + bundle.Agent.ModelName
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if bundle.Agent.PromptFormat == "" {
+		bundle.Agent.PromptFormat = model.PromptFormatPlain
+	}
+	bundleAgent := &model.Agent{
+		Name:              bundle.Agent.Name,
+		SystemPrompt:      bundle.Agent.SystemPrompt,
+		PromptFormat:      bundle.Agent.PromptFormat,
+		Variables:         bundle.Agent.Variables,
+		OutputSchema:      bundle.Agent.OutputSchema,
+		OutputMaxAttempts: bundle.Agent.OutputMaxAttempts,
+	}
+	if err := validatePrompt(ctx, bundleAgent); err != nil {
+		return nil, err
+	}
+	if err := validateOutputSchema(bundleAgent); err != nil {
+		return nil, err
+	}
+	toolsByName, err := s.getToolsByNames(userID, bundle.Tools)
+	if err != nil {
+		logs.Errorf("getToolsByNames error: %v", err)
+		return nil, errs.DBError
+	}
+	var toolIds []uuid.UUID
+	for _, bundleTool := range bundle.Tools {
+		imported := &ImportedTool{
+			Name:     bundleTool.Name,
+			ToolType: bundleTool.ToolType,
+			Status:   BundleToolMissing,
+		}
+		if t, ok := toolsByName[bundleTool.Name]; ok && t.ToolType == bundleTool.ToolType {
+			imported.Status = BundleToolMatched
+			imported.ToolID = &t.ID
+			toolIds = append(toolIds, t.ID)
+		} else {
+			imported.Inputs = bundleToolInputs(bundleTool)
+		}
+		resp.Tools = append(resp.Tools, imported)
+	}
+	if dryRun {
+		return resp, nil
+	}
+	agent := &model.Agent{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:          userID,
+		Name:               bundle.Agent.Name,
+		Description:        bundle.Agent.Description,
+		Icon:               bundle.Agent.Icon,
+		SystemPrompt:       bundle.Agent.SystemPrompt,
+		PromptFormat:       bundle.Agent.PromptFormat,
+		Variables:          bundle.Agent.Variables,
+		OutputSchema:       bundle.Agent.OutputSchema,
+		OutputMaxAttempts:  bundle.Agent.OutputMaxAttempts,
+		ModelProvider:      bundle.Agent.ModelProvider,
+		ModelName:          bundle.Agent.ModelName,
+		ModelParameters:    bundle.Agent.ModelParameters,
+		OpeningDialogue:    bundle.Agent.OpeningDialogue,
+		SuggestedQuestions: bundle.Agent.SuggestedQuestions,
+		Status:             model.Draft,
+		Visibility:         model.Private,
+	}
+	if err := s.createAgentWithTools(ctx, agent, toolIds); err != nil {
+		return nil, err
+	}
+	resp.Agent = agent
+	return resp, nil
+}
+
+// getToolsByNames 同名工具优先使用自己创建的
+func (s *Service) getToolsByNames(userID uuid.UUID, bundleTools []BundleTool) (map[string]*model.Tool, error) {
+	names := make([]string, 0, len(bundleTools))
+	for _, t := range bundleTools {
+		names = append(names, t.Name)
+	}
+	trigger, err := event.Trigger("getToolsByNames", &shared.GetToolsByNamesRequest{
+		Names:  names,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	toolsByName := make(map[string]*model.Tool)
+	for _, t := range trigger.([]*model.Tool) {
+		if _, ok := toolsByName[t.Name]; !ok {
+			toolsByName[t.Name] = t
+		}
+	}
+	return toolsByName, nil
+}
@@ -0,0 +1,117 @@
+package agents
+
+import (
+	"context"
+	"model"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+)
+
+func TestNewBundleToolStripsSecrets(t *testing.T) {
+	userID := uuid.New()
+	mcpTool := &model.Tool{
+		CreatorID: userID,
+		Name:      "fetch",
+		ToolType:  model.McpToolType,
+		McpConfig: &model.McpConfig{
+			Type:       model.McpTypeStdio,
+			Command:    "uvx",
+			Args:       []string{"mcp-server", "--token=secret"},
+			Url:        "https://mcp.example.com/sse?key=secret",
+			Env:        map[string]string{"API_KEY": "secret"},
+			Credential: "encrypted",
+		},
+	}
+	bundleTool := newBundleTool(mcpTool, userID)
+	c := bundleTool.McpConfig
+	if c.Url != "" || c.Args != nil || c.Credential != "" || c.Env["API_KEY"] != "" || c.Command != "uvx" {
+		t.Errorf("mcp config = %+v", c)
+	}
+	if _, ok := c.Env["API_KEY"]; !ok {
+		t.Error("env names should be kept")
+	}
+	if mcpTool.McpConfig.Url == "" || len(mcpTool.McpConfig.Args) != 2 || mcpTool.McpConfig.Env["API_KEY"] != "secret" {
+		t.Error("original tool should not be modified")
+	}
+
+	httpTool := &model.Tool{
+		CreatorID: userID,
+		Name:      "weather",
+		ToolType:  model.HttpToolType,
+		HttpConfig: &model.HttpConfig{
+			Method:     "GET",
+			Url:        "https://api.example.com/weather",
+			Headers:    map[string]string{"X-Api-Key": "secret", "Accept": "application/json"},
+			AuthType:   model.HttpAuthBearer,
+			Credential: "encrypted",
+		},
+	}
+	h := newBundleTool(httpTool, userID).HttpConfig
+	if h.Credential != "" || len(h.Headers) != 2 || h.Headers["X-Api-Key"] != "" || h.Url != "https://api.example.com/weather" {
+		t.Errorf("http config = %+v", h)
+	}
+	if httpTool.HttpConfig.Headers["X-Api-Key"] != "secret" {
+		t.Error("original headers should not be modified")
+	}
+
+	// 安装的工具只导出名称
+	installed := newBundleTool(httpTool, uuid.New())
+	if installed.HttpConfig != nil || installed.McpConfig != nil {
+		t.Errorf("installed tool = %+v", installed)
+	}
+}
+
+func TestBundleToolInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		tool BundleTool
+		want []string
+	}{
+		{"system", BundleTool{ToolType: model.SystemToolType}, nil},
+		{"mcp sse", BundleTool{McpConfig: &model.McpConfig{Type: model.McpTypeSSE, CredentialType: model.McpCredentialBearer}},
+			[]string{"mcpConfig.url", "credential"}},
+		{"mcp stdio", BundleTool{McpConfig: &model.McpConfig{Type: model.McpTypeStdio, Env: map[string]string{"B": "", "A": ""}}},
+			[]string{"mcpConfig.args", "mcpConfig.env.A", "mcpConfig.env.B"}},
+		{"http", BundleTool{HttpConfig: &model.HttpConfig{Headers: map[string]string{"X-Api-Key": ""}, AuthType: model.HttpAuthHeader}},
+			[]string{"httpConfig.headers.X-Api-Key", "credential"}},
+		{"database", BundleTool{DataSource: &BundleDataSource{Name: "db"}}, []string{"dataSourceId"}},
+	}
+	for _, tt := range tests {
+		if got := bundleToolInputs(tt.tool); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: inputs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImportAgentListsInputsForMissingTools(t *testing.T) {
+	matched := newTool("weather")
+	matched.ToolType = model.HttpToolType
+	event.Register("getToolsByNames", func(e event.Event) (any, error) {
+		return []*model.Tool{matched}, nil
+	})
+	bundle := &AgentBundle{
+		BundleVersion: AgentBundleVersion,
+		Agent:         BundleAgent{Name: "imported", SystemPrompt: "hi"},
+		Tools: []BundleTool{
+			{Name: "weather", ToolType: model.HttpToolType, HttpConfig: &model.HttpConfig{Headers: map[string]string{"X-Api-Key": ""}}},
+			{Name: "fetch", ToolType: model.McpToolType, McpConfig: &model.McpConfig{Type: model.McpTypeStreamableHTTP}},
+		},
+	}
+	s := &Service{}
+	resp, err := s.importAgent(context.Background(), uuid.New(), bundle, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tools) != 2 {
+		t.Fatalf("tools = %+v", resp.Tools)
+	}
+	if resp.Tools[0].Status != BundleToolMatched || resp.Tools[0].Inputs != nil {
+		t.Errorf("matched tool = %+v", resp.Tools[0])
+	}
+	if resp.Tools[1].Status != BundleToolMissing || !slices.Equal(resp.Tools[1].Inputs, []string{"mcpConfig.url"}) {
+		t.Errorf("missing tool = %+v", resp.Tools[1])
+	}
+}
@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"app/shared"
+	"context"
+	"model"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+)
+
+// fakeRepo 只实现测试用到的方法，其它方法调用时 panic
+type fakeRepo struct {
+	repository
+	agent    *model.Agent
+	version  *model.AgentVersion
+	updated  *model.Agent
+	snapshot *model.AgentSnapshot
+	filter   *AgentFilter
+	created  []*model.AgentTool
+}
+
+func (f *fakeRepo) createAgent(ctx context.Context, agent *model.Agent, tools []*model.AgentTool) error {
+	f.agent = agent
+	f.created = tools
+	return nil
+}
+
+func (f *fakeRepo) updateVisibility(ctx context.Context, agent *model.Agent) error {
+	f.updated = agent
+	return nil
+}
+
+func (f *fakeRepo) listPublicAgents(ctx context.Context, filter AgentFilter) ([]*model.Agent, int64, error) {
+	f.filter = &filter
+	return nil, 0, nil
+}
+
+func (f *fakeRepo) listLiveVersions(ctx context.Context, agents []*model.Agent) ([]*model.AgentVersion, error) {
+	return nil, nil
+}
+
+func (f *fakeRepo) getAgentById(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Agent, error) {
+	return f.agent, nil
+}
+
+func (f *fakeRepo) updateAgent(ctx context.Context, agent *model.Agent) error {
+	f.updated = agent
+	return nil
+}
+
+func (f *fakeRepo) getAgentVersion(ctx context.Context, agentId uuid.UUID, version uint) (*model.AgentVersion, error) {
+	return f.version, nil
+}
+
+func (f *fakeRepo) rollbackAgent(ctx context.Context, agent *model.Agent, snapshot *model.AgentSnapshot) error {
+	f.snapshot = snapshot
+	return nil
+}
+
+// registerUsableTools 模拟工具模块的 getToolsByIds 事件，只返回 usable 中的工具
+func registerUsableTools(usable ...*model.Tool) *[]*shared.GetToolsByIdsRequest {
+	var requests []*shared.GetToolsByIdsRequest
+	event.Register("getToolsByIds", func(e event.Event) (any, error) {
+		request := e.Data.(*shared.GetToolsByIdsRequest)
+		requests = append(requests, request)
+		var toolList []*model.Tool
+		// 倒序返回，检查调用方是否恢复了原来的顺序
+		for _, t := range slices.Backward(usable) {
+			if slices.Contains(request.Ids, t.ID) {
+				toolList = append(toolList, t)
+			}
+		}
+		return toolList, nil
+	})
+	return &requests
+}
+
+func newTool(name string) *model.Tool {
+	t := &model.Tool{Name: name}
+	t.ID = uuid.New()
+	return t
+}
@@ -0,0 +1,235 @@
+package agents
+
+import (
+	"app/shared"
+	"common/configs"
+	"container/list"
+	"context"
+	"core/ai"
+	"core/ai/documents"
+	"core/ai/retrieval"
+	"core/ai/sandbox"
+	"core/ai/tools"
+	"fmt"
+	"model"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// retrieverTTL 会话检索器的缓存时间，会话中的文件变化或者过期后重新构建
+const retrieverTTL = 30 * time.Minute
+
+// sessionRetrievers 会话中大文件的临时检索器，只保存在内存中，会话删除时清除。
+// 数量超过配置的上限时淘汰最久没有使用的
+var sessionRetrievers = newRetrieverCache()
+
+type cachedRetriever struct {
+	sessionID uuid.UUID
+	// key 构建时的文件和向量模型，任何一个变化都需要重新构建
+	key       string
+	retriever *retrieval.MemoryRetriever
+	expiresAt time.Time
+}
+
+type retrieverCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*list.Element
+	// order 按最近使用排序，最前面的最近使用
+	order *list.List
+}
+
+func newRetrieverCache() *retrieverCache {
+	return &retrieverCache{entries: make(map[uuid.UUID]*list.Element), order: list.New()}
+}
+
+func (c *retrieverCache) get(sessionID uuid.UUID, key string) *retrieval.MemoryRetriever {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[sessionID]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cachedRetriever)
+	if entry.key != key || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	entry.expiresAt = time.Now().Add(retrieverTTL)
+	c.order.MoveToFront(elem)
+	return entry.retriever
+}
+
+func (c *retrieverCache) put(sessionID uuid.UUID, key string, r *retrieval.MemoryRetriever) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	entry := &cachedRetriever{sessionID: sessionID, key: key, retriever: r, expiresAt: now.Add(retrieverTTL)}
+	if elem, ok := c.entries[sessionID]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[sessionID] = c.order.PushFront(entry)
+	}
+	// 从最久没有使用的开始淘汰过期和超出数量的
+	limit := configs.GetConfig().Attachment.GetMaxCachedRetrievers()
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		old := elem.Value.(*cachedRetriever)
+		if c.order.Len() <= limit && !now.After(old.expiresAt) {
+			break
+		}
+		c.order.Remove(elem)
+		delete(c.entries, old.sessionID)
+		elem = prev
+	}
+}
+
+func (c *retrieverCache) evict(sessionID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[sessionID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, sessionID)
+	}
+}
+
+// prepareSessionFiles 把会话中的文件放入上下文。按上传顺序，总字数不超过配置的文件直接放入全文，
+// 其余文件切块后检索和本次消息相关的片段。返回的 context 中带有工具读取文件的 FileResolver
+func (s *Service) prepareSessionFiles(ctx context.Context, message string, inputs promptInputs) (context.Context, string, error) {
+	if inputs.SessionID == uuid.Nil {
+		return ctx, "", nil
+	}
+	trigger, err := event.Trigger("getSessionFiles", &shared.GetSessionFilesRequest{
+		UserID:    inputs.UserID,
+		SessionID: inputs.SessionID,
+	})
+	if err != nil {
+		logs.Errorf("getSessionFiles error: %v", err)
+		return ctx, "", err
+	}
+	files := trigger.([]*model.Attachment)
+	if len(files) == 0 {
+		return ctx, "", nil
+	}
+	ctx = tools.WithFileResolver(ctx, sessionFileResolver(inputs.UserID, inputs.SessionID))
+
+	var fileList, contents strings.Builder
+	var large []*model.Attachment
+	budget := configs.GetConfig().Attachment.GetInlineChars()
+	for _, file := range files {
+		fmt.Fprintf(&fileList, "- 文件ID：%s，文件名：%s，%d 字\n", file.ID, file.FileName, file.TextLength)
+		if file.TextLength > budget {
+			large = append(large, file)
+			continue
+		}
+		budget -= file.TextLength
+		fmt.Fprintf(&contents, "\n## 文件《%s》的内容\n%s\n", file.FileName, file.Text)
+	}
+	if len(large) > 0 {
+		chunks, err := s.retrieveFileChunks(ctx, message, inputs, large)
+		if err != nil {
+			return ctx, "", err
+		}
+		if len(chunks) > 0 {
+			contents.WriteString("\n## 以下是较大的文件中和问题相关的片段\n")
+			for _, chunk := range chunks {
+				fmt.Fprintf(&contents, "\n[%s]\n%s\n", chunk.MetaData["fileName"], chunk.Content)
+			}
+		}
+	}
+	return ctx, ai.BuildSessionFilesPrompt(fileList.String(), contents.String()), nil
+}
+
+// retrieveFileChunks 用户配置了向量模型时按语义检索，没有时按关键词检索
+func (s *Service) retrieveFileChunks(ctx context.Context, message string, inputs promptInputs, files []*model.Attachment) ([]*schema.Document, error) {
+	trigger, err := event.Trigger("getEmbeddingModel", &shared.GetEmbeddingModelRequest{UserID: inputs.UserID})
+	if err != nil {
+		logs.Errorf("getEmbeddingModel error: %v", err)
+		return nil, err
+	}
+	embeddingModel := trigger.(*shared.EmbeddingModel)
+	keys := make([]string, 0, len(files)+1)
+	for _, file := range files {
+		keys = append(keys, file.ID.String())
+	}
+	var embedder embedding.Embedder
+	if embeddingModel != nil {
+		keys = append(keys, embeddingModel.ProviderConfig.Provider+"/"+embeddingModel.ModelName)
+		if embedder, err = shared.BuildEmbedder(ctx, embeddingModel.ProviderConfig, embeddingModel.ModelName); err != nil {
+			logs.Errorf("build embedder error: %v", err)
+			embedder = nil
+		}
+	}
+	key := strings.Join(keys, ",")
+	r := sessionRetrievers.get(inputs.SessionID, key)
+	if r == nil {
+		docs := fileChunks(files)
+		if r, err = retrieval.NewMemoryRetriever(ctx, embedder, docs, configs.GetConfig().Attachment.GetTopK()); err != nil {
+			// 向量模型不可用时退回关键词检索，不影响对话
+			logs.Errorf("embed session files error: %v", err)
+			if r, err = retrieval.NewMemoryRetriever(ctx, nil, docs, configs.GetConfig().Attachment.GetTopK()); err != nil {
+				return nil, err
+			}
+		}
+		sessionRetrievers.put(inputs.SessionID, key, r)
+	}
+	chunks, err := r.Retrieve(ctx, message)
+	if err != nil {
+		logs.Errorf("retrieve session files error: %v", err)
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// fileChunks 按配置的大小切分文件文本，CSV 的每块都带有表头
+func fileChunks(files []*model.Attachment) []*schema.Document {
+	config := configs.GetConfig().Attachment
+	var docs []*schema.Document
+	for _, file := range files {
+		doc := &documents.Document{Format: documents.FormatText, Text: file.Text}
+		if file.ContentType == "text/csv" {
+			doc.Format = documents.FormatCSV
+			doc.Header, _, _ = strings.Cut(file.Text, "\n")
+		}
+		for i, chunk := range documents.Split(doc, config.GetChunkSize(), config.GetChunkOverlap()) {
+			docs = append(docs, &schema.Document{
+				ID:      fmt.Sprintf("%s#%d", file.ID, i),
+				Content: chunk,
+				MetaData: map[string]any{
+					"fileId":   file.ID.String(),
+					"fileName": file.FileName,
+				},
+			})
+		}
+	}
+	return docs
+}
+
+// sessionFileResolver 工具按文件ID读取会话中的文件，只能读取当前会话的文件
+func sessionFileResolver(userID uuid.UUID, sessionID uuid.UUID) tools.FileResolver {
+	return func(ctx context.Context, ref string) (*sandbox.File, error) {
+		id, err := uuid.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, fmt.Errorf("invalid file id: %s", ref)
+		}
+		trigger, err := event.Trigger("getAttachmentsByIds", &shared.GetAttachmentsRequest{
+			UserID: userID,
+			IDs:    []uuid.UUID{id},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range trigger.([]*model.Attachment) {
+			if file.SessionID != nil && *file.SessionID == sessionID {
+				return &sandbox.File{Name: file.FileName, Size: file.Size, Data: file.Data}, nil
+			}
+		}
+		return nil, fmt.Errorf("file not found in this session: %s", ref)
+	}
+}
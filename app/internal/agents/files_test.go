@@ -0,0 +1,52 @@
+package agents
+
+import (
+	"common/configs"
+	"core/ai/retrieval"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRetrieverCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newRetrieverCache()
+	limit := configs.GetConfig().Attachment.GetMaxCachedRetrievers()
+	ids := make([]uuid.UUID, limit+1)
+	r := &retrieval.MemoryRetriever{}
+	for i := range ids {
+		ids[i] = uuid.New()
+		c.put(ids[i], "k", r)
+		// 第一个会话一直在使用，不会被淘汰
+		if i > 0 && c.get(ids[0], "k") == nil {
+			t.Fatalf("first session evicted after %d puts", i)
+		}
+	}
+	if c.order.Len() != limit || len(c.entries) != limit {
+		t.Fatalf("cache size = %d/%d, want %d", c.order.Len(), len(c.entries), limit)
+	}
+	if c.get(ids[1], "k") != nil {
+		t.Error("least recently used session should be evicted")
+	}
+	if c.get(ids[limit], "k") != r || c.get(ids[0], "other") != nil {
+		t.Error("cache lookup mismatch")
+	}
+	c.evict(ids[0])
+	if c.get(ids[0], "k") != nil || c.order.Len() != limit-1 {
+		t.Error("evicted session should be removed")
+	}
+}
+
+func TestRetrieverCacheDropsExpired(t *testing.T) {
+	c := newRetrieverCache()
+	expired, fresh := uuid.New(), uuid.New()
+	c.put(expired, "k", &retrieval.MemoryRetriever{})
+	c.entries[expired].Value.(*cachedRetriever).expiresAt = time.Now().Add(-time.Second)
+	if c.get(expired, "k") != nil {
+		t.Error("expired retriever should not be returned")
+	}
+	c.put(fresh, "k", &retrieval.MemoryRetriever{})
+	if _, ok := c.entries[expired]; ok || c.order.Len() != 1 {
+		t.Error("expired retriever should be removed on put")
+	}
+}
@@ -1,18 +1,27 @@
 package agents
 
 import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
 	"context"
 	"fmt"
+	"model"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
 	"github.com/mszlu521/thunder/logs"
 	"github.com/mszlu521/thunder/req"
 	"github.com/mszlu521/thunder/res"
 )
 
+// invokeTimeout 非流式调用agent的最长等待时间
+const invokeTimeout = 5 * time.Minute
+
 type Handler struct {
 	service *Service
 }
@@ -115,6 +124,20 @@ func (h *Handler) AgentMessage(c *gin.Context) {
 	if !exist {
 		return
 	}
+	ctx, cancel := h.prepareStream(c)
+	defer cancel()
+	// 这个接口是AI回答，返回两个chan，一个用于返回数据，一个用于返回错误
+	// 调用大模型，需要放在协程中执行
+	dataChan, errorChan, err := h.service.agentMessageStream(ctx, userId, agentMessageReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	h.writeStream(c, ctx, cancel, dataChan, errorChan)
+}
+
+// prepareStream 设置SSE响应头并取消写超时，返回的context在客户端断开时取消
+func (h *Handler) prepareStream(c *gin.Context) (context.Context, context.CancelFunc) {
 	// AI回答响应时间比较长，所以这里不能设限制，全局是10s超时，这里需要单独设置超时
 	rc := http.NewResponseController(c.Writer)
 	//  将当前请求的写入超时设置为零值（即无限制）
@@ -128,16 +151,14 @@ func (h *Handler) AgentMessage(c *gin.Context) {
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
-	//生产环境使用指定域名
-	c.Header("Access-Control-Allow-Origin", "*")
+	// 跨域响应头由全局的 CORS 中间件和 widget 的来源校验负责
 
 	// 使用带取消功能的context
-	ctx, cancel := context.WithCancel(c.Request.Context())
-	defer cancel()
+	return context.WithCancel(c.Request.Context())
+}
 
-	// 这个接口是AI回答，返回两个chan，一个用于返回数据，一个用于返回错误
-	// 调用大模型，需要放在协程中执行
-	dataChan, errorChan := h.service.agentMessageStream(ctx, userId, agentMessageReq)
+// writeStream 把agent的输出按SSE协议写给客户端，直到输出结束或者客户端断开
+func (h *Handler) writeStream(c *gin.Context, ctx context.Context, cancel context.CancelFunc, dataChan <-chan string, errorChan <-chan error) {
 	// 创建一个心跳定时器，例如每 5 秒跳一次，防止一些防火墙拦截，导致连接中断
 	heartbeat := time.NewTicker(5 * time.Second)
 	defer heartbeat.Stop()
@@ -216,3 +237,705 @@ func (h *Handler) UpdateAgentTool(c *gin.Context) {
 	}
 	res.Success(c, resp)
 }
+
+// ImportMcpPrompt 使用MCP服务提供的提示词模板作为agent的系统提示词
+func (h *Handler) ImportMcpPrompt(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var importReq ImportMcpPromptReq
+	if err := req.JsonParam(c, &importReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agent, err := h.service.importMcpPrompt(c.Request.Context(), userID, id, importReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+// ListInvocableAgents 列出当前API令牌可以调用的agent
+func (h *Handler) ListInvocableAgents(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agents, err := h.service.listInvocableAgents(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agents)
+}
+
+// InvokeAgent 非流式调用agent，等agent运行结束后返回最终回答
+func (h *Handler) InvokeAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var invokeReq InvokeAgentReq
+	if err := req.JsonParam(c, &invokeReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	// agent 可能会多轮调用工具，耗时远超全局的写超时
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logs.Warn("Failed to set write deadline", "err", err)
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), invokeTimeout)
+	defer cancel()
+	resp, err := h.service.invokeAgent(ctx, userID, id, invokeReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, resp)
+}
+
+func (h *Handler) PublishAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var publishReq PublishAgentReq
+	if err := req.JsonParam(c, &publishReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	version, err := h.service.publishAgent(c.Request.Context(), userID, id, publishReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, version)
+}
+
+func (h *Handler) ListAgentVersions(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	versions, err := h.service.listAgentVersions(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, versions)
+}
+
+func (h *Handler) GetAgentVersion(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var version int
+	if err := req.Path(c, "version", &version); err != nil {
+		return
+	}
+	if version <= 0 {
+		res.Error(c, errs.ErrParam)
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agentVersion, err := h.service.getAgentVersion(c.Request.Context(), userID, id, uint(version))
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agentVersion)
+}
+
+func (h *Handler) DiffAgentVersions(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var diffReq DiffAgentVersionsReq
+	if err := req.QueryParam(c, &diffReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	diff, err := h.service.diffAgentVersions(c.Request.Context(), userID, id, diffReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, diff)
+}
+
+func (h *Handler) RollbackAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var rollbackReq RollbackAgentReq
+	if err := req.JsonParam(c, &rollbackReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agent, err := h.service.rollbackAgent(c.Request.Context(), userID, id, rollbackReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+// UploadIcon 上传agent的图标，表单字段为 file
+func (h *Handler) UploadIcon(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	maxSize := configs.GetConfig().Attachment.GetMaxImageSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	data, fileName, err := shared.ReadFormFile(c, maxSize, biz.ErrBlobTooLarge, biz.ErrBlobInvalid)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	agent, err := h.service.uploadIcon(c.Request.Context(), userID, id, fileName, data)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+func (h *Handler) UpdateVisibility(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var visibilityReq UpdateVisibilityReq
+	if err := req.JsonParam(c, &visibilityReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agent, err := h.service.updateVisibility(c.Request.Context(), userID, id, visibilityReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+func (h *Handler) CreateShare(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var shareReq CreateShareReq
+	if err := req.JsonParam(c, &shareReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	share, err := h.service.createShare(c.Request.Context(), userID, id, shareReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, share)
+}
+
+func (h *Handler) ListShares(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	shares, err := h.service.listShares(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, shares)
+}
+
+func (h *Handler) RevokeShare(c *gin.Context) {
+	var id, shareId uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	if err := req.Path(c, "shareId", &shareId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.revokeShare(c.Request.Context(), userID, id, shareId); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// ListPublicAgents 公开目录，不需要登录
+func (h *Handler) ListPublicAgents(c *gin.Context) {
+	var publicReq PublicAgentsReq
+	if err := req.QueryParam(c, &publicReq); err != nil {
+		return
+	}
+	agents, err := h.service.listPublicAgents(c.Request.Context(), publicReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agents)
+}
+
+func (h *Handler) GetPublicAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	agent, err := h.service.getPublicAgent(c.Request.Context(), id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+func (h *Handler) GetSharedAgent(c *gin.Context) {
+	var token string
+	if err := req.Path(c, "token", &token); err != nil {
+		return
+	}
+	agent, err := h.service.getSharedAgent(c.Request.Context(), token)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+// PublicAgentChat 访客和公开目录中的agent对话
+func (h *Handler) PublicAgentChat(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var messageReq GuestMessageReq
+	if err := req.JsonParam(c, &messageReq); err != nil {
+		return
+	}
+	target, err := h.service.getGuestTargetById(c.Request.Context(), id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	h.guestChat(c, target, messageReq)
+}
+
+// SharedAgentChat 访客通过分享链接和agent对话
+func (h *Handler) SharedAgentChat(c *gin.Context) {
+	var token string
+	if err := req.Path(c, "token", &token); err != nil {
+		return
+	}
+	var messageReq GuestMessageReq
+	if err := req.JsonParam(c, &messageReq); err != nil {
+		return
+	}
+	target, err := h.service.getGuestTargetByToken(c.Request.Context(), token)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	h.guestChat(c, target, messageReq)
+}
+
+func (h *Handler) guestChat(c *gin.Context, target *guestTarget, messageReq GuestMessageReq) {
+	if err := validateGuestMessage(messageReq.Message); err != nil {
+		res.Error(c, err)
+		return
+	}
+	// 限流和额度检查在开始SSE之前，失败时返回普通的错误响应
+	if err := h.service.chargeGuest(c.Request.Context(), target, c.ClientIP()); err != nil {
+		res.Error(c, err)
+		return
+	}
+	ctx, cancel := h.prepareStream(c)
+	defer cancel()
+	dataChan, errorChan := h.service.guestMessageStream(ctx, target, messageReq)
+	h.writeStream(c, ctx, cancel, dataChan, errorChan)
+}
+
+func (h *Handler) CreateEmbed(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var embedReq EmbedReq
+	if err := req.JsonParam(c, &embedReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	embed, err := h.service.createEmbed(c.Request.Context(), userID, id, embedReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, embed)
+}
+
+func (h *Handler) ListEmbeds(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	embeds, err := h.service.listEmbeds(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, embeds)
+}
+
+func (h *Handler) UpdateEmbed(c *gin.Context) {
+	var id, embedId uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	if err := req.Path(c, "embedId", &embedId); err != nil {
+		return
+	}
+	var embedReq EmbedReq
+	if err := req.JsonParam(c, &embedReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	embed, err := h.service.updateEmbed(c.Request.Context(), userID, id, embedId, embedReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, embed)
+}
+
+func (h *Handler) RevokeEmbed(c *gin.Context) {
+	var id, embedId uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	if err := req.Path(c, "embedId", &embedId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.revokeEmbed(c.Request.Context(), userID, id, embedId); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+func (h *Handler) ListConversations(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var conversationsReq ConversationsReq
+	if err := req.QueryParam(c, &conversationsReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listConversations(c.Request.Context(), userID, id, conversationsReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) ListConversationMessages(c *gin.Context) {
+	var id, conversationId uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	if err := req.Path(c, "conversationId", &conversationId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	messages, err := h.service.listConversationMessages(c.Request.Context(), userID, id, conversationId)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, messages)
+}
+
+// WidgetBootstrap 组件加载时调用，嵌入配置已经由 WidgetAuth 校验
+func (h *Handler) WidgetBootstrap(c *gin.Context) {
+	var bootstrapReq WidgetBootstrapReq
+	if err := req.QueryParam(c, &bootstrapReq); err != nil {
+		return
+	}
+	embed := c.MustGet(widgetEmbedKey).(*model.AgentEmbed)
+	resp, err := h.service.widgetBootstrap(c.Request.Context(), embed, bootstrapReq.VisitorID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, resp)
+}
+
+// WidgetChat 访客通过组件和agent对话，和分享链接使用同样的限流和额度
+func (h *Handler) WidgetChat(c *gin.Context) {
+	var messageReq WidgetMessageReq
+	if err := req.JsonParam(c, &messageReq); err != nil {
+		return
+	}
+	// 不合法的消息不计入额度
+	if err := validateWidgetMessage(messageReq); err != nil {
+		res.Error(c, err)
+		return
+	}
+	embed := c.MustGet(widgetEmbedKey).(*model.AgentEmbed)
+	target, err := h.service.getWidgetTarget(c.Request.Context(), embed)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	if err := h.service.chargeGuest(c.Request.Context(), target, c.ClientIP()); err != nil {
+		res.Error(c, err)
+		return
+	}
+	ctx, cancel := h.prepareStream(c)
+	defer cancel()
+	dataChan, errorChan, err := h.service.widgetMessageStream(ctx, target, embed, messageReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	h.writeStream(c, ctx, cancel, dataChan, errorChan)
+}
+
+// ExportAgent 下载agent的导出文件，默认JSON，format=yaml 时导出YAML
+func (h *Handler) ExportAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var exportReq ExportAgentReq
+	if err := req.QueryParam(c, &exportReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	bundle, err := h.service.exportAgent(c.Request.Context(), userID, id, exportReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	if exportReq.Format == BundleFormatYAML {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="agent-%s.yaml"`, id))
+		c.YAML(http.StatusOK, bundle)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="agent-%s.json"`, id))
+	c.IndentedJSON(http.StatusOK, bundle)
+}
+
+// ImportAgent 导入agent，请求体是导出文件的内容，Content-Type 为 YAML 时按YAML解析
+func (h *Handler) ImportAgent(c *gin.Context) {
+	var importReq ImportAgentReq
+	if err := req.QueryParam(c, &importReq); err != nil {
+		return
+	}
+	var bundle AgentBundle
+	if strings.Contains(c.ContentType(), BundleFormatYAML) {
+		if err := c.ShouldBindYAML(&bundle); err != nil {
+			logs.Errorf("parse agent bundle error: %v", err)
+			res.Error(c, biz.ErrAgentBundleInvalid)
+			return
+		}
+	} else if err := req.JsonParam(c, &bundle); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.service.importAgent(c.Request.Context(), userID, &bundle, importReq.DryRun)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, resp)
+}
+
+func (h *Handler) DuplicateAgent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var duplicateReq DuplicateAgentReq
+	if err := req.JsonParam(c, &duplicateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	agent, err := h.service.duplicateAgent(c.Request.Context(), userID, id, duplicateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, agent)
+}
+
+func (h *Handler) ListTemplates(c *gin.Context) {
+	var templatesReq ListTemplatesReq
+	if err := req.QueryParam(c, &templatesReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	templates, err := h.service.listTemplates(c.Request.Context(), userID, templatesReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, templates)
+}
+
+// SaveTemplate 把agent保存为模板
+func (h *Handler) SaveTemplate(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var templateReq SaveTemplateReq
+	if err := req.JsonParam(c, &templateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	template, err := h.service.saveTemplate(c.Request.Context(), userID, id, templateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, template)
+}
+
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	var templateId uuid.UUID
+	if err := req.Path(c, "templateId", &templateId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteTemplate(c.Request.Context(), userID, templateId); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// CreateSession 开始一个新的平台内会话，会话中可以上传文件
+func (h *Handler) CreateSession(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	session, err := h.service.createSession(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, session)
+}
+
+func (h *Handler) DeleteSession(c *gin.Context) {
+	var id, sessionId uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	if err := req.Path(c, "sessionId", &sessionId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteSession(c.Request.Context(), userID, id, sessionId); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
@@ -0,0 +1,41 @@
+package agents
+
+import (
+	"app/shared"
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// uploadIcon 保存上传的图标并设置为agent的图标，之前的图标不再被引用后由存储定期清理
+func (s *Service) uploadIcon(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, fileName string, data []byte) (*model.Agent, error) {
+	agent, err := s.getAgent(ctx, userID, agentId)
+	if err != nil {
+		return nil, err
+	}
+	trigger, err := event.Trigger("putBlob", &shared.PutBlobRequest{
+		Ctx:      ctx,
+		OwnerID:  userID,
+		Kind:     model.BlobAgentIcon,
+		RefID:    agentId,
+		FileName: fileName,
+		Data:     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	agent.Icon = trigger.(*model.Blob).Path()
+	agent.UpdatedAt = time.Now()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.repo.updateAgentIcon(ctx, agent); err != nil {
+		logs.Errorf("update agent icon error: %v", err)
+		return nil, errs.DBError
+	}
+	return agent, nil
+}
@@ -0,0 +1,75 @@
+package agents
+
+import (
+	"app/shared"
+	"common/configs"
+	"context"
+	"core/ai"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// recallMemories 检索和本次消息相关的用户记忆，失败时不影响对话
+func (s *Service) recallMemories(ctx context.Context, agent *model.Agent, message string, inputs promptInputs) string {
+	if !inputs.Memory || inputs.UserID == uuid.Nil || !configs.GetConfig().Memory.IsEnabled() {
+		return ""
+	}
+	trigger, err := event.Trigger("searchMemories", &shared.SearchMemoriesRequest{
+		Ctx:     ctx,
+		UserID:  inputs.UserID,
+		AgentID: agent.ID,
+		Query:   message,
+	})
+	if err != nil {
+		logs.Errorf("searchMemories error: %v", err)
+		return ""
+	}
+	memories := trigger.([]*model.Memory)
+	if len(memories) == 0 {
+		return ""
+	}
+	contents := make([]string, len(memories))
+	for i, memory := range memories {
+		contents[i] = memory.Content
+	}
+	return ai.BuildMemoryPrompt(contents)
+}
+
+// rememberTurn 回答结束后在后台提取本轮对话中需要记住的信息，使用agent自己的模型
+func (s *Service) rememberTurn(agent *model.Agent, message string, answer string, inputs promptInputs) {
+	if !inputs.Memory || inputs.UserID == uuid.Nil || !configs.GetConfig().Memory.IsEnabled() {
+		return
+	}
+	var conversationID *uuid.UUID
+	if inputs.SessionID != uuid.Nil {
+		conversationID = &inputs.SessionID
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Errorf("Panic in rememberTurn: %v", r)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		providerConfig, err := s.getProviderConfig(ctx, model.LLMTypeChat, agent.ModelProvider, agent.ModelName)
+		cancel()
+		if err != nil {
+			return
+		}
+		if _, err := event.Trigger("extractMemories", &shared.ExtractMemoriesRequest{
+			UserID:         inputs.UserID,
+			AgentID:        agent.ID,
+			ConversationID: conversationID,
+			ProviderConfig: providerConfig,
+			ModelName:      agent.ModelName,
+			Message:        message,
+			Answer:         answer,
+		}); err != nil {
+			logs.Errorf("extractMemories error: %v", err)
+		}
+	}()
+}
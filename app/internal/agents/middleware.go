@@ -0,0 +1,37 @@
+package agents
+
+import (
+	"common/biz"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mszlu521/thunder/res"
+)
+
+// widgetEmbedKey 校验通过的嵌入配置在 gin.Context 中的key
+const widgetEmbedKey = "widgetEmbed"
+
+// WidgetAuth 校验嵌入密钥和请求来源，只有白名单中的网站可以使用组件。
+// 通过后把响应的 Access-Control-Allow-Origin 设为请求来源，覆盖全局跨域配置
+func WidgetAuth() gin.HandlerFunc {
+	s := NewService()
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		embed, err := s.getWidgetEmbed(c.Request.Context(), c.Param("key"), origin)
+		if errors.Is(err, biz.ErrEmbedNotFound) || errors.Is(err, biz.ErrEmbedOrigin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			res.Error(c, err)
+			c.Abort()
+			return
+		}
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Vary", "Origin")
+		c.Set(widgetEmbedKey, embed)
+		c.Next()
+	}
+}
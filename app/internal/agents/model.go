@@ -3,6 +3,7 @@ package agents
 import (
 	"context"
 	"model"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,8 +27,23 @@ type AgentFilter struct {
 	Offset int
 }
 
-func (m *models) createAgent(ctx context.Context, agent *model.Agent) error {
-	return m.db.WithContext(ctx).Create(agent).Error
+type ConversationFilter struct {
+	Channel string
+	Limit   int
+	Offset  int
+}
+
+// createAgent 在一个事务中创建agent和工具关联，关联失败时不会留下没有工具的agent
+func (m *models) createAgent(ctx context.Context, agent *model.Agent, tools []*model.AgentTool) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(agent).Error; err != nil {
+			return err
+		}
+		if len(tools) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(tools, len(tools)).Error
+	})
 }
 
 func (m *models) listAgents(ctx context.Context, filter AgentFilter, userId uuid.UUID) ([]*model.Agent, int64, error) {
@@ -63,7 +79,7 @@ func (m *models) getAgentById(ctx context.Context, userId uuid.UUID, id uuid.UUI
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	var agent model.Agent
-	err := m.db.WithContext(ctx).Preload("Tools").Where("id = ? AND creator_id = ?", id, userId).First(&agent).Error
+	err := m.db.WithContext(ctx).Preload("Tools").Preload("Tools.DataSource").Where("id = ? AND creator_id = ?", id, userId).First(&agent).Error
 	if gorms.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
@@ -83,3 +99,362 @@ func (m *models) deleteAgentTools(ctx context.Context, agentId uuid.UUID) error
 func (m *models) createAgentTools(ctx context.Context, tools []*model.AgentTool) error {
 	return m.db.WithContext(ctx).CreateInBatches(tools, len(tools)).Error
 }
+
+// listInvocableAgents 用户可以通过API调用的agent，只有自己已发布的。
+// 运行时使用创建者的工具和模型凭证，其他人的agent只能通过分享以访客身份使用
+func (m *models) listInvocableAgents(ctx context.Context, userId uuid.UUID) ([]*model.Agent, error) {
+	var agents []*model.Agent
+	err := m.db.WithContext(ctx).
+		Where("status = ? AND creator_id = ?", model.Published, userId).
+		Order("created_at desc").
+		Find(&agents).Error
+	return agents, err
+}
+
+func (m *models) getInvocableAgent(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Agent, error) {
+	var agent model.Agent
+	err := m.db.WithContext(ctx).Preload("Tools").Preload("Tools.DataSource").
+		Where("id = ? AND status = ? AND creator_id = ?", id, model.Published, userId).
+		First(&agent).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &agent, err
+}
+
+// publishAgent 保存快照并把它设置为线上版本，版本号在事务中按顺序生成
+func (m *models) publishAgent(ctx context.Context, agent *model.Agent, version *model.AgentVersion) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest uint
+		if err := tx.Model(&model.AgentVersion{}).Where("agent_id = ?", agent.ID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		agent.Version = version.Version
+		agent.Status = model.AgentStatus(model.Published)
+		agent.PublishedAt = &now
+		return tx.Model(agent).Select("version", "status", "published_at").Updates(agent).Error
+	})
+}
+
+func (m *models) listAgentVersions(ctx context.Context, agentId uuid.UUID) ([]*model.AgentVersion, error) {
+	var versions []*model.AgentVersion
+	err := m.db.WithContext(ctx).Where("agent_id = ?", agentId).Order("version desc").Find(&versions).Error
+	return versions, err
+}
+
+func (m *models) getAgentVersion(ctx context.Context, agentId uuid.UUID, version uint) (*model.AgentVersion, error) {
+	var agentVersion model.AgentVersion
+	err := m.db.WithContext(ctx).Where("agent_id = ? AND version = ?", agentId, version).First(&agentVersion).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &agentVersion, err
+}
+
+// rollbackAgent 切换线上版本，snapshot 不为空时同时用它覆盖草稿和草稿关联的工具
+func (m *models) rollbackAgent(ctx context.Context, agent *model.Agent, snapshot *model.AgentSnapshot) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		columns := []string{"version", "status", "published_at", "updated_at"}
+		if snapshot != nil {
+			snapshot.Apply(agent)
+			columns = append(columns, "name", "description", "icon", "system_prompt", "prompt_format", "variables",
+				"output_schema", "output_max_attempts", "model_provider", "model_name", "model_parameters",
+				"opening_dialogue", "suggested_questions")
+		}
+		if err := tx.Model(agent).Select(columns).Updates(agent).Error; err != nil {
+			return err
+		}
+		if snapshot == nil {
+			return nil
+		}
+		if err := tx.Where("agent_id = ?", agent.ID).Delete(&model.AgentTool{}).Error; err != nil {
+			return err
+		}
+		if len(snapshot.ToolIds) == 0 {
+			return nil
+		}
+		agentTools := make([]*model.AgentTool, len(snapshot.ToolIds))
+		for i, toolId := range snapshot.ToolIds {
+			agentTools[i] = &model.AgentTool{
+				AgentID:   agent.ID,
+				ToolID:    toolId,
+				Status:    model.Enabled,
+				CreatedAt: time.Now(),
+			}
+		}
+		return tx.Create(agentTools).Error
+	})
+}
+
+func (m *models) updateVisibility(ctx context.Context, agent *model.Agent) error {
+	return m.db.WithContext(ctx).Model(agent).Select("visibility", "guest_tools", "updated_at").Updates(agent).Error
+}
+
+func (m *models) updateAgentIcon(ctx context.Context, agent *model.Agent) error {
+	return m.db.WithContext(ctx).Model(agent).Select("icon", "updated_at").Updates(agent).Error
+}
+
+func (m *models) createShare(ctx context.Context, share *model.AgentShare) error {
+	return m.db.WithContext(ctx).Create(share).Error
+}
+
+func (m *models) listShares(ctx context.Context, agentId uuid.UUID) ([]*model.AgentShare, error) {
+	var shares []*model.AgentShare
+	err := m.db.WithContext(ctx).Where("agent_id = ?", agentId).Order("created_at desc").Find(&shares).Error
+	return shares, err
+}
+
+func (m *models) getShare(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.AgentShare, error) {
+	var share model.AgentShare
+	err := m.db.WithContext(ctx).Where("id = ? AND agent_id = ?", id, agentId).First(&share).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &share, err
+}
+
+func (m *models) getShareByToken(ctx context.Context, token string) (*model.AgentShare, error) {
+	var share model.AgentShare
+	err := m.db.WithContext(ctx).Where("token = ?", token).First(&share).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &share, err
+}
+
+func (m *models) revokeShare(ctx context.Context, share *model.AgentShare) error {
+	return m.db.WithContext(ctx).Model(share).Select("revoked_at", "updated_at").Updates(share).Error
+}
+
+// getPublishedAgent 不限制创建者，可见范围由调用方判断
+func (m *models) getPublishedAgent(ctx context.Context, id uuid.UUID) (*model.Agent, error) {
+	var agent model.Agent
+	err := m.db.WithContext(ctx).Preload("Tools").Preload("Tools.DataSource").
+		Where("id = ? AND status = ?", id, model.Published).
+		First(&agent).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &agent, err
+}
+
+// listPublicAgents 公开目录中的agent：已发布并且公开的
+func (m *models) listPublicAgents(ctx context.Context, filter AgentFilter) ([]*model.Agent, int64, error) {
+	var agents []*model.Agent
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.Agent{}).
+		Where("status = ? AND visibility = ?", model.Published, model.Public)
+	if filter.Name != "" {
+		query = query.Where("(name ILIKE ? OR description ILIKE ?)", "%"+filter.Name+"%", "%"+filter.Name+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	err := query.Order("invocation_count desc, published_at desc").Find(&agents).Error
+	return agents, total, err
+}
+
+// listLiveVersions 批量查询agent当前线上版本的快照
+func (m *models) listLiveVersions(ctx context.Context, agents []*model.Agent) ([]*model.AgentVersion, error) {
+	var versions []*model.AgentVersion
+	if len(agents) == 0 {
+		return versions, nil
+	}
+	keys := make([][]any, len(agents))
+	for i, agent := range agents {
+		keys[i] = []any{agent.ID, agent.Version}
+	}
+	err := m.db.WithContext(ctx).Where("(agent_id, version) IN ?", keys).Find(&versions).Error
+	return versions, err
+}
+
+func (m *models) getUserPlan(ctx context.Context, userId uuid.UUID) (model.SubscriptionPlan, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("current_plan").Where("id = ?", userId).First(&user).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return model.FreePlan, nil
+	}
+	return user.CurrentPlan, err
+}
+
+// recordGuestMessage 访客的消息计入agent和分享链接的统计
+func (m *models) recordGuestMessage(ctx context.Context, agentId uuid.UUID, shareId *uuid.UUID) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Agent{}).Where("id = ?", agentId).
+			UpdateColumn("invocation_count", gorm.Expr("invocation_count + 1")).Error; err != nil {
+			return err
+		}
+		if shareId == nil {
+			return nil
+		}
+		return tx.Model(&model.AgentShare{}).Where("id = ?", *shareId).
+			UpdateColumn("message_count", gorm.Expr("message_count + 1")).Error
+	})
+}
+
+func (m *models) createEmbed(ctx context.Context, embed *model.AgentEmbed) error {
+	return m.db.WithContext(ctx).Create(embed).Error
+}
+
+func (m *models) listEmbeds(ctx context.Context, agentId uuid.UUID) ([]*model.AgentEmbed, error) {
+	var embeds []*model.AgentEmbed
+	err := m.db.WithContext(ctx).Where("agent_id = ?", agentId).Order("created_at desc").Find(&embeds).Error
+	return embeds, err
+}
+
+func (m *models) getEmbed(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.AgentEmbed, error) {
+	var embed model.AgentEmbed
+	err := m.db.WithContext(ctx).Where("id = ? AND agent_id = ?", id, agentId).First(&embed).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &embed, err
+}
+
+func (m *models) getEmbedByKey(ctx context.Context, key string) (*model.AgentEmbed, error) {
+	var embed model.AgentEmbed
+	err := m.db.WithContext(ctx).Where("key = ?", key).First(&embed).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &embed, err
+}
+
+func (m *models) updateEmbed(ctx context.Context, embed *model.AgentEmbed) error {
+	return m.db.WithContext(ctx).Model(embed).
+		Select("name", "allowed_origins", "revoked_at", "updated_at").
+		Updates(embed).Error
+}
+
+// getVisitorConversation 访客在同一个嵌入密钥下只有一个会话
+func (m *models) getVisitorConversation(ctx context.Context, embedId uuid.UUID, visitorId string) (*model.Conversation, error) {
+	var conversation model.Conversation
+	err := m.db.WithContext(ctx).Where("embed_id = ? AND visitor_id = ?", embedId, visitorId).
+		Order("created_at desc").First(&conversation).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &conversation, err
+}
+
+func (m *models) createConversation(ctx context.Context, conversation *model.Conversation) error {
+	return m.db.WithContext(ctx).Create(conversation).Error
+}
+
+func (m *models) listConversations(ctx context.Context, agentId uuid.UUID, filter ConversationFilter) ([]*model.Conversation, int64, error) {
+	var conversations []*model.Conversation
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.Conversation{}).Where("agent_id = ?", agentId)
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	err := query.Order("last_message_at desc nulls last").Find(&conversations).Error
+	return conversations, total, err
+}
+
+func (m *models) getConversation(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.Conversation, error) {
+	var conversation model.Conversation
+	err := m.db.WithContext(ctx).Where("id = ? AND agent_id = ?", id, agentId).First(&conversation).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &conversation, err
+}
+
+// listMessages 按时间顺序返回会话消息，limit 大于 0 时只返回最近的 limit 条
+func (m *models) listMessages(ctx context.Context, conversationId uuid.UUID, limit int) ([]*model.ConversationMessage, error) {
+	var messages []*model.ConversationMessage
+	query := m.db.WithContext(ctx).Where("conversation_id = ?", conversationId).Order("created_at desc")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+func (m *models) addMessage(ctx context.Context, conversation *model.Conversation, message *model.ConversationMessage) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(conversation).UpdateColumns(map[string]any{
+			"message_count":   gorm.Expr("message_count + 1"),
+			"last_message_at": message.CreatedAt,
+		}).Error
+	})
+}
+
+// getChatSession 查询用户在平台内的会话，不存在或不属于该用户时返回 nil
+func (m *models) getChatSession(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Conversation, error) {
+	var conversation model.Conversation
+	err := m.db.WithContext(ctx).Where("id = ? AND user_id = ? AND channel = ?", id, userId, model.ConversationChannelChat).
+		First(&conversation).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &conversation, err
+}
+
+func (m *models) deleteConversation(ctx context.Context, id uuid.UUID) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ?", id).Delete(&model.ConversationMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.Conversation{}).Error
+	})
+}
+
+func (m *models) createTemplate(ctx context.Context, template *model.AgentTemplate) error {
+	return m.db.WithContext(ctx).Create(template).Error
+}
+
+func (m *models) listTemplates(ctx context.Context, userId uuid.UUID, category string) ([]*model.AgentTemplate, error) {
+	var templates []*model.AgentTemplate
+	query := m.db.WithContext(ctx).Where("creator_id = ?", userId)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Order("created_at desc").Find(&templates).Error
+	return templates, err
+}
+
+func (m *models) getTemplate(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.AgentTemplate, error) {
+	var template model.AgentTemplate
+	err := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userId).First(&template).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &template, err
+}
+
+func (m *models) deleteTemplate(ctx context.Context, userId uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userId).Delete(&model.AgentTemplate{})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) getUserName(ctx context.Context, userId uuid.UUID) (string, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("username").Where("id = ?", userId).First(&user).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return "", nil
+	}
+	return user.Username, err
+}
@@ -0,0 +1,47 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunModels 只生成 SQL 不连接数据库
+func newDryRunModels(t *testing.T) (*models, *[]string) {
+	t.Helper()
+	var statements []string
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewModels(db), &statements
+}
+
+// 通过API调用会使用创建者的工具和凭证，只能调用自己的agent
+func TestInvocableAgentsAreOwnedOnly(t *testing.T) {
+	m, statements := newDryRunModels(t)
+	if _, err := m.listInvocableAgents(context.Background(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.getInvocableAgent(context.Background(), uuid.New(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) == 0 {
+		t.Fatal("no query recorded")
+	}
+	for _, sql := range *statements {
+		if strings.Contains(sql, "visibility") || !strings.Contains(sql, "creator_id = $") {
+			t.Errorf("query should only match the creator: %s", sql)
+		}
+	}
+}
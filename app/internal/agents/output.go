@@ -0,0 +1,103 @@
+package agents
+
+import (
+	"app/shared"
+	"common/biz"
+	"context"
+	"core/ai"
+	"core/ai/jsonschema"
+	"encoding/json"
+	"model"
+	"strings"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+const (
+	// defaultOutputAttempts 没有配置时，回答不符合输出格式最多尝试的次数（包括第一次）
+	defaultOutputAttempts = 3
+	maxOutputAttempts     = 5
+)
+
+// answerResult 非流式运行的结果，Output 是按输出格式解析后的 JSON，没有定义输出格式时为空
+type answerResult struct {
+	*shared.AgentEvaluation
+	Output any
+}
+
+// validateOutputSchema 保存agent时检查输出格式的定义
+func validateOutputSchema(agent *model.Agent) error {
+	if agent.OutputMaxAttempts < 0 || agent.OutputMaxAttempts > maxOutputAttempts {
+		return errs.NewError(biz.ErrAgentOutputSchema.Code, biz.ErrAgentOutputSchema.Msg+"：尝试次数必须在 0 到 5 之间")
+	}
+	if len(agent.OutputSchema) == 0 {
+		return nil
+	}
+	if err := jsonschema.Check(agent.OutputSchema); err != nil {
+		return errs.NewError(biz.ErrAgentOutputSchema.Code, biz.ErrAgentOutputSchema.Msg+"："+err.Error())
+	}
+	return nil
+}
+
+func outputAttempts(agent *model.Agent) int {
+	if agent.OutputMaxAttempts > 0 {
+		return agent.OutputMaxAttempts
+	}
+	return defaultOutputAttempts
+}
+
+// outputSchemaPrompt 附加到系统提示词的输出格式说明，没有定义输出格式时为空
+func outputSchemaPrompt(agent *model.Agent) string {
+	if len(agent.OutputSchema) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(agent.OutputSchema, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return ai.BuildOutputSchemaPrompt(string(data))
+}
+
+// runToAnswer 非流式运行agent。定义了输出格式时校验最终回答，
+// 不符合时把错误作为新的用户消息继续对话，直到符合或者达到尝试次数
+func (s *Service) runToAnswer(ctx context.Context, agent *model.Agent, message string, inputs promptInputs) (*answerResult, error) {
+	loadAgent := func(ctx context.Context) (*model.Agent, error) {
+		return agent, nil
+	}
+	if len(agent.OutputSchema) == 0 {
+		dataChan, errorChan := s.runAgentStream(ctx, message, inputs, loadAgent)
+		result, err := s.collectAnswer(ctx, agent.ID, dataChan, errorChan)
+		if err != nil {
+			return nil, err
+		}
+		return &answerResult{AgentEvaluation: result}, nil
+	}
+	var history []adk.Message
+	var issues []string
+	toolCalls := make([]string, 0)
+	prompt := message
+	attempts := outputAttempts(agent)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		dataChan, errorChan := s.runAgentConversation(ctx, history, prompt, inputs, loadAgent)
+		result, err := s.collectAnswer(ctx, agent.ID, dataChan, errorChan)
+		if err != nil {
+			return nil, err
+		}
+		toolCalls = append(toolCalls, result.ToolCalls...)
+		text := jsonschema.Extract(result.Answer)
+		var output any
+		output, issues = jsonschema.ValidateJSON(agent.OutputSchema, text)
+		if len(issues) == 0 {
+			result.Answer = text
+			result.ToolCalls = toolCalls
+			return &answerResult{AgentEvaluation: result, Output: output}, nil
+		}
+		logs.Warnf("agent %s output does not match schema (attempt %d/%d): %s", agent.ID, attempt, attempts, strings.Join(issues, "; "))
+		history = append(history, schema.UserMessage(prompt), schema.AssistantMessage(result.Answer, nil))
+		prompt = ai.BuildOutputRetryPrompt(issues)
+	}
+	return nil, errs.NewError(biz.ErrAgentOutputInvalid.Code, biz.ErrAgentOutputInvalid.Msg+"："+strings.Join(issues, "; "))
+}
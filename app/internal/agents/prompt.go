@@ -0,0 +1,238 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"fmt"
+	"model"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 内置的提示词变量，agent定义的变量不能和它们重名
+const (
+	builtinVarDate      = "date"
+	builtinVarNow       = "now"
+	builtinVarUserName  = "user_name"
+	builtinVarAgentName = "agent_name"
+)
+
+var builtinVars = map[string]bool{
+	builtinVarDate:      true,
+	builtinVarNow:       true,
+	builtinVarUserName:  true,
+	builtinVarAgentName: true,
+}
+
+// maxVariableLen 单个字符串变量的最大长度
+const maxVariableLen = 4000
+
+// guestUserName 访客对话时 user_name 的值
+const guestUserName = "访客"
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// promptInputs 渲染系统提示词和构建用户消息时调用方提供的内容
+type promptInputs struct {
+	// Variables 请求中传入的变量值
+	Variables map[string]any
+	// UserID 对话的用户，访客为空
+	UserID uuid.UUID
+	// AttachmentIDs 本次消息附带的图片，是 UserID 上传的附件
+	AttachmentIDs []uuid.UUID
+	// SessionID 平台内对话的会话，会话中上传的文件在对话中可用，没有会话时为空
+	SessionID uuid.UUID
+	// Memory 是否使用和更新用户的长期记忆，只在用户和自己的agent对话时开启
+	Memory bool
+}
+
+func promptFormatType(format model.PromptFormat) (schema.FormatType, bool) {
+	switch format {
+	case model.PromptFormatGoTemplate:
+		return schema.GoTemplate, true
+	case model.PromptFormatJinja2:
+		return schema.Jinja2, true
+	}
+	return 0, false
+}
+
+func promptVariableError(format string, args ...any) error {
+	return errs.NewError(biz.ErrPromptVariable.Code, biz.ErrPromptVariable.Msg+"："+fmt.Sprintf(format, args...))
+}
+
+// validatePrompt 保存agent时校验变量定义，并用默认值试渲染一次提示词，模板语法错误在保存时就能发现
+func validatePrompt(ctx context.Context, agent *model.Agent) error {
+	format := agent.PromptFormat
+	if format == "" || format == model.PromptFormatPlain {
+		if len(agent.Variables) > 0 {
+			return promptVariableError("定义变量需要使用模板格式")
+		}
+		return nil
+	}
+	if _, ok := promptFormatType(format); !ok {
+		return biz.ErrAgentPromptTemplate
+	}
+	names := make(map[string]bool)
+	for _, v := range agent.Variables {
+		if !variableNamePattern.MatchString(v.Name) || builtinVars[v.Name] {
+			return promptVariableError("变量名 %q 不可用", v.Name)
+		}
+		if names[v.Name] {
+			return promptVariableError("变量 %s 重复", v.Name)
+		}
+		names[v.Name] = true
+		if v.Type != model.VariableTypeString && v.Type != model.VariableTypeNumber && v.Type != model.VariableTypeBoolean {
+			return promptVariableError("变量 %s 的类型 %q 不支持", v.Name, v.Type)
+		}
+		if v.Default != nil {
+			if _, err := convertVariable(v, v.Default); err != nil {
+				return err
+			}
+		}
+	}
+	values := make(map[string]any)
+	for _, v := range agent.Variables {
+		values[v.Name] = sampleValue(v)
+	}
+	if _, err := renderPrompt(ctx, agent, values, "", time.Now()); err != nil {
+		logs.Warnf("render agent prompt error: %v", err)
+		return errs.NewError(biz.ErrAgentPromptTemplate.Code, biz.ErrAgentPromptTemplate.Msg+"："+err.Error())
+	}
+	return nil
+}
+
+// resolveVariables 按变量定义校验并转换传入的值，未定义的变量忽略
+func resolveVariables(defs model.PromptVariables, inputs map[string]any) (map[string]any, error) {
+	values := make(map[string]any, len(defs))
+	for _, v := range defs {
+		input, ok := inputs[v.Name]
+		if !ok || input == nil {
+			if v.Default != nil {
+				input = v.Default
+			} else if v.Required {
+				return nil, promptVariableError("缺少变量 %s", v.Name)
+			} else {
+				values[v.Name] = zeroValue(v)
+				continue
+			}
+		}
+		value, err := convertVariable(v, input)
+		if err != nil {
+			return nil, err
+		}
+		values[v.Name] = value
+	}
+	return values, nil
+}
+
+// convertVariable 把 JSON 中的值转为变量类型，字符串形式的数字和布尔值也可以接受
+func convertVariable(v model.PromptVariable, input any) (any, error) {
+	switch v.Type {
+	case model.VariableTypeString:
+		s, ok := input.(string)
+		if !ok {
+			return nil, promptVariableError("变量 %s 应该是字符串", v.Name)
+		}
+		if utf8.RuneCountInString(s) > maxVariableLen {
+			return nil, promptVariableError("变量 %s 超过 %d 个字符", v.Name, maxVariableLen)
+		}
+		return s, nil
+	case model.VariableTypeNumber:
+		switch n := input.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case string:
+			if f, err := strconv.ParseFloat(n, 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, promptVariableError("变量 %s 应该是数字", v.Name)
+	case model.VariableTypeBoolean:
+		switch b := input.(type) {
+		case bool:
+			return b, nil
+		case string:
+			if parsed, err := strconv.ParseBool(b); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, promptVariableError("变量 %s 应该是布尔值", v.Name)
+	}
+	return nil, promptVariableError("变量 %s 的类型 %q 不支持", v.Name, v.Type)
+}
+
+func zeroValue(v model.PromptVariable) any {
+	switch v.Type {
+	case model.VariableTypeNumber:
+		return float64(0)
+	case model.VariableTypeBoolean:
+		return false
+	}
+	return ""
+}
+
+func sampleValue(v model.PromptVariable) any {
+	if v.Default != nil {
+		if value, err := convertVariable(v, v.Default); err == nil {
+			return value
+		}
+	}
+	return zeroValue(v)
+}
+
+// renderPrompt 渲染agent的系统提示词，变量值只作为数据传给模板引擎，
+// 用户传入的内容即使包含模板语法也不会再被解析
+func renderPrompt(ctx context.Context, agent *model.Agent, values map[string]any, userName string, now time.Time) (string, error) {
+	formatType, ok := promptFormatType(agent.PromptFormat)
+	if !ok {
+		return agent.SystemPrompt, nil
+	}
+	data := make(map[string]any, len(values)+len(builtinVars))
+	for k, v := range values {
+		data[k] = v
+	}
+	data[builtinVarDate] = now.Format(time.DateOnly)
+	data[builtinVarNow] = now.Format(time.DateTime)
+	data[builtinVarUserName] = userName
+	data[builtinVarAgentName] = agent.Name
+	messages, err := schema.SystemMessage(agent.SystemPrompt).Format(ctx, data, formatType)
+	if err != nil {
+		return "", err
+	}
+	return messages[0].Content, nil
+}
+
+// renderSystemPrompt 对话时渲染系统提示词
+func (s *Service) renderSystemPrompt(ctx context.Context, agent *model.Agent, inputs promptInputs) (string, error) {
+	if _, ok := promptFormatType(agent.PromptFormat); !ok {
+		return agent.SystemPrompt, nil
+	}
+	values, err := resolveVariables(agent.Variables, inputs.Variables)
+	if err != nil {
+		return "", err
+	}
+	userName := guestUserName
+	if inputs.UserID != uuid.Nil {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if userName, err = s.repo.getUserName(ctx, inputs.UserID); err != nil {
+			logs.Errorf("get user name error: %v", err)
+			return "", errs.DBError
+		}
+	}
+	prompt, err := renderPrompt(ctx, agent, values, userName, time.Now())
+	if err != nil {
+		logs.Errorf("render agent %s prompt error: %v", agent.ID, err)
+		return "", biz.ErrAgentPromptTemplate
+	}
+	return prompt, nil
+}
@@ -0,0 +1,52 @@
+package agents
+
+import (
+	"app/shared"
+	"context"
+	"time"
+
+	"github.com/mszlu521/thunder/event"
+)
+
+type PublicService struct {
+	service *Service
+}
+
+func (s *PublicService) InvokeAgent(e event.Event) (any, error) {
+	request := e.Data.(*shared.InvokeAgentRequest)
+	ctx := request.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	resp, err := s.service.invokeAgent(ctx, request.UserID, request.AgentID, InvokeAgentReq{
+		Message:   request.Message,
+		Variables: request.Variables,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Answer, nil
+}
+
+// EvaluateAgent 评测模块运行agent的指定版本
+func (s *PublicService) EvaluateAgent(e event.Event) (any, error) {
+	request := e.Data.(*shared.EvaluateAgentRequest)
+	if request.Ctx == nil {
+		request.Ctx = context.Background()
+	}
+	return s.service.evaluateAgent(request.Ctx, request)
+}
+
+// GetChatSession 查询用户在平台内的会话，不存在时返回 nil
+func (s *PublicService) GetChatSession(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetChatSessionRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.service.repo.getChatSession(ctx, request.UserID, request.SessionID)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		service: NewService(),
+	}
+}
@@ -8,10 +8,46 @@ import (
 )
 
 type repository interface {
-	createAgent(ctx context.Context, agent *model.Agent) error
+	createAgent(ctx context.Context, agent *model.Agent, tools []*model.AgentTool) error
 	listAgents(ctx context.Context, filter AgentFilter, userId uuid.UUID) ([]*model.Agent, int64, error)
 	getAgentById(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Agent, error)
 	updateAgent(ctx context.Context, agent *model.Agent) error
 	deleteAgentTools(ctx context.Context, agentId uuid.UUID) error
 	createAgentTools(ctx context.Context, tools []*model.AgentTool) error
+	listInvocableAgents(ctx context.Context, userId uuid.UUID) ([]*model.Agent, error)
+	getInvocableAgent(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Agent, error)
+	publishAgent(ctx context.Context, agent *model.Agent, version *model.AgentVersion) error
+	listAgentVersions(ctx context.Context, agentId uuid.UUID) ([]*model.AgentVersion, error)
+	getAgentVersion(ctx context.Context, agentId uuid.UUID, version uint) (*model.AgentVersion, error)
+	rollbackAgent(ctx context.Context, agent *model.Agent, snapshot *model.AgentSnapshot) error
+	updateVisibility(ctx context.Context, agent *model.Agent) error
+	updateAgentIcon(ctx context.Context, agent *model.Agent) error
+	createShare(ctx context.Context, share *model.AgentShare) error
+	listShares(ctx context.Context, agentId uuid.UUID) ([]*model.AgentShare, error)
+	getShare(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.AgentShare, error)
+	getShareByToken(ctx context.Context, token string) (*model.AgentShare, error)
+	revokeShare(ctx context.Context, share *model.AgentShare) error
+	getPublishedAgent(ctx context.Context, id uuid.UUID) (*model.Agent, error)
+	listPublicAgents(ctx context.Context, filter AgentFilter) ([]*model.Agent, int64, error)
+	listLiveVersions(ctx context.Context, agents []*model.Agent) ([]*model.AgentVersion, error)
+	getUserPlan(ctx context.Context, userId uuid.UUID) (model.SubscriptionPlan, error)
+	getUserName(ctx context.Context, userId uuid.UUID) (string, error)
+	recordGuestMessage(ctx context.Context, agentId uuid.UUID, shareId *uuid.UUID) error
+	createEmbed(ctx context.Context, embed *model.AgentEmbed) error
+	listEmbeds(ctx context.Context, agentId uuid.UUID) ([]*model.AgentEmbed, error)
+	getEmbed(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.AgentEmbed, error)
+	getEmbedByKey(ctx context.Context, key string) (*model.AgentEmbed, error)
+	updateEmbed(ctx context.Context, embed *model.AgentEmbed) error
+	getVisitorConversation(ctx context.Context, embedId uuid.UUID, visitorId string) (*model.Conversation, error)
+	createConversation(ctx context.Context, conversation *model.Conversation) error
+	listConversations(ctx context.Context, agentId uuid.UUID, filter ConversationFilter) ([]*model.Conversation, int64, error)
+	getConversation(ctx context.Context, agentId uuid.UUID, id uuid.UUID) (*model.Conversation, error)
+	listMessages(ctx context.Context, conversationId uuid.UUID, limit int) ([]*model.ConversationMessage, error)
+	addMessage(ctx context.Context, conversation *model.Conversation, message *model.ConversationMessage) error
+	getChatSession(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.Conversation, error)
+	deleteConversation(ctx context.Context, id uuid.UUID) error
+	createTemplate(ctx context.Context, template *model.AgentTemplate) error
+	listTemplates(ctx context.Context, userId uuid.UUID, category string) ([]*model.AgentTemplate, error)
+	getTemplate(ctx context.Context, userId uuid.UUID, id uuid.UUID) (*model.AgentTemplate, error)
+	deleteTemplate(ctx context.Context, userId uuid.UUID, id uuid.UUID) (int64, error)
 }
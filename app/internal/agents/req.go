@@ -10,6 +10,8 @@ type CreateAgentRequest struct {
 	Name        string            `json:"name" `
 	Description string            `json:"description"`
 	Status      model.AgentStatus `json:"status"`
+	// TemplateId 从模板创建，名称和描述为空时使用模板的
+	TemplateId *uuid.UUID `json:"templateId"`
 }
 
 type SearchRequest struct {
@@ -29,12 +31,26 @@ type UpdateAgentRequest struct {
 	ModelProvider   string            `json:"modelProvider"`
 	ModelParameters model.JSON        `json:"modelParameters"`
 	OpeningDialogue string            `json:"openingDialogue"`
+	// PromptFormat 为空时不修改
+	PromptFormat model.PromptFormat `json:"promptFormat"`
+	// Variables 为 null 时不修改，传空数组清空
+	Variables model.PromptVariables `json:"variables"`
+	// OutputSchema 最终回答的 JSON Schema，为 null 时不修改，传 {} 清空
+	OutputSchema model.JSON `json:"outputSchema"`
+	// OutputMaxAttempts 为 null 时不修改
+	OutputMaxAttempts *int `json:"outputMaxAttempts"`
 }
 
 type AgentMessageReq struct {
 	AgentId   uuid.UUID `json:"agentId"`
 	Message   string    `json:"message"`
 	SessionId uuid.UUID `json:"sessionId"`
+	// Version 使用指定的发布版本对话，为 0 时使用草稿
+	Version uint `json:"version"`
+	// Variables agent定义的提示词变量的值
+	Variables map[string]any `json:"variables"`
+	// Attachments 消息附带的图片，先通过附件接口上传或者添加 URL
+	Attachments []uuid.UUID `json:"attachments"`
 }
 
 type UpdateAgentToolReq struct {
@@ -45,3 +61,110 @@ type ToolItem struct {
 	ID   uuid.UUID `json:"id"`
 	Type string    `json:"type"`
 }
+
+type ImportMcpPromptReq struct {
+	ToolId     uuid.UUID         `json:"toolId"`
+	PromptName string            `json:"promptName"`
+	Arguments  map[string]string `json:"arguments"`
+}
+
+type InvokeAgentReq struct {
+	Message   string         `json:"message"`
+	Variables map[string]any `json:"variables"`
+}
+
+type PublishAgentReq struct {
+	// Note 发布说明
+	Note string `json:"note"`
+}
+
+type DiffAgentVersionsReq struct {
+	// From、To 为 0 表示当前草稿
+	From uint `json:"from" form:"from"`
+	To   uint `json:"to" form:"to"`
+}
+
+type RollbackAgentReq struct {
+	Version uint `json:"version"`
+	// RestoreDraft 同时把草稿恢复为该版本
+	RestoreDraft bool `json:"restoreDraft"`
+}
+
+type UpdateVisibilityReq struct {
+	// Visibility 可见范围：private、public、link_only
+	Visibility model.AgentVisibility `json:"visibility"`
+	// GuestTools 是否允许访客调用配置了凭证的工具，为 null 时不修改
+	GuestTools *bool `json:"guestTools"`
+}
+
+type CreateShareReq struct {
+	Name string `json:"name"`
+	// ExpiresInDays 有效天数，0 表示永不过期
+	ExpiresInDays int `json:"expiresInDays"`
+}
+
+type PublicAgentsReq struct {
+	Name     string `json:"name" form:"name"`
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+type GuestMessageReq struct {
+	Message   string         `json:"message"`
+	Variables map[string]any `json:"variables"`
+}
+
+type EmbedReq struct {
+	Name string `json:"name"`
+	// AllowedOrigins 允许嵌入组件的网站来源
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+type ConversationsReq struct {
+	Channel  string `json:"channel" form:"channel"`
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+type WidgetBootstrapReq struct {
+	// VisitorID 组件保存的访客标识，第一次访问时为空
+	VisitorID string `json:"visitorId" form:"visitorId"`
+}
+
+type WidgetMessageReq struct {
+	VisitorID string         `json:"visitorId"`
+	Message   string         `json:"message"`
+	Variables map[string]any `json:"variables"`
+}
+
+// 导出格式
+const (
+	BundleFormatJSON = "json"
+	BundleFormatYAML = "yaml"
+)
+
+type ExportAgentReq struct {
+	Format string `json:"format" form:"format"`
+	// Version 导出指定的发布版本，为0时导出当前草稿
+	Version int `json:"version" form:"version"`
+}
+
+type ImportAgentReq struct {
+	// DryRun 只检查模型和工具能否关联，不创建agent
+	DryRun bool `json:"dryRun" form:"dryRun"`
+}
+
+type DuplicateAgentReq struct {
+	// Name 副本名称，为空时在原名称后加上"副本"
+	Name string `json:"name"`
+}
+
+type SaveTemplateReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+}
+
+type ListTemplatesReq struct {
+	Category string `json:"category" form:"category"`
+}
@@ -1,8 +1,155 @@
 package agents
 
-import "model"
+import (
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type ListAgentResponse struct {
 	Agents []*model.Agent `json:"agents"`
 	Total  int64          `json:"total"`
 }
+
+type InvokeAgentResponse struct {
+	AgentId uuid.UUID `json:"agentId"`
+	Answer  string    `json:"answer"`
+	// Output agent定义了输出格式时，按格式解析后的回答
+	Output any `json:"output,omitempty"`
+}
+
+type AgentVersionDiffResponse struct {
+	From    uint           `json:"from"`
+	To      uint           `json:"to"`
+	Changes []*FieldChange `json:"changes"`
+}
+
+// FieldChange 快照中一个字段的变化
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// PublicAgent 展示给访客的agent信息，不包含提示词、模型和工具配置
+type PublicAgent struct {
+	ID                 uuid.UUID  `json:"id"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Icon               string     `json:"icon"`
+	OpeningDialogue    string     `json:"openingDialogue"`
+	SuggestedQuestions model.JSON `json:"suggestedQuestions"`
+	// Variables 对话时需要传入的提示词变量
+	Variables       model.PromptVariables `json:"variables"`
+	InvocationCount uint64                `json:"invocationCount"`
+	PublishedAt     *time.Time            `json:"publishedAt"`
+}
+
+// newPublicAgent snapshot 不为空时使用快照中的信息
+func newPublicAgent(agent *model.Agent, snapshot *model.AgentSnapshot) *PublicAgent {
+	if snapshot != nil {
+		versioned := *agent
+		snapshot.Apply(&versioned)
+		agent = &versioned
+	}
+	return &PublicAgent{
+		ID:                 agent.ID,
+		Name:               agent.Name,
+		Description:        agent.Description,
+		Icon:               agent.Icon,
+		OpeningDialogue:    agent.OpeningDialogue,
+		SuggestedQuestions: agent.SuggestedQuestions,
+		Variables:          agent.Variables,
+		InvocationCount:    agent.InvocationCount,
+		PublishedAt:        agent.PublishedAt,
+	}
+}
+
+type ListPublicAgentResponse struct {
+	Agents []*PublicAgent `json:"agents"`
+	Total  int64          `json:"total"`
+}
+
+type ListConversationResponse struct {
+	Conversations []*model.Conversation `json:"conversations"`
+	Total         int64                 `json:"total"`
+}
+
+type WidgetBootstrapResponse struct {
+	Agent *PublicAgent `json:"agent"`
+	// VisitorID 组件需要保存下来，之后的请求都带上
+	VisitorID      string                       `json:"visitorId"`
+	ConversationID *uuid.UUID                   `json:"conversationId"`
+	Messages       []*model.ConversationMessage `json:"messages"`
+}
+
+// AgentBundleVersion 导出文件的格式版本，格式不兼容时增加
+const AgentBundleVersion = 1
+
+// AgentBundle 导出的agent，不包含ID、创建者和任何凭证，可以导入到其他环境
+type AgentBundle struct {
+	BundleVersion int          `json:"bundleVersion"`
+	ExportedAt    time.Time    `json:"exportedAt"`
+	Agent         BundleAgent  `json:"agent"`
+	Tools         []BundleTool `json:"tools"`
+}
+
+type BundleAgent struct {
+	Name               string                `json:"name"`
+	Description        string                `json:"description,omitempty"`
+	Icon               string                `json:"icon,omitempty"`
+	SystemPrompt       string                `json:"systemPrompt"`
+	PromptFormat       model.PromptFormat    `json:"promptFormat,omitempty"`
+	Variables          model.PromptVariables `json:"variables,omitempty"`
+	OutputSchema       model.JSON            `json:"outputSchema,omitempty"`
+	OutputMaxAttempts  int                   `json:"outputMaxAttempts,omitempty"`
+	ModelProvider      string                `json:"modelProvider"`
+	ModelName          string                `json:"modelName"`
+	ModelParameters    model.JSON            `json:"modelParameters,omitempty"`
+	OpeningDialogue    string                `json:"openingDialogue,omitempty"`
+	SuggestedQuestions model.JSON            `json:"suggestedQuestions,omitempty"`
+}
+
+// BundleTool 导入时按名称关联目标环境中的工具，配置只用于参考和手动重建，
+// 凭证、请求头的值、MCP的地址、启动参数和环境变量的值都不会导出
+type BundleTool struct {
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description,omitempty"`
+	ToolType         model.ToolType         `json:"toolType"`
+	ParametersSchema model.ParametersSchema `json:"parametersSchema,omitempty"`
+	McpConfig        *model.McpConfig       `json:"mcpConfig,omitempty"`
+	HttpConfig       *model.HttpConfig      `json:"httpConfig,omitempty"`
+	// DataSource 只记录数据源的名称和类型，连接串不会导出
+	DataSource *BundleDataSource `json:"dataSource,omitempty"`
+}
+
+type BundleDataSource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// 导入时工具的处理结果
+const (
+	BundleToolMatched = "matched"
+	BundleToolMissing = "missing"
+)
+
+type ImportedTool struct {
+	Name     string         `json:"name"`
+	ToolType model.ToolType `json:"toolType"`
+	Status   string         `json:"status"`
+	// ToolID 关联到的目标环境中的工具
+	ToolID *uuid.UUID `json:"toolId,omitempty"`
+	// Inputs 找不到的工具按导出文件重建时需要填写的配置，如 mcpConfig.url、httpConfig.headers.X-Api-Key、credential
+	Inputs []string `json:"inputs,omitempty"`
+}
+
+type ImportAgentResponse struct {
+	DryRun bool `json:"dryRun"`
+	// Agent 导入后的agent，试运行时为空
+	Agent *model.Agent    `json:"agent"`
+	Tools []*ImportedTool `json:"tools"`
+	// MissingProvider 目标环境没有配置的模型，格式为 provider/modelName
+	MissingProvider string `json:"missingProvider,omitempty"`
+}
@@ -6,27 +6,20 @@ import (
 	"context"
 	"core/ai"
 	"core/ai/mcps"
-	"core/ai/tools"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"model"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/cloudwego/eino-ext/components/model/ollama"
-	"github.com/cloudwego/eino-ext/components/model/openai"
-	"github.com/cloudwego/eino-ext/components/model/qwen"
 	"github.com/cloudwego/eino/adk"
 	"github.com/cloudwego/eino/adk/prebuilt/supervisor"
-	aiModel "github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-	"github.com/eino-contrib/ollama/api"
 	"github.com/google/uuid"
-	"github.com/mszlu521/thunder/ai/einos"
 	"github.com/mszlu521/thunder/database"
 	"github.com/mszlu521/thunder/errs"
 	"github.com/mszlu521/thunder/event"
@@ -46,27 +39,38 @@ func NewService() *Service {
 func (s *Service) createAgent(parent context.Context, req *CreateAgentRequest, userId uuid.UUID) (*model.Agent, error) {
 	ctx, cancel := context.WithTimeout(parent, 5*time.Second) // 子上下文，不能超过10s
 	defer cancel()
-	agent := &model.Agent{
-		BaseModel: model.BaseModel{
-			ID: uuid.New(),
-		},
-		Name:            req.Name,
-		Description:     req.Description,
-		Status:          req.Status,
-		CreatorID:       userId,
-		SystemPrompt:    "",
-		ModelProvider:   "",
-		ModelName:       "",
-		ModelParameters: model.JSON{},
-		Version:         0,
-		Visibility:      model.Private,
-		InvocationCount: 0,
-		OpeningDialogue: "",
-	}
-	err := s.repo.createAgent(ctx, agent)
-	if err != nil {
-		logs.Errorf("create agent error: %v", err)
-		return nil, errs.DBError
+	status := req.Status
+	if status == "" {
+		status = model.Draft
+	}
+	agent := model.DefaultAgent(userId, req.Name, req.Description, status)
+	var toolIds []uuid.UUID
+	if req.TemplateId != nil {
+		template, err := s.getTemplate(ctx, userId, *req.TemplateId)
+		if err != nil {
+			return nil, err
+		}
+		template.Snapshot.Apply(agent)
+		if req.Name != "" {
+			agent.Name = req.Name
+		}
+		if req.Description != "" {
+			agent.Description = req.Description
+		}
+		// 模板中的工具可能已经删除或者取消安装，只关联仍然可以使用的
+		if len(template.Snapshot.ToolIds) > 0 {
+			usableTools, err := s.getToolsByIds(userId, template.Snapshot.ToolIds)
+			if err != nil {
+				logs.Errorf("getToolsByIds error: %v", err)
+				return nil, errs.DBError
+			}
+			for _, t := range usableTools {
+				toolIds = append(toolIds, t.ID)
+			}
+		}
+	}
+	if err := s.createAgentWithTools(ctx, agent, toolIds); err != nil {
+		return nil, err
 	}
 	return agent, nil
 }
@@ -102,6 +106,14 @@ func (s *Service) getAgent(ctx context.Context, userId uuid.UUID, id uuid.UUID)
 	if agent == nil {
 		return nil, biz.ErrAgentNotFound
 	}
+	for _, t := range agent.Tools {
+		// 安装的工具属于其他用户，配置不能返回
+		if t.CreatorID != userId {
+			t.HideConfig()
+		} else {
+			t.MaskCredentials()
+		}
+	}
 	return agent, err
 }
 
@@ -110,9 +122,13 @@ func (s *Service) updateAgent(ctx context.Context, userId uuid.UUID, req *Update
 	defer cancel()
 	// 先查询Id是否存在
 	agent, err := s.repo.getAgentById(ctx, userId, req.Id)
-	if err != nil {
+	if err != nil || agent == nil {
 		return nil, biz.ErrAgentNotFound
 	}
+	// 发布会生成版本快照，直接改状态会让没有快照的草稿上线
+	if req.Status != "" && req.Status != agent.Status {
+		return nil, biz.ErrAgentStatusChange
+	}
 
 	if req.Name != "" {
 		agent.Name = req.Name
@@ -122,9 +138,6 @@ func (s *Service) updateAgent(ctx context.Context, userId uuid.UUID, req *Update
 		agent.Description = req.Description
 	}
 
-	if req.Status != "" {
-		agent.Status = req.Status
-	}
 	if req.ModelProvider != "" {
 		agent.ModelProvider = req.ModelProvider
 	}
@@ -140,6 +153,24 @@ func (s *Service) updateAgent(ctx context.Context, userId uuid.UUID, req *Update
 	if req.OpeningDialogue != "" {
 		agent.OpeningDialogue = req.OpeningDialogue
 	}
+	if req.PromptFormat != "" {
+		agent.PromptFormat = req.PromptFormat
+	}
+	if req.Variables != nil {
+		agent.Variables = req.Variables
+	}
+	if req.OutputSchema != nil {
+		agent.OutputSchema = req.OutputSchema
+	}
+	if req.OutputMaxAttempts != nil {
+		agent.OutputMaxAttempts = *req.OutputMaxAttempts
+	}
+	if err := validatePrompt(ctx, agent); err != nil {
+		return nil, err
+	}
+	if err := validateOutputSchema(agent); err != nil {
+		return nil, err
+	}
 	if err := s.repo.updateAgent(ctx, agent); err != nil {
 		return nil, errs.DBError
 	}
@@ -147,7 +178,37 @@ func (s *Service) updateAgent(ctx context.Context, userId uuid.UUID, req *Update
 
 }
 
-func (s *Service) agentMessageStream(ctx context.Context, userID uuid.UUID, req AgentMessageReq) (<-chan string, <-chan error) {
+// agentMessageStream 指定了会话时，消息和回答保存在会话中，会话中上传的文件可以在对话中使用
+func (s *Service) agentMessageStream(ctx context.Context, userID uuid.UUID, req AgentMessageReq) (<-chan string, <-chan error, error) {
+	inputs := promptInputs{Variables: req.Variables, UserID: userID, AttachmentIDs: req.Attachments, Memory: true}
+	loadAgent := func(ctx context.Context) (*model.Agent, error) {
+		agent, err := s.repo.getAgentById(ctx, userID, req.AgentId)
+		if err != nil || agent == nil || req.Version == 0 {
+			return agent, err
+		}
+		return s.loadAgentVersion(ctx, agent, req.Version)
+	}
+	if req.SessionId == uuid.Nil {
+		dataChan, errorChan := s.runAgentStream(ctx, req.Message, inputs, loadAgent)
+		return dataChan, errorChan, nil
+	}
+	session, history, err := s.prepareChatSession(ctx, userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs.SessionID = session.ID
+	dataChan, errorChan := s.runAgentConversation(ctx, history, req.Message, inputs, loadAgent)
+	out, errOut := s.recordAnswer(ctx, session, dataChan, errorChan)
+	return out, errOut, nil
+}
+
+// runAgentStream 运行agent并以流的方式返回消息，loadAgent 决定了调用方可以使用哪些agent
+func (s *Service) runAgentStream(ctx context.Context, message string, inputs promptInputs, loadAgent func(ctx context.Context) (*model.Agent, error)) (<-chan string, <-chan error) {
+	return s.runAgentConversation(ctx, nil, message, inputs, loadAgent)
+}
+
+// runAgentConversation 和 runAgentStream 相同，history 是本次消息之前的对话记录
+func (s *Service) runAgentConversation(ctx context.Context, history []adk.Message, message string, inputs promptInputs, loadAgent func(ctx context.Context) (*model.Agent, error)) (<-chan string, <-chan error) {
 	dataChan := make(chan string, 100)
 	errorChan := make(chan error, 10)
 	go func() {
@@ -167,14 +228,31 @@ func (s *Service) agentMessageStream(ctx context.Context, userID uuid.UUID, req
 		}()
 
 		// 获取Agent
-		agent, err := s.repo.getAgentById(ctx, userID, req.AgentId)
+		agent, err := loadAgent(ctx)
+		if err != nil {
+			s.sendError(ctx, errorChan, err)
+			return
+		}
+		if agent == nil {
+			s.sendError(ctx, errorChan, biz.ErrAgentNotFound)
+			return
+		}
+
+		userMessage, err := s.buildUserMessage(ctx, agent, message, inputs)
+		if err != nil {
+			s.sendError(ctx, errorChan, err)
+			return
+		}
+
+		// 会话中的文件：小文件直接放入上下文，大文件检索相关片段，工具可以通过文件ID读取
+		ctx, ragContext, err := s.prepareSessionFiles(ctx, message, inputs)
 		if err != nil {
 			s.sendError(ctx, errorChan, err)
 			return
 		}
 
 		// 使用eino 框架中的 adk 来进行agent开发，这里需要创建一个主agent，支持多智能体协同工作
-		mainAgent, err := s.buildMainAgent(ctx, agent, req.Message, dataChan)
+		mainAgent, err := s.buildMainAgent(ctx, agent, message, ragContext, inputs, dataChan)
 		if err != nil {
 			s.sendError(ctx, errorChan, err)
 			return
@@ -193,7 +271,9 @@ func (s *Service) agentMessageStream(ctx context.Context, userID uuid.UUID, req
 			Agent:           supervisorAgent,
 			EnableStreaming: true,
 		})
-		iter := runner.Query(ctx, req.Message)
+		input := append(slices.Clip(history), userMessage)
+		iter := runner.Run(ctx, input)
+		var answer string
 		for {
 			events, ok := iter.Next()
 			if !ok {
@@ -234,13 +314,136 @@ func (s *Service) agentMessageStream(ctx context.Context, userID uuid.UUID, req
 					// 可以在这里打印日志，但不要用 println (非并发安全且无法分级)
 					// logs.Infof(...)
 					s.sendData(ctx, dataChan, ai.BuildContentMessage(events.AgentName, msg.ToolName, msg.Content))
+					if msg.ToolName == "" {
+						answer = msg.Content
+					}
 				}
 			}
 		}
+		if answer != "" {
+			s.rememberTurn(agent, message, answer, inputs)
+		}
 	}()
 	return dataChan, errorChan
 }
 
+func (s *Service) listInvocableAgents(ctx context.Context, userID uuid.UUID) ([]*model.Agent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agents, err := s.repo.listInvocableAgents(ctx, userID)
+	if err != nil {
+		logs.Errorf("list invocable agents error: %v", err)
+		return nil, errs.DBError
+	}
+	return agents, nil
+}
+
+// invokeAgent 走和对话一样的流程运行agent，等待运行结束后返回最终回答
+func (s *Service) invokeAgent(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req InvokeAgentReq) (*InvokeAgentResponse, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getInvocableAgent(dbCtx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get invocable agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	// 通过API调用时使用线上版本，不受草稿修改的影响
+	agent, err = s.loadPublishedAgent(dbCtx, agent)
+	if err != nil {
+		logs.Errorf("load published agent error: %v", err)
+		return nil, errs.DBError
+	}
+	inputs := promptInputs{Variables: req.Variables, UserID: userID}
+	result, err := s.runToAnswer(ctx, agent, req.Message, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return &InvokeAgentResponse{AgentId: agentId, Answer: result.Answer, Output: result.Output}, nil
+}
+
+// evaluateAgent 评测时运行agent的指定版本，除了回答还返回调用过的工具
+func (s *Service) evaluateAgent(ctx context.Context, req *shared.EvaluateAgentRequest) (*shared.AgentEvaluation, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(dbCtx, req.UserID, req.AgentID)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	if req.Version > 0 {
+		agent, err = s.loadAgentVersion(dbCtx, agent, req.Version)
+		if errors.Is(err, biz.ErrAgentVersionNotFound) {
+			return nil, err
+		}
+		if err != nil {
+			logs.Errorf("load agent version error: %v", err)
+			return nil, errs.DBError
+		}
+	}
+	inputs := promptInputs{Variables: req.Variables, UserID: req.UserID}
+	result, err := s.runToAnswer(ctx, agent, req.Message, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return result.AgentEvaluation, nil
+}
+
+// collectAnswer 等待agent运行结束，最后一条模型输出是最终回答
+func (s *Service) collectAnswer(ctx context.Context, agentId uuid.UUID, dataChan <-chan string, errorChan <-chan error) (*shared.AgentEvaluation, error) {
+	result := &shared.AgentEvaluation{ToolCalls: []string{}}
+	for dataChan != nil || errorChan != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case data, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			var msg ai.AgentMessage
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				continue
+			}
+			if msg.IsErr {
+				logs.Errorf("invoke agent %s error: %s", agentId, msg.Content)
+				return nil, biz.ErrAgentInvoke
+			}
+			// 工具返回的内容不是回答，按顺序记录调用过的工具
+			if msg.ToolName != "" {
+				if msg.Content != "" {
+					result.ToolCalls = append(result.ToolCalls, msg.ToolName)
+				}
+				continue
+			}
+			if msg.Content != "" {
+				result.Answer = msg.Content
+			}
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+				continue
+			}
+			if errors.Is(err, biz.ErrAgentNotFound) || errors.Is(err, biz.ErrAgentVersionNotFound) {
+				return nil, err
+			}
+			// 变量和模板错误需要调用方修正请求，原样返回
+			var bizErr *errs.Errors
+			if errors.As(err, &bizErr) && (bizErr.Code == biz.ErrPromptVariable.Code || bizErr.Code == biz.ErrAgentPromptTemplate.Code) {
+				return nil, err
+			}
+			logs.Errorf("invoke agent %s error: %v", agentId, err)
+			return nil, biz.ErrAgentInvoke
+		}
+	}
+	return result, nil
+}
+
 func (s *Service) sendError(ctx context.Context, errorChan chan error, err error) {
 	select {
 	case <-ctx.Done():
@@ -258,7 +461,7 @@ func (s *Service) sendData(ctx context.Context, dataChan chan string, data strin
 }
 
 // 创建主agent
-func (s *Service) buildMainAgent(ctx context.Context, agent *model.Agent, message string, dataChan chan string) (adk.Agent, error) {
+func (s *Service) buildMainAgent(ctx context.Context, agent *model.Agent, message string, ragContext string, inputs promptInputs, dataChan chan string) (adk.Agent, error) {
 	// 1. 先获取当前agent的模型配置信息
 	providerConfig, err := s.getProviderConfig(ctx, model.LLMTypeChat, agent.ModelProvider, agent.ModelName)
 	if err != nil {
@@ -268,34 +471,40 @@ func (s *Service) buildMainAgent(ctx context.Context, agent *model.Agent, messag
 		return nil, biz.ProviderConfigNotFound
 	}
 	// 构建 chatmodel，这里需要调用llms包中的服务，所以需要定义,调用event事件
-	chatModel, err := s.buildToolCallingChatModel(ctx, agent, providerConfig)
+	// 定义了输出格式时，提供商支持的话使用原生的结构化输出，同时在提示词中说明格式
+	chatModel, err := shared.BuildChatModelWithOptions(ctx, providerConfig, agent.ModelName, agent.ModelParameters, shared.ChatModelOptions{
+		OutputSchema: agent.OutputSchema,
+	})
 	if err != nil {
 		logs.Error("Failed to build tool calling chat model", "err", err)
 		return nil, err
 	}
+	// 变量缺失等错误在调用模型之前返回
+	systemPrompt, err := s.renderSystemPrompt(ctx, agent, inputs)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt += outputSchemaPrompt(agent)
+	memories := s.recallMemories(ctx, agent, message, inputs)
+	toolList, err := s.usableTools(agent)
+	if err != nil {
+		logs.Errorf("get usable tools error: %v", err)
+		return nil, errs.DBError
+	}
 	var allTools []tool.BaseTool
-	allTools = append(allTools, s.buildTools(agent)...)
+	allTools = append(allTools, shared.BuildTools(toolList)...)
 	// 构建系统提示词 adk.NewChatModelAgent 实现了ReAct模式，能调用工具，多智能体协作
 	//systemPrompt := fmt.Sprintf(ai.BASE_ADK_TEMPLATE, agentInfo.SystemPrompt, ragContext)
 	modelAgent, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
 		Model:       chatModel,
 		Description: agent.Description,
 		Name:        agent.Name,
-		Instruction: agent.SystemPrompt, // 基础提示词
+		Instruction: systemPrompt, // 基础提示词
 		// GenModelInput 是发送给 大模型前做的处理
 		GenModelInput: func(ctx context.Context, instruction string, input *adk.AgentInput) ([]adk.Message, error) {
-			template := prompt.FromMessages(schema.FString,
-				schema.SystemMessage(ai.BaseSystemPrompt),
-			)
-			messages, err := template.Format(ctx, map[string]any{
-				"role":       agent.SystemPrompt,
-				"toolsInfo":  s.formatToolsInfo(allTools),
-				"agentsInfo": "",
-				"ragContext": "",
-			})
-			if err != nil {
-				logs.Error("Failed to format template", "err", err)
-				return nil, err
+			// 用户的提示词中可能有花括号，不能再作为 FString 模板解析
+			messages := []adk.Message{
+				schema.SystemMessage(ai.BuildSystemPrompt(systemPrompt, ragContext, memories, s.formatToolsInfo(allTools), "")),
 			}
 			messages = append(messages, input.Messages...)
 			return messages, nil // messages 是最终给模型输入的内容
@@ -333,57 +542,6 @@ func (s *Service) getProviderConfig(ctx context.Context, llmType model.LLMType,
 
 }
 
-func (s *Service) buildToolCallingChatModel(ctx context.Context, agentInfo *model.Agent, config *model.ProviderConfig) (aiModel.ToolCallingChatModel, error) {
-	var chatModel aiModel.ToolCallingChatModel
-	var err error
-	modelParams := agentInfo.ModelParameters.ToModelParams()
-	temperature := float32(modelParams.Temperature)
-	topP := float32(modelParams.TopP)
-	maxTokens := modelParams.MaxTokens
-
-	// 打印配置信息以便调试
-	logs.Infof("Building chat model - Provider: %s, BaseURL: %s, Model: %s", config.Provider, config.APIBase, agentInfo.ModelName)
-
-	if config.Provider == model.OllamaProvider {
-		// 创建聊天模型
-		chatModel, err = ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
-			BaseURL: config.APIBase,
-			Model:   agentInfo.ModelName,
-			Options: &api.Options{
-				Temperature: temperature,
-				TopP:        topP,
-				Runner: api.Runner{
-					NumCtx: maxTokens,
-				},
-			},
-		})
-	} else if config.Provider == model.QwenProvider {
-		chatModel, err = qwen.NewChatModel(ctx, &qwen.ChatModelConfig{
-			BaseURL:     config.APIBase,
-			APIKey:      config.APIKey,
-			Model:       agentInfo.ModelName,
-			MaxTokens:   &maxTokens,
-			Temperature: &temperature,
-			TopP:        &topP,
-		})
-	} else {
-		chatModel, err = openai.NewChatModel(ctx, &openai.ChatModelConfig{
-			BaseURL:             config.APIBase,
-			APIKey:              config.APIKey,
-			Model:               agentInfo.ModelName,
-			MaxCompletionTokens: &maxTokens,
-			Temperature:         &temperature,
-			TopP:                &topP,
-		})
-	}
-	if err != nil {
-		logs.Error("Failed to create chat model", "err", err)
-		return nil, err
-	}
-	return chatModel, nil
-
-}
-
 func (s *Service) updateAgentTool(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req UpdateAgentToolReq) (any, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
@@ -411,7 +569,7 @@ func (s *Service) updateAgentTool(ctx context.Context, userID uuid.UUID, agentId
 		toolIds = append(toolIds, v.ID)
 	}
 	//获取到工具的ID，去工具表查询出对应的工具信息
-	toolsList, err := s.getToolsByIds(toolIds)
+	toolsList, err := s.getToolsByIds(userID, toolIds)
 	for _, t := range toolsList {
 		agentTools = append(agentTools, &model.AgentTool{
 			AgentID:   agentId,
@@ -429,50 +587,91 @@ func (s *Service) updateAgentTool(ctx context.Context, userID uuid.UUID, agentId
 	return agentTools, nil
 }
 
-func (s *Service) getToolsByIds(ids []uuid.UUID) ([]*model.Tool, error) {
+func (s *Service) importMcpPrompt(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req ImportMcpPromptReq) (*model.Agent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	toolsList, err := s.getToolsByIds(userID, []uuid.UUID{req.ToolId})
+	if err != nil {
+		logs.Errorf("get tools error: %v", err)
+		return nil, errs.DBError
+	}
+	// 只能使用自己创建的MCP工具
+	if len(toolsList) == 0 || toolsList[0].CreatorID != userID {
+		return nil, biz.ErrToolNotExisted
+	}
+	if toolsList[0].McpConfig == nil {
+		return nil, biz.ErrMcpConfigNotExisted
+	}
+	mcpConfig, err := shared.BuildMcpServerConfig(toolsList[0].McpConfig)
+	if err != nil {
+		logs.Errorf("build mcp config error: %v", err)
+		return nil, biz.ErrGetMcpPrompts
+	}
+	prompt, err := mcps.GetManager().GetPromptText(ctx, mcpConfig, req.PromptName, req.Arguments)
+	if err != nil {
+		logs.Errorf("get mcp prompt error: %v", err)
+		return nil, biz.ErrGetMcpPrompts
+	}
+	agent.SystemPrompt = prompt
+	if err := s.repo.updateAgent(ctx, agent); err != nil {
+		logs.Errorf("update agent error: %v", err)
+		return nil, errs.DBError
+	}
+	return agent, nil
+}
+
+// getToolsByIds 只返回用户自己创建的和已安装的工具
+func (s *Service) getToolsByIds(userID uuid.UUID, ids []uuid.UUID) ([]*model.Tool, error) {
 	//event 获取工具信息
 	trigger, err := event.Trigger("getToolsByIds", &shared.GetToolsByIdsRequest{
-		Ids: ids,
+		Ids:    ids,
+		UserID: userID,
 	})
-	return trigger.([]*model.Tool), err
+	if err != nil {
+		return nil, err
+	}
+	return trigger.([]*model.Tool), nil
 }
 
-func (s *Service) buildTools(agent *model.Agent) []tool.BaseTool {
-	var agentTools []tool.BaseTool
-	for _, v := range agent.Tools {
-		// 工具类型又system和mcp两种
-		switch v.ToolType {
-		case model.McpToolType:
-			// 查询出MCP工具列表，转为Eino中的BaseTool
-			mcpConfigs := einos.McpConfig{
-				BaseUrl: v.McpConfig.Url,
-				Token:   v.McpConfig.CredentialType,
-				Name:    "FaberAI",
-				Version: "1.0.0",
-			}
-			baseTools, err := mcps.GetEinoBaseTools(context.Background(), &mcpConfigs)
-			if err != nil {
-				logs.Warnf("获取MCP工具列表时出错: %v", err)
-				continue
-			}
-			agentTools = append(agentTools, baseTools...)
-		case model.SystemToolType:
-			// 根据名称获取工具
-			systemTool := s.loadSystemTool(v.Name)
-			if systemTool == nil {
-				logs.Warnf("加载系统工具时，找不到工具: %s", v.Name)
-				continue
-			}
-			agentTools = append(agentTools, systemTool)
-		default:
-			logs.Warnf("Unknown tool type: %s", v.ToolType)
-		}
+// usableTools 运行前按agent创建者重新查询关联的工具，
+// 安装的工具可能已被卸载、改回私有，或者创建者已经离开了工具所在的组织
+func (s *Service) usableTools(agent *model.Agent) ([]*model.Tool, error) {
+	ids := make([]uuid.UUID, len(agent.Tools))
+	for i, t := range agent.Tools {
+		ids[i] = t.ID
+	}
+	toolList, err := s.usableToolsByIds(agent.CreatorID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(toolList) < len(ids) {
+		logs.Warnf("agent %s 有 %d 个工具已不可用", agent.ID, len(ids)-len(toolList))
 	}
-	return agentTools
+	return toolList, nil
 }
 
-func (s *Service) loadSystemTool(name string) tool.BaseTool {
-	return tools.FindTool(name)
+// usableToolsByIds 过滤掉用户不能使用的工具，结果按 ids 的顺序排列
+func (s *Service) usableToolsByIds(userID uuid.UUID, ids []uuid.UUID) ([]*model.Tool, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	toolList, err := s.getToolsByIds(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	// 保持关联时的顺序
+	slices.SortFunc(toolList, func(a, b *model.Tool) int {
+		return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+	})
+	return toolList, nil
 }
 
 func (s *Service) formatToolsInfo(allTools []tool.BaseTool) string {
@@ -0,0 +1,56 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"errors"
+	"model"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// 运行时按创建者重新查询工具，已卸载或不再共享的工具被去掉，其余保持关联时的顺序
+func TestUsableToolsRechecksTools(t *testing.T) {
+	creatorID := uuid.New()
+	own, installed, revoked := newTool("own"), newTool("installed"), newTool("revoked")
+	requests := registerUsableTools(own, installed)
+	agent := &model.Agent{CreatorID: creatorID, Tools: []*model.Tool{installed, revoked, own}}
+	s := &Service{}
+	toolList, err := s.usableTools(agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 || (*requests)[0].UserID != creatorID || len((*requests)[0].Ids) != 3 {
+		t.Fatalf("requests = %+v", *requests)
+	}
+	if !slices.Equal(toolList, []*model.Tool{installed, own}) {
+		t.Errorf("tools = %v", toolList)
+	}
+
+	empty, err := s.usableTools(&model.Agent{CreatorID: creatorID})
+	if err != nil || len(empty) != 0 || len(*requests) != 1 {
+		t.Errorf("usableTools() = %v, %v", empty, err)
+	}
+}
+
+func TestUpdateAgentRejectsStatusChange(t *testing.T) {
+	agent := &model.Agent{Name: "draft", Status: model.Draft}
+	repo := &fakeRepo{agent: agent}
+	s := &Service{repo: repo}
+	_, err := s.updateAgent(context.Background(), uuid.New(), &UpdateAgentRequest{Name: "renamed", Status: model.AgentStatus(model.Published)})
+	if !errors.Is(err, biz.ErrAgentStatusChange) {
+		t.Fatalf("err = %v, want ErrAgentStatusChange", err)
+	}
+	if repo.updated != nil || agent.Status != model.Draft {
+		t.Fatal("agent should not be updated")
+	}
+	// 前端回传当前状态时不算修改
+	if _, err := s.updateAgent(context.Background(), uuid.New(), &UpdateAgentRequest{Name: "renamed", Status: model.Draft}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.updated == nil || repo.updated.Name != "renamed" || repo.updated.Status != model.Draft {
+		t.Fatalf("updated = %+v", repo.updated)
+	}
+}
@@ -0,0 +1,89 @@
+package agents
+
+import (
+	"app/shared"
+	"common/biz"
+	"context"
+	"model"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// createSession 用户在平台内和自己的agent开始一个新会话，会话列表通过 conversations?channel=chat 查询
+func (s *Service) createSession(ctx context.Context, userID uuid.UUID, agentId uuid.UUID) (*model.Conversation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	session := &model.Conversation{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		AgentID: agentId,
+		Channel: model.ConversationChannelChat,
+		UserID:  &userID,
+	}
+	if err := s.repo.createConversation(ctx, session); err != nil {
+		logs.Errorf("create conversation error: %v", err)
+		return nil, errs.DBError
+	}
+	return session, nil
+}
+
+// deleteSession 删除会话、会话消息和会话中上传的文件
+func (s *Service) deleteSession(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, sessionId uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	session, err := s.getChatSession(ctx, userID, agentId, sessionId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.deleteConversation(ctx, session.ID); err != nil {
+		logs.Errorf("delete conversation error: %v", err)
+		return errs.DBError
+	}
+	// 会话已经删除，文件删除失败只记录日志，不影响结果
+	if _, err := event.Trigger("deleteSessionFiles", &shared.DeleteSessionFilesRequest{SessionID: session.ID}); err != nil {
+		logs.Errorf("delete session files error: %v", err)
+	}
+	sessionRetrievers.evict(session.ID)
+	return nil
+}
+
+// getChatSession 查询用户在指定agent下的会话
+func (s *Service) getChatSession(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, sessionId uuid.UUID) (*model.Conversation, error) {
+	session, err := s.repo.getChatSession(ctx, userID, sessionId)
+	if err != nil {
+		logs.Errorf("get chat session error: %v", err)
+		return nil, errs.DBError
+	}
+	if session == nil || session.AgentID != agentId {
+		return nil, biz.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// prepareChatSession 保存本次消息并返回会话之前的对话记录
+func (s *Service) prepareChatSession(ctx context.Context, userID uuid.UUID, req AgentMessageReq) (*model.Conversation, []adk.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	session, err := s.getChatSession(ctx, userID, req.AgentId, req.SessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	history, err := s.conversationHistory(ctx, session)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.addMessage(ctx, session, newConversationMessage(session, model.MessageRoleUser, req.Message)); err != nil {
+		logs.Errorf("add conversation message error: %v", err)
+		return nil, nil, errs.DBError
+	}
+	return session, history, nil
+}
@@ -0,0 +1,336 @@
+package agents
+
+import (
+	"common/biz"
+	"common/configs"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"model"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 分享令牌的随机字节数
+const shareTokenBytes = 24
+
+// guestTarget 访客要对话的agent，share 为空表示从公开目录进入
+type guestTarget struct {
+	agent *model.Agent
+	share *model.AgentShare
+}
+
+// updateVisibility 修改agent的可见范围，改为私有后所有分享链接立即失效
+func (s *Service) updateVisibility(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req UpdateVisibilityReq) (*model.Agent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	switch req.Visibility {
+	case model.Private, model.AgentVisibility(model.Public), model.AgentVisibility(model.LinkOnly):
+	default:
+		return nil, biz.ErrAgentVisibility
+	}
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	agent.Visibility = req.Visibility
+	if req.GuestTools != nil {
+		agent.GuestTools = *req.GuestTools
+	}
+	agent.UpdatedAt = time.Now()
+	if err := s.repo.updateVisibility(ctx, agent); err != nil {
+		logs.Errorf("update agent visibility error: %v", err)
+		return nil, errs.DBError
+	}
+	return agent, nil
+}
+
+func (s *Service) createShare(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req CreateShareReq) (*model.AgentShare, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	tokenBytes := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		logs.Errorf("generate share token error: %v", err)
+		return nil, biz.ErrTokenGenerate
+	}
+	share := &model.AgentShare{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		AgentID:   agentId,
+		CreatorID: userID,
+		Name:      req.Name,
+		Token:     hex.EncodeToString(tokenBytes),
+	}
+	share.CreatedAt = time.Now()
+	share.UpdatedAt = share.CreatedAt
+	if req.ExpiresInDays > 0 {
+		expiresAt := share.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		share.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.createShare(ctx, share); err != nil {
+		logs.Errorf("create agent share error: %v", err)
+		return nil, errs.DBError
+	}
+	return share, nil
+}
+
+func (s *Service) listShares(ctx context.Context, userID uuid.UUID, agentId uuid.UUID) ([]*model.AgentShare, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	shares, err := s.repo.listShares(ctx, agentId)
+	if err != nil {
+		logs.Errorf("list agent shares error: %v", err)
+		return nil, errs.DBError
+	}
+	return shares, nil
+}
+
+func (s *Service) revokeShare(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, shareId uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return err
+	}
+	share, err := s.repo.getShare(ctx, agentId, shareId)
+	if err != nil {
+		logs.Errorf("get agent share error: %v", err)
+		return errs.DBError
+	}
+	if share == nil {
+		return biz.ErrAgentShareNotFound
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	share.RevokedAt = &now
+	share.UpdatedAt = now
+	if err := s.repo.revokeShare(ctx, share); err != nil {
+		logs.Errorf("revoke agent share error: %v", err)
+		return errs.DBError
+	}
+	return nil
+}
+
+// listPublicAgents 公开目录，展示的是线上版本的信息
+func (s *Service) listPublicAgents(ctx context.Context, req PublicAgentsReq) (*ListPublicAgentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	filter := AgentFilter{
+		Name:   req.Name,
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	}
+	agents, total, err := s.repo.listPublicAgents(ctx, filter)
+	if err != nil {
+		logs.Errorf("list public agents error: %v", err)
+		return nil, errs.DBError
+	}
+	versions, err := s.repo.listLiveVersions(ctx, agents)
+	if err != nil {
+		logs.Errorf("list agent versions error: %v", err)
+		return nil, errs.DBError
+	}
+	snapshots := make(map[uuid.UUID]*model.AgentSnapshot, len(versions))
+	for _, v := range versions {
+		snapshots[v.AgentID] = &v.Snapshot
+	}
+	list := make([]*PublicAgent, len(agents))
+	for i, agent := range agents {
+		list[i] = newPublicAgent(agent, snapshots[agent.ID])
+	}
+	return &ListPublicAgentResponse{Agents: list, Total: total}, nil
+}
+
+// getPublicAgent 访客打开公开的agent时展示的信息
+func (s *Service) getPublicAgent(ctx context.Context, agentId uuid.UUID) (*PublicAgent, error) {
+	target, err := s.getGuestTargetById(ctx, agentId)
+	if err != nil {
+		return nil, err
+	}
+	return newPublicAgent(target.agent, nil), nil
+}
+
+// getSharedAgent 访客打开分享链接时展示的信息
+func (s *Service) getSharedAgent(ctx context.Context, token string) (*PublicAgent, error) {
+	target, err := s.getGuestTargetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return newPublicAgent(target.agent, nil), nil
+}
+
+// getGuestTargetById 公开目录中的agent，返回的agent已经替换为线上版本
+func (s *Service) getGuestTargetById(ctx context.Context, agentId uuid.UUID) (*guestTarget, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getPublishedAgent(ctx, agentId)
+	if err != nil {
+		logs.Errorf("get published agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil || agent.Visibility != model.AgentVisibility(model.Public) {
+		return nil, biz.ErrAgentNotFound
+	}
+	return s.newGuestTarget(ctx, agent, nil)
+}
+
+// getGuestTargetByToken 通过分享链接访问，公开和仅链接可见的agent都可以分享
+func (s *Service) getGuestTargetByToken(ctx context.Context, token string) (*guestTarget, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	share, err := s.repo.getShareByToken(ctx, token)
+	if err != nil {
+		logs.Errorf("get agent share error: %v", err)
+		return nil, errs.DBError
+	}
+	if share == nil || !share.Active(time.Now()) {
+		return nil, biz.ErrAgentNotShared
+	}
+	agent, err := s.repo.getPublishedAgent(ctx, share.AgentID)
+	if err != nil {
+		logs.Errorf("get published agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil || agent.Visibility == model.Private {
+		return nil, biz.ErrAgentNotShared
+	}
+	return s.newGuestTarget(ctx, agent, share)
+}
+
+func (s *Service) newGuestTarget(ctx context.Context, agent *model.Agent, share *model.AgentShare) (*guestTarget, error) {
+	published, err := s.loadPublishedAgent(ctx, agent)
+	if err != nil {
+		logs.Errorf("load published agent error: %v", err)
+		return nil, errs.DBError
+	}
+	// 访客使用的是创建者的凭证，没有开启时只保留系统工具
+	if !published.GuestTools {
+		published.Tools = systemTools(published.Tools)
+	}
+	return &guestTarget{agent: published, share: share}, nil
+}
+
+func systemTools(toolList []*model.Tool) []*model.Tool {
+	var kept []*model.Tool
+	for _, t := range toolList {
+		if t.ToolType == model.SystemToolType {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// validateGuestMessage 访客的消息在计入额度之前检查
+func validateGuestMessage(message string) error {
+	if strings.TrimSpace(message) == "" || utf8.RuneCountInString(message) > configs.GetConfig().Share.GetMaxMessageLength() {
+		return biz.ErrGuestMessageInvalid
+	}
+	return nil
+}
+
+// guestKey 按IP区分访客，IPv6 用户通常拥有整个 /64 网段，按网段计算
+func guestKey(clientIP string) string {
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return clientIP
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return prefix.String()
+	}
+	return addr.String()
+}
+
+// chargeGuest 访客每发送一条消息先检查限流，再计入创建者的每日额度
+func (s *Service) chargeGuest(ctx context.Context, target *guestTarget, clientIP string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	shareConf := configs.GetConfig().Share
+	now := time.Now()
+	rateKey := fmt.Sprintf("agent:guest:rate:%s:%s:%d", target.agent.ID, guestKey(clientIP), now.Unix()/60)
+	allowed, err := s.countWithin(ctx, rateKey, shareConf.GetRatePerMinute(), time.Minute)
+	if err != nil {
+		logs.Errorf("guest rate limit error: %v", err)
+		return errs.DBError
+	}
+	if !allowed {
+		return biz.ErrGuestRateLimited
+	}
+	plan, err := s.repo.getUserPlan(ctx, target.agent.CreatorID)
+	if err != nil {
+		logs.Errorf("get user plan error: %v", err)
+		return errs.DBError
+	}
+	quotaKey := fmt.Sprintf("agent:guest:quota:%s:%s", target.agent.CreatorID, now.Format("20060102"))
+	allowed, err = s.countWithin(ctx, quotaKey, shareConf.GetDailyQuota(string(plan)), 25*time.Hour)
+	if err != nil {
+		logs.Errorf("guest quota error: %v", err)
+		return errs.DBError
+	}
+	if !allowed {
+		return biz.ErrGuestQuotaExceeded
+	}
+	var shareId *uuid.UUID
+	if target.share != nil {
+		shareId = &target.share.ID
+	}
+	if err := s.repo.recordGuestMessage(ctx, target.agent.ID, shareId); err != nil {
+		// 统计失败不影响对话
+		logs.Warnf("record guest message error: %v", err)
+	}
+	return nil
+}
+
+// countWithin 计数加一并判断是否超过限制，计数在第一次写入后 window 时间过期
+func (s *Service) countWithin(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	client := database.RedisCli.Client
+	count, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if count == 1 {
+		if err := client.Expire(ctx, key, window).Err(); err != nil {
+			return false, err
+		}
+	}
+	return count <= limit, nil
+}
+
+// guestMessageStream 访客和agent对话，使用线上版本，工具等资源仍然属于创建者
+func (s *Service) guestMessageStream(ctx context.Context, target *guestTarget, req GuestMessageReq) (<-chan string, <-chan error) {
+	return s.runAgentStream(ctx, req.Message, promptInputs{Variables: req.Variables}, func(ctx context.Context) (*model.Agent, error) {
+		return target.agent, nil
+	})
+}
@@ -0,0 +1,118 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"errors"
+	"model"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGuestKey(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":                "203.0.113.7",
+		"::ffff:203.0.113.7":         "203.0.113.7",
+		"2001:db8:1:2:aaaa::1":       "2001:db8:1:2::/64",
+		"2001:db8:1:2:ffff:ffff::99": "2001:db8:1:2::/64",
+		"not-an-ip":                  "not-an-ip",
+	}
+	for ip, want := range tests {
+		if got := guestKey(ip); got != want {
+			t.Errorf("guestKey(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
+
+func TestValidateGuestMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		ok      bool
+	}{
+		{"你好", true},
+		{strings.Repeat("字", 4000), true},
+		{"", false},
+		{" \n\t", false},
+		{strings.Repeat("字", 4001), false},
+	}
+	for _, tt := range tests {
+		err := validateGuestMessage(tt.message)
+		if tt.ok && err != nil {
+			t.Errorf("message of %d bytes: %v", len(tt.message), err)
+		}
+		if !tt.ok && !errors.Is(err, biz.ErrGuestMessageInvalid) {
+			t.Errorf("message of %d bytes: err = %v, want ErrGuestMessageInvalid", len(tt.message), err)
+		}
+	}
+}
+
+func TestNewGuestTargetDropsCredentialedTools(t *testing.T) {
+	search := &model.Tool{Name: "search", ToolType: model.SystemToolType}
+	api := &model.Tool{Name: "api", ToolType: model.HttpToolType}
+	db := &model.Tool{Name: "db", ToolType: model.DatabaseToolType}
+	s := &Service{repo: &fakeRepo{}}
+
+	agent := &model.Agent{Status: model.Draft, Tools: []*model.Tool{search, api, db}}
+	target, err := s.newGuestTarget(context.Background(), agent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(target.agent.Tools) != 1 || target.agent.Tools[0] != search {
+		t.Errorf("tools = %v, want only system tools", target.agent.Tools)
+	}
+
+	agent = &model.Agent{Status: model.Draft, GuestTools: true, Tools: []*model.Tool{search, api, db}}
+	target, err = s.newGuestTarget(context.Background(), agent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(target.agent.Tools) != 3 {
+		t.Errorf("tools = %v, want all tools after opt-in", target.agent.Tools)
+	}
+}
+
+func TestUpdateVisibilityGuestTools(t *testing.T) {
+	repo := &fakeRepo{agent: &model.Agent{Visibility: model.Private}}
+	s := &Service{repo: repo}
+	enabled := true
+	agent, err := s.updateVisibility(context.Background(), uuid.New(), uuid.New(), UpdateVisibilityReq{
+		Visibility: model.AgentVisibility(model.Public),
+		GuestTools: &enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !agent.GuestTools || repo.updated != agent {
+		t.Fatalf("agent = %+v", agent)
+	}
+	// 不传 guestTools 时保持原来的设置
+	if _, err := s.updateVisibility(context.Background(), uuid.New(), uuid.New(), UpdateVisibilityReq{
+		Visibility: model.AgentVisibility(model.LinkOnly),
+	}); err != nil || !repo.agent.GuestTools {
+		t.Fatalf("guestTools = %v, err = %v", repo.agent.GuestTools, err)
+	}
+}
+
+func TestListPublicAgentsClampsPage(t *testing.T) {
+	tests := []struct {
+		page, pageSize int
+		limit, offset  int
+	}{
+		{0, 0, 20, 0},
+		{-3, -1, 20, 0},
+		{2, 10, 10, 10},
+		{3, 100000, 20, 40},
+	}
+	for _, tt := range tests {
+		repo := &fakeRepo{}
+		s := &Service{repo: repo}
+		if _, err := s.listPublicAgents(context.Background(), PublicAgentsReq{Page: tt.page, PageSize: tt.pageSize}); err != nil {
+			t.Fatal(err)
+		}
+		if repo.filter.Limit != tt.limit || repo.filter.Offset != tt.offset {
+			t.Errorf("page %d size %d: limit = %d, offset = %d", tt.page, tt.pageSize, repo.filter.Limit, repo.filter.Offset)
+		}
+	}
+}
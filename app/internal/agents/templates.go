@@ -0,0 +1,208 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 模板分类
+const (
+	TemplateCategoryGeneral = "general"
+	TemplateCategoryService = "service"
+	TemplateCategoryWriting = "writing"
+	TemplateCategoryDev     = "development"
+)
+
+// builtinTemplates 内置模板，ID固定，不关联工具，模型使用创建后再选择
+var builtinTemplates = []*model.AgentTemplate{
+	{
+		BaseModel:   model.BaseModel{ID: uuid.MustParse("6f1c2a3e-0b8d-4c59-9d0a-1a2b3c4d5e01")},
+		Name:        "通用助手",
+		Description: "回答各类问题的通用对话助手",
+		Category:    TemplateCategoryGeneral,
+		Snapshot: model.AgentSnapshot{
+			Name:            "通用助手",
+			Description:     "回答各类问题的通用对话助手",
+			SystemPrompt:    "你是一个乐于助人的AI助手。请准确、简洁地回答用户的问题，不确定的内容要明确说明，不要编造事实。",
+			ModelParameters: model.JSON{"temperature": 0.7},
+			OpeningDialogue: "你好，我是你的AI助手，有什么可以帮你的？",
+			SuggestedQuestions: model.JSON{"questions": []any{
+				map[string]any{"text": "帮我总结一下这段文字"},
+				map[string]any{"text": "解释一个概念给我听"},
+			}},
+		},
+	},
+	{
+		BaseModel:   model.BaseModel{ID: uuid.MustParse("6f1c2a3e-0b8d-4c59-9d0a-1a2b3c4d5e02")},
+		Name:        "客服助手",
+		Description: "礼貌、耐心地解答产品和售后问题",
+		Category:    TemplateCategoryService,
+		Snapshot: model.AgentSnapshot{
+			Name:            "客服助手",
+			Description:     "礼貌、耐心地解答产品和售后问题",
+			SystemPrompt:    "你是一名专业的客服人员。请使用礼貌、耐心的语气回答用户关于产品和售后的问题。遇到无法处理的问题，引导用户留下联系方式或转人工客服，不要承诺无法确认的事项。",
+			ModelParameters: model.JSON{"temperature": 0.3},
+			OpeningDialogue: "您好，很高兴为您服务，请问有什么可以帮您？",
+		},
+	},
+	{
+		BaseModel:   model.BaseModel{ID: uuid.MustParse("6f1c2a3e-0b8d-4c59-9d0a-1a2b3c4d5e03")},
+		Name:        "翻译助手",
+		Description: "中英文互译，保留原文格式",
+		Category:    TemplateCategoryWriting,
+		Snapshot: model.AgentSnapshot{
+			Name:            "翻译助手",
+			Description:     "中英文互译，保留原文格式",
+			SystemPrompt:    "你是一名专业翻译。用户输入中文时翻译为英文，输入其他语言时翻译为中文。只输出译文，保留原文的格式、换行和专有名词。",
+			ModelParameters: model.JSON{"temperature": 0.2},
+			OpeningDialogue: "请发送需要翻译的内容。",
+		},
+	},
+	{
+		BaseModel:   model.BaseModel{ID: uuid.MustParse("6f1c2a3e-0b8d-4c59-9d0a-1a2b3c4d5e04")},
+		Name:        "代码助手",
+		Description: "编写、解释和审查代码",
+		Category:    TemplateCategoryDev,
+		Snapshot: model.AgentSnapshot{
+			Name:            "代码助手",
+			Description:     "编写、解释和审查代码",
+			SystemPrompt:    "你是一名资深软件工程师。回答编程问题时先给出结论，再给出可以直接运行的代码，代码使用 markdown 代码块并标注语言。审查代码时指出具体的问题和修改方式。",
+			ModelParameters: model.JSON{"temperature": 0.2},
+			OpeningDialogue: "你好，需要写什么代码或者排查什么问题？",
+		},
+	},
+}
+
+func init() {
+	for _, template := range builtinTemplates {
+		template.BuiltIn = true
+	}
+}
+
+// listTemplates 内置模板在前，然后是用户自己保存的模板
+func (s *Service) listTemplates(ctx context.Context, userID uuid.UUID, req ListTemplatesReq) ([]*model.AgentTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	templates := make([]*model.AgentTemplate, 0, len(builtinTemplates))
+	for _, template := range builtinTemplates {
+		if req.Category == "" || template.Category == req.Category {
+			templates = append(templates, template)
+		}
+	}
+	saved, err := s.repo.listTemplates(ctx, userID, req.Category)
+	if err != nil {
+		logs.Errorf("list agent templates error: %v", err)
+		return nil, errs.DBError
+	}
+	return append(templates, saved...), nil
+}
+
+// saveTemplate 把agent当前的草稿保存为模板
+func (s *Service) saveTemplate(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req SaveTemplateReq) (*model.AgentTemplate, error) {
+	agent, err := s.getAgent(ctx, userID, agentId)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	template := &model.AgentTemplate{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:   userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Category:    req.Category,
+		Snapshot:    model.NewAgentSnapshot(agent),
+	}
+	if template.Name == "" {
+		template.Name = agent.Name
+	}
+	if template.Description == "" {
+		template.Description = agent.Description
+	}
+	if err := s.repo.createTemplate(ctx, template); err != nil {
+		logs.Errorf("create agent template error: %v", err)
+		return nil, errs.DBError
+	}
+	return template, nil
+}
+
+func (s *Service) deleteTemplate(ctx context.Context, userID uuid.UUID, templateId uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteTemplate(ctx, userID, templateId)
+	if err != nil {
+		logs.Errorf("delete agent template error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrAgentTemplateNotFound
+	}
+	return nil
+}
+
+func (s *Service) getTemplate(ctx context.Context, userID uuid.UUID, templateId uuid.UUID) (*model.AgentTemplate, error) {
+	for _, template := range builtinTemplates {
+		if template.ID == templateId {
+			return template, nil
+		}
+	}
+	template, err := s.repo.getTemplate(ctx, userID, templateId)
+	if err != nil {
+		logs.Errorf("get agent template error: %v", err)
+		return nil, errs.DBError
+	}
+	if template == nil {
+		return nil, biz.ErrAgentTemplateNotFound
+	}
+	return template, nil
+}
+
+// duplicateAgent 复制agent的草稿配置和工具关联，副本是新的私有草稿，不复制版本、分享和嵌入
+func (s *Service) duplicateAgent(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req DuplicateAgentReq) (*model.Agent, error) {
+	source, err := s.getAgent(ctx, userID, agentId)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent := model.DefaultAgent(userID, source.Name, source.Description, model.Draft)
+	snapshot := model.NewAgentSnapshot(source)
+	snapshot.Apply(agent)
+	agent.Name = req.Name
+	if agent.Name == "" {
+		agent.Name = source.Name + " 副本"
+	}
+	if err := s.createAgentWithTools(ctx, agent, snapshot.ToolIds); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// createAgentWithTools 创建agent并关联工具
+func (s *Service) createAgentWithTools(ctx context.Context, agent *model.Agent, toolIds []uuid.UUID) error {
+	if agent.ModelParameters == nil {
+		agent.ModelParameters = model.JSON{}
+	}
+	agentTools := make([]*model.AgentTool, 0, len(toolIds))
+	for _, toolId := range toolIds {
+		agentTools = append(agentTools, &model.AgentTool{
+			AgentID:   agent.ID,
+			ToolID:    toolId,
+			Status:    model.Enabled,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err := s.repo.createAgent(ctx, agent, agentTools); err != nil {
+		logs.Errorf("create agent error: %v", err)
+		return errs.DBError
+	}
+	return nil
+}
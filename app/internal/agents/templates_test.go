@@ -0,0 +1,30 @@
+package agents
+
+import (
+	"context"
+	"model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateAgentWithToolsCreatesInOneCall(t *testing.T) {
+	repo := &fakeRepo{}
+	s := &Service{repo: repo}
+	agent := model.DefaultAgent(uuid.New(), "copy", "", model.Draft)
+	toolIds := []uuid.UUID{uuid.New(), uuid.New()}
+	if err := s.createAgentWithTools(context.Background(), agent, toolIds); err != nil {
+		t.Fatal(err)
+	}
+	if repo.agent != agent || len(repo.created) != 2 {
+		t.Fatalf("agent = %v, tools = %v", repo.agent, repo.created)
+	}
+	for i, tool := range repo.created {
+		if tool.AgentID != agent.ID || tool.ToolID != toolIds[i] || tool.Status != model.Enabled {
+			t.Errorf("tool %d = %+v", i, tool)
+		}
+	}
+	if agent.Version != 0 || agent.ModelParameters == nil {
+		t.Errorf("agent = %+v", agent)
+	}
+}
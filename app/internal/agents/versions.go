@@ -0,0 +1,233 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"encoding/json"
+	"errors"
+	"model"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// publishAgent 把当前草稿保存为新的版本并上线，之后草稿的修改不会影响线上版本
+func (s *Service) publishAgent(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req PublishAgentReq) (*model.AgentVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	if agent.ModelProvider == "" || agent.ModelName == "" {
+		return nil, biz.ErrAgentNotPublishable
+	}
+	version := &model.AgentVersion{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		AgentID:   agent.ID,
+		CreatorID: userID,
+		Note:      req.Note,
+		Snapshot:  model.NewAgentSnapshot(agent),
+	}
+	if err := s.repo.publishAgent(ctx, agent, version); err != nil {
+		logs.Errorf("publish agent error: %v", err)
+		return nil, errs.DBError
+	}
+	return version, nil
+}
+
+func (s *Service) listAgentVersions(ctx context.Context, userID uuid.UUID, agentId uuid.UUID) ([]*model.AgentVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.listAgentVersions(ctx, agentId)
+	if err != nil {
+		logs.Errorf("list agent versions error: %v", err)
+		return nil, errs.DBError
+	}
+	return versions, nil
+}
+
+func (s *Service) getAgentVersion(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, version uint) (*model.AgentVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	agentVersion, err := s.repo.getAgentVersion(ctx, agentId, version)
+	if err != nil {
+		logs.Errorf("get agent version error: %v", err)
+		return nil, errs.DBError
+	}
+	if agentVersion == nil {
+		return nil, biz.ErrAgentVersionNotFound
+	}
+	return agentVersion, nil
+}
+
+// diffAgentVersions 比较两个版本的配置，版本号为 0 表示当前草稿
+func (s *Service) diffAgentVersions(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req DiffAgentVersionsReq) (*AgentVersionDiffResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	snapshot := func(version uint) (*model.AgentSnapshot, error) {
+		if version == 0 {
+			draft := model.NewAgentSnapshot(agent)
+			return &draft, nil
+		}
+		agentVersion, err := s.repo.getAgentVersion(ctx, agentId, version)
+		if err != nil {
+			logs.Errorf("get agent version error: %v", err)
+			return nil, errs.DBError
+		}
+		if agentVersion == nil {
+			return nil, biz.ErrAgentVersionNotFound
+		}
+		return &agentVersion.Snapshot, nil
+	}
+	from, err := snapshot(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := snapshot(req.To)
+	if err != nil {
+		return nil, err
+	}
+	return &AgentVersionDiffResponse{
+		From:    req.From,
+		To:      req.To,
+		Changes: diffSnapshots(from, to),
+	}, nil
+}
+
+// rollbackAgent 把线上版本切换到指定版本，RestoreDraft 为 true 时草稿也恢复为该版本
+func (s *Service) rollbackAgent(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req RollbackAgentReq) (*model.Agent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getAgentById(ctx, userID, agentId)
+	if err != nil {
+		logs.Errorf("get agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil {
+		return nil, biz.ErrAgentNotFound
+	}
+	agentVersion, err := s.repo.getAgentVersion(ctx, agentId, req.Version)
+	if err != nil {
+		logs.Errorf("get agent version error: %v", err)
+		return nil, errs.DBError
+	}
+	if agentVersion == nil {
+		return nil, biz.ErrAgentVersionNotFound
+	}
+	now := time.Now()
+	agent.Version = agentVersion.Version
+	agent.Status = model.AgentStatus(model.Published)
+	agent.PublishedAt = &now
+	agent.UpdatedAt = now
+	var snapshot *model.AgentSnapshot
+	if req.RestoreDraft {
+		// 快照中的工具可能已被删除、卸载或不再共享，只恢复现在还能使用的
+		toolList, err := s.usableToolsByIds(userID, agentVersion.Snapshot.ToolIds)
+		if err != nil {
+			logs.Errorf("get usable tools error: %v", err)
+			return nil, errs.DBError
+		}
+		snapshot = &agentVersion.Snapshot
+		snapshot.ToolIds = make([]uuid.UUID, len(toolList))
+		for i, t := range toolList {
+			snapshot.ToolIds[i] = t.ID
+		}
+	}
+	if err := s.repo.rollbackAgent(ctx, agent, snapshot); err != nil {
+		logs.Errorf("rollback agent error: %v", err)
+		return nil, errs.DBError
+	}
+	// 返回最新的草稿，工具关联可能已经变化
+	return s.getAgent(ctx, userID, agentId)
+}
+
+// loadAgentVersion 用版本快照替换agent的配置和工具，返回的agent只用于运行，不能保存
+func (s *Service) loadAgentVersion(ctx context.Context, agent *model.Agent, version uint) (*model.Agent, error) {
+	agentVersion, err := s.repo.getAgentVersion(ctx, agent.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if agentVersion == nil {
+		return nil, biz.ErrAgentVersionNotFound
+	}
+	// 和草稿一样只使用创建者现在还能使用的工具
+	snapshotTools, err := s.usableToolsByIds(agent.CreatorID, agentVersion.Snapshot.ToolIds)
+	if err != nil {
+		return nil, err
+	}
+	versioned := *agent
+	agentVersion.Snapshot.Apply(&versioned)
+	versioned.Version = agentVersion.Version
+	versioned.Tools = snapshotTools
+	return &versioned, nil
+}
+
+// loadPublishedAgent 已发布的agent使用线上版本的快照运行，
+// 引入版本之前发布的agent没有快照，仍然使用当前配置
+func (s *Service) loadPublishedAgent(ctx context.Context, agent *model.Agent) (*model.Agent, error) {
+	if agent == nil || agent.Status != model.AgentStatus(model.Published) {
+		return agent, nil
+	}
+	versioned, err := s.loadAgentVersion(ctx, agent, agent.Version)
+	if errors.Is(err, biz.ErrAgentVersionNotFound) {
+		return agent, nil
+	}
+	return versioned, err
+}
+
+// diffSnapshots 按字段比较两个快照
+func diffSnapshots(from *model.AgentSnapshot, to *model.AgentSnapshot) []*FieldChange {
+	fromFields, toFields := snapshotFields(from), snapshotFields(to)
+	changes := make([]*FieldChange, 0)
+	keys := make([]string, 0, len(fromFields))
+	for key := range fromFields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !reflect.DeepEqual(fromFields[key], toFields[key]) {
+			changes = append(changes, &FieldChange{Field: key, From: fromFields[key], To: toFields[key]})
+		}
+	}
+	return changes
+}
+
+func snapshotFields(snapshot *model.AgentSnapshot) map[string]any {
+	if snapshot.ToolIds != nil {
+		// 工具的顺序没有意义，排序后再比较
+		toolIds := slices.Clone(snapshot.ToolIds)
+		slices.SortFunc(toolIds, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+		sorted := *snapshot
+		sorted.ToolIds = toolIds
+		snapshot = &sorted
+	}
+	data, _ := json.Marshal(snapshot)
+	fields := make(map[string]any)
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
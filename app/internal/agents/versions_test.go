@@ -0,0 +1,65 @@
+package agents
+
+import (
+	"context"
+	"model"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newVersionRepo(creatorID uuid.UUID, toolIds ...uuid.UUID) *fakeRepo {
+	agent := &model.Agent{CreatorID: creatorID, Status: model.Draft}
+	agent.ID = uuid.New()
+	return &fakeRepo{
+		agent: agent,
+		version: &model.AgentVersion{
+			AgentID: agent.ID,
+			Version: 1,
+			Snapshot: model.AgentSnapshot{
+				Name:    "v1",
+				ToolIds: toolIds,
+			},
+		},
+	}
+}
+
+// 快照中的工具现在不能使用时，运行版本不应该带上它
+func TestLoadAgentVersionFiltersTools(t *testing.T) {
+	creatorID := uuid.New()
+	kept, revoked := newTool("kept"), newTool("revoked")
+	requests := registerUsableTools(kept)
+	repo := newVersionRepo(creatorID, revoked.ID, kept.ID)
+	s := &Service{repo: repo}
+	versioned, err := s.loadAgentVersion(context.Background(), repo.agent, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versioned.Name != "v1" || versioned.Version != 1 {
+		t.Errorf("versioned = %+v", versioned)
+	}
+	if !slices.Equal(versioned.Tools, []*model.Tool{kept}) {
+		t.Errorf("tools = %v", versioned.Tools)
+	}
+	if len(*requests) != 1 || (*requests)[0].UserID != creatorID {
+		t.Errorf("requests = %+v", *requests)
+	}
+}
+
+func TestRollbackAgentRestoresOnlyUsableTools(t *testing.T) {
+	userID := uuid.New()
+	first, revoked, second := newTool("first"), newTool("revoked"), newTool("second")
+	registerUsableTools(first, second)
+	repo := newVersionRepo(userID, first.ID, revoked.ID, second.ID)
+	s := &Service{repo: repo}
+	if _, err := s.rollbackAgent(context.Background(), userID, repo.agent.ID, RollbackAgentReq{Version: 1, RestoreDraft: true}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.snapshot == nil || !slices.Equal(repo.snapshot.ToolIds, []uuid.UUID{first.ID, second.ID}) {
+		t.Fatalf("snapshot = %+v", repo.snapshot)
+	}
+	if repo.agent.Status != model.AgentStatus(model.Published) || repo.agent.Version != 1 {
+		t.Errorf("agent = %+v", repo.agent)
+	}
+}
@@ -0,0 +1,465 @@
+package agents
+
+import (
+	"common/biz"
+	"context"
+	"core/ai"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"model"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 嵌入密钥统一前缀，和API令牌区分
+const embedKeyPrefix = "wk_"
+
+// historyLimit 每次对话带给模型的历史消息数
+const historyLimit = 20
+
+// 访客标识由服务端生成后保存在浏览器中，前缀加 16 字节随机数的十六进制
+const (
+	visitorIdPrefix = "v_"
+	visitorIdBytes  = 16
+)
+
+func (s *Service) createEmbed(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req EmbedReq) (*model.AgentEmbed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	origins, err := normalizeOrigins(req.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		logs.Errorf("generate embed key error: %v", err)
+		return nil, biz.ErrTokenGenerate
+	}
+	embed := &model.AgentEmbed{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		AgentID:        agentId,
+		CreatorID:      userID,
+		Name:           req.Name,
+		Key:            embedKeyPrefix + hex.EncodeToString(keyBytes),
+		AllowedOrigins: origins,
+	}
+	embed.CreatedAt = time.Now()
+	embed.UpdatedAt = embed.CreatedAt
+	if err := s.repo.createEmbed(ctx, embed); err != nil {
+		logs.Errorf("create agent embed error: %v", err)
+		return nil, errs.DBError
+	}
+	return embed, nil
+}
+
+func (s *Service) listEmbeds(ctx context.Context, userID uuid.UUID, agentId uuid.UUID) ([]*model.AgentEmbed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	embeds, err := s.repo.listEmbeds(ctx, agentId)
+	if err != nil {
+		logs.Errorf("list agent embeds error: %v", err)
+		return nil, errs.DBError
+	}
+	return embeds, nil
+}
+
+func (s *Service) updateEmbed(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, embedId uuid.UUID, req EmbedReq) (*model.AgentEmbed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	origins, err := normalizeOrigins(req.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	embed, err := s.getOwnedEmbed(ctx, userID, agentId, embedId)
+	if err != nil {
+		return nil, err
+	}
+	embed.Name = req.Name
+	embed.AllowedOrigins = origins
+	embed.UpdatedAt = time.Now()
+	if err := s.repo.updateEmbed(ctx, embed); err != nil {
+		logs.Errorf("update agent embed error: %v", err)
+		return nil, errs.DBError
+	}
+	return embed, nil
+}
+
+func (s *Service) revokeEmbed(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, embedId uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	embed, err := s.getOwnedEmbed(ctx, userID, agentId, embedId)
+	if err != nil {
+		return err
+	}
+	if embed.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	embed.RevokedAt = &now
+	embed.UpdatedAt = now
+	if err := s.repo.updateEmbed(ctx, embed); err != nil {
+		logs.Errorf("revoke agent embed error: %v", err)
+		return errs.DBError
+	}
+	return nil
+}
+
+func (s *Service) getOwnedEmbed(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, embedId uuid.UUID) (*model.AgentEmbed, error) {
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	embed, err := s.repo.getEmbed(ctx, agentId, embedId)
+	if err != nil {
+		logs.Errorf("get agent embed error: %v", err)
+		return nil, errs.DBError
+	}
+	if embed == nil {
+		return nil, biz.ErrEmbedNotFound
+	}
+	return embed, nil
+}
+
+// listConversations 创建者查看agent的访客会话
+func (s *Service) listConversations(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, req ConversationsReq) (*ListConversationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	filter := ConversationFilter{
+		Channel: req.Channel,
+		Limit:   req.PageSize,
+		Offset:  (req.Page - 1) * req.PageSize,
+	}
+	conversations, total, err := s.repo.listConversations(ctx, agentId, filter)
+	if err != nil {
+		logs.Errorf("list conversations error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListConversationResponse{Conversations: conversations, Total: total}, nil
+}
+
+func (s *Service) listConversationMessages(ctx context.Context, userID uuid.UUID, agentId uuid.UUID, conversationId uuid.UUID) ([]*model.ConversationMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getAgent(ctx, userID, agentId); err != nil {
+		return nil, err
+	}
+	conversation, err := s.repo.getConversation(ctx, agentId, conversationId)
+	if err != nil {
+		logs.Errorf("get conversation error: %v", err)
+		return nil, errs.DBError
+	}
+	if conversation == nil {
+		return nil, biz.ErrConversationNotFound
+	}
+	messages, err := s.repo.listMessages(ctx, conversation.ID, 0)
+	if err != nil {
+		logs.Errorf("list conversation messages error: %v", err)
+		return nil, errs.DBError
+	}
+	return messages, nil
+}
+
+// getWidgetEmbed 校验嵌入密钥和请求来源，没有配置白名单的密钥不能使用
+func (s *Service) getWidgetEmbed(ctx context.Context, key string, origin string) (*model.AgentEmbed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if !strings.HasPrefix(key, embedKeyPrefix) {
+		return nil, biz.ErrEmbedNotFound
+	}
+	embed, err := s.repo.getEmbedByKey(ctx, key)
+	if err != nil {
+		logs.Errorf("get agent embed error: %v", err)
+		return nil, errs.DBError
+	}
+	if embed == nil || embed.RevokedAt != nil {
+		return nil, biz.ErrEmbedNotFound
+	}
+	if !matchOrigin(origin, embed.AllowedOrigins) {
+		return nil, biz.ErrEmbedOrigin
+	}
+	return embed, nil
+}
+
+// getWidgetTarget 组件对应的agent，和分享链接一样只能使用已发布且不是私有的agent
+func (s *Service) getWidgetTarget(ctx context.Context, embed *model.AgentEmbed) (*guestTarget, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	agent, err := s.repo.getPublishedAgent(ctx, embed.AgentID)
+	if err != nil {
+		logs.Errorf("get published agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if agent == nil || agent.Visibility == model.Private {
+		return nil, biz.ErrAgentNotShared
+	}
+	return s.newGuestTarget(ctx, agent, nil)
+}
+
+// widgetBootstrap 组件加载时需要的信息：agent的开场白、建议问题和访客之前的对话
+func (s *Service) widgetBootstrap(ctx context.Context, embed *model.AgentEmbed, visitorId string) (*WidgetBootstrapResponse, error) {
+	target, err := s.getWidgetTarget(ctx, embed)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp := &WidgetBootstrapResponse{
+		Agent:    newPublicAgent(target.agent, nil),
+		Messages: []*model.ConversationMessage{},
+	}
+	if !validVisitorId(visitorId) {
+		resp.VisitorID = newVisitorId()
+		return resp, nil
+	}
+	resp.VisitorID = visitorId
+	conversation, err := s.repo.getVisitorConversation(ctx, embed.ID, visitorId)
+	if err != nil {
+		logs.Errorf("get visitor conversation error: %v", err)
+		return nil, errs.DBError
+	}
+	if conversation == nil {
+		return resp, nil
+	}
+	resp.ConversationID = &conversation.ID
+	if resp.Messages, err = s.repo.listMessages(ctx, conversation.ID, historyLimit); err != nil {
+		logs.Errorf("list conversation messages error: %v", err)
+		return nil, errs.DBError
+	}
+	return resp, nil
+}
+
+// widgetMessageStream 访客通过组件发送消息，消息和回答都保存在访客的会话中
+func (s *Service) widgetMessageStream(ctx context.Context, target *guestTarget, embed *model.AgentEmbed, req WidgetMessageReq) (<-chan string, <-chan error, error) {
+	if err := validateWidgetMessage(req); err != nil {
+		return nil, nil, err
+	}
+	conversation, history, err := s.prepareWidgetConversation(ctx, embed, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataChan, errorChan := s.runAgentConversation(ctx, history, req.Message, promptInputs{Variables: req.Variables}, func(ctx context.Context) (*model.Agent, error) {
+		return target.agent, nil
+	})
+	out, errOut := s.recordAnswer(ctx, conversation, dataChan, errorChan)
+	return out, errOut, nil
+}
+
+// recordAnswer 转发agent的输出，流结束后把最终回答保存到会话中
+func (s *Service) recordAnswer(ctx context.Context, conversation *model.Conversation, dataChan <-chan string, errorChan <-chan error) (<-chan string, <-chan error) {
+	out := make(chan string, 100)
+	errOut := make(chan error, 10)
+	go func() {
+		var answer string
+		for data := range dataChan {
+			var msg ai.AgentMessage
+			if err := json.Unmarshal([]byte(data), &msg); err == nil && !msg.IsErr && msg.ToolName == "" && msg.Content != "" {
+				answer = msg.Content
+			}
+			select {
+			case out <- data:
+			case <-ctx.Done():
+			}
+		}
+		for err := range errorChan {
+			errOut <- err
+		}
+		close(out)
+		close(errOut)
+		if answer != "" {
+			s.saveConversationMessage(conversation, model.MessageRoleAssistant, answer)
+		}
+	}()
+	return out, errOut
+}
+
+// prepareWidgetConversation 找到或创建访客的会话，保存本次消息并返回之前的对话记录
+func (s *Service) prepareWidgetConversation(ctx context.Context, embed *model.AgentEmbed, req WidgetMessageReq) (*model.Conversation, []adk.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conversation, err := s.repo.getVisitorConversation(ctx, embed.ID, req.VisitorID)
+	if err != nil {
+		logs.Errorf("get visitor conversation error: %v", err)
+		return nil, nil, errs.DBError
+	}
+	var history []adk.Message
+	if conversation == nil {
+		conversation = &model.Conversation{
+			BaseModel: model.BaseModel{
+				ID: uuid.New(),
+			},
+			AgentID:   embed.AgentID,
+			Channel:   model.ConversationChannelWidget,
+			EmbedID:   &embed.ID,
+			VisitorID: req.VisitorID,
+		}
+		if err := s.repo.createConversation(ctx, conversation); err != nil {
+			logs.Errorf("create conversation error: %v", err)
+			return nil, nil, errs.DBError
+		}
+	} else {
+		if history, err = s.conversationHistory(ctx, conversation); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.repo.addMessage(ctx, conversation, newConversationMessage(conversation, model.MessageRoleUser, req.Message)); err != nil {
+		logs.Errorf("add conversation message error: %v", err)
+		return nil, nil, errs.DBError
+	}
+	return conversation, history, nil
+}
+
+// conversationHistory 会话最近的消息，转换成模型的输入
+func (s *Service) conversationHistory(ctx context.Context, conversation *model.Conversation) ([]adk.Message, error) {
+	messages, err := s.repo.listMessages(ctx, conversation.ID, historyLimit)
+	if err != nil {
+		logs.Errorf("list conversation messages error: %v", err)
+		return nil, errs.DBError
+	}
+	var history []adk.Message
+	for _, m := range messages {
+		if m.Role == model.MessageRoleAssistant {
+			history = append(history, schema.AssistantMessage(m.Content, nil))
+		} else {
+			history = append(history, schema.UserMessage(m.Content))
+		}
+	}
+	return history, nil
+}
+
+// saveConversationMessage 回答在流结束后保存，请求的context可能已经取消，使用单独的context
+func (s *Service) saveConversationMessage(conversation *model.Conversation, role string, content string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.addMessage(ctx, conversation, newConversationMessage(conversation, role, content)); err != nil {
+		logs.Errorf("add conversation message error: %v", err)
+	}
+}
+
+func newConversationMessage(conversation *model.Conversation, role string, content string) *model.ConversationMessage {
+	message := &model.ConversationMessage{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		ConversationID: conversation.ID,
+		Role:           role,
+		Content:        content,
+	}
+	message.CreatedAt = time.Now()
+	message.UpdatedAt = message.CreatedAt
+	return message
+}
+
+// normalizeOrigins 校验来源白名单，支持完整的来源（协议+域名+端口）和 https://*.example.com 形式的子域名通配，
+// 省略协议的 *.example.com 按 https 处理
+func normalizeOrigins(origins []string) (model.StringList, error) {
+	var normalized model.StringList
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		if origin == "" {
+			continue
+		}
+		if strings.HasPrefix(origin, "*.") {
+			origin = "https://" + origin
+		}
+		if scheme, host, ok := strings.Cut(origin, "://*."); ok {
+			u, err := url.Parse(scheme + "://" + host)
+			// 通配至少要限定到二级域名，不允许 *.com
+			if err != nil || (scheme != "http" && scheme != "https") || u.Host != host ||
+				strings.Contains(host, "*") || !strings.Contains(u.Hostname(), ".") {
+				return nil, biz.ErrEmbedOrigin
+			}
+			normalized = append(normalized, scheme+"://*."+host)
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Contains(u.Host, "*") ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return nil, biz.ErrEmbedOrigin
+		}
+		normalized = append(normalized, u.Scheme+"://"+u.Host)
+	}
+	// 白名单为空等于允许任何网站使用，不允许
+	if len(normalized) == 0 {
+		return nil, biz.ErrEmbedOrigin
+	}
+	return normalized, nil
+}
+
+// matchOrigin 请求来源是否在白名单中，浏览器发起的跨域请求一定带有 Origin。
+// 通配的来源要求协议和端口一致，之前保存的不带协议的 *.example.com 只匹配 https
+func matchOrigin(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	if origin == "" || origin == "null" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, o := range allowed {
+		if o == origin {
+			return true
+		}
+		if strings.HasPrefix(o, "*.") {
+			o = "https://" + o
+		}
+		scheme, host, ok := strings.Cut(o, "://*.")
+		if !ok || scheme != u.Scheme {
+			continue
+		}
+		domain, port, _ := strings.Cut(host, ":")
+		if port == u.Port() && strings.HasSuffix(u.Hostname(), "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// validVisitorId 访客标识只接受 newVisitorId 生成的格式，不能用短的或者容易猜到的标识读取别人的会话
+func validVisitorId(visitorId string) bool {
+	hexPart, ok := strings.CutPrefix(visitorId, visitorIdPrefix)
+	if !ok || len(hexPart) != visitorIdBytes*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hexPart)
+	return err == nil && strings.ToLower(hexPart) == hexPart
+}
+
+// newVisitorId 由服务端生成 128 位随机的访客标识
+func newVisitorId() string {
+	b := make([]byte, visitorIdBytes)
+	_, _ = rand.Read(b)
+	return visitorIdPrefix + hex.EncodeToString(b)
+}
+
+// validateWidgetMessage 组件的消息在计入额度之前检查
+func validateWidgetMessage(req WidgetMessageReq) error {
+	if !validVisitorId(req.VisitorID) {
+		return errs.ErrParam
+	}
+	return validateGuestMessage(req.Message)
+}
@@ -0,0 +1,119 @@
+package agents
+
+import (
+	"common/biz"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mszlu521/thunder/errs"
+)
+
+func TestNormalizeOrigins(t *testing.T) {
+	got, err := normalizeOrigins([]string{
+		" https://App.Example.com/ ",
+		"http://localhost:3000",
+		"*.example.org",
+		"http://*.dev.example.net:8080",
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"https://app.example.com",
+		"http://localhost:3000",
+		"https://*.example.org",
+		"http://*.dev.example.net:8080",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("normalizeOrigins() = %v, want %v", got, want)
+	}
+	for _, origin := range []string{
+		"*.com",
+		"https://*.com",
+		"ftp://example.com",
+		"https://example.com/path",
+		"https://user@example.com",
+		"https://*.*.example.com",
+		"https://a.*.example.com",
+		"example.com",
+	} {
+		if _, err := normalizeOrigins([]string{origin}); !errors.Is(err, biz.ErrEmbedOrigin) {
+			t.Errorf("normalizeOrigins(%q) error = %v, want ErrEmbedOrigin", origin, err)
+		}
+	}
+	if _, err := normalizeOrigins(nil); !errors.Is(err, biz.ErrEmbedOrigin) {
+		t.Error("empty allow-list should be rejected")
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	allowed := []string{
+		"https://app.example.com",
+		"https://*.example.org",
+		"http://*.dev.example.net:8080",
+		// 旧版本保存的不带协议的通配
+		"*.legacy.com",
+	}
+	tests := map[string]bool{
+		"https://app.example.com":          true,
+		"http://app.example.com":           false,
+		"https://app.example.com:8443":     false,
+		"https://a.example.org":            true,
+		"https://a.b.example.org":          true,
+		"http://a.example.org":             false,
+		"https://example.org":              false,
+		"https://evilexample.org":          false,
+		"https://a.example.org:444":        false,
+		"http://x.dev.example.net:8080":    true,
+		"http://x.dev.example.net":         false,
+		"https://www.legacy.com":           true,
+		"http://www.legacy.com":            false,
+		"":                                 false,
+		"null":                             false,
+		"https://app.example.com.evil.com": false,
+	}
+	for origin, want := range tests {
+		if got := matchOrigin(origin, allowed); got != want {
+			t.Errorf("matchOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestVisitorId(t *testing.T) {
+	id := newVisitorId()
+	if !validVisitorId(id) || len(id) != len(visitorIdPrefix)+32 {
+		t.Fatalf("newVisitorId() = %q", id)
+	}
+	if newVisitorId() == id {
+		t.Fatal("visitor ids should be random")
+	}
+	for _, bad := range []string{
+		"",
+		"v_1",
+		"visitor",
+		"v_" + strings.Repeat("g", 32),
+		"v_" + strings.Repeat("A", 32),
+		"x_" + strings.Repeat("a", 32),
+		"v_" + strings.Repeat("a", 34),
+	} {
+		if validVisitorId(bad) {
+			t.Errorf("validVisitorId(%q) = true", bad)
+		}
+	}
+}
+
+func TestValidateWidgetMessage(t *testing.T) {
+	visitor := newVisitorId()
+	if err := validateWidgetMessage(WidgetMessageReq{VisitorID: visitor, Message: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateWidgetMessage(WidgetMessageReq{VisitorID: "guess-me", Message: "hi"}); !errors.Is(err, errs.ErrParam) {
+		t.Errorf("err = %v, want ErrParam", err)
+	}
+	if err := validateWidgetMessage(WidgetMessageReq{VisitorID: visitor, Message: strings.Repeat("a", 5000)}); !errors.Is(err, biz.ErrGuestMessageInvalid) {
+		t.Errorf("err = %v, want ErrGuestMessageInvalid", err)
+	}
+}
@@ -0,0 +1,64 @@
+package apitokens
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+func (h *Handler) CreateToken(c *gin.Context) {
+	var createReq CreateTokenReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	token, err := h.service.createToken(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, token)
+}
+
+func (h *Handler) ListTokens(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	tokens, err := h.service.listTokens(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, tokens)
+}
+
+func (h *Handler) DeleteToken(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteToken(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
@@ -0,0 +1,55 @@
+package apitokens
+
+import (
+	"app/shared"
+	"common/biz"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mszlu521/thunder/res"
+	"github.com/mszlu521/thunder/tools/jwt"
+)
+
+// Auth 使用API令牌或者登录的JWT认证，认证通过后把用户ID放到 userId 中，
+// 后续可以直接使用 req.GetUserIdUUID 获取
+func Auth() gin.HandlerFunc {
+	s := newService()
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if len(token) > 7 && strings.ToLower(token[:7]) == "bearer " {
+			token = token[7:]
+		}
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
+			c.Abort()
+			return
+		}
+		// 不是API令牌时按登录令牌处理，前端和自己的客户端可以直接使用登录态，刷新令牌不能访问接口
+		if !strings.HasPrefix(token, tokenPrefix) {
+			claims, err := jwt.ParseToken(token)
+			if err != nil || shared.IsRefreshToken(claims) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				c.Abort()
+				return
+			}
+			c.Set("userId", claims.UserId)
+			c.Next()
+			return
+		}
+		userID, err := s.verifyToken(c.Request.Context(), token)
+		if errors.Is(err, biz.ErrTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			res.Error(c, err)
+			c.Abort()
+			return
+		}
+		c.Set("userId", userID.String())
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package apitokens
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+func (m *models) createToken(ctx context.Context, token *model.ApiToken) error {
+	return m.db.WithContext(ctx).Create(token).Error
+}
+
+func (m *models) listTokens(ctx context.Context, userID uuid.UUID) ([]*model.ApiToken, error) {
+	var tokens []*model.ApiToken
+	err := m.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error
+	return tokens, err
+}
+
+func (m *models) deleteToken(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? and user_id = ?", id, userID).Delete(&model.ApiToken{})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) getTokenByHash(ctx context.Context, hash string) (*model.ApiToken, error) {
+	var token model.ApiToken
+	err := m.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &token, err
+}
+
+func (m *models) touchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return m.db.WithContext(ctx).Model(&model.ApiToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
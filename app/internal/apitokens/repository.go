@@ -0,0 +1,17 @@
+package apitokens
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createToken(ctx context.Context, token *model.ApiToken) error
+	listTokens(ctx context.Context, userID uuid.UUID) ([]*model.ApiToken, error)
+	deleteToken(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	getTokenByHash(ctx context.Context, hash string) (*model.ApiToken, error)
+	touchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,7 @@
+package apitokens
+
+type CreateTokenReq struct {
+	Name string `json:"name"`
+	// ExpiresInDays 有效天数，0 表示永不过期
+	ExpiresInDays int `json:"expiresInDays"`
+}
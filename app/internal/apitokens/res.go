@@ -0,0 +1,9 @@
+package apitokens
+
+import "model"
+
+type CreateTokenResponse struct {
+	*model.ApiToken
+	// Token 令牌明文，只在创建时返回一次
+	Token string `json:"token"`
+}
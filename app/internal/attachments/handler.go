@@ -0,0 +1,168 @@
+package attachments
+
+import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+// UploadImage 上传图片，表单字段为 file
+func (h *Handler) UploadImage(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	maxSize := configs.GetConfig().Attachment.GetMaxImageSize()
+	// 表单的其他内容很少，多留 1MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	data, fileName, err := shared.ReadFormFile(c, maxSize, biz.ErrAttachmentTooLarge, biz.ErrAttachmentInvalid)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	attachment, err := h.service.uploadImage(c.Request.Context(), userID, fileName, data)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, attachment)
+}
+
+// UploadFile 上传文件到会话中，表单字段为 file 和 sessionId
+func (h *Handler) UploadFile(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	maxSize := configs.GetConfig().Attachment.GetMaxFileSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	data, fileName, err := shared.ReadFormFile(c, maxSize, biz.ErrAttachmentTooLarge, biz.ErrAttachmentInvalid)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	sessionID, err := uuid.Parse(c.PostForm("sessionId"))
+	if err != nil {
+		res.Error(c, errs.ErrParam)
+		return
+	}
+	attachment, err := h.service.uploadFile(c.Request.Context(), userID, sessionID, fileName, data)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, attachment)
+}
+
+// ListFiles 查询会话中的文件
+func (h *Handler) ListFiles(c *gin.Context) {
+	var listReq ListFilesReq
+	if err := req.QueryParam(c, &listReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	files, err := h.service.listSessionFiles(c.Request.Context(), userID, listReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, files)
+}
+
+// AddImageUrl 通过 URL 添加图片
+func (h *Handler) AddImageUrl(c *gin.Context) {
+	var addReq AddImageUrlReq
+	if err := req.JsonParam(c, &addReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	attachment, err := h.service.addImageUrl(c.Request.Context(), userID, addReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, attachment)
+}
+
+func (h *Handler) GetAttachment(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	attachment, err := h.service.getAttachment(c.Request.Context(), userID, id, false)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, attachment)
+}
+
+// GetContent 返回附件的文件内容，保存在文件存储中的附件跳转到签名下载地址
+func (h *Handler) GetContent(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	u, attachment, err := h.service.contentURL(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	if u != "" {
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, u)
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Length", strconv.Itoa(len(attachment.Data)))
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
+
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteAttachment(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
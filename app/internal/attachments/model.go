@@ -0,0 +1,75 @@
+package attachments
+
+import (
+	"context"
+	"model"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+// 查询附件信息时不读取文件内容
+var metaColumns = []string{"id", "created_at", "updated_at", "creator_id", "kind", "session_id", "file_name", "content_type", "size", "source_url", "blob_id", "text_length"}
+
+func (m *models) createAttachment(ctx context.Context, attachment *model.Attachment) error {
+	return m.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (m *models) getAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID, withData bool) (*model.Attachment, error) {
+	var attachment model.Attachment
+	query := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID)
+	if !withData {
+		query = query.Select(metaColumns)
+	}
+	err := query.First(&attachment).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &attachment, err
+}
+
+func (m *models) getAttachments(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.Attachment, error) {
+	var attachments []*model.Attachment
+	err := m.db.WithContext(ctx).Where("id IN ? AND creator_id = ?", ids, userID).Find(&attachments).Error
+	return attachments, err
+}
+
+// deleteAttachment 直接删除记录，软删除会一直占用文件内容的空间
+func (m *models) deleteAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Unscoped().Where("id = ? AND creator_id = ?", id, userID).Delete(&model.Attachment{})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) countSessionFiles(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.Attachment{}).Where("session_id = ?", sessionID).Count(&count).Error
+	return count, err
+}
+
+// listSessionFiles 按上传顺序返回会话中的文件，不读取原始内容，withText 为 true 时读取解析出的文本
+func (m *models) listSessionFiles(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, withText bool) ([]*model.Attachment, error) {
+	var files []*model.Attachment
+	columns := metaColumns
+	if withText {
+		columns = append(slices.Clip(metaColumns), "text")
+	}
+	err := m.db.WithContext(ctx).Select(columns).
+		Where("session_id = ? AND creator_id = ? AND kind = ?", sessionID, userID, model.AttachmentFile).
+		Order("created_at").Find(&files).Error
+	return files, err
+}
+
+func (m *models) deleteSessionFiles(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Unscoped().Where("session_id = ?", sessionID).Delete(&model.Attachment{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,51 @@
+package attachments
+
+import (
+	"app/shared"
+	"context"
+	"time"
+
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/event"
+)
+
+type PublicService struct {
+	repo repository
+}
+
+// GetAttachments 按ID查询用户的附件，包含文件内容，不属于该用户的附件不会返回
+func (s *PublicService) GetAttachments(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetAttachmentsRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	attachments, err := s.repo.getAttachments(ctx, request.UserID, request.IDs)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if err := loadData(context.Background(), attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetSessionFiles 查询会话中的文件，包含解析出的文本
+func (s *PublicService) GetSessionFiles(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetSessionFilesRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.repo.listSessionFiles(ctx, request.UserID, request.SessionID, true)
+}
+
+// DeleteSessionFiles 会话删除时删除其中的所有文件
+func (s *PublicService) DeleteSessionFiles(e event.Event) (any, error) {
+	request := e.Data.(*shared.DeleteSessionFilesRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.repo.deleteSessionFiles(ctx, request.SessionID)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
@@ -0,0 +1,18 @@
+package attachments
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createAttachment(ctx context.Context, attachment *model.Attachment) error
+	getAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID, withData bool) (*model.Attachment, error)
+	getAttachments(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.Attachment, error)
+	deleteAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	countSessionFiles(ctx context.Context, sessionID uuid.UUID) (int64, error)
+	listSessionFiles(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, withText bool) ([]*model.Attachment, error)
+	deleteSessionFiles(ctx context.Context, sessionID uuid.UUID) (int64, error)
+}
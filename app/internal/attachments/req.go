@@ -0,0 +1,9 @@
+package attachments
+
+type AddImageUrlReq struct {
+	Url string `json:"url"`
+}
+
+type ListFilesReq struct {
+	SessionID string `json:"sessionId" form:"sessionId"`
+}
@@ -0,0 +1,291 @@
+package attachments
+
+import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
+	"context"
+	"core/ai/documents"
+	"core/ai/webfetch"
+	"errors"
+	"mime"
+	"model"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 支持的图片格式，按文件内容判断，不信任上传时声明的类型
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// 下载图片的超时时间
+const fetchTimeout = 15 * time.Second
+
+type service struct {
+	repo    repository
+	fetcher *webfetch.Fetcher
+}
+
+func newService() *service {
+	options := webfetch.DefaultOptions
+	options.Timeout = fetchTimeout
+	options.CacheTTL = 0
+	// 下载后还会按当前配置检查大小，这里只限制读取的上限
+	options.MaxBodySize = configs.GetConfig().Attachment.GetMaxImageSize()
+	return &service{
+		repo:    newModels(database.GetPostgresDB().GormDB),
+		fetcher: webfetch.NewFetcher(options),
+	}
+}
+
+// uploadImage 保存上传的图片
+func (s *service) uploadImage(ctx context.Context, userID uuid.UUID, fileName string, data []byte) (*model.Attachment, error) {
+	attachment, err := newImage(userID, fileName, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, attachment)
+}
+
+// addImageUrl 下载 URL 指向的图片并保存，之后不再依赖原地址
+func (s *service) addImageUrl(ctx context.Context, userID uuid.UUID, req AddImageUrlReq) (*model.Attachment, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	file, err := s.fetcher.Download(fetchCtx, req.Url)
+	if err != nil {
+		if errors.Is(err, webfetch.ErrTooLarge) {
+			return nil, biz.ErrAttachmentTooLarge
+		}
+		logs.Warnf("download image %s error: %v", req.Url, err)
+		return nil, errs.NewError(biz.ErrAttachmentFetch.Code, biz.ErrAttachmentFetch.Msg+"："+err.Error())
+	}
+	attachment, err := newImage(userID, urlFileName(file.Url), file.Data)
+	if err != nil {
+		return nil, err
+	}
+	attachment.SourceURL = req.Url
+	return s.create(ctx, attachment)
+}
+
+// urlFileName 取 URL 路径的最后一段作为文件名
+func urlFileName(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// newImage 检查图片的格式和大小
+func newImage(userID uuid.UUID, fileName string, data []byte) (*model.Attachment, error) {
+	if int64(len(data)) > configs.GetConfig().Attachment.GetMaxImageSize() {
+		return nil, biz.ErrAttachmentTooLarge
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !imageTypes[contentType] {
+		return nil, biz.ErrAttachmentInvalid
+	}
+	return &model.Attachment{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:   userID,
+		Kind:        model.AttachmentImage,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Data:        data,
+	}, nil
+}
+
+// uploadFile 解析文件并保存到会话中，解析失败的文件不保存
+func (s *service) uploadFile(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, fileName string, data []byte) (*model.Attachment, error) {
+	if int64(len(data)) > configs.GetConfig().Attachment.GetMaxFileSize() {
+		return nil, biz.ErrAttachmentTooLarge
+	}
+	if err := s.checkSession(userID, sessionID); err != nil {
+		return nil, err
+	}
+	countCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	count, err := s.repo.countSessionFiles(countCtx, sessionID)
+	cancel()
+	if err != nil {
+		logs.Errorf("count session files error: %v", err)
+		return nil, errs.DBError
+	}
+	if count >= int64(configs.GetConfig().Attachment.GetMaxSessionFiles()) {
+		return nil, biz.ErrAttachmentLimit
+	}
+	doc, err := documents.Parse(fileName, data)
+	if err != nil {
+		if errors.Is(err, documents.ErrUnsupportedFormat) {
+			return nil, biz.ErrAttachmentInvalid
+		}
+		return nil, errs.NewError(biz.ErrAttachmentParse.Code, biz.ErrAttachmentParse.Msg+"："+err.Error())
+	}
+	return s.create(ctx, &model.Attachment{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:   userID,
+		Kind:        model.AttachmentFile,
+		SessionID:   &sessionID,
+		FileName:    path.Base(fileName),
+		ContentType: fileContentType(doc.Format),
+		Size:        int64(len(data)),
+		Data:        data,
+		Text:        doc.Text,
+		TextLength:  utf8.RuneCountInString(doc.Text),
+	})
+}
+
+func fileContentType(format string) string {
+	switch format {
+	case documents.FormatPDF:
+		return "application/pdf"
+	case documents.FormatCSV:
+		return "text/csv"
+	}
+	return "text/plain"
+}
+
+// checkSession 文件只能上传到用户自己的会话中
+func (s *service) checkSession(userID uuid.UUID, sessionID uuid.UUID) error {
+	trigger, err := event.Trigger("getChatSession", &shared.GetChatSessionRequest{
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		logs.Errorf("getChatSession error: %v", err)
+		return errs.DBError
+	}
+	if trigger.(*model.Conversation) == nil {
+		return biz.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *service) listSessionFiles(ctx context.Context, userID uuid.UUID, req ListFilesReq) ([]*model.Attachment, error) {
+	sessionID, err := uuid.Parse(req.SessionID)
+	if err != nil {
+		return nil, errs.ErrParam
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	files, err := s.repo.listSessionFiles(ctx, userID, sessionID, false)
+	if err != nil {
+		logs.Errorf("list session files error: %v", err)
+		return nil, errs.DBError
+	}
+	return files, nil
+}
+
+// create 文件内容保存到文件存储，数据库只保存对象的ID
+func (s *service) create(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error) {
+	trigger, err := event.Trigger("putBlob", &shared.PutBlobRequest{
+		Ctx:         ctx,
+		OwnerID:     attachment.CreatorID,
+		Kind:        model.BlobAttachment,
+		RefID:       attachment.ID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Data:        attachment.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	blob := trigger.(*model.Blob)
+	attachment.BlobID = &blob.ID
+	attachment.Data = nil
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.repo.createAttachment(ctx, attachment); err != nil {
+		logs.Errorf("create attachment error: %v", err)
+		return nil, errs.DBError
+	}
+	return attachment, nil
+}
+
+// getAttachment withData 为 true 时同时读取文件内容
+func (s *service) getAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID, withData bool) (*model.Attachment, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	attachment, err := s.repo.getAttachment(dbCtx, userID, id, withData)
+	if err != nil {
+		logs.Errorf("get attachment error: %v", err)
+		return nil, errs.DBError
+	}
+	if attachment == nil {
+		return nil, biz.ErrAttachmentNotFound
+	}
+	if withData {
+		if err := loadData(ctx, []*model.Attachment{attachment}); err != nil {
+			return nil, err
+		}
+	}
+	return attachment, nil
+}
+
+// contentURL 保存在文件存储中的附件返回签名下载地址，之前保存在数据库中的附件返回空
+func (s *service) contentURL(ctx context.Context, userID uuid.UUID, id uuid.UUID) (string, *model.Attachment, error) {
+	attachment, err := s.getAttachment(ctx, userID, id, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if attachment.BlobID == nil {
+		attachment, err = s.getAttachment(ctx, userID, id, true)
+		return "", attachment, err
+	}
+	trigger, err := event.Trigger("getBlobURL", &shared.GetBlobURLRequest{Ctx: ctx, ID: *attachment.BlobID})
+	if err != nil {
+		return "", nil, err
+	}
+	return trigger.(string), attachment, nil
+}
+
+// loadData 从文件存储读取附件的内容，之前保存在数据库中的附件已经带有内容
+func loadData(ctx context.Context, attachments []*model.Attachment) error {
+	for _, attachment := range attachments {
+		if attachment.BlobID == nil {
+			continue
+		}
+		trigger, err := event.Trigger("readBlob", &shared.ReadBlobRequest{Ctx: ctx, ID: *attachment.BlobID})
+		if err != nil {
+			logs.Errorf("read attachment %s error: %v", attachment.ID, err)
+			return err
+		}
+		attachment.Data = trigger.([]byte)
+	}
+	return nil
+}
+
+func (s *service) deleteAttachment(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteAttachment(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete attachment error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrAttachmentNotFound
+	}
+	return nil
+}
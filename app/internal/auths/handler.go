@@ -1,6 +1,11 @@
 package auths
 
 import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mszlu521/thunder/req"
 	"github.com/mszlu521/thunder/res"
@@ -122,3 +127,24 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		"message": "密码重置成功",
 	})
 }
+
+// UploadAvatar 上传当前用户的头像，表单字段为 file
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	maxSize := configs.GetConfig().Attachment.GetMaxImageSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	data, fileName, err := shared.ReadFormFile(c, maxSize, biz.ErrBlobTooLarge, biz.ErrBlobInvalid)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	user, err := h.service.uploadAvatar(c.Request.Context(), userID, fileName, data)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, user)
+}
@@ -1,13 +1,13 @@
 package auths
 
 import (
+	"app/shared"
 	"common/biz"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"model"
-	"net/smtp"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +15,7 @@ import (
 	"github.com/mszlu521/thunder/config"
 	"github.com/mszlu521/thunder/database"
 	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
 	"github.com/mszlu521/thunder/logs"
 	"github.com/mszlu521/thunder/tools/jwt"
 	"github.com/mszlu521/thunder/tools/randoms"
@@ -121,23 +122,7 @@ func (s *Service) sendVerifyEmail(email string, username string, token string) e
 	verifyURL := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", emailConfig.GetBaseURL(), token)
 	body := fmt.Sprintf("尊敬的 %s，\n\n感谢您注册我们的服务！\n\n请点击以下链接验证您的邮箱地址：\n%s\n\n如果链接无法点击，请复制并粘贴到浏览器地址栏中。\n\n谢谢！\n", username, verifyURL)
 
-	// Set up authentication information
-	auth := smtp.PlainAuth("", emailConfig.GetUsername(), emailConfig.GetPassword(), emailConfig.GetHost())
-
-	// Connect to the server, authenticate, and send the email
-	to := []string{email}
-	msg := []byte("To: " + email + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"\r\n" +
-		body + "\r\n")
-
-	addr := fmt.Sprintf("%s:%d", emailConfig.GetHost(), emailConfig.GetPort())
-	err := smtp.SendMail(addr, auth, emailConfig.GetFrom(), to, msg)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
-
-	return nil
+	return shared.SendMail([]string{email}, subject, body)
 }
 
 // 验证邮箱
@@ -222,7 +207,7 @@ func (s *Service) token(u *model.User) (*LoginResp, error) {
 		logs.Errorf("GenToken err: %v", err)
 		return nil, biz.ErrTokenGenerate
 	}
-	refreshToken, err := jwt.GenToken(u.Id.String(), u.Username, refreshExpire)
+	refreshToken, err := shared.GenRefreshToken(u.Id.String(), u.Username, refreshExpire)
 	if err != nil {
 		logs.Errorf("GenToken err: %v", err)
 		return nil, biz.ErrTokenGenerate
@@ -254,6 +239,10 @@ func (s *Service) refreshToken(token string) (*LoginResp, error) {
 		logs.Errorf("ParseToken err: %v", err)
 		return nil, biz.ErrTokenInvalid
 	}
+	// 只接受刷新令牌，访问令牌不能用来续期
+	if !shared.IsRefreshToken(claims) {
+		return nil, biz.ErrTokenInvalid
+	}
 	userIdStr := claims.UserId
 	userId, err := uuid.Parse(userIdStr)
 	if err != nil {
@@ -318,23 +307,7 @@ func (s *Service) sendForgotPasswordEmail(email, username, code string) error {
 	subject := "您的验证码"
 	body := fmt.Sprintf("尊敬的 %s，\n\n您正在重置密码，验证码是：%s\n\n验证码5分钟内有效，如非本人操作请忽略。\n\n谢谢！\n", username, code)
 
-	// Set up authentication information
-	auth := smtp.PlainAuth("", emailConfig.GetUsername(), emailConfig.GetPassword(), emailConfig.GetHost())
-
-	// Connect to the server, authenticate, and send the email
-	to := []string{email}
-	msg := []byte("To: " + email + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"\r\n" +
-		body + "\r\n")
-
-	addr := fmt.Sprintf("%s:%d", emailConfig.GetHost(), emailConfig.GetPort())
-	err := smtp.SendMail(addr, auth, emailConfig.GetFrom(), to, msg)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
-
-	return nil
+	return shared.SendMail([]string{email}, subject, body)
 }
 
 func (s *Service) verifyCode(req VerifyCodeReq) (string, error) {
@@ -435,3 +408,43 @@ func (s *Service) validateResetToken(token string) (string, error) {
 
 	return email, nil
 }
+
+// uploadAvatar 保存上传的头像并设置为用户的头像，之前的头像不再被引用后由存储定期清理
+func (s *Service) uploadAvatar(ctx context.Context, userID uuid.UUID, fileName string, data []byte) (*model.UserDTO, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	u, err := s.repo.findById(dbCtx, userID)
+	if err != nil {
+		logs.Errorf("findById err: %v", err)
+		return nil, errs.DBError
+	}
+	if u == nil {
+		return nil, biz.ErrUserNotFound
+	}
+	trigger, err := event.Trigger("putBlob", &shared.PutBlobRequest{
+		Ctx:      ctx,
+		OwnerID:  userID,
+		Kind:     model.BlobAvatar,
+		RefID:    userID,
+		FileName: fileName,
+		Data:     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.Avatar = trigger.(*model.Blob).Path()
+	dbCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.repo.updateUser(dbCtx, nil, &model.User{Id: u.Id, Avatar: u.Avatar}); err != nil {
+		logs.Errorf("updateUser err: %v", err)
+		return nil, errs.DBError
+	}
+	return &model.UserDTO{
+		Id:            u.Id,
+		Username:      u.Username,
+		Avatar:        u.Avatar,
+		Status:        u.Status,
+		LastLoginTime: u.LastLoginTime,
+		CurrentPlan:   u.CurrentPlan,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package datasources
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+func (h *Handler) CreateDataSource(c *gin.Context) {
+	var createReq CreateDataSourceReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	ds, err := h.service.createDataSource(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, ds)
+}
+
+func (h *Handler) ListDataSources(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listDataSources(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) GetDataSource(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	ds, err := h.service.getDataSource(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, ds)
+}
+
+func (h *Handler) UpdateDataSource(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var updateReq UpdateDataSourceReq
+	if err := req.JsonParam(c, &updateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	ds, err := h.service.updateDataSource(c.Request.Context(), userID, id, updateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, ds)
+}
+
+func (h *Handler) DeleteDataSource(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteDataSource(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+func (h *Handler) GetSchema(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	schema, err := h.service.getSchema(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, schema)
+}
+
+func (h *Handler) TestQuery(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var queryReq QueryReq
+	if err := req.JsonParam(c, &queryReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.testQuery(c.Request.Context(), userID, id, queryReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, result)
+}
@@ -0,0 +1,46 @@
+package datasources
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+func (m *models) createDataSource(ctx context.Context, ds *model.DataSource) error {
+	return m.db.WithContext(ctx).Create(ds).Error
+}
+
+func (m *models) listDataSources(ctx context.Context, userID uuid.UUID) ([]*model.DataSource, error) {
+	var list []*model.DataSource
+	err := m.db.WithContext(ctx).Where("creator_id = ?", userID).Order("created_at desc").Find(&list).Error
+	return list, err
+}
+
+func (m *models) getDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.DataSource, error) {
+	var ds model.DataSource
+	err := m.db.WithContext(ctx).Where("id = ? and creator_id = ?", id, userID).First(&ds).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &ds, err
+}
+
+func (m *models) updateDataSource(ctx context.Context, ds *model.DataSource) error {
+	return m.db.WithContext(ctx).Save(ds).Error
+}
+
+func (m *models) deleteDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? and creator_id = ?", id, userID).Delete(&model.DataSource{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,25 @@
+package datasources
+
+import (
+	"app/shared"
+	"context"
+
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/event"
+)
+
+type PublicService struct {
+	repo repository
+}
+
+// GetDataSource 按创建者查询数据源，不存在时返回 nil
+func (s *PublicService) GetDataSource(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetDataSourceRequest)
+	return s.repo.getDataSource(context.Background(), request.UserID, request.ID)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
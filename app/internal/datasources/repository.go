@@ -0,0 +1,16 @@
+package datasources
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createDataSource(ctx context.Context, ds *model.DataSource) error
+	listDataSources(ctx context.Context, userID uuid.UUID) ([]*model.DataSource, error)
+	getDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.DataSource, error)
+	updateDataSource(ctx context.Context, ds *model.DataSource) error
+	deleteDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+}
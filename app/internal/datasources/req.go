@@ -0,0 +1,27 @@
+package datasources
+
+type CreateDataSourceReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Type 数据库类型：postgres、mysql
+	Type string `json:"type"`
+	// Dsn 明文连接串，保存时加密
+	Dsn            string   `json:"dsn"`
+	Tables         []string `json:"tables"`
+	MaxRows        int      `json:"maxRows"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+}
+
+// UpdateDataSourceReq Dsn 为空时保留原来的连接串
+type UpdateDataSourceReq struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Dsn            string   `json:"dsn"`
+	Tables         []string `json:"tables"`
+	MaxRows        int      `json:"maxRows"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+}
+
+type QueryReq struct {
+	Sql string `json:"sql"`
+}
@@ -0,0 +1,7 @@
+package datasources
+
+import "core/ai/dbquery"
+
+type SchemaResponse struct {
+	Tables []*dbquery.Table `json:"tables"`
+}
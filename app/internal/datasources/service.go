@@ -0,0 +1,219 @@
+package datasources
+
+import (
+	"app/shared"
+	"common/biz"
+	"common/secrets"
+	"context"
+	"core/ai/dbquery"
+	"model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 单次查询允许配置的最长超时时间
+const maxTimeoutSeconds = 120
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+func (s *service) createDataSource(ctx context.Context, userID uuid.UUID, req CreateDataSourceReq) (*model.DataSource, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || strings.TrimSpace(req.Dsn) == "" {
+		return nil, biz.ErrDataSourceInvalid
+	}
+	if req.Type != model.DataSourcePostgres && req.Type != model.DataSourceMysql {
+		return nil, biz.ErrDataSourceInvalid
+	}
+	if err := validateLimits(req.MaxRows, req.TimeoutSeconds); err != nil {
+		return nil, err
+	}
+	ds := &model.DataSource{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:      userID,
+		Name:           name,
+		Description:    req.Description,
+		Type:           req.Type,
+		Tables:         req.Tables,
+		MaxRows:        req.MaxRows,
+		TimeoutSeconds: req.TimeoutSeconds,
+	}
+	// 保存前先确认能连上，连接测试本身有超时
+	if err := dbquery.Ping(ctx, &dbquery.Source{Type: ds.Type, Dsn: req.Dsn}); err != nil {
+		logs.Warnf("ping data source error: %v", err)
+		return nil, biz.ErrDataSourceConnect
+	}
+	dsn, err := secrets.Encrypt(req.Dsn)
+	if err != nil {
+		logs.Errorf("encrypt data source dsn error: %v", err)
+		return nil, biz.ErrCredentialEncrypt
+	}
+	ds.Dsn = dsn
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.repo.createDataSource(ctx, ds); err != nil {
+		logs.Errorf("create data source error: %v", err)
+		return nil, errs.DBError
+	}
+	return ds, nil
+}
+
+func (s *service) listDataSources(ctx context.Context, userID uuid.UUID) ([]*model.DataSource, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	list, err := s.repo.listDataSources(ctx, userID)
+	if err != nil {
+		logs.Errorf("list data sources error: %v", err)
+		return nil, errs.DBError
+	}
+	return list, nil
+}
+
+func (s *service) getDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.DataSource, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ds, err := s.repo.getDataSource(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get data source error: %v", err)
+		return nil, errs.DBError
+	}
+	if ds == nil {
+		return nil, biz.ErrDataSourceNotFound
+	}
+	return ds, nil
+}
+
+func (s *service) updateDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateDataSourceReq) (*model.DataSource, error) {
+	ds, err := s.getDataSource(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLimits(req.MaxRows, req.TimeoutSeconds); err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		ds.Name = name
+	}
+	ds.Description = req.Description
+	ds.Tables = req.Tables
+	ds.MaxRows = req.MaxRows
+	ds.TimeoutSeconds = req.TimeoutSeconds
+	// 修改了 DSN 时旧的连接池不会再被使用，更新成功后关闭
+	var old *dbquery.Source
+	if strings.TrimSpace(req.Dsn) != "" {
+		if old, err = shared.BuildDataSource(ds); err != nil {
+			logs.Warnf("build old data source error: %v", err)
+		}
+		if err := dbquery.Ping(ctx, &dbquery.Source{Type: ds.Type, Dsn: req.Dsn}); err != nil {
+			logs.Warnf("ping data source error: %v", err)
+			return nil, biz.ErrDataSourceConnect
+		}
+		dsn, err := secrets.Encrypt(req.Dsn)
+		if err != nil {
+			logs.Errorf("encrypt data source dsn error: %v", err)
+			return nil, biz.ErrCredentialEncrypt
+		}
+		ds.Dsn = dsn
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ds.UpdatedAt = time.Now()
+	if err := s.repo.updateDataSource(ctx, ds); err != nil {
+		logs.Errorf("update data source error: %v", err)
+		return nil, errs.DBError
+	}
+	if old != nil {
+		dbquery.Release(old)
+	}
+	return ds, nil
+}
+
+func (s *service) deleteDataSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ds, err := s.getDataSource(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteDataSource(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete data source error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrDataSourceNotFound
+	}
+	// 关闭数据源的连接池，已经创建的查询工具随之失效
+	if source, err := shared.BuildDataSource(ds); err == nil {
+		dbquery.Release(source)
+	}
+	return nil
+}
+
+// getSchema 返回模型能看到的表结构
+func (s *service) getSchema(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*SchemaResponse, error) {
+	source, err := s.buildSource(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := dbquery.Schema(ctx, source)
+	if err != nil {
+		logs.Warnf("load data source schema error: %v", err)
+		return nil, biz.ErrDataSourceConnect
+	}
+	if tables == nil {
+		tables = []*dbquery.Table{}
+	}
+	return &SchemaResponse{Tables: tables}, nil
+}
+
+// testQuery 用和工具相同的校验执行一次查询，方便用户调试
+func (s *service) testQuery(ctx context.Context, userID uuid.UUID, id uuid.UUID, req QueryReq) (*dbquery.Result, error) {
+	source, err := s.buildSource(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := dbquery.Query(ctx, source, req.Sql)
+	if err != nil {
+		logs.Warnf("test data source query error: %v", err)
+		return nil, errs.NewError(biz.ErrDataSourceQuery.Code, err.Error())
+	}
+	return result, nil
+}
+
+func (s *service) buildSource(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*dbquery.Source, error) {
+	ds, err := s.getDataSource(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	source, err := shared.BuildDataSource(ds)
+	if err != nil {
+		logs.Errorf("build data source error: %v", err)
+		return nil, biz.ErrDataSourceInvalid
+	}
+	return source, nil
+}
+
+func validateLimits(maxRows int, timeoutSeconds int) error {
+	if maxRows < 0 || maxRows > dbquery.MaxRowsLimit {
+		return biz.ErrDataSourceInvalid
+	}
+	if timeoutSeconds < 0 || timeoutSeconds > maxTimeoutSeconds {
+		return biz.ErrDataSourceInvalid
+	}
+	return nil
+}
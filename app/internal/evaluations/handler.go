@@ -0,0 +1,259 @@
+package evaluations
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+func (h *Handler) CreateDataset(c *gin.Context) {
+	var createReq CreateDatasetReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	dataset, err := h.service.createDataset(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, dataset)
+}
+
+func (h *Handler) ListDatasets(c *gin.Context) {
+	var searchReq SearchDatasetReq
+	if err := req.QueryParam(c, &searchReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listDatasets(c.Request.Context(), userID, searchReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) GetDataset(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	dataset, err := h.service.getDataset(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, dataset)
+}
+
+func (h *Handler) UpdateDataset(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var updateReq UpdateDatasetReq
+	if err := req.JsonParam(c, &updateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	dataset, err := h.service.updateDataset(c.Request.Context(), userID, id, updateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, dataset)
+}
+
+func (h *Handler) DeleteDataset(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteDataset(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+func (h *Handler) AddCases(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var addReq AddCasesReq
+	if err := req.JsonParam(c, &addReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	cases, err := h.service.addCases(c.Request.Context(), userID, id, addReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, cases)
+}
+
+func (h *Handler) UpdateCase(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var caseID uuid.UUID
+	if err := req.Path(c, "caseId", &caseID); err != nil {
+		return
+	}
+	var caseReq CaseReq
+	if err := req.JsonParam(c, &caseReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	evalCase, err := h.service.updateCase(c.Request.Context(), userID, id, caseID, caseReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, evalCase)
+}
+
+func (h *Handler) DeleteCase(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var caseID uuid.UUID
+	if err := req.Path(c, "caseId", &caseID); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteCase(c.Request.Context(), userID, id, caseID); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// CreateRun 创建评测后立即返回，评测在后台执行
+func (h *Handler) CreateRun(c *gin.Context) {
+	var createReq CreateRunReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.createRun(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	var searchReq SearchRunReq
+	if err := req.QueryParam(c, &searchReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listRuns(c.Request.Context(), userID, searchReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+// GetRun 返回评测的进度和已经完成的用例结果
+func (h *Handler) GetRun(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.getRun(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
+
+func (h *Handler) CancelRun(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.cancelRun(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// CompareRuns 按用例并排对比多次评测的结果
+func (h *Handler) CompareRuns(c *gin.Context) {
+	var compareReq CompareRunsReq
+	if err := req.QueryParam(c, &compareReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	compare, err := h.service.compareRuns(c.Request.Context(), userID, compareReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, compare)
+}
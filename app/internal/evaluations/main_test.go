@@ -0,0 +1,15 @@
+package evaluations
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/mszlu521/thunder/config"
+	"github.com/mszlu521/thunder/logs"
+)
+
+func TestMain(m *testing.M) {
+	logs.Init(&config.LogConfig{Output: io.Discard})
+	os.Exit(m.Run())
+}
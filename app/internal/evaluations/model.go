@@ -0,0 +1,210 @@
+package evaluations
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+type DatasetFilter struct {
+	Name   string
+	Limit  int
+	Offset int
+}
+
+type RunFilter struct {
+	DatasetID *uuid.UUID
+	AgentID   *uuid.UUID
+	Limit     int
+	Offset    int
+}
+
+func (m *models) createDataset(ctx context.Context, dataset *model.EvalDataset) error {
+	return m.db.WithContext(ctx).Create(dataset).Error
+}
+
+func (m *models) listDatasets(ctx context.Context, userID uuid.UUID, filter DatasetFilter) ([]*model.EvalDataset, int64, error) {
+	var datasets []*model.EvalDataset
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.EvalDataset{}).Where("creator_id = ?", userID)
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.Order("updated_at desc").Find(&datasets).Error
+	return datasets, total, err
+}
+
+func (m *models) getDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalDataset, error) {
+	var dataset model.EvalDataset
+	err := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).First(&dataset).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &dataset, err
+}
+
+func (m *models) updateDataset(ctx context.Context, dataset *model.EvalDataset) error {
+	return m.db.WithContext(ctx).Model(dataset).
+		Select("name", "description", "updated_at").
+		Updates(dataset).Error
+}
+
+// deleteDataset 同时删除用例，评测记录保留，结果中保存了用例当时的内容
+func (m *models) deleteDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	var affected int64
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND creator_id = ?", id, userID).Delete(&model.EvalDataset{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		if affected == 0 {
+			return nil
+		}
+		return tx.Where("dataset_id = ?", id).Delete(&model.EvalCase{}).Error
+	})
+	return affected, err
+}
+
+func (m *models) countCases(ctx context.Context, datasetID uuid.UUID) (int64, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.EvalCase{}).Where("dataset_id = ?", datasetID).Count(&count).Error
+	return count, err
+}
+
+func (m *models) createCases(ctx context.Context, cases []*model.EvalCase) error {
+	return m.db.WithContext(ctx).Create(cases).Error
+}
+
+func (m *models) listCases(ctx context.Context, datasetID uuid.UUID) ([]*model.EvalCase, error) {
+	var cases []*model.EvalCase
+	err := m.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Order("created_at asc, id asc").Find(&cases).Error
+	return cases, err
+}
+
+func (m *models) getCase(ctx context.Context, datasetID uuid.UUID, id uuid.UUID) (*model.EvalCase, error) {
+	var evalCase model.EvalCase
+	err := m.db.WithContext(ctx).Where("id = ? AND dataset_id = ?", id, datasetID).First(&evalCase).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &evalCase, err
+}
+
+func (m *models) updateCase(ctx context.Context, evalCase *model.EvalCase) error {
+	return m.db.WithContext(ctx).Model(evalCase).
+		Select("question", "expected_answer", "expected_tools", "variables", "updated_at").
+		Updates(evalCase).Error
+}
+
+func (m *models) deleteCase(ctx context.Context, datasetID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? AND dataset_id = ?", id, datasetID).Delete(&model.EvalCase{})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) agentExists(ctx context.Context, userID uuid.UUID, agentID uuid.UUID) (bool, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.Agent{}).Where("id = ? AND creator_id = ?", agentID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (m *models) agentVersionExists(ctx context.Context, agentID uuid.UUID, version uint) (bool, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.AgentVersion{}).Where("agent_id = ? AND version = ?", agentID, version).Count(&count).Error
+	return count > 0, err
+}
+
+func (m *models) createRun(ctx context.Context, run *model.EvalRun) error {
+	return m.db.WithContext(ctx).Create(run).Error
+}
+
+func (m *models) updateRun(ctx context.Context, run *model.EvalRun) error {
+	return m.db.WithContext(ctx).Model(run).
+		Select("status", "total", "completed", "passed", "avg_score", "avg_latency_ms", "error", "started_at", "finished_at", "updated_at").
+		Updates(run).Error
+}
+
+// touchRuns 更新正在执行的评测的心跳
+func (m *models) touchRuns(ctx context.Context, ids []uuid.UUID, now time.Time) error {
+	return m.db.WithContext(ctx).Model(&model.EvalRun{}).
+		Where("id IN ? AND status IN ?", ids, unfinishedStatuses).
+		Update("updated_at", now).Error
+}
+
+// failStaleRuns 把 before 之后没有心跳的未结束评测标记为失败
+func (m *models) failStaleRuns(ctx context.Context, before time.Time, reason string) (int64, error) {
+	now := time.Now()
+	result := m.db.WithContext(ctx).Model(&model.EvalRun{}).
+		Where("status IN ? AND updated_at < ?", unfinishedStatuses, before).
+		Updates(map[string]any{"status": model.EvalRunFailed, "error": reason, "finished_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) listRuns(ctx context.Context, userID uuid.UUID, filter RunFilter) ([]*model.EvalRun, int64, error) {
+	var runs []*model.EvalRun
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.EvalRun{}).Where("creator_id = ?", userID)
+	if filter.DatasetID != nil {
+		query = query.Where("dataset_id = ?", *filter.DatasetID)
+	}
+	if filter.AgentID != nil {
+		query = query.Where("agent_id = ?", *filter.AgentID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.Order("created_at desc").Find(&runs).Error
+	return runs, total, err
+}
+
+func (m *models) getRun(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalRun, error) {
+	var run model.EvalRun
+	err := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).First(&run).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &run, err
+}
+
+func (m *models) getRuns(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.EvalRun, error) {
+	var runs []*model.EvalRun
+	err := m.db.WithContext(ctx).Where("id IN ? AND creator_id = ?", ids, userID).Find(&runs).Error
+	return runs, err
+}
+
+func (m *models) createResult(ctx context.Context, result *model.EvalResult) error {
+	return m.db.WithContext(ctx).Create(result).Error
+}
+
+func (m *models) listResults(ctx context.Context, runIDs []uuid.UUID) ([]*model.EvalResult, error) {
+	var results []*model.EvalResult
+	err := m.db.WithContext(ctx).Where("run_id IN ?", runIDs).Order("created_at asc").Find(&results).Error
+	return results, err
+}
@@ -0,0 +1,100 @@
+package evaluations
+
+import (
+	"context"
+	"errors"
+	"model"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/logs"
+)
+
+const (
+	// heartbeatInterval 为本实例正在执行的评测更新心跳的间隔
+	heartbeatInterval = 30 * time.Second
+	// staleAfter 超过这个时间没有心跳的评测，执行它的实例已经退出
+	staleAfter = 3 * heartbeatInterval
+)
+
+// errRunInterrupted 执行评测的实例退出，评测没有完成
+var errRunInterrupted = errors.New("评测中断：执行的实例已退出")
+
+// Monitor 为本实例正在执行的评测更新心跳，并把长时间没有心跳的评测标记为失败，
+// 服务重启或实例退出后评测不会一直停留在执行中
+type Monitor struct {
+	service *service
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// StartMonitor 启动心跳和中断评测的清理，服务退出时调用 Stop
+func StartMonitor() *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Monitor{
+		service: newService(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	m.wg.Add(1)
+	go m.loop()
+	return m
+}
+
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		m.check(time.Now())
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) check(now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Errorf("Panic in evaluation monitor: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
+	defer cancel()
+	if ids := runningIds(); len(ids) > 0 {
+		if err := m.service.repo.touchRuns(ctx, ids, now); err != nil {
+			logs.Errorf("touch evaluation runs error: %v", err)
+		}
+	}
+	rows, err := m.service.repo.failStaleRuns(ctx, now.Add(-staleAfter), errRunInterrupted.Error())
+	if err != nil {
+		logs.Errorf("fail stale evaluation runs error: %v", err)
+		return
+	}
+	if rows > 0 {
+		logs.Warnf("%d interrupted evaluation runs marked as failed", rows)
+	}
+}
+
+// runningIds 本实例上正在执行的评测
+func runningIds() []uuid.UUID {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	ids := make([]uuid.UUID, 0, len(running))
+	for id := range running {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// unfinishedStatuses 还没有结束的评测状态
+var unfinishedStatuses = []model.EvalRunStatus{model.EvalRunPending, model.EvalRunRunning}
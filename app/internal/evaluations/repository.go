@@ -0,0 +1,34 @@
+package evaluations
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createDataset(ctx context.Context, dataset *model.EvalDataset) error
+	listDatasets(ctx context.Context, userID uuid.UUID, filter DatasetFilter) ([]*model.EvalDataset, int64, error)
+	getDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalDataset, error)
+	updateDataset(ctx context.Context, dataset *model.EvalDataset) error
+	deleteDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	countCases(ctx context.Context, datasetID uuid.UUID) (int64, error)
+	createCases(ctx context.Context, cases []*model.EvalCase) error
+	listCases(ctx context.Context, datasetID uuid.UUID) ([]*model.EvalCase, error)
+	getCase(ctx context.Context, datasetID uuid.UUID, id uuid.UUID) (*model.EvalCase, error)
+	updateCase(ctx context.Context, evalCase *model.EvalCase) error
+	deleteCase(ctx context.Context, datasetID uuid.UUID, id uuid.UUID) (int64, error)
+	agentExists(ctx context.Context, userID uuid.UUID, agentID uuid.UUID) (bool, error)
+	agentVersionExists(ctx context.Context, agentID uuid.UUID, version uint) (bool, error)
+	createRun(ctx context.Context, run *model.EvalRun) error
+	updateRun(ctx context.Context, run *model.EvalRun) error
+	touchRuns(ctx context.Context, ids []uuid.UUID, now time.Time) error
+	failStaleRuns(ctx context.Context, before time.Time, reason string) (int64, error)
+	listRuns(ctx context.Context, userID uuid.UUID, filter RunFilter) ([]*model.EvalRun, int64, error)
+	getRun(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalRun, error)
+	getRuns(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.EvalRun, error)
+	createResult(ctx context.Context, result *model.EvalResult) error
+	listResults(ctx context.Context, runIDs []uuid.UUID) ([]*model.EvalResult, error)
+}
@@ -0,0 +1,57 @@
+package evaluations
+
+import (
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type CreateDatasetReq struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Cases       []*CaseReq `json:"cases"`
+}
+
+type UpdateDatasetReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SearchDatasetReq struct {
+	Name     string `json:"name" form:"name"`
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+type CaseReq struct {
+	Question       string     `json:"question"`
+	ExpectedAnswer string     `json:"expectedAnswer"`
+	ExpectedTools  []string   `json:"expectedTools"`
+	Variables      model.JSON `json:"variables"`
+}
+
+// AddCasesReq 一次可以添加多个用例
+type AddCasesReq struct {
+	Cases []*CaseReq `json:"cases"`
+}
+
+// CreateRunReq AgentVersion 为 0 时评测当前草稿，Concurrency 为 0 时使用配置的最大并发
+type CreateRunReq struct {
+	DatasetID    uuid.UUID           `json:"datasetId"`
+	AgentID      uuid.UUID           `json:"agentId"`
+	AgentVersion uint                `json:"agentVersion"`
+	Scorers      []*model.EvalScorer `json:"scorers"`
+	Concurrency  int                 `json:"concurrency"`
+}
+
+type SearchRunReq struct {
+	DatasetID string `json:"datasetId" form:"datasetId"`
+	AgentID   string `json:"agentId" form:"agentId"`
+	Page      int    `json:"page" form:"page"`
+	PageSize  int    `json:"pageSize" form:"pageSize"`
+}
+
+// CompareRunsReq RunIds 用逗号分隔
+type CompareRunsReq struct {
+	RunIds string `json:"runIds" form:"runIds"`
+}
@@ -0,0 +1,37 @@
+package evaluations
+
+import "model"
+
+type ListDatasetResponse struct {
+	Datasets []*model.EvalDataset `json:"datasets"`
+	Total    int64                `json:"total"`
+}
+
+type DatasetResponse struct {
+	*model.EvalDataset
+	Cases []*model.EvalCase `json:"cases"`
+}
+
+type ListRunResponse struct {
+	Runs  []*model.EvalRun `json:"runs"`
+	Total int64            `json:"total"`
+}
+
+type RunResultsResponse struct {
+	Run     *model.EvalRun      `json:"run"`
+	Results []*model.EvalResult `json:"results"`
+}
+
+// CompareRow 同一个用例在各次评测中的结果，Results 和 CompareResponse.Runs 的顺序一致，
+// 用例在某次评测中没有结果时对应位置为 null
+type CompareRow struct {
+	CaseID         string              `json:"caseId"`
+	Question       string              `json:"question"`
+	ExpectedAnswer string              `json:"expectedAnswer"`
+	Results        []*model.EvalResult `json:"results"`
+}
+
+type CompareResponse struct {
+	Runs []*model.EvalRun `json:"runs"`
+	Rows []*CompareRow    `json:"rows"`
+}
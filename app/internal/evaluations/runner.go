@@ -0,0 +1,207 @@
+package evaluations
+
+import (
+	"app/shared"
+	"common/configs"
+	"context"
+	"errors"
+	"model"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 本实例上正在执行的评测，用于取消和服务退出时停止
+var (
+	runningMu sync.Mutex
+	running   = make(map[uuid.UUID]context.CancelFunc)
+	runningWg sync.WaitGroup
+)
+
+// StopRuns 服务退出时取消所有正在执行的评测，等待评测保存状态后返回
+func StopRuns() {
+	runningMu.Lock()
+	for _, cancel := range running {
+		cancel()
+	}
+	runningMu.Unlock()
+	runningWg.Wait()
+}
+
+// cancelRunning 取消本实例上正在执行的评测，评测不在本实例上时返回 false
+func cancelRunning(runID uuid.UUID) bool {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	cancel, ok := running[runID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// runAggregate 汇总已完成的用例，每完成一个用例就更新评测记录，前端可以显示进度
+type runAggregate struct {
+	mu         sync.Mutex
+	run        *model.EvalRun
+	totalScore float64
+	totalMs    int64
+}
+
+func (a *runAggregate) add(result *model.EvalResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.run.Completed++
+	if result.Passed {
+		a.run.Passed++
+	}
+	a.totalScore += result.Score
+	a.totalMs += result.LatencyMs
+	a.run.AvgScore = a.totalScore / float64(a.run.Completed)
+	a.run.AvgLatencyMs = a.totalMs / int64(a.run.Completed)
+}
+
+// startRun 在后台执行评测，同时运行的用例数不超过评测的并发数
+func (s *service) startRun(run *model.EvalRun, cases []*model.EvalCase) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runningMu.Lock()
+	running[run.ID] = cancel
+	runningMu.Unlock()
+	runningWg.Add(1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Errorf("Panic in evaluation run %s: %v", run.ID, r)
+				s.finishRun(run, errors.New("internal server error"))
+			}
+			runningMu.Lock()
+			delete(running, run.ID)
+			runningMu.Unlock()
+			cancel()
+			runningWg.Done()
+		}()
+		s.execute(ctx, run, cases)
+	}()
+}
+
+func (s *service) execute(ctx context.Context, run *model.EvalRun, cases []*model.EvalCase) {
+	now := time.Now()
+	run.Status = model.EvalRunRunning
+	run.StartedAt = &now
+	s.saveRun(run)
+
+	aggregate := &runAggregate{run: run}
+	slots := make(chan struct{}, run.Concurrency)
+	var wg sync.WaitGroup
+	for _, evalCase := range cases {
+		select {
+		case <-ctx.Done():
+		case slots <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logs.Errorf("Panic in evaluation case %s: %v", evalCase.ID, r)
+				}
+				<-slots
+				wg.Done()
+			}()
+			result := s.evaluateCase(ctx, run, evalCase)
+			// 取消时正在运行的用例没有意义，不保存结果
+			if ctx.Err() != nil {
+				return
+			}
+			dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.repo.createResult(dbCtx, result); err != nil {
+				logs.Errorf("create evaluation result error: %v", err)
+				return
+			}
+			aggregate.add(result)
+			aggregate.mu.Lock()
+			s.saveRun(run)
+			aggregate.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		s.finishRun(run, ctx.Err())
+		return
+	}
+	s.finishRun(run, nil)
+}
+
+// evaluateCase 运行agent并打分，agent运行失败时结果记为不通过
+func (s *service) evaluateCase(ctx context.Context, run *model.EvalRun, evalCase *model.EvalCase) *model.EvalResult {
+	ctx, cancel := context.WithTimeout(ctx, configs.GetConfig().Evaluation.GetCaseTimeout())
+	defer cancel()
+	result := &model.EvalResult{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		RunID:          run.ID,
+		CaseID:         evalCase.ID,
+		Question:       evalCase.Question,
+		ExpectedAnswer: evalCase.ExpectedAnswer,
+		ToolCalls:      model.StringList{},
+		Scores:         model.EvalScores{},
+	}
+	start := time.Now()
+	output, err := event.Trigger("evaluateAgent", &shared.EvaluateAgentRequest{
+		Ctx:       ctx,
+		UserID:    run.CreatorID,
+		AgentID:   run.AgentID,
+		Version:   run.AgentVersion,
+		Message:   evalCase.Question,
+		Variables: evalCase.Variables,
+	})
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	evaluation := output.(*shared.AgentEvaluation)
+	result.Output = evaluation.Answer
+	result.ToolCalls = evaluation.ToolCalls
+	result.Scores = s.score(ctx, run.Scorers, evalCase, evaluation)
+	result.Passed = len(result.Scores) > 0
+	for _, score := range result.Scores {
+		result.Score += score.Score
+		result.Passed = result.Passed && score.Passed
+	}
+	if len(result.Scores) > 0 {
+		result.Score /= float64(len(result.Scores))
+	}
+	return result
+}
+
+// finishRun 保存评测的最终状态，被取消的评测保留已经完成的结果
+func (s *service) finishRun(run *model.EvalRun, runErr error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	switch {
+	case runErr == nil:
+		run.Status = model.EvalRunCompleted
+	case errors.Is(runErr, context.Canceled):
+		run.Status = model.EvalRunCanceled
+	default:
+		run.Status = model.EvalRunFailed
+		run.Error = runErr.Error()
+	}
+	s.saveRun(run)
+}
+
+func (s *service) saveRun(run *model.EvalRun) {
+	run.UpdatedAt = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.updateRun(ctx, run); err != nil {
+		logs.Errorf("update evaluation run error: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package evaluations
+
+import (
+	"app/shared"
+	"context"
+	"core/ai/fakemodel"
+	"model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+)
+
+// runRepo 只实现执行评测和心跳用到的方法
+type runRepo struct {
+	repository
+	mu      sync.Mutex
+	results []*model.EvalResult
+	saved   []model.EvalRun
+	touched []uuid.UUID
+	before  time.Time
+}
+
+func (f *runRepo) createResult(ctx context.Context, result *model.EvalResult) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, result)
+	return nil
+}
+
+func (f *runRepo) updateRun(ctx context.Context, run *model.EvalRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saved = append(f.saved, *run)
+	return nil
+}
+
+func (f *runRepo) touchRuns(ctx context.Context, ids []uuid.UUID, now time.Time) error {
+	f.touched = ids
+	return nil
+}
+
+func (f *runRepo) failStaleRuns(ctx context.Context, before time.Time, reason string) (int64, error) {
+	f.before = before
+	return 1, nil
+}
+
+// registerFakeAgent 用 fake 模型代替agent运行，同样的输入总是得到同样的回答和工具调用
+func registerFakeAgent(rules []fakemodel.Rule) {
+	event.Register("evaluateAgent", func(e event.Event) (any, error) {
+		req := e.Data.(*shared.EvaluateAgentRequest)
+		chatModel, err := fakemodel.New(rules).WithTools([]*schema.ToolInfo{{Name: "search"}})
+		if err != nil {
+			return nil, err
+		}
+		messages := []*schema.Message{schema.UserMessage(req.Message)}
+		evaluation := &shared.AgentEvaluation{ToolCalls: []string{}}
+		for {
+			msg, err := chatModel.Generate(req.Ctx, messages)
+			if err != nil {
+				return nil, err
+			}
+			if len(msg.ToolCalls) == 0 {
+				evaluation.Answer = msg.Content
+				return evaluation, nil
+			}
+			messages = append(messages, msg)
+			for _, call := range msg.ToolCalls {
+				evaluation.ToolCalls = append(evaluation.ToolCalls, call.Function.Name)
+				messages = append(messages, schema.ToolMessage("Paris", call.ID))
+			}
+		}
+	})
+}
+
+func TestExecuteScoresCasesDeterministically(t *testing.T) {
+	registerFakeAgent([]fakemodel.Rule{
+		{Match: "capital", ToolCalls: []fakemodel.ToolCall{{Name: "search", Arguments: `{"q":"capital of France"}`}}},
+		{Match: "2+2", Content: "4"},
+	})
+	cases := []*model.EvalCase{
+		{Question: "capital of France?", ExpectedAnswer: "Paris", ExpectedTools: model.StringList{"search"}},
+		{Question: "2+2=?", ExpectedAnswer: "4", ExpectedTools: model.StringList{}},
+		{Question: "unknown", ExpectedAnswer: "something", ExpectedTools: model.StringList{"search"}},
+	}
+	for _, c := range cases {
+		c.ID = uuid.New()
+	}
+	for i := 0; i < 3; i++ {
+		repo := &runRepo{}
+		s := &service{repo: repo}
+		run := &model.EvalRun{
+			Concurrency: 2,
+			Total:       len(cases),
+			Scorers:     model.EvalScorers{{Type: model.ScorerExactMatch}, {Type: model.ScorerToolCalls}},
+		}
+		run.ID = uuid.New()
+		s.execute(context.Background(), run, cases)
+		if run.Status != model.EvalRunCompleted || run.Completed != 3 || run.Passed != 2 {
+			t.Fatalf("run = %+v", run)
+		}
+		// 第三个用例两个评分器都不通过，平均分 (1 + 1 + 0) / 3
+		if run.AvgScore < 0.66 || run.AvgScore > 0.67 {
+			t.Errorf("avg score = %v", run.AvgScore)
+		}
+		byCase := make(map[uuid.UUID]*model.EvalResult)
+		for _, result := range repo.results {
+			byCase[result.CaseID] = result
+		}
+		first := byCase[cases[0].ID]
+		if first.Output != "Paris" || len(first.ToolCalls) != 1 || !first.Passed {
+			t.Errorf("first result = %+v", first)
+		}
+		if third := byCase[cases[2].ID]; third.Output != "unknown" || third.Passed || third.Score != 0 {
+			t.Errorf("third result = %+v", third)
+		}
+		if last := repo.saved[len(repo.saved)-1]; last.Status != model.EvalRunCompleted || last.FinishedAt == nil {
+			t.Errorf("last saved run = %+v", last)
+		}
+	}
+}
+
+func TestExecuteCanceled(t *testing.T) {
+	registerFakeAgent(nil)
+	repo := &runRepo{}
+	s := &service{repo: repo}
+	run := &model.EvalRun{Concurrency: 1, Scorers: model.EvalScorers{{Type: model.ScorerExactMatch}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.execute(ctx, run, []*model.EvalCase{{Question: "a"}, {Question: "b"}})
+	if run.Status != model.EvalRunCanceled || len(repo.results) != 0 {
+		t.Fatalf("run = %+v, results = %d", run, len(repo.results))
+	}
+}
+
+// 本实例上的评测更新心跳，超过时间没有心跳的评测标记为失败
+func TestMonitorCheck(t *testing.T) {
+	repo := &runRepo{}
+	m := &Monitor{service: &service{repo: repo}, ctx: context.Background()}
+	id := uuid.New()
+	runningMu.Lock()
+	running[id] = func() {}
+	runningMu.Unlock()
+	defer func() {
+		runningMu.Lock()
+		delete(running, id)
+		runningMu.Unlock()
+	}()
+	now := time.Now()
+	m.check(now)
+	if len(repo.touched) != 1 || repo.touched[0] != id {
+		t.Errorf("touched = %v", repo.touched)
+	}
+	if !repo.before.Equal(now.Add(-staleAfter)) {
+		t.Errorf("before = %v, want %v", repo.before, now.Add(-staleAfter))
+	}
+}
@@ -0,0 +1,199 @@
+package evaluations
+
+import (
+	"app/shared"
+	"common/biz"
+	"context"
+	"core/ai/jsonschema"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"model"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/event"
+)
+
+// 评分模型没有配置阈值时，分数达到这个值算通过
+const defaultJudgeThreshold = 0.5
+
+const judgeSystemPrompt = `你是一个严格的评测员，需要判断AI助手的回答是否正确地回答了问题。
+按照评分标准和参考答案给出 0 到 1 之间的分数，1 表示完全正确，0 表示完全错误。
+只输出一个 JSON 对象，不要输出其他内容，格式为：{"score": 0.8, "reason": "简短的理由"}`
+
+// validateScorers 创建评测时检查评分器配置，没有配置时使用完全匹配
+func validateScorers(scorers []*model.EvalScorer) ([]*model.EvalScorer, error) {
+	if len(scorers) == 0 {
+		return []*model.EvalScorer{{Type: model.ScorerExactMatch}}, nil
+	}
+	for _, scorer := range scorers {
+		if scorer == nil {
+			return nil, errors.New("评分器不能为空")
+		}
+		switch scorer.Type {
+		case model.ScorerExactMatch, model.ScorerToolCalls:
+		case model.ScorerRegex:
+			if scorer.Pattern != "" {
+				if _, err := regexp.Compile(scorer.Pattern); err != nil {
+					return nil, fmt.Errorf("正则表达式错误: %v", err)
+				}
+			}
+		case model.ScorerJSONSchema:
+			if len(scorer.Schema) == 0 {
+				return nil, errors.New("json_schema 评分器需要配置 schema")
+			}
+			if err := jsonschema.Check(scorer.Schema); err != nil {
+				return nil, err
+			}
+		case model.ScorerLLMJudge:
+			if scorer.ModelProvider == "" || scorer.ModelName == "" {
+				return nil, errors.New("llm_judge 评分器需要配置评分模型")
+			}
+			if scorer.Threshold < 0 || scorer.Threshold > 1 {
+				return nil, errors.New("llm_judge 的阈值必须在 0 到 1 之间")
+			}
+		default:
+			return nil, fmt.Errorf("不支持的评分方式: %s", scorer.Type)
+		}
+	}
+	return scorers, nil
+}
+
+// score 用所有评分器给一个用例的输出打分
+func (s *service) score(ctx context.Context, scorers model.EvalScorers, evalCase *model.EvalCase, output *shared.AgentEvaluation) model.EvalScores {
+	scores := make(model.EvalScores, 0, len(scorers))
+	for _, scorer := range scorers {
+		var score *model.EvalScore
+		switch scorer.Type {
+		case model.ScorerExactMatch:
+			score = scoreExactMatch(scorer, evalCase.ExpectedAnswer, output.Answer)
+		case model.ScorerRegex:
+			score = scoreRegex(scorer, evalCase.ExpectedAnswer, output.Answer)
+		case model.ScorerJSONSchema:
+			score = scoreJSONSchema(scorer, output.Answer)
+		case model.ScorerToolCalls:
+			score = scoreToolCalls(evalCase.ExpectedTools, output.ToolCalls)
+		case model.ScorerLLMJudge:
+			score = s.scoreLLMJudge(ctx, scorer, evalCase, output.Answer)
+		default:
+			score = &model.EvalScore{Reason: "不支持的评分方式"}
+		}
+		score.Type = scorer.Type
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+func passed(ok bool, reason string) *model.EvalScore {
+	if ok {
+		return &model.EvalScore{Score: 1, Passed: true}
+	}
+	return &model.EvalScore{Reason: reason}
+}
+
+func scoreExactMatch(scorer *model.EvalScorer, expected string, answer string) *model.EvalScore {
+	expected, answer = strings.TrimSpace(expected), strings.TrimSpace(answer)
+	if scorer.IgnoreCase {
+		return passed(strings.EqualFold(expected, answer), "回答和期望回答不一致")
+	}
+	return passed(expected == answer, "回答和期望回答不一致")
+}
+
+// scoreRegex 没有配置正则时把用例的期望回答作为正则
+func scoreRegex(scorer *model.EvalScorer, expected string, answer string) *model.EvalScore {
+	pattern := scorer.Pattern
+	if pattern == "" {
+		pattern = expected
+	}
+	if scorer.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return &model.EvalScore{Reason: "正则表达式错误: " + err.Error()}
+	}
+	return passed(re.MatchString(answer), "回答不匹配 "+pattern)
+}
+
+func scoreJSONSchema(scorer *model.EvalScorer, answer string) *model.EvalScore {
+	_, issues := jsonschema.ValidateJSON(scorer.Schema, jsonschema.Extract(answer))
+	return passed(len(issues) == 0, strings.Join(issues, "; "))
+}
+
+// scoreToolCalls 分数是调用到的期望工具的比例，和调用顺序、次数无关
+func scoreToolCalls(expected []string, calls []string) *model.EvalScore {
+	if len(expected) == 0 {
+		return &model.EvalScore{Score: 1, Passed: true}
+	}
+	called := make(map[string]bool, len(calls))
+	for _, name := range calls {
+		called[name] = true
+	}
+	var missing []string
+	for _, name := range expected {
+		if !called[name] {
+			missing = append(missing, name)
+		}
+	}
+	score := &model.EvalScore{
+		Score:  float64(len(expected)-len(missing)) / float64(len(expected)),
+		Passed: len(missing) == 0,
+	}
+	if len(missing) > 0 {
+		score.Reason = "没有调用工具: " + strings.Join(missing, ", ")
+	}
+	return score
+}
+
+type judgement struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+func (s *service) scoreLLMJudge(ctx context.Context, scorer *model.EvalScorer, evalCase *model.EvalCase, answer string) *model.EvalScore {
+	trigger, err := event.Trigger("getProviderConfigByProvider", &shared.GetProviderConfigRequest{
+		LLMType:   model.LLMTypeChat,
+		Provider:  scorer.ModelProvider,
+		ModelName: scorer.ModelName,
+	})
+	if err != nil {
+		return &model.EvalScore{Reason: "获取评分模型配置失败: " + err.Error()}
+	}
+	providerConfig := trigger.(*model.ProviderConfig)
+	if providerConfig.Provider == "" {
+		return &model.EvalScore{Reason: biz.ProviderConfigNotFound.Error()}
+	}
+	chatModel, err := shared.BuildChatModel(ctx, providerConfig, scorer.ModelName, scorer.ModelParameters)
+	if err != nil {
+		return &model.EvalScore{Reason: "创建评分模型失败: " + err.Error()}
+	}
+	rubric := scorer.Rubric
+	if rubric == "" {
+		rubric = "回答和参考答案的意思一致即为正确，不要求措辞相同"
+	}
+	prompt := fmt.Sprintf("## 评分标准\n%s\n\n## 问题\n%s\n\n## 参考答案\n%s\n\n## AI助手的回答\n%s",
+		rubric, evalCase.Question, evalCase.ExpectedAnswer, answer)
+	msg, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(judgeSystemPrompt),
+		schema.UserMessage(prompt),
+	})
+	if err != nil {
+		return &model.EvalScore{Reason: "评分模型调用失败: " + err.Error()}
+	}
+	var result judgement
+	if err := json.Unmarshal([]byte(jsonschema.Extract(msg.Content)), &result); err != nil {
+		return &model.EvalScore{Reason: "评分模型的输出不是有效的JSON: " + msg.Content}
+	}
+	result.Score = min(max(result.Score, 0), 1)
+	threshold := scorer.Threshold
+	if threshold == 0 {
+		threshold = defaultJudgeThreshold
+	}
+	return &model.EvalScore{
+		Score:  result.Score,
+		Passed: result.Score >= threshold,
+		Reason: result.Reason,
+	}
+}
@@ -0,0 +1,58 @@
+package evaluations
+
+import (
+	"model"
+	"testing"
+)
+
+func TestScorers(t *testing.T) {
+	schema := model.JSON{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		"required":   []any{"city"},
+	}
+	tests := []struct {
+		name     string
+		score    *model.EvalScore
+		passed   bool
+		expected float64
+	}{
+		{"exact", scoreExactMatch(&model.EvalScorer{}, " Paris ", "Paris"), true, 1},
+		{"exact case", scoreExactMatch(&model.EvalScorer{}, "paris", "Paris"), false, 0},
+		{"exact ignore case", scoreExactMatch(&model.EvalScorer{IgnoreCase: true}, "paris", "Paris"), true, 1},
+		{"regex from expected", scoreRegex(&model.EvalScorer{}, `\d+ km`, "about 340 km away"), true, 1},
+		{"regex pattern", scoreRegex(&model.EvalScorer{Pattern: "^yes", IgnoreCase: true}, "", "Yes, it is"), true, 1},
+		{"regex invalid", scoreRegex(&model.EvalScorer{Pattern: "("}, "", "x"), false, 0},
+		{"json schema", scoreJSONSchema(&model.EvalScorer{Schema: schema}, "```json\n{\"city\": \"Paris\"}\n```"), true, 1},
+		{"json schema missing", scoreJSONSchema(&model.EvalScorer{Schema: schema}, `{"town": "Paris"}`), false, 0},
+		{"tools all", scoreToolCalls([]string{"search", "fetch"}, []string{"fetch", "search", "search"}), true, 1},
+		{"tools partial", scoreToolCalls([]string{"search", "fetch"}, []string{"search"}), false, 0.5},
+		{"tools none expected", scoreToolCalls(nil, []string{"search"}), true, 1},
+	}
+	for _, tt := range tests {
+		if tt.score.Passed != tt.passed || tt.score.Score != tt.expected {
+			t.Errorf("%s: score = %+v, want passed %v score %v", tt.name, tt.score, tt.passed, tt.expected)
+		}
+	}
+}
+
+func TestValidateScorers(t *testing.T) {
+	scorers, err := validateScorers(nil)
+	if err != nil || len(scorers) != 1 || scorers[0].Type != model.ScorerExactMatch {
+		t.Fatalf("validateScorers(nil) = %v, %v", scorers, err)
+	}
+	invalid := [][]*model.EvalScorer{
+		{nil},
+		{{Type: "unknown"}},
+		{{Type: model.ScorerRegex, Pattern: "("}},
+		{{Type: model.ScorerJSONSchema}},
+		{{Type: model.ScorerJSONSchema, Schema: model.JSON{"type": "text"}}},
+		{{Type: model.ScorerLLMJudge, ModelProvider: "openai"}},
+		{{Type: model.ScorerLLMJudge, ModelProvider: "openai", ModelName: "gpt", Threshold: 2}},
+	}
+	for _, scorers := range invalid {
+		if _, err := validateScorers(scorers); err == nil {
+			t.Errorf("validateScorers(%+v) should fail", scorers[0])
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package evaluations
+
+import (
+	"common/biz"
+	"common/configs"
+	"context"
+	"model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 一次最多对比的评测数量
+const maxCompareRuns = 5
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+func (s *service) createDataset(ctx context.Context, userID uuid.UUID, req CreateDatasetReq) (*DatasetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, biz.ErrEvalInvalid
+	}
+	if len(req.Cases) > configs.GetConfig().Evaluation.GetMaxCases() {
+		return nil, biz.ErrEvalCaseLimit
+	}
+	dataset := &model.EvalDataset{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:   userID,
+		Name:        name,
+		Description: req.Description,
+	}
+	cases, err := buildCases(dataset.ID, req.Cases)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.createDataset(ctx, dataset); err != nil {
+		logs.Errorf("create evaluation dataset error: %v", err)
+		return nil, errs.DBError
+	}
+	if len(cases) > 0 {
+		if err := s.repo.createCases(ctx, cases); err != nil {
+			logs.Errorf("create evaluation cases error: %v", err)
+			return nil, errs.DBError
+		}
+	}
+	return &DatasetResponse{EvalDataset: dataset, Cases: cases}, nil
+}
+
+func (s *service) listDatasets(ctx context.Context, userID uuid.UUID, req SearchDatasetReq) (*ListDatasetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	datasets, total, err := s.repo.listDatasets(ctx, userID, DatasetFilter{
+		Name:   req.Name,
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	})
+	if err != nil {
+		logs.Errorf("list evaluation datasets error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListDatasetResponse{
+		Datasets: datasets,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) getDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*DatasetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	dataset, err := s.findDataset(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	cases, err := s.repo.listCases(ctx, id)
+	if err != nil {
+		logs.Errorf("list evaluation cases error: %v", err)
+		return nil, errs.DBError
+	}
+	return &DatasetResponse{EvalDataset: dataset, Cases: cases}, nil
+}
+
+func (s *service) updateDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateDatasetReq) (*model.EvalDataset, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	dataset, err := s.findDataset(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		dataset.Name = name
+	}
+	dataset.Description = req.Description
+	dataset.UpdatedAt = time.Now()
+	if err := s.repo.updateDataset(ctx, dataset); err != nil {
+		logs.Errorf("update evaluation dataset error: %v", err)
+		return nil, errs.DBError
+	}
+	return dataset, nil
+}
+
+func (s *service) deleteDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	affected, err := s.repo.deleteDataset(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete evaluation dataset error: %v", err)
+		return errs.DBError
+	}
+	if affected == 0 {
+		return biz.ErrEvalDatasetNotFound
+	}
+	return nil
+}
+
+func (s *service) addCases(ctx context.Context, userID uuid.UUID, datasetID uuid.UUID, req AddCasesReq) ([]*model.EvalCase, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if len(req.Cases) == 0 {
+		return nil, biz.ErrEvalInvalid
+	}
+	if _, err := s.findDataset(ctx, userID, datasetID); err != nil {
+		return nil, err
+	}
+	count, err := s.repo.countCases(ctx, datasetID)
+	if err != nil {
+		logs.Errorf("count evaluation cases error: %v", err)
+		return nil, errs.DBError
+	}
+	if int(count)+len(req.Cases) > configs.GetConfig().Evaluation.GetMaxCases() {
+		return nil, biz.ErrEvalCaseLimit
+	}
+	cases, err := buildCases(datasetID, req.Cases)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.createCases(ctx, cases); err != nil {
+		logs.Errorf("create evaluation cases error: %v", err)
+		return nil, errs.DBError
+	}
+	return cases, nil
+}
+
+func (s *service) updateCase(ctx context.Context, userID uuid.UUID, datasetID uuid.UUID, id uuid.UUID, req CaseReq) (*model.EvalCase, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.findDataset(ctx, userID, datasetID); err != nil {
+		return nil, err
+	}
+	evalCase, err := s.repo.getCase(ctx, datasetID, id)
+	if err != nil {
+		logs.Errorf("get evaluation case error: %v", err)
+		return nil, errs.DBError
+	}
+	if evalCase == nil {
+		return nil, biz.ErrEvalCaseNotFound
+	}
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, biz.ErrEvalInvalid
+	}
+	evalCase.Question = question
+	evalCase.ExpectedAnswer = req.ExpectedAnswer
+	evalCase.ExpectedTools = req.ExpectedTools
+	evalCase.Variables = req.Variables
+	evalCase.UpdatedAt = time.Now()
+	if err := s.repo.updateCase(ctx, evalCase); err != nil {
+		logs.Errorf("update evaluation case error: %v", err)
+		return nil, errs.DBError
+	}
+	return evalCase, nil
+}
+
+func (s *service) deleteCase(ctx context.Context, userID uuid.UUID, datasetID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.findDataset(ctx, userID, datasetID); err != nil {
+		return err
+	}
+	affected, err := s.repo.deleteCase(ctx, datasetID, id)
+	if err != nil {
+		logs.Errorf("delete evaluation case error: %v", err)
+		return errs.DBError
+	}
+	if affected == 0 {
+		return biz.ErrEvalCaseNotFound
+	}
+	return nil
+}
+
+// createRun 检查配置并创建评测，评测在后台执行，通过 getRun 查看进度
+func (s *service) createRun(ctx context.Context, userID uuid.UUID, req CreateRunReq) (*model.EvalRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	scorers, err := validateScorers(req.Scorers)
+	if err != nil {
+		return nil, errs.NewError(biz.ErrEvalInvalid.Code, biz.ErrEvalInvalid.Msg+"："+err.Error())
+	}
+	maxConcurrency := configs.GetConfig().Evaluation.GetMaxConcurrency()
+	if req.Concurrency < 0 || req.Concurrency > maxConcurrency {
+		return nil, biz.ErrEvalInvalid
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = maxConcurrency
+	}
+	if _, err := s.findDataset(ctx, userID, req.DatasetID); err != nil {
+		return nil, err
+	}
+	cases, err := s.repo.listCases(ctx, req.DatasetID)
+	if err != nil {
+		logs.Errorf("list evaluation cases error: %v", err)
+		return nil, errs.DBError
+	}
+	if len(cases) == 0 {
+		return nil, biz.ErrEvalDatasetEmpty
+	}
+	exists, err := s.repo.agentExists(ctx, userID, req.AgentID)
+	if err != nil {
+		logs.Errorf("check agent error: %v", err)
+		return nil, errs.DBError
+	}
+	if !exists {
+		return nil, biz.ErrAgentNotFound
+	}
+	if req.AgentVersion > 0 {
+		exists, err = s.repo.agentVersionExists(ctx, req.AgentID, req.AgentVersion)
+		if err != nil {
+			logs.Errorf("check agent version error: %v", err)
+			return nil, errs.DBError
+		}
+		if !exists {
+			return nil, biz.ErrAgentVersionNotFound
+		}
+	}
+	run := &model.EvalRun{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:    userID,
+		DatasetID:    req.DatasetID,
+		AgentID:      req.AgentID,
+		AgentVersion: req.AgentVersion,
+		Scorers:      scorers,
+		Concurrency:  req.Concurrency,
+		Status:       model.EvalRunPending,
+		Total:        len(cases),
+	}
+	if err := s.repo.createRun(ctx, run); err != nil {
+		logs.Errorf("create evaluation run error: %v", err)
+		return nil, errs.DBError
+	}
+	// 后台执行会修改 run，返回副本
+	response := *run
+	s.startRun(run, cases)
+	return &response, nil
+}
+
+func (s *service) listRuns(ctx context.Context, userID uuid.UUID, req SearchRunReq) (*ListRunResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	filter := RunFilter{
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	}
+	if req.DatasetID != "" {
+		id, err := uuid.Parse(req.DatasetID)
+		if err != nil {
+			return nil, errs.ErrParam
+		}
+		filter.DatasetID = &id
+	}
+	if req.AgentID != "" {
+		id, err := uuid.Parse(req.AgentID)
+		if err != nil {
+			return nil, errs.ErrParam
+		}
+		filter.AgentID = &id
+	}
+	runs, total, err := s.repo.listRuns(ctx, userID, filter)
+	if err != nil {
+		logs.Errorf("list evaluation runs error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListRunResponse{
+		Runs:  runs,
+		Total: total,
+	}, nil
+}
+
+func (s *service) getRun(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*RunResultsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	run, err := s.findRun(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.repo.listResults(ctx, []uuid.UUID{id})
+	if err != nil {
+		logs.Errorf("list evaluation results error: %v", err)
+		return nil, errs.DBError
+	}
+	return &RunResultsResponse{Run: run, Results: results}, nil
+}
+
+// cancelRun 取消评测，已经完成的用例结果保留。评测不在本实例上执行时
+// （执行的实例已经退出）直接修改状态
+func (s *service) cancelRun(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	run, err := s.findRun(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if run.Status != model.EvalRunPending && run.Status != model.EvalRunRunning {
+		return biz.ErrEvalRunFinished
+	}
+	if cancelRunning(id) {
+		return nil
+	}
+	s.finishRun(run, context.Canceled)
+	return nil
+}
+
+// compareRuns 按用例对比同一个数据集上的多次评测
+func (s *service) compareRuns(ctx context.Context, userID uuid.UUID, req CompareRunsReq) (*CompareResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var ids []uuid.UUID
+	for _, value := range strings.Split(req.RunIds, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errs.ErrParam
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) < 2 || len(ids) > maxCompareRuns {
+		return nil, errs.ErrParam
+	}
+	runs, err := s.repo.getRuns(ctx, userID, ids)
+	if err != nil {
+		logs.Errorf("get evaluation runs error: %v", err)
+		return nil, errs.DBError
+	}
+	// 按请求的顺序返回
+	runIndex := make(map[uuid.UUID]int, len(ids))
+	ordered := make([]*model.EvalRun, len(ids))
+	for i, id := range ids {
+		runIndex[id] = i
+	}
+	for _, run := range runs {
+		ordered[runIndex[run.ID]] = run
+	}
+	for _, run := range ordered {
+		if run == nil {
+			return nil, biz.ErrEvalRunNotFound
+		}
+		if run.DatasetID != ordered[0].DatasetID {
+			return nil, biz.ErrEvalCompare
+		}
+	}
+	results, err := s.repo.listResults(ctx, ids)
+	if err != nil {
+		logs.Errorf("list evaluation results error: %v", err)
+		return nil, errs.DBError
+	}
+	rows := make([]*CompareRow, 0)
+	rowIndex := make(map[uuid.UUID]*CompareRow)
+	for _, result := range results {
+		row, ok := rowIndex[result.CaseID]
+		if !ok {
+			row = &CompareRow{
+				CaseID:         result.CaseID.String(),
+				Question:       result.Question,
+				ExpectedAnswer: result.ExpectedAnswer,
+				Results:        make([]*model.EvalResult, len(ids)),
+			}
+			rowIndex[result.CaseID] = row
+			rows = append(rows, row)
+		}
+		row.Results[runIndex[result.RunID]] = result
+	}
+	return &CompareResponse{Runs: ordered, Rows: rows}, nil
+}
+
+func (s *service) findDataset(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalDataset, error) {
+	dataset, err := s.repo.getDataset(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get evaluation dataset error: %v", err)
+		return nil, errs.DBError
+	}
+	if dataset == nil {
+		return nil, biz.ErrEvalDatasetNotFound
+	}
+	return dataset, nil
+}
+
+func (s *service) findRun(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.EvalRun, error) {
+	run, err := s.repo.getRun(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get evaluation run error: %v", err)
+		return nil, errs.DBError
+	}
+	if run == nil {
+		return nil, biz.ErrEvalRunNotFound
+	}
+	return run, nil
+}
+
+// buildCases 创建时间依次递增，列表和评测按提交的顺序排列
+func buildCases(datasetID uuid.UUID, reqs []*CaseReq) ([]*model.EvalCase, error) {
+	cases := make([]*model.EvalCase, 0, len(reqs))
+	now := time.Now()
+	for i, req := range reqs {
+		if req == nil || strings.TrimSpace(req.Question) == "" {
+			return nil, biz.ErrEvalInvalid
+		}
+		cases = append(cases, &model.EvalCase{
+			BaseModel: model.BaseModel{
+				ID:        uuid.New(),
+				CreatedAt: now.Add(time.Duration(i) * time.Microsecond),
+			},
+			DatasetID:      datasetID,
+			Question:       strings.TrimSpace(req.Question),
+			ExpectedAnswer: req.ExpectedAnswer,
+			ExpectedTools:  req.ExpectedTools,
+			Variables:      req.Variables,
+		})
+	}
+	return cases, nil
+}
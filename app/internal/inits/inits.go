@@ -1,8 +1,15 @@
 package inits
 
 import (
+	"app/internal/evaluations"
 	"app/internal/router"
+	"app/internal/storages"
+	toolsmodule "app/internal/tools"
+	"app/internal/triggers"
+	"core/ai/dbquery"
+	"core/ai/mcps"
 	"core/ai/tools"
+	"log"
 
 	"github.com/mszlu521/thunder/config"
 	"github.com/mszlu521/thunder/database"
@@ -19,15 +26,49 @@ func Init(s *server.Server, conf *config.Config) {
 	jwt.Init(conf.Jwt.GetSecret())
 	// 注册工具
 	registerTools()
+	// 迁移旧格式的MCP凭证
+	toolsmodule.MigrateLegacyCredentials()
+	// 初始化MCP连接管理器，服务退出时关闭所有MCP连接
+	mcpManager := mcps.InitManager(mcps.DefaultManagerConfig())
+	// 初始化文件存储，配置有误时不能启动。启动孤立对象的清理，服务退出时停止
+	if err := storages.Init(); err != nil {
+		log.Fatalf("init storage error: %v", err)
+	}
+	storageGC := storages.StartGC()
+	// 启动触发器调度，服务退出时停止
+	triggerScheduler := triggers.StartScheduler()
+	// 启动评测的心跳，中断的评测标记为失败，服务退出时停止
+	evalMonitor := evaluations.StartMonitor()
+	s.Close = func() {
+		triggerScheduler.Stop()
+		storageGC.Stop()
+		evaluations.StopRuns()
+		evalMonitor.Stop()
+		_ = mcpManager.Close()
+		_ = dbquery.Close()
+	}
 	s.RegisterRouters(
 		&router.Event{},
 		&router.AuthRouter{},
 		&router.SubscriptionRouter{},
 		&router.AgentRouter{},
 		&router.LLMRouter{},
-		&router.ToolsRouter{})
+		&router.ToolsRouter{},
+		&router.ApiTokenRouter{},
+		&router.DataSourceRouter{},
+		&router.OpenRouter{},
+		&router.PublicRouter{},
+		&router.WidgetRouter{},
+		&router.WorkflowRouter{},
+		&router.TriggerRouter{},
+		&router.EvaluationRouter{},
+		&router.AttachmentRouter{},
+		&router.MemoryRouter{},
+		&router.StorageRouter{})
 }
 
 func registerTools() {
-	tools.RegisterSystemTools(tools.NewWeatherTool(&tools.WeatherConfig{ApiKey: tools.ApiKey}))
+	for _, t := range tools.BuiltinTools() {
+		tools.RegisterSystemTools(t)
+	}
 }
@@ -62,3 +62,31 @@ func (m *models) getProviderConfig(ctx context.Context, provider string) (*model
 	}
 	return &providerConfig, err
 }
+
+// getLLMByModel 按提供商和模型标识查询用户添加的模型，没有添加时返回 nil
+func (m *models) getLLMByModel(ctx context.Context, userId uuid.UUID, provider string, modelName string) (*model.LLM, error) {
+	var llm model.LLM
+	err := m.db.WithContext(ctx).
+		Joins("JOIN provider_configs ON provider_configs.id = llms.provider_config_id").
+		Where("llms.user_id = ? AND llms.model_name = ? AND provider_configs.provider = ?", userId, modelName, provider).
+		Preload("ProviderConfig").
+		First(&llm).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &llm, err
+}
+
+// getActiveLLM 查询用户最近更新的启用中的某类模型，没有时返回 nil
+func (m *models) getActiveLLM(ctx context.Context, userId uuid.UUID, modelType model.LLMType) (*model.LLM, error) {
+	var llm model.LLM
+	err := m.db.WithContext(ctx).
+		Where("user_id = ? AND model_type = ? AND status = ?", userId, modelType, model.LLMStatusActive).
+		Order("updated_at desc").
+		Preload("ProviderConfig").
+		First(&llm).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &llm, err
+}
@@ -2,7 +2,10 @@ package llms
 
 import (
 	"app/shared"
+	"common/biz"
+	"common/configs"
 	"context"
+	"model"
 	"time"
 
 	"github.com/mszlu521/thunder/database"
@@ -16,6 +19,13 @@ type PublicService struct {
 
 func (s PublicService) GetProviderConfig(e event.Event) (any, error) {
 	request := e.Data.(*shared.GetProviderConfigRequest)
+	if request.Provider == model.FakeProvider {
+		if !configs.GetConfig().Evaluation.FakeModelEnabled() {
+			return nil, biz.ProviderConfigNotFound
+		}
+		// 内置模型没有厂商配置
+		return &model.ProviderConfig{Provider: model.FakeProvider}, nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	providerConfig, err := s.repo.getProviderConfig(ctx, request.Provider)
@@ -26,6 +36,55 @@ func (s PublicService) GetProviderConfig(e event.Event) (any, error) {
 	return providerConfig, nil
 }
 
+// GetVisionModel 判断对话的主模型能否直接处理图片。主模型是用户添加的图像模型时直接使用，
+// 否则返回用户配置的图像模型，由它先理解图片。都没有时返回 ErrVisionNotSupported
+func (s PublicService) GetVisionModel(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetVisionModelRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if shared.SupportsImageInput(request.Provider) {
+		llm, err := s.repo.getLLMByModel(ctx, request.UserID, request.Provider, request.ModelName)
+		if err != nil {
+			logs.Errorf("GetVisionModel error: %v", err)
+			return nil, err
+		}
+		if llm != nil && llm.ModelType == model.LLMTypeVision {
+			return &shared.VisionModel{Native: true}, nil
+		}
+	}
+	llm, err := s.repo.getActiveLLM(ctx, request.UserID, model.LLMTypeVision)
+	if err != nil {
+		logs.Errorf("GetVisionModel error: %v", err)
+		return nil, err
+	}
+	if llm == nil || !shared.SupportsImageInput(llm.ProviderConfig.Provider) {
+		return nil, biz.ErrVisionNotSupported
+	}
+	return &shared.VisionModel{
+		ProviderConfig: &llm.ProviderConfig,
+		ModelName:      llm.ModelName,
+	}, nil
+}
+
+// GetEmbeddingModel 返回用户配置的向量模型，没有配置时返回 nil，由调用方决定是否降级
+func (s PublicService) GetEmbeddingModel(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetEmbeddingModelRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	llm, err := s.repo.getActiveLLM(ctx, request.UserID, model.LLMTypeEmbedding)
+	if err != nil {
+		logs.Errorf("GetEmbeddingModel error: %v", err)
+		return nil, err
+	}
+	if llm == nil {
+		return (*shared.EmbeddingModel)(nil), nil
+	}
+	return &shared.EmbeddingModel{
+		ProviderConfig: &llm.ProviderConfig,
+		ModelName:      llm.ModelName,
+	}, nil
+}
+
 func NewPublicService() *PublicService {
 	return &PublicService{
 		repo: NewModels(database.GetPostgresDB().GormDB),
@@ -13,4 +13,6 @@ type repository interface {
 	createLLM(ctx context.Context, llm *model.LLM) error
 	listLLMS(ctx context.Context, userId uuid.UUID, filter LLMFilter) ([]*model.LLM, int64, error)
 	getProviderConfig(ctx context.Context, provider string) (*model.ProviderConfig, error)
+	getLLMByModel(ctx context.Context, userId uuid.UUID, provider string, modelName string) (*model.LLM, error)
+	getActiveLLM(ctx context.Context, userId uuid.UUID, modelType model.LLMType) (*model.LLM, error)
 }
@@ -0,0 +1,91 @@
+package memories
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+// ListMemories 查询agent记住的关于当前用户的信息
+func (h *Handler) ListMemories(c *gin.Context) {
+	var listReq ListMemoriesReq
+	if err := req.QueryParam(c, &listReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.service.listMemories(c.Request.Context(), userID, listReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, resp)
+}
+
+func (h *Handler) UpdateMemory(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var updateReq UpdateMemoryReq
+	if err := req.JsonParam(c, &updateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	memory, err := h.service.updateMemory(c.Request.Context(), userID, id, updateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, memory)
+}
+
+func (h *Handler) DeleteMemory(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteMemory(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// ClearMemories 删除某个agent下或者全部的记忆
+func (h *Handler) ClearMemories(c *gin.Context) {
+	var clearReq ClearMemoriesReq
+	if err := req.QueryParam(c, &clearReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	deleted, err := h.service.clearMemories(c.Request.Context(), userID, clearReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, &ClearMemoriesResponse{Deleted: deleted})
+}
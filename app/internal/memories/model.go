@@ -0,0 +1,89 @@
+package memories
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+func (m *models) listMemories(ctx context.Context, userID uuid.UUID, filter MemoryFilter) ([]*model.Memory, int64, error) {
+	var memories []*model.Memory
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.Memory{}).Where("user_id = ?", userID)
+	if filter.AgentID != nil {
+		query = query.Where("agent_id = ?", *filter.AgentID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("updated_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&memories).Error
+	return memories, total, err
+}
+
+// listAgentMemories 用户在agent下的全部记忆，最近更新的在前
+func (m *models) listAgentMemories(ctx context.Context, userID uuid.UUID, agentID uuid.UUID) ([]*model.Memory, error) {
+	var memories []*model.Memory
+	err := m.db.WithContext(ctx).Where("user_id = ? AND agent_id = ?", userID, agentID).
+		Order("updated_at DESC").Find(&memories).Error
+	return memories, err
+}
+
+func (m *models) getMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Memory, error) {
+	var memory model.Memory
+	err := m.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&memory).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &memory, err
+}
+
+func (m *models) createMemory(ctx context.Context, memory *model.Memory) error {
+	return m.db.WithContext(ctx).Create(memory).Error
+}
+
+func (m *models) updateMemory(ctx context.Context, memory *model.Memory) error {
+	return m.db.WithContext(ctx).Save(memory).Error
+}
+
+// updateEmbedding 只更新向量，不改变记忆的更新时间
+func (m *models) updateEmbedding(ctx context.Context, memory *model.Memory) error {
+	return m.db.WithContext(ctx).Model(memory).UpdateColumns(map[string]any{
+		"embedding":       memory.Embedding,
+		"embedding_model": memory.EmbeddingModel,
+	}).Error
+}
+
+// deleteMemory 用户删除的记忆直接删除记录，不保留软删除的数据
+func (m *models) deleteMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&model.Memory{})
+	return result.RowsAffected, result.Error
+}
+
+// deleteMemories agentID 为空时删除用户的全部记忆
+func (m *models) deleteMemories(ctx context.Context, userID uuid.UUID, agentID *uuid.UUID) (int64, error) {
+	query := m.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID)
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+	result := query.Delete(&model.Memory{})
+	return result.RowsAffected, result.Error
+}
+
+// trimMemories 只保留最近更新的 keep 条记忆
+func (m *models) trimMemories(ctx context.Context, userID uuid.UUID, agentID uuid.UUID, keep int) error {
+	stale := m.db.Model(&model.Memory{}).Select("id").
+		Where("user_id = ? AND agent_id = ?", userID, agentID).
+		Order("updated_at DESC").Offset(keep)
+	return m.db.WithContext(ctx).Unscoped().Where("id IN (?)", stale).Delete(&model.Memory{}).Error
+}
@@ -0,0 +1,41 @@
+package memories
+
+import (
+	"app/shared"
+	"context"
+	"time"
+
+	"github.com/mszlu521/thunder/event"
+)
+
+// 提取记忆需要调用模型，比普通的查询需要更长的时间
+const extractTimeout = time.Minute
+
+type PublicService struct {
+	service *service
+}
+
+// SearchMemories 检索和本次消息相关的记忆，放入对话的提示词中
+func (s *PublicService) SearchMemories(e event.Event) (any, error) {
+	request := e.Data.(*shared.SearchMemoriesRequest)
+	if request.Ctx == nil {
+		request.Ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(request.Ctx, 10*time.Second)
+	defer cancel()
+	return s.service.searchMemories(ctx, request)
+}
+
+// ExtractMemories 一轮对话结束后提取需要记住的信息，返回新增或者更新的条数
+func (s *PublicService) ExtractMemories(e event.Event) (any, error) {
+	request := e.Data.(*shared.ExtractMemoriesRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+	defer cancel()
+	return s.service.extractMemories(ctx, request)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		service: newService(),
+	}
+}
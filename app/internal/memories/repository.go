@@ -0,0 +1,26 @@
+package memories
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	listMemories(ctx context.Context, userID uuid.UUID, filter MemoryFilter) ([]*model.Memory, int64, error)
+	listAgentMemories(ctx context.Context, userID uuid.UUID, agentID uuid.UUID) ([]*model.Memory, error)
+	getMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Memory, error)
+	createMemory(ctx context.Context, memory *model.Memory) error
+	updateMemory(ctx context.Context, memory *model.Memory) error
+	updateEmbedding(ctx context.Context, memory *model.Memory) error
+	deleteMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	deleteMemories(ctx context.Context, userID uuid.UUID, agentID *uuid.UUID) (int64, error)
+	trimMemories(ctx context.Context, userID uuid.UUID, agentID uuid.UUID, keep int) error
+}
+
+type MemoryFilter struct {
+	AgentID *uuid.UUID
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,17 @@
+package memories
+
+type ListMemoriesReq struct {
+	// AgentID 只查询指定agent下的记忆，为空时查询全部
+	AgentID  string `json:"agentId" form:"agentId"`
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+type UpdateMemoryReq struct {
+	Content string `json:"content"`
+}
+
+type ClearMemoriesReq struct {
+	// AgentID 只删除指定agent下的记忆，为空时删除全部
+	AgentID string `json:"agentId" form:"agentId"`
+}
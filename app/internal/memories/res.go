@@ -0,0 +1,12 @@
+package memories
+
+import "model"
+
+type ListMemoriesResponse struct {
+	Memories []*model.Memory `json:"memories"`
+	Total    int64           `json:"total"`
+}
+
+type ClearMemoriesResponse struct {
+	Deleted int64 `json:"deleted"`
+}
@@ -0,0 +1,341 @@
+package memories
+
+import (
+	"app/shared"
+	"common/biz"
+	"common/configs"
+	"context"
+	"core/ai"
+	"core/ai/jsonschema"
+	"core/ai/retrieval"
+	"encoding/json"
+	"fmt"
+	"model"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// maxMemoryChars 单条记忆的最大字符数，过长的内容不是需要记住的事实
+const maxMemoryChars = 500
+
+// maxTurnChars 提取记忆时消息和回答各自保留的字符数，长回答的后半部分很少有关于用户的信息
+const maxTurnChars = 4000
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+func (s *service) listMemories(ctx context.Context, userID uuid.UUID, req ListMemoriesReq) (*ListMemoriesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	filter := MemoryFilter{
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	}
+	if req.AgentID != "" {
+		agentID, err := uuid.Parse(req.AgentID)
+		if err != nil {
+			return nil, errs.ErrParam
+		}
+		filter.AgentID = &agentID
+	}
+	memories, total, err := s.repo.listMemories(ctx, userID, filter)
+	if err != nil {
+		logs.Errorf("list memories error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListMemoriesResponse{Memories: memories, Total: total}, nil
+}
+
+// updateMemory 用户修改记忆的内容，向量在下次检索时重新生成
+func (s *service) updateMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateMemoryReq) (*model.Memory, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxMemoryChars {
+		return nil, errs.ErrParam
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	memory, err := s.repo.getMemory(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get memory error: %v", err)
+		return nil, errs.DBError
+	}
+	if memory == nil {
+		return nil, biz.ErrMemoryNotFound
+	}
+	memory.Content = content
+	memory.Embedding = nil
+	memory.EmbeddingModel = ""
+	if err := s.repo.updateMemory(ctx, memory); err != nil {
+		logs.Errorf("update memory error: %v", err)
+		return nil, errs.DBError
+	}
+	return memory, nil
+}
+
+func (s *service) deleteMemory(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteMemory(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete memory error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrMemoryNotFound
+	}
+	return nil
+}
+
+// clearMemories 删除用户在某个agent下或者全部的记忆，返回删除的条数
+func (s *service) clearMemories(ctx context.Context, userID uuid.UUID, req ClearMemoriesReq) (int64, error) {
+	var agentID *uuid.UUID
+	if req.AgentID != "" {
+		id, err := uuid.Parse(req.AgentID)
+		if err != nil {
+			return 0, errs.ErrParam
+		}
+		agentID = &id
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteMemories(ctx, userID, agentID)
+	if err != nil {
+		logs.Errorf("delete memories error: %v", err)
+		return 0, errs.DBError
+	}
+	return rows, nil
+}
+
+// searchMemories 返回和 query 相关的记忆。用户配置了向量模型时按相似度检索，否则按关键词检索
+func (s *service) searchMemories(ctx context.Context, req *shared.SearchMemoriesRequest) ([]*model.Memory, error) {
+	memories, err := s.repo.listAgentMemories(ctx, req.UserID, req.AgentID)
+	if err != nil {
+		logs.Errorf("list agent memories error: %v", err)
+		return nil, errs.DBError
+	}
+	if len(memories) == 0 {
+		return nil, nil
+	}
+	config := configs.GetConfig().Memory
+	docs := make([]*schema.Document, len(memories))
+	byID := make(map[string]*model.Memory, len(memories))
+	for i, memory := range memories {
+		docs[i] = &schema.Document{ID: memory.ID.String(), Content: memory.Content}
+		byID[docs[i].ID] = memory
+	}
+	var results []*schema.Document
+	searched := false
+	if embedder, modelKey := s.embedder(ctx, req.UserID); embedder != nil {
+		results, err = s.searchByVector(ctx, embedder, modelKey, memories, docs, req.Query)
+		if err != nil {
+			// 向量模型不可用时退回关键词检索
+			logs.Errorf("search memories by vector error: %v", err)
+		}
+		searched = err == nil
+	}
+	if !searched {
+		r, err := retrieval.NewMemoryRetriever(ctx, nil, docs, config.GetTopK())
+		if err != nil {
+			return nil, err
+		}
+		if results, err = r.Retrieve(ctx, req.Query); err != nil {
+			return nil, err
+		}
+	}
+	found := make([]*model.Memory, 0, len(results))
+	for _, doc := range results {
+		found = append(found, byID[doc.ID])
+	}
+	return found, nil
+}
+
+func (s *service) searchByVector(ctx context.Context, embedder embedding.Embedder, modelKey string, memories []*model.Memory, docs []*schema.Document, query string) ([]*schema.Document, error) {
+	if err := s.ensureEmbeddings(ctx, embedder, modelKey, memories); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float64, len(memories))
+	for i, memory := range memories {
+		vectors[i] = memory.Embedding
+	}
+	config := configs.GetConfig().Memory
+	r := retrieval.NewVectorRetriever(embedder, docs, vectors, config.GetTopK())
+	return r.Retrieve(ctx, query, retriever.WithScoreThreshold(config.GetMinScore()))
+}
+
+// embedder 用户配置的向量模型，没有配置或者创建失败时返回 nil。
+// modelKey 标识生成向量的模型，模型不同的向量不能比较
+func (s *service) embedder(ctx context.Context, userID uuid.UUID) (embedding.Embedder, string) {
+	trigger, err := event.Trigger("getEmbeddingModel", &shared.GetEmbeddingModelRequest{UserID: userID})
+	if err != nil {
+		logs.Errorf("getEmbeddingModel error: %v", err)
+		return nil, ""
+	}
+	embeddingModel := trigger.(*shared.EmbeddingModel)
+	if embeddingModel == nil {
+		return nil, ""
+	}
+	embedder, err := shared.BuildEmbedder(ctx, embeddingModel.ProviderConfig, embeddingModel.ModelName)
+	if err != nil {
+		logs.Errorf("build embedder error: %v", err)
+		return nil, ""
+	}
+	return embedder, embeddingModel.ProviderConfig.Provider + "/" + embeddingModel.ModelName
+}
+
+// ensureEmbeddings 为没有向量或者向量不是当前模型生成的记忆生成向量并保存
+func (s *service) ensureEmbeddings(ctx context.Context, embedder embedding.Embedder, modelKey string, memories []*model.Memory) error {
+	var missing []*model.Memory
+	var texts []string
+	for _, memory := range memories {
+		if memory.EmbeddingModel != modelKey || len(memory.Embedding) == 0 {
+			missing = append(missing, memory)
+			texts = append(texts, memory.Content)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	vectors, err := retrieval.EmbedTexts(ctx, embedder, texts)
+	if err != nil {
+		return err
+	}
+	for i, memory := range missing {
+		memory.Embedding = vectors[i]
+		memory.EmbeddingModel = modelKey
+		if err := s.repo.updateEmbedding(ctx, memory); err != nil {
+			logs.Errorf("update memory embedding error: %v", err)
+		}
+	}
+	return nil
+}
+
+// memoryProposal 提取模型给出的一条记忆，Replaces 是被它更新的旧记忆的ID
+type memoryProposal struct {
+	Content  string `json:"content"`
+	Replaces string `json:"replaces"`
+}
+
+// extractMemories 让agent的模型从本轮对话中提取需要记住的信息，新增或者更新记忆，返回变化的条数
+func (s *service) extractMemories(ctx context.Context, req *shared.ExtractMemoriesRequest) (int, error) {
+	existing, err := s.repo.listAgentMemories(ctx, req.UserID, req.AgentID)
+	if err != nil {
+		logs.Errorf("list agent memories error: %v", err)
+		return 0, errs.DBError
+	}
+	proposals, err := s.proposeMemories(ctx, req, existing)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[string]*model.Memory, len(existing))
+	seen := make(map[string]bool, len(existing))
+	for _, memory := range existing {
+		byID[memory.ID.String()] = memory
+		seen[normalizeMemory(memory.Content)] = true
+	}
+	var changed []*model.Memory
+	for _, proposal := range proposals {
+		content := strings.TrimSpace(proposal.Content)
+		if content == "" || utf8.RuneCountInString(content) > maxMemoryChars || seen[normalizeMemory(content)] {
+			continue
+		}
+		seen[normalizeMemory(content)] = true
+		memory := byID[strings.TrimSpace(proposal.Replaces)]
+		if memory != nil {
+			memory.Content = content
+			memory.ConversationID = req.ConversationID
+			memory.Embedding = nil
+			memory.EmbeddingModel = ""
+			err = s.repo.updateMemory(ctx, memory)
+		} else {
+			memory = &model.Memory{
+				BaseModel: model.BaseModel{
+					ID: uuid.New(),
+				},
+				UserID:         req.UserID,
+				AgentID:        req.AgentID,
+				Content:        content,
+				ConversationID: req.ConversationID,
+			}
+			err = s.repo.createMemory(ctx, memory)
+		}
+		if err != nil {
+			logs.Errorf("save memory error: %v", err)
+			return len(changed), errs.DBError
+		}
+		changed = append(changed, memory)
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.trimMemories(ctx, req.UserID, req.AgentID, configs.GetConfig().Memory.GetMaxMemories()); err != nil {
+		logs.Errorf("trim memories error: %v", err)
+	}
+	// 向量生成失败时在下次检索时重新生成
+	if embedder, modelKey := s.embedder(ctx, req.UserID); embedder != nil {
+		if err := s.ensureEmbeddings(ctx, embedder, modelKey, changed); err != nil {
+			logs.Errorf("embed memories error: %v", err)
+		}
+	}
+	return len(changed), nil
+}
+
+func (s *service) proposeMemories(ctx context.Context, req *shared.ExtractMemoriesRequest, existing []*model.Memory) ([]memoryProposal, error) {
+	chatModel, err := shared.BuildChatModel(ctx, req.ProviderConfig, req.ModelName, nil)
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	for _, memory := range existing {
+		fmt.Fprintf(&sb, "- [%s] %s\n", memory.ID, memory.Content)
+	}
+	msg, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.UserMessage(ai.BuildMemoryExtractionPrompt(sb.String(), truncate(req.Message), truncate(req.Answer))),
+	})
+	if err != nil {
+		logs.Errorf("extract memories with %s error: %v", req.ModelName, err)
+		return nil, err
+	}
+	var proposals []memoryProposal
+	if err := json.Unmarshal([]byte(jsonschema.Extract(msg.Content)), &proposals); err != nil {
+		logs.Warnf("invalid memory extraction output: %s", msg.Content)
+		return nil, nil
+	}
+	return proposals, nil
+}
+
+// normalizeMemory 比较记忆是否重复时忽略空白和结尾的标点
+func normalizeMemory(content string) string {
+	content = strings.Join(strings.Fields(strings.ToLower(content)), " ")
+	return strings.TrimRight(content, "。.！!")
+}
+
+func truncate(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxTurnChars {
+		return text
+	}
+	return string(runes[:maxTurnChars]) + "……"
+}
@@ -19,5 +19,33 @@ func (u *AgentRouter) Register(engine *gin.Engine) {
 		agentsGroup.PUT("/update", agentsHandler.UpdateAgent)
 		agentsGroup.POST("/chat", agentsHandler.AgentMessage)
 		agentsGroup.POST("/:id/tools/batch", agentsHandler.UpdateAgentTool)
+		agentsGroup.POST("/:id/prompt/import", agentsHandler.ImportMcpPrompt)
+		agentsGroup.GET("/:id/export", agentsHandler.ExportAgent)
+		agentsGroup.POST("/import", agentsHandler.ImportAgent)
+		agentsGroup.POST("/:id/duplicate", agentsHandler.DuplicateAgent)
+		agentsGroup.POST("/:id/icon", agentsHandler.UploadIcon)
+		agentsGroup.GET("/templates", agentsHandler.ListTemplates)
+		agentsGroup.POST("/:id/template", agentsHandler.SaveTemplate)
+		agentsGroup.DELETE("/templates/:templateId", agentsHandler.DeleteTemplate)
+		// 版本管理
+		agentsGroup.POST("/:id/publish", agentsHandler.PublishAgent)
+		agentsGroup.GET("/:id/versions", agentsHandler.ListAgentVersions)
+		agentsGroup.GET("/:id/versions/diff", agentsHandler.DiffAgentVersions)
+		agentsGroup.GET("/:id/versions/:version", agentsHandler.GetAgentVersion)
+		agentsGroup.POST("/:id/rollback", agentsHandler.RollbackAgent)
+		// 分享
+		agentsGroup.PUT("/:id/visibility", agentsHandler.UpdateVisibility)
+		agentsGroup.POST("/:id/shares", agentsHandler.CreateShare)
+		agentsGroup.GET("/:id/shares", agentsHandler.ListShares)
+		agentsGroup.DELETE("/:id/shares/:shareId", agentsHandler.RevokeShare)
+		agentsGroup.POST("/:id/embeds", agentsHandler.CreateEmbed)
+		agentsGroup.GET("/:id/embeds", agentsHandler.ListEmbeds)
+		agentsGroup.PUT("/:id/embeds/:embedId", agentsHandler.UpdateEmbed)
+		agentsGroup.DELETE("/:id/embeds/:embedId", agentsHandler.RevokeEmbed)
+		agentsGroup.GET("/:id/conversations", agentsHandler.ListConversations)
+		agentsGroup.GET("/:id/conversations/:conversationId/messages", agentsHandler.ListConversationMessages)
+		// 平台内会话
+		agentsGroup.POST("/:id/sessions", agentsHandler.CreateSession)
+		agentsGroup.DELETE("/:id/sessions/:sessionId", agentsHandler.DeleteSession)
 	}
 }
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/internal/apitokens"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiTokenRouter struct {
+}
+
+func (a *ApiTokenRouter) Register(engine *gin.Engine) {
+	tokenGroup := engine.Group("/api/v1/api-tokens")
+	{
+		tokenHandler := apitokens.NewHandler()
+		tokenGroup.POST("", tokenHandler.CreateToken)
+		tokenGroup.GET("", tokenHandler.ListTokens)
+		tokenGroup.DELETE("/:id", tokenHandler.DeleteToken)
+	}
+}
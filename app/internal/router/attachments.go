@@ -0,0 +1,24 @@
+package router
+
+import (
+	"app/internal/attachments"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentRouter struct {
+}
+
+func (a *AttachmentRouter) Register(engine *gin.Engine) {
+	attachmentHandler := attachments.NewHandler()
+	attachmentGroup := engine.Group("/api/v1/attachments")
+	{
+		attachmentGroup.POST("/images", attachmentHandler.UploadImage)
+		attachmentGroup.POST("/images/url", attachmentHandler.AddImageUrl)
+		attachmentGroup.POST("/files", attachmentHandler.UploadFile)
+		attachmentGroup.GET("/files", attachmentHandler.ListFiles)
+		attachmentGroup.GET("/:id", attachmentHandler.GetAttachment)
+		attachmentGroup.GET("/:id/content", attachmentHandler.GetContent)
+		attachmentGroup.DELETE("/:id", attachmentHandler.DeleteAttachment)
+	}
+}
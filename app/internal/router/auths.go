@@ -22,4 +22,10 @@ func (u *AuthRouter) Register(engine *gin.Engine) {
 		userGroup.POST("/verify-code", userHandler.VerifyCode)
 		userGroup.POST("/reset-password", userHandler.ResetPassword)
 	}
+	// 当前登录用户的资料
+	profileGroup := engine.Group("/api/v1/users")
+	{
+		profileHandler := auths.NewHandler()
+		profileGroup.POST("/avatar", profileHandler.UploadAvatar)
+	}
 }
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"app/internal/datasources"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataSourceRouter struct {
+}
+
+func (d *DataSourceRouter) Register(engine *gin.Engine) {
+	dataSourceGroup := engine.Group("/api/v1/data-sources")
+	{
+		dataSourceHandler := datasources.NewHandler()
+		dataSourceGroup.POST("", dataSourceHandler.CreateDataSource)
+		dataSourceGroup.GET("", dataSourceHandler.ListDataSources)
+		dataSourceGroup.GET("/:id", dataSourceHandler.GetDataSource)
+		dataSourceGroup.PUT("/:id", dataSourceHandler.UpdateDataSource)
+		dataSourceGroup.DELETE("/:id", dataSourceHandler.DeleteDataSource)
+		dataSourceGroup.GET("/:id/schema", dataSourceHandler.GetSchema)
+		dataSourceGroup.POST("/:id/query", dataSourceHandler.TestQuery)
+	}
+}
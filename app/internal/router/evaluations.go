@@ -0,0 +1,33 @@
+package router
+
+import (
+	"app/internal/evaluations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EvaluationRouter struct {
+}
+
+func (e *EvaluationRouter) Register(engine *gin.Engine) {
+	evaluationHandler := evaluations.NewHandler()
+	datasetGroup := engine.Group("/api/v1/evaluations/datasets")
+	{
+		datasetGroup.POST("", evaluationHandler.CreateDataset)
+		datasetGroup.GET("", evaluationHandler.ListDatasets)
+		datasetGroup.GET("/:id", evaluationHandler.GetDataset)
+		datasetGroup.PUT("/:id", evaluationHandler.UpdateDataset)
+		datasetGroup.DELETE("/:id", evaluationHandler.DeleteDataset)
+		datasetGroup.POST("/:id/cases", evaluationHandler.AddCases)
+		datasetGroup.PUT("/:id/cases/:caseId", evaluationHandler.UpdateCase)
+		datasetGroup.DELETE("/:id/cases/:caseId", evaluationHandler.DeleteCase)
+	}
+	runGroup := engine.Group("/api/v1/evaluations/runs")
+	{
+		runGroup.POST("", evaluationHandler.CreateRun)
+		runGroup.GET("", evaluationHandler.ListRuns)
+		runGroup.GET("/compare", evaluationHandler.CompareRuns)
+		runGroup.GET("/:id", evaluationHandler.GetRun)
+		runGroup.POST("/:id/cancel", evaluationHandler.CancelRun)
+	}
+}
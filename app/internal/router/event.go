@@ -1,8 +1,14 @@
 package router
 
 import (
+	"app/internal/agents"
+	"app/internal/attachments"
+	"app/internal/datasources"
 	"app/internal/llms"
+	"app/internal/memories"
+	"app/internal/storages"
 	"app/internal/tools"
+	"app/internal/workflows"
 
 	"github.com/mszlu521/thunder/event"
 )
@@ -15,9 +21,31 @@ func (*Event) Register() {
 	// 注册事件相关的路由
 	llmService := llms.NewPublicService()
 	event.Register("getProviderConfigByProvider", llmService.GetProviderConfig)
+	event.Register("getVisionModel", llmService.GetVisionModel)
+	event.Register("getEmbeddingModel", llmService.GetEmbeddingModel)
 	//event.Register("getEmbeddingConfig", llmService.GetEmbeddingConfig)
 	toolService := tools.NewPublicService()
 	event.Register("getToolsByIds", toolService.GetToolsByIds)
+	event.Register("getToolsByNames", toolService.GetToolsByNames)
+	dataSourceService := datasources.NewPublicService()
+	event.Register("getDataSourceById", dataSourceService.GetDataSource)
+	agentService := agents.NewPublicService()
+	event.Register("invokeAgent", agentService.InvokeAgent)
+	event.Register("evaluateAgent", agentService.EvaluateAgent)
+	event.Register("getChatSession", agentService.GetChatSession)
+	attachmentService := attachments.NewPublicService()
+	event.Register("getAttachmentsByIds", attachmentService.GetAttachments)
+	event.Register("getSessionFiles", attachmentService.GetSessionFiles)
+	event.Register("deleteSessionFiles", attachmentService.DeleteSessionFiles)
+	storageService := storages.NewPublicService()
+	event.Register("putBlob", storageService.PutBlob)
+	event.Register("readBlob", storageService.ReadBlob)
+	event.Register("getBlobURL", storageService.GetBlobURL)
+	memoryService := memories.NewPublicService()
+	event.Register("searchMemories", memoryService.SearchMemories)
+	event.Register("extractMemories", memoryService.ExtractMemories)
+	workflowService := workflows.NewPublicService()
+	event.Register("runWorkflow", workflowService.RunWorkflow)
 	//knowledgeService := knowledges.NewPublicService()
 	//event.Register("getKnowledgeBase", knowledgeService.GetKnowledgeBase)
 	//event.Register("searchKnowledgeBase", knowledgeService.SearchKnowledgeBase)
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/internal/memories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MemoryRouter struct {
+}
+
+func (m *MemoryRouter) Register(engine *gin.Engine) {
+	memoryHandler := memories.NewHandler()
+	memoryGroup := engine.Group("/api/v1/memories")
+	{
+		memoryGroup.GET("", memoryHandler.ListMemories)
+		memoryGroup.DELETE("", memoryHandler.ClearMemories)
+		memoryGroup.PUT("/:id", memoryHandler.UpdateMemory)
+		memoryGroup.DELETE("/:id", memoryHandler.DeleteMemory)
+	}
+}
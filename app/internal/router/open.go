@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/internal/agents"
+	"app/internal/apitokens"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenRouter 使用API令牌认证的开放接口，供 mcp-server 等外部客户端调用
+type OpenRouter struct {
+}
+
+func (o *OpenRouter) Register(engine *gin.Engine) {
+	openGroup := engine.Group("/api/v1/open", apitokens.Auth())
+	{
+		agentsHandler := agents.NewHandler()
+		openGroup.GET("/agents", agentsHandler.ListInvocableAgents)
+		openGroup.POST("/agents/:id/invoke", agentsHandler.InvokeAgent)
+	}
+}
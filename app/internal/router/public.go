@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/internal/agents"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicRouter 不需要登录的访客接口，公开目录和分享链接
+type PublicRouter struct {
+}
+
+func (p *PublicRouter) Register(engine *gin.Engine) {
+	publicGroup := engine.Group("/api/v1/public")
+	{
+		agentsHandler := agents.NewHandler()
+		publicGroup.GET("/agents", agentsHandler.ListPublicAgents)
+		publicGroup.GET("/agents/:id", agentsHandler.GetPublicAgent)
+		publicGroup.POST("/agents/:id/chat", agentsHandler.PublicAgentChat)
+		publicGroup.GET("/shares/:token", agentsHandler.GetSharedAgent)
+		publicGroup.POST("/shares/:token/chat", agentsHandler.SharedAgentChat)
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/internal/storages"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorageRouter struct {
+}
+
+func (s *StorageRouter) Register(engine *gin.Engine) {
+	storageHandler := storages.NewHandler()
+	// 头像、图标和本地存储的签名下载地址不需要登录
+	engine.GET("/api/v1/blobs/:id", storageHandler.GetBlob)
+	engine.GET("/api/v1/files/*key", storageHandler.GetFile)
+	storageGroup := engine.Group("/api/v1/storage")
+	{
+		storageGroup.GET("/usage", storageHandler.GetUsage)
+	}
+}
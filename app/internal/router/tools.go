@@ -22,5 +22,14 @@ func (t *ToolsRouter) Register(r *gin.Engine) {
 		toolGroup.GET("", toolHandler.ListTools)
 		toolGroup.POST("/:id/test", toolHandler.TestTool)
 		toolGroup.GET("/mcp/:mcpId/tools", toolHandler.GetMcpTools)
+		toolGroup.GET("/mcp/status", toolHandler.GetMcpStatus)
+		toolGroup.GET("/mcp/:mcpId/prompts", toolHandler.GetMcpPrompts)
+		toolGroup.POST("/http/import", toolHandler.ImportOpenApi)
+		// 工具市场
+		toolGroup.PUT("/:id/visibility", toolHandler.UpdateVisibility)
+		toolGroup.GET("/catalog", toolHandler.ListCatalog)
+		toolGroup.GET("/installed", toolHandler.ListInstalledTools)
+		toolGroup.POST("/:id/install", toolHandler.InstallTool)
+		toolGroup.DELETE("/:id/install", toolHandler.UninstallTool)
 	}
 }
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"app/internal/triggers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TriggerRouter struct {
+}
+
+func (t *TriggerRouter) Register(engine *gin.Engine) {
+	triggerHandler := triggers.NewHandler()
+	triggerGroup := engine.Group("/api/v1/triggers")
+	{
+		triggerGroup.POST("", triggerHandler.CreateTrigger)
+		triggerGroup.GET("", triggerHandler.ListTriggers)
+		triggerGroup.GET("/:id", triggerHandler.GetTrigger)
+		triggerGroup.PUT("/:id", triggerHandler.UpdateTrigger)
+		triggerGroup.DELETE("/:id", triggerHandler.DeleteTrigger)
+		triggerGroup.POST("/:id/secret", triggerHandler.RotateSecret)
+		triggerGroup.POST("/:id/run", triggerHandler.RunTrigger)
+		triggerGroup.GET("/:id/runs", triggerHandler.ListRuns)
+		triggerGroup.GET("/:id/runs/:runId", triggerHandler.GetRun)
+		triggerGroup.POST("/:id/runs/:runId/retry", triggerHandler.RetryRun)
+	}
+	// webhook 不需要登录，由密钥认证
+	hookGroup := engine.Group("/api/v1/hooks")
+	{
+		hookGroup.POST("/triggers/:id", triggerHandler.Webhook)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/internal/agents"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WidgetRouter 网页嵌入组件的接口，使用嵌入密钥和来源白名单代替登录
+type WidgetRouter struct {
+}
+
+func (w *WidgetRouter) Register(engine *gin.Engine) {
+	widgetGroup := engine.Group("/api/v1/widget/:key", agents.WidgetAuth())
+	{
+		agentsHandler := agents.NewHandler()
+		widgetGroup.GET("/bootstrap", agentsHandler.WidgetBootstrap)
+		widgetGroup.POST("/chat", agentsHandler.WidgetChat)
+	}
+}
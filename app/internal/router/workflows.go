@@ -0,0 +1,26 @@
+package router
+
+import (
+	"app/internal/workflows"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkflowRouter struct {
+}
+
+func (w *WorkflowRouter) Register(engine *gin.Engine) {
+	workflowGroup := engine.Group("/api/v1/workflows")
+	{
+		workflowHandler := workflows.NewHandler()
+		workflowGroup.POST("", workflowHandler.CreateWorkflow)
+		workflowGroup.GET("", workflowHandler.ListWorkflows)
+		workflowGroup.POST("/validate", workflowHandler.ValidateWorkflow)
+		workflowGroup.GET("/:id", workflowHandler.GetWorkflow)
+		workflowGroup.PUT("/:id", workflowHandler.UpdateWorkflow)
+		workflowGroup.DELETE("/:id", workflowHandler.DeleteWorkflow)
+		workflowGroup.POST("/:id/run", workflowHandler.RunWorkflow)
+		workflowGroup.GET("/:id/runs", workflowHandler.ListRuns)
+		workflowGroup.GET("/:id/runs/:runId", workflowHandler.GetRun)
+	}
+}
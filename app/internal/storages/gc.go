@@ -0,0 +1,149 @@
+package storages
+
+import (
+	"common/configs"
+	"context"
+	"core/storage"
+	"sync"
+	"time"
+
+	"github.com/mszlu521/thunder/logs"
+)
+
+const (
+	// 每一轮最多删除的孤立记录数量，剩下的留到下一轮
+	maxOrphansPerRound = 500
+	// 按 key 查询记录时每批的数量
+	keyBatchSize = 200
+)
+
+// Collector 定期清理孤立对象：不再被引用的记录连同存储中的对象一起删除，
+// 存储中没有记录的对象也删除。新对象在宽限期内不清理，等待引用它的记录保存
+type Collector struct {
+	service *service
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// StartGC 启动清理，服务退出时调用 Stop
+func StartGC() *Collector {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Collector{
+		service: newService(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+func (c *Collector) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func (c *Collector) loop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(configs.GetConfig().Storage.GetGCInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.collect()
+		}
+	}
+}
+
+func (c *Collector) collect() {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Errorf("Panic in storage gc: %v", r)
+		}
+	}()
+	if store == nil {
+		return
+	}
+	before := time.Now().Add(-configs.GetConfig().Storage.GetGCGrace())
+	if err := c.deleteOrphanBlobs(before); err != nil {
+		logs.Errorf("delete orphan blobs error: %v", err)
+	}
+	if err := c.deleteUntrackedObjects(before); err != nil {
+		logs.Errorf("delete untracked objects error: %v", err)
+	}
+}
+
+// deleteOrphanBlobs 先删除存储中的对象再删除记录，对象删除失败时保留记录，下一轮重试
+func (c *Collector) deleteOrphanBlobs(before time.Time) error {
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	blobs, err := c.service.repo.listOrphanBlobs(ctx, before, maxOrphansPerRound)
+	cancel()
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if err := store.Delete(c.ctx, blob.Key); err != nil {
+			logs.Errorf("delete object %s error: %v", blob.Key, err)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+		err := c.service.repo.deleteBlob(ctx, blob.ID)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if len(blobs) > 0 {
+		logs.Infof("storage gc deleted %d orphan blobs", len(blobs))
+	}
+	return nil
+}
+
+// deleteUntrackedObjects 删除宽限期之前写入、但没有记录的对象，通常是保存记录失败留下的
+func (c *Collector) deleteUntrackedObjects(before time.Time) error {
+	var batch []string
+	deleted := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+		existing, err := c.service.repo.existingKeys(ctx, batch)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, key := range batch {
+			if existing[key] {
+				continue
+			}
+			if err := store.Delete(c.ctx, key); err != nil {
+				logs.Errorf("delete object %s error: %v", key, err)
+				continue
+			}
+			deleted++
+		}
+		batch = batch[:0]
+		return nil
+	}
+	err := store.List(c.ctx, "", func(object *storage.Object) error {
+		if !object.LastModified.Before(before) {
+			return nil
+		}
+		batch = append(batch, object.Key)
+		if len(batch) < keyBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if deleted > 0 {
+		logs.Infof("storage gc deleted %d untracked objects", deleted)
+	}
+	return err
+}
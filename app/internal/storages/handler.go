@@ -0,0 +1,91 @@
+package storages
+
+import (
+	"common/biz"
+	"core/storage"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/logs"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+// GetBlob 头像和图标的固定地址，跳转到有效期很短的签名下载地址
+func (h *Handler) GetBlob(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	u, err := h.service.publicURL(c.Request.Context(), id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, u)
+}
+
+// GetFile 本地存储的签名下载接口，校验签名后返回文件，不需要登录
+func (h *Handler) GetFile(c *gin.Context) {
+	local, ok := store.(*storage.Local)
+	if !ok {
+		res.Error(c, biz.ErrBlobNotFound)
+		return
+	}
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := local.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	reader, object, err := local.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		logs.Errorf("get object %s error: %v", key, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("X-Content-Type-Options", "nosniff")
+	// 文件和接口在同一个域名下，禁止其中的脚本执行
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	c.Header("Content-Length", strconv.FormatInt(object.Size, 10))
+	c.Header("Content-Type", object.ContentType)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logs.Warnf("write object %s error: %v", key, err)
+	}
+}
+
+// GetUsage 查询当前用户的存储用量
+func (h *Handler) GetUsage(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	usage, err := h.service.usage(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, usage)
+}
@@ -0,0 +1,105 @@
+package storages
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+// createBlobWithinQuota 在用量加上 blob 不超过 quota 时保存记录，超过时返回 false。
+// 锁住用户的记录，同一个用户的并发上传依次检查用量
+func (m *models) createBlobWithinQuota(ctx context.Context, blob *model.Blob, quota int64) (bool, error) {
+	created := false
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", blob.OwnerID).First(&user).Error
+		if err != nil && !gorms.IsRecordNotFoundError(err) {
+			return err
+		}
+		var used int64
+		err = tx.Model(&model.Blob{}).Select("COALESCE(SUM(size), 0)").Where("owner_id = ?", blob.OwnerID).Scan(&used).Error
+		if err != nil {
+			return err
+		}
+		if used+blob.Size > quota {
+			return nil
+		}
+		created = true
+		return tx.Create(blob).Error
+	})
+	return created && err == nil, err
+}
+
+func (m *models) getBlob(ctx context.Context, id uuid.UUID) (*model.Blob, error) {
+	var blob model.Blob
+	err := m.db.WithContext(ctx).Where("id = ?", id).First(&blob).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &blob, err
+}
+
+// sumSize 用户已经使用的存储字节数
+func (m *models) sumSize(ctx context.Context, ownerID uuid.UUID) (int64, error) {
+	var size int64
+	err := m.db.WithContext(ctx).Model(&model.Blob{}).Select("COALESCE(SUM(size), 0)").
+		Where("owner_id = ?", ownerID).Scan(&size).Error
+	return size, err
+}
+
+func (m *models) getUserPlan(ctx context.Context, userID uuid.UUID) (model.SubscriptionPlan, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("current_plan").Where("id = ?", userID).First(&user).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return model.FreePlan, nil
+	}
+	return user.CurrentPlan, err
+}
+
+// listOrphanBlobs 创建时间早于 before 且不再被引用的对象：附件已经删除，
+// 或者用户头像、agent 图标、agent 版本和模板快照中的图标都不再是它的地址
+func (m *models) listOrphanBlobs(ctx context.Context, before time.Time, limit int) ([]*model.Blob, error) {
+	var blobs []*model.Blob
+	path := "? || blobs.id::text"
+	err := m.db.WithContext(ctx).Where("blobs.created_at < ?", before).
+		Where(m.db.Where("blobs.kind = ? AND NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.id = blobs.ref_id)", model.BlobAttachment).
+			Or("blobs.kind = ? AND NOT EXISTS (SELECT 1 FROM users WHERE users.avatar = "+path+")", model.BlobAvatar, model.BlobPathPrefix).
+			Or("blobs.kind = ? AND NOT EXISTS (SELECT 1 FROM agents WHERE agents.icon = "+path+")"+
+				" AND NOT EXISTS (SELECT 1 FROM agent_versions WHERE agent_versions.snapshot->>'icon' = "+path+")"+
+				" AND NOT EXISTS (SELECT 1 FROM agent_templates WHERE agent_templates.snapshot->>'icon' = "+path+")",
+				model.BlobAgentIcon, model.BlobPathPrefix, model.BlobPathPrefix, model.BlobPathPrefix)).
+		Order("blobs.created_at").Limit(limit).Find(&blobs).Error
+	return blobs, err
+}
+
+// deleteBlob 直接删除记录，对象已经从存储中删除
+func (m *models) deleteBlob(ctx context.Context, id uuid.UUID) error {
+	return m.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&model.Blob{}).Error
+}
+
+// existingKeys 返回 keys 中有记录的部分，包括已经软删除的记录
+func (m *models) existingKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	var found []string
+	err := m.db.WithContext(ctx).Unscoped().Model(&model.Blob{}).Where("key IN ?", keys).Pluck("key", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for _, key := range found {
+		existing[key] = true
+	}
+	return existing, nil
+}
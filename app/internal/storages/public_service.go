@@ -0,0 +1,53 @@
+package storages
+
+import (
+	"app/shared"
+	"context"
+	"time"
+
+	"github.com/mszlu521/thunder/event"
+)
+
+// 读写对象的超时时间，包含上传和下载文件内容
+const transferTimeout = time.Minute
+
+type PublicService struct {
+	service *service
+}
+
+// PutBlob 保存文件，返回 *model.Blob
+func (s *PublicService) PutBlob(e event.Event) (any, error) {
+	request := e.Data.(*shared.PutBlobRequest)
+	if request.Ctx == nil {
+		request.Ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(request.Ctx, transferTimeout)
+	defer cancel()
+	return s.service.put(ctx, request.OwnerID, request.Kind, request.RefID, request.FileName, request.ContentType, request.Data)
+}
+
+// ReadBlob 读取文件内容，返回 []byte
+func (s *PublicService) ReadBlob(e event.Event) (any, error) {
+	request := e.Data.(*shared.ReadBlobRequest)
+	if request.Ctx == nil {
+		request.Ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(request.Ctx, transferTimeout)
+	defer cancel()
+	return s.service.read(ctx, request.ID)
+}
+
+// GetBlobURL 生成签名下载地址，返回 string
+func (s *PublicService) GetBlobURL(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetBlobURLRequest)
+	if request.Ctx == nil {
+		request.Ctx = context.Background()
+	}
+	return s.service.signedURL(request.Ctx, request.ID)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		service: newService(),
+	}
+}
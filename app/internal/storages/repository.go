@@ -0,0 +1,19 @@
+package storages
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createBlobWithinQuota(ctx context.Context, blob *model.Blob, quota int64) (bool, error)
+	getBlob(ctx context.Context, id uuid.UUID) (*model.Blob, error)
+	sumSize(ctx context.Context, ownerID uuid.UUID) (int64, error)
+	getUserPlan(ctx context.Context, userID uuid.UUID) (model.SubscriptionPlan, error)
+	listOrphanBlobs(ctx context.Context, before time.Time, limit int) ([]*model.Blob, error)
+	deleteBlob(ctx context.Context, id uuid.UUID) error
+	existingKeys(ctx context.Context, keys []string) (map[string]bool, error)
+}
@@ -0,0 +1,8 @@
+package storages
+
+// UsageResponse 用户的存储用量，单位为字节
+type UsageResponse struct {
+	Used          int64 `json:"used"`
+	Quota         int64 `json:"quota"`
+	MaxObjectSize int64 `json:"maxObjectSize"`
+}
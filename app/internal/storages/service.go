@@ -0,0 +1,208 @@
+package storages
+
+import (
+	"bytes"
+	"common/biz"
+	"common/configs"
+	"context"
+	"core/storage"
+	"errors"
+	"io"
+	"mime"
+	"model"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 头像和图标可以不登录访问，只允许这些图片格式
+var publicTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+// isPublic 头像和图标通过不需要登录的地址访问
+func isPublic(kind model.BlobKind) bool {
+	return kind == model.BlobAvatar || kind == model.BlobAgentIcon
+}
+
+// put 按所有者的套餐检查大小和容量后保存对象。先在事务中检查用量并保存记录占用容量，
+// 再写存储，写入失败时删除记录
+func (s *service) put(ctx context.Context, ownerID uuid.UUID, kind model.BlobKind, refID uuid.UUID, fileName string, contentType string, data []byte) (*model.Blob, error) {
+	if store == nil {
+		return nil, biz.ErrStorageFailed
+	}
+	if contentType == "" {
+		contentType = storage.DetectContentType(fileName, data)
+	}
+	ext, ok := publicTypes[contentType]
+	if isPublic(kind) && !ok {
+		return nil, biz.ErrBlobInvalid
+	}
+	if !ok {
+		ext = strings.ToLower(path.Ext(fileName))
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 && !slices.Contains(exts, ext) {
+			ext = exts[0]
+		}
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	plan, err := s.repo.getUserPlan(dbCtx, ownerID)
+	if err != nil {
+		logs.Errorf("get user plan error: %v", err)
+		return nil, errs.DBError
+	}
+	config := configs.GetConfig().Storage
+	size := int64(len(data))
+	if size > config.GetMaxObjectSize(string(plan)) {
+		return nil, biz.ErrBlobTooLarge
+	}
+	id := uuid.New()
+	blob := &model.Blob{
+		BaseModel: model.BaseModel{
+			ID: id,
+		},
+		OwnerID:     ownerID,
+		Kind:        kind,
+		RefID:       refID,
+		Key:         string(kind) + "/" + ownerID.String() + "/" + id.String() + ext,
+		FileName:    path.Base(fileName),
+		ContentType: contentType,
+		Size:        size,
+	}
+	created, err := s.repo.createBlobWithinQuota(dbCtx, blob, config.GetQuota(string(plan)))
+	if err != nil {
+		logs.Errorf("create blob error: %v", err)
+		return nil, errs.DBError
+	}
+	if !created {
+		return nil, biz.ErrStorageQuota
+	}
+	if err := store.Put(ctx, blob.Key, data, contentType); err != nil {
+		logs.Errorf("put object %s error: %v", blob.Key, err)
+		// 删除失败时记录没有被引用，由定期清理删除
+		deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := s.repo.deleteBlob(deleteCtx, blob.ID); err != nil {
+			logs.Errorf("delete blob %s error: %v", blob.ID, err)
+		}
+		return nil, biz.ErrStorageFailed
+	}
+	return blob, nil
+}
+
+func (s *service) getBlob(ctx context.Context, id uuid.UUID) (*model.Blob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	blob, err := s.repo.getBlob(ctx, id)
+	if err != nil {
+		logs.Errorf("get blob error: %v", err)
+		return nil, errs.DBError
+	}
+	if blob == nil {
+		return nil, biz.ErrBlobNotFound
+	}
+	return blob, nil
+}
+
+// read 读取对象的全部内容
+func (s *service) read(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	if store == nil {
+		return nil, biz.ErrStorageFailed
+	}
+	blob, err := s.getBlob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reader, _, err := store.Get(ctx, blob.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, biz.ErrBlobNotFound
+		}
+		logs.Errorf("get object %s error: %v", blob.Key, err)
+		return nil, biz.ErrStorageFailed
+	}
+	defer reader.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		logs.Errorf("read object %s error: %v", blob.Key, err)
+		return nil, biz.ErrStorageFailed
+	}
+	return buf.Bytes(), nil
+}
+
+// signedURL 生成对象的签名下载地址
+func (s *service) signedURL(ctx context.Context, id uuid.UUID) (string, error) {
+	if store == nil {
+		return "", biz.ErrStorageFailed
+	}
+	blob, err := s.getBlob(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return s.blobURL(ctx, blob)
+}
+
+func (s *service) blobURL(ctx context.Context, blob *model.Blob) (string, error) {
+	u, err := store.SignedURL(ctx, blob.Key, configs.GetConfig().Storage.GetSignedURLTTL())
+	if err != nil {
+		logs.Errorf("sign object %s error: %v", blob.Key, err)
+		return "", biz.ErrStorageFailed
+	}
+	return u, nil
+}
+
+// publicURL 头像和图标的下载地址，其他对象只能通过所属的接口访问
+func (s *service) publicURL(ctx context.Context, id uuid.UUID) (string, error) {
+	if store == nil {
+		return "", biz.ErrStorageFailed
+	}
+	blob, err := s.getBlob(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if !isPublic(blob.Kind) {
+		return "", biz.ErrBlobNotFound
+	}
+	return s.blobURL(ctx, blob)
+}
+
+// usage 用户已经使用的存储空间和当前套餐的限制
+func (s *service) usage(ctx context.Context, userID uuid.UUID) (*UsageResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	plan, err := s.repo.getUserPlan(ctx, userID)
+	if err != nil {
+		logs.Errorf("get user plan error: %v", err)
+		return nil, errs.DBError
+	}
+	used, err := s.repo.sumSize(ctx, userID)
+	if err != nil {
+		logs.Errorf("sum blob size error: %v", err)
+		return nil, errs.DBError
+	}
+	config := configs.GetConfig().Storage
+	return &UsageResponse{
+		Used:          used,
+		Quota:         config.GetQuota(string(plan)),
+		MaxObjectSize: config.GetMaxObjectSize(string(plan)),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package storages
+
+import (
+	"common/biz"
+	"context"
+	"core/storage"
+	"errors"
+	"model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// blobRepo 只实现上传用到的方法，按 quota 检查已经保存的记录
+type blobRepo struct {
+	repository
+	blobs   []*model.Blob
+	deleted []uuid.UUID
+}
+
+func (f *blobRepo) getUserPlan(ctx context.Context, userID uuid.UUID) (model.SubscriptionPlan, error) {
+	return model.FreePlan, nil
+}
+
+func (f *blobRepo) createBlobWithinQuota(ctx context.Context, blob *model.Blob, quota int64) (bool, error) {
+	var used int64
+	for _, b := range f.blobs {
+		used += b.Size
+	}
+	if used+blob.Size > quota {
+		return false, nil
+	}
+	f.blobs = append(f.blobs, blob)
+	return true, nil
+}
+
+func (f *blobRepo) deleteBlob(ctx context.Context, id uuid.UUID) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+// failingStore 写入总是失败的存储
+type failingStore struct {
+	storage.Storage
+}
+
+func (failingStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return errors.New("disk full")
+}
+
+func useStore(t *testing.T, s storage.Storage) {
+	t.Helper()
+	old := store
+	store = s
+	t.Cleanup(func() { store = old })
+}
+
+func TestPutReservesQuota(t *testing.T) {
+	local, err := storage.NewLocal(storage.LocalOptions{Root: t.TempDir(), BaseURL: "http://localhost/api/v1/files", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	useStore(t, local)
+	ownerID := uuid.New()
+	// 未配置时 free 套餐的容量是 100MB
+	repo := &blobRepo{blobs: []*model.Blob{{OwnerID: ownerID, Size: 100<<20 - 4}}}
+	s := &service{repo: repo}
+	blob, err := s.put(context.Background(), ownerID, model.BlobAttachment, uuid.New(), "a.txt", "text/plain", []byte("abcd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.blobs) != 2 || repo.blobs[1] != blob {
+		t.Fatalf("blobs = %v", repo.blobs)
+	}
+	if _, err := s.put(context.Background(), ownerID, model.BlobAttachment, uuid.New(), "b.txt", "text/plain", []byte("e")); !errors.Is(err, biz.ErrStorageQuota) {
+		t.Fatalf("err = %v, want ErrStorageQuota", err)
+	}
+	if len(repo.blobs) != 2 {
+		t.Errorf("blob over quota should not be saved")
+	}
+}
+
+func TestPutReleasesQuotaOnStoreError(t *testing.T) {
+	useStore(t, failingStore{})
+	repo := &blobRepo{}
+	s := &service{repo: repo}
+	_, err := s.put(context.Background(), uuid.New(), model.BlobAttachment, uuid.New(), "a.txt", "text/plain", []byte("abcd"))
+	if !errors.Is(err, biz.ErrStorageFailed) {
+		t.Fatalf("err = %v, want ErrStorageFailed", err)
+	}
+	if len(repo.blobs) != 1 || len(repo.deleted) != 1 || repo.deleted[0] != repo.blobs[0].ID {
+		t.Errorf("blob record should be deleted, blobs = %v, deleted = %v", repo.blobs, repo.deleted)
+	}
+}
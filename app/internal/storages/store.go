@@ -0,0 +1,64 @@
+package storages
+
+import (
+	"common/configs"
+	"core/storage"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// filesPath 本地存储的签名下载接口
+const filesPath = "/api/v1/files"
+
+// signingInfo 从 secret.key 派生下载签名密钥时使用的 HKDF info，和加密凭证的密钥分开
+const signingInfo = "faber storage signed url"
+
+// store 按配置创建的文件存储
+var store storage.Storage
+
+// Init 按配置创建文件存储，失败时服务不能启动。
+// 本地存储用从 secret.key 派生的密钥签名下载地址
+func Init() error {
+	config := configs.GetConfig().Storage
+	options := storage.Options{Driver: config.GetDriver()}
+	switch options.Driver {
+	case "local":
+		key, err := signingKey(configs.GetConfig().Secret)
+		if err != nil {
+			return err
+		}
+		local := config.GetLocal()
+		options.Local = storage.LocalOptions{Root: local.Root, BaseURL: local.BaseURL + filesPath, Secret: key}
+	case "s3":
+		s3 := config.GetS3()
+		options.S3 = storage.S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			PathStyle: s3.PathStyle,
+		}
+	}
+	s, err := storage.New(options)
+	if err != nil {
+		return err
+	}
+	store = s
+	return nil
+}
+
+// signingKey 用 HKDF 从 secret.key 派生签名密钥，密钥没有配置或者是示例密钥时返回错误
+func signingKey(secret *configs.Secret) (string, error) {
+	if err := secret.Validate(); err != nil {
+		return "", err
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret.GetKey()), nil, []byte(signingInfo)), key); err != nil {
+		return "", fmt.Errorf("derive storage signing key: %w", err)
+	}
+	return string(key), nil
+}
@@ -0,0 +1,29 @@
+package storages
+
+import (
+	"common/configs"
+	"testing"
+)
+
+func TestSigningKey(t *testing.T) {
+	for _, key := range []string{"", "faber-ai-secret!"} {
+		if _, err := signingKey(&configs.Secret{Key: &key}); err == nil {
+			t.Errorf("signingKey(%q) should fail", key)
+		}
+	}
+	if _, err := signingKey(nil); err == nil {
+		t.Error("signingKey(nil) should fail")
+	}
+	secret := "0123456789abcdef"
+	key, err := signingKey(&configs.Secret{Key: &secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 || key == secret {
+		t.Errorf("signing key should be derived from secret.key, got %x", key)
+	}
+	again, _ := signingKey(&configs.Secret{Key: &secret})
+	if again != key {
+		t.Error("signing key should be stable across restarts")
+	}
+}
@@ -1,6 +1,7 @@
 package subscriptions
 
 import (
+	"common/configs"
 	"model"
 	"time"
 
@@ -17,7 +18,7 @@ func (h Handler) GetUserSubscription(context *gin.Context) {
 		Configs: &model.PlanConfig{
 			MaxAgents:            10,
 			MaxKnowledgeBaseSize: 10,
-			MaxWorkflows:         10,
+			MaxWorkflows:         configs.GetConfig().Workflow.GetMaxWorkflows(string(model.FreePlan)),
 		},
 		ID:            uuid.New(),
 		UserID:        uuid.New(),
@@ -122,8 +122,139 @@ func (h *Handler) GetMcpTools(c *gin.Context) {
 	res.Success(c, tools)
 }
 
+func (h *Handler) GetMcpStatus(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	statuses, err := h.service.getMcpStatus(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, statuses)
+}
+
+func (h *Handler) GetMcpPrompts(c *gin.Context) {
+	var mcpId uuid.UUID
+	if err := req.Path(c, "mcpId", &mcpId); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	prompts, err := h.service.getMcpPrompts(c.Request.Context(), userID, mcpId)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, prompts)
+}
+
+func (h *Handler) ImportOpenApi(c *gin.Context) {
+	var importReq ImportOpenApiReq
+	if err := req.JsonParam(c, &importReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.service.importOpenApi(c.Request.Context(), userID, importReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, resp)
+}
+
 func NewHandler() *Handler {
 	return &Handler{
 		service: newService(),
 	}
 }
+
+func (h *Handler) UpdateVisibility(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var visibilityReq UpdateVisibilityReq
+	if err := req.JsonParam(c, &visibilityReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	tool, err := h.service.updateVisibility(c.Request.Context(), userID, id, visibilityReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, tool)
+}
+
+func (h *Handler) ListCatalog(c *gin.Context) {
+	var catalogReq CatalogReq
+	if err := req.QueryParam(c, &catalogReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	page, err := h.service.listCatalog(c.Request.Context(), userID, catalogReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, page)
+}
+
+func (h *Handler) InstallTool(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	tool, err := h.service.installTool(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, tool)
+}
+
+func (h *Handler) UninstallTool(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.uninstallTool(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+func (h *Handler) ListInstalledTools(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listInstalledTools(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
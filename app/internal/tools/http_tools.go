@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"common/biz"
+	"common/secrets"
+	"context"
+	"core/ai/httptools"
+	"errors"
+	"model"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// http 工具允许的最长超时时间
+const maxHttpTimeoutSeconds = 300
+
+var httpMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// validateHttpConfig 校验http工具配置，路径中出现的参数自动标记为 path 参数
+func validateHttpConfig(config *model.HttpConfig, params model.ParametersSchema, credential string) error {
+	config.Method = strings.ToUpper(config.Method)
+	if config.Method == "" {
+		config.Method = http.MethodGet
+	}
+	if !slices.Contains(httpMethods, config.Method) {
+		return biz.ErrHttpConfigInvalid
+	}
+	u, err := url.Parse(config.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return biz.ErrHttpConfigInvalid
+	}
+	if config.ParamLocations == nil {
+		config.ParamLocations = make(map[string]string)
+	}
+	for name, location := range config.ParamLocations {
+		if _, ok := params[name]; !ok {
+			return biz.ErrHttpConfigInvalid
+		}
+		switch location {
+		case httptools.InPath, httptools.InQuery, httptools.InBody:
+		case httptools.InHeader:
+			if !isValidHeaderName(name) {
+				return biz.ErrHttpConfigInvalid
+			}
+		default:
+			return biz.ErrHttpConfigInvalid
+		}
+	}
+	for _, match := range httpPathParams(config.Url) {
+		param, ok := params[match]
+		if !ok {
+			return biz.ErrHttpConfigInvalid
+		}
+		param.Required = true
+		config.ParamLocations[match] = httptools.InPath
+	}
+	for name := range config.Headers {
+		if !isValidHeaderName(name) {
+			return biz.ErrHttpConfigInvalid
+		}
+	}
+	if config.ResponsePath != "" {
+		if err := httptools.ValidatePath(config.ResponsePath); err != nil {
+			return biz.ErrHttpConfigInvalid
+		}
+	}
+	if config.TimeoutSeconds < 0 || config.TimeoutSeconds > maxHttpTimeoutSeconds {
+		return biz.ErrHttpConfigInvalid
+	}
+	switch config.AuthType {
+	case "":
+		config.HeaderName = ""
+	case model.HttpAuthBearer, model.HttpAuthBasic:
+		if credential == "" {
+			return biz.ErrHttpConfigInvalid
+		}
+		config.HeaderName = ""
+	case model.HttpAuthHeader:
+		if credential == "" || !isValidHeaderName(config.HeaderName) {
+			return biz.ErrHttpConfigInvalid
+		}
+	default:
+		return biz.ErrHttpConfigInvalid
+	}
+	return nil
+}
+
+// httpPathParams 返回地址中 {name} 形式的路径参数
+func httpPathParams(rawUrl string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(rawUrl, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(rawUrl[start:], '}')
+		if end < 0 {
+			return names
+		}
+		names = append(names, rawUrl[start+1:start+end])
+		rawUrl = rawUrl[start+end+1:]
+	}
+}
+
+func (s *service) importOpenApi(ctx context.Context, userId uuid.UUID, req ImportOpenApiReq) (*ImportOpenApiResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	operations, err := httptools.ParseOpenAPI([]byte(req.Spec), req.BaseUrl)
+	if err != nil {
+		logs.Warnf("parse openapi error: %v", err)
+		return nil, biz.ErrOpenApiInvalid
+	}
+	credential, err := secrets.Encrypt(req.Credential)
+	if err != nil {
+		logs.Errorf("encrypt http credential error: %v", err)
+		return nil, biz.ErrCredentialEncrypt
+	}
+	// 先校验全部接口，任何一个不合法都不导入
+	var tools []*model.Tool
+	for _, operation := range operations {
+		if len(req.OperationIds) > 0 && !slices.Contains(req.OperationIds, operation.OperationId) {
+			continue
+		}
+		config := &model.HttpConfig{
+			Method:         operation.Method,
+			Url:            operation.Url,
+			Headers:        req.Headers,
+			ParamLocations: operation.ParamLocations,
+			OperationId:    operation.OperationId,
+			AuthType:       req.AuthType,
+			HeaderName:     req.HeaderName,
+		}
+		params := model.ParametersSchema(operation.Params)
+		if err := validateHttpConfig(config, params, req.Credential); err != nil {
+			return nil, err
+		}
+		config.Credential = credential
+		tools = append(tools, &model.Tool{
+			BaseModel: model.BaseModel{
+				ID: uuid.New(),
+			},
+			CreatorID:        userId,
+			Name:             operation.Name,
+			Description:      operation.Description,
+			ToolType:         model.HttpToolType,
+			IsEnable:         true,
+			Visibility:       model.ToolPrivate,
+			ParametersSchema: params,
+			HttpConfig:       config,
+		})
+	}
+	resp := &ImportOpenApiResponse{Tools: []*model.Tool{}, Skipped: []string{}}
+	var creates []*model.Tool
+	for _, tool := range tools {
+		existed, err := s.repo.getToolByName(ctx, userId, tool.Name)
+		if err != nil {
+			logs.Errorf("get tool by name error: %v", err)
+			return nil, errs.DBError
+		}
+		if existed != nil || slices.ContainsFunc(creates, func(t *model.Tool) bool { return t.Name == tool.Name }) {
+			resp.Skipped = append(resp.Skipped, tool.Name)
+			continue
+		}
+		creates = append(creates, tool)
+	}
+	if len(creates) == 0 {
+		return resp, nil
+	}
+	// 在一个事务中创建，避免只导入了一部分
+	err = s.repo.createTools(ctx, creates)
+	if errors.Is(err, errNameConflict) {
+		return nil, biz.ErrToolNameExisted
+	}
+	if err != nil {
+		logs.Errorf("create tools error: %v", err)
+		return nil, errs.DBError
+	}
+	for _, tool := range creates {
+		tool.MaskCredentials()
+		resp.Tools = append(resp.Tools, tool)
+	}
+	return resp, nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"common/biz"
+	"context"
+	"errors"
+	"model"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepo 只实现导入用到的方法，其它方法调用时 panic
+type fakeRepo struct {
+	repository
+	existing []string
+	created  [][]*model.Tool
+}
+
+func (f *fakeRepo) getToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error) {
+	if slices.Contains(f.existing, name) {
+		return &model.Tool{Name: name}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeRepo) createTools(ctx context.Context, tools []*model.Tool) error {
+	f.created = append(f.created, tools)
+	return nil
+}
+
+const importSpec = `
+openapi: 3.0.0
+servers:
+  - url: https://api.example.com
+paths:
+  /a:
+    get:
+      operationId: opA
+  /b:
+    get:
+      operationId: opB
+  /c:
+    get:
+      operationId: opA
+`
+
+func TestImportOpenApiCreatesInOneBatch(t *testing.T) {
+	repo := &fakeRepo{existing: []string{"opB"}}
+	s := &service{repo: repo}
+	resp, err := s.importOpenApi(context.Background(), uuid.New(), ImportOpenApiReq{Spec: importSpec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.created) != 1 {
+		t.Fatalf("createTools called %d times, want 1", len(repo.created))
+	}
+	if len(resp.Tools) != 1 || resp.Tools[0].Name != "opA" {
+		t.Fatalf("created %v, want only opA", resp.Tools)
+	}
+	// opB 已存在，第二个 opA 和第一个重名
+	if !slices.Equal(resp.Skipped, []string{"opB", "opA"}) {
+		t.Fatalf("skipped = %v", resp.Skipped)
+	}
+}
+
+func TestImportOpenApiValidatesBeforeCreating(t *testing.T) {
+	repo := &fakeRepo{}
+	s := &service{repo: repo}
+	// 第一个接口合法，第二个接口的路径参数没有声明
+	spec := `
+openapi: 3.0.0
+servers:
+  - url: https://api.example.com
+paths:
+  /a:
+    get:
+      operationId: opA
+  /z/{id}:
+    get:
+      operationId: opZ
+`
+	_, err := s.importOpenApi(context.Background(), uuid.New(), ImportOpenApiReq{Spec: spec})
+	if !errors.Is(err, biz.ErrHttpConfigInvalid) {
+		t.Fatalf("err = %v, want ErrHttpConfigInvalid", err)
+	}
+	if len(repo.created) != 0 {
+		t.Fatalf("nothing should be created, got %v", repo.created)
+	}
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"common/biz"
+	"context"
+	"model"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+	"github.com/mszlu521/thunder/res"
+)
+
+// updateVisibility 修改工具的可见范围，设置为组织内可见时需要创建者属于某个组织
+func (s *service) updateVisibility(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateVisibilityReq) (*model.Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	toolInfo, err := s.repo.getTool(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get tool error: %v", err)
+		return nil, errs.DBError
+	}
+	if toolInfo == nil {
+		return nil, biz.ErrToolNotExisted
+	}
+	// 系统工具每个用户都可以直接添加，不需要共享
+	if toolInfo.ToolType == model.SystemToolType && req.Visibility != model.ToolPrivate {
+		return nil, biz.ErrToolNotInstallable
+	}
+	// 数据库工具使用创建者的数据源连接查询，共享后其他用户就能读取创建者的数据
+	if toolInfo.ToolType == model.DatabaseToolType && req.Visibility != model.ToolPrivate {
+		return nil, biz.ErrToolNotShareable
+	}
+	switch req.Visibility {
+	case model.ToolPrivate, model.ToolPublic:
+		toolInfo.OrgID = nil
+	case model.ToolOrg:
+		orgID, err := s.repo.getUserOrgID(ctx, userID)
+		if err != nil {
+			logs.Errorf("get user org error: %v", err)
+			return nil, errs.DBError
+		}
+		if orgID == nil {
+			return nil, biz.ErrUserNoOrg
+		}
+		toolInfo.OrgID = orgID
+	default:
+		return nil, biz.ErrToolVisibility
+	}
+	toolInfo.Visibility = req.Visibility
+	toolInfo.UpdatedAt = time.Now()
+	if err := s.repo.updateVisibility(ctx, toolInfo); err != nil {
+		logs.Errorf("update tool visibility error: %v", err)
+		return nil, errs.DBError
+	}
+	toolInfo.MaskCredentials()
+	return toolInfo, nil
+}
+
+// listCatalog 浏览其他用户共享的工具
+func (s *service) listCatalog(ctx context.Context, userID uuid.UUID, req CatalogReq) (*res.Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := toolFilter{
+		Name:     req.Keyword,
+		ToolType: req.Type,
+		Limit:    req.PageSize,
+		Offset:   (req.Page - 1) * req.PageSize,
+	}
+	toolList, total, err := s.repo.listCatalog(ctx, userID, filter)
+	if err != nil {
+		logs.Errorf("list tool catalog error: %v", err)
+		return nil, errs.DBError
+	}
+	ids := make([]uuid.UUID, len(toolList))
+	for i, t := range toolList {
+		ids[i] = t.ID
+	}
+	var installed []uuid.UUID
+	if len(ids) > 0 {
+		if installed, err = s.repo.getInstalledToolIds(ctx, userID, ids); err != nil {
+			logs.Errorf("get installed tools error: %v", err)
+			return nil, errs.DBError
+		}
+	}
+	list := make([]*CatalogTool, len(toolList))
+	for i, t := range toolList {
+		list[i] = newCatalogTool(t, slices.Contains(installed, t.ID))
+	}
+	return &res.Page{
+		List:        list,
+		Total:       total,
+		CurrentPage: int64(req.Page),
+		PageSize:    int64(req.PageSize),
+	}, nil
+}
+
+// installTool 安装共享的工具，之后可以关联到自己的agent上，调用时使用创建者保存的配置和凭证
+func (s *service) installTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*CatalogTool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	toolInfo, err := s.repo.getVisibleTool(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get tool error: %v", err)
+		return nil, errs.DBError
+	}
+	if toolInfo == nil {
+		return nil, biz.ErrToolNotExisted
+	}
+	if toolInfo.CreatorID == userID {
+		return nil, biz.ErrToolNotInstallable
+	}
+	// agent 中的工具按名称调用，不能和自己的工具或者已安装的工具重名
+	owned, err := s.repo.getToolByName(ctx, userID, toolInfo.Name)
+	if err != nil {
+		logs.Errorf("get tool by name error: %v", err)
+		return nil, errs.DBError
+	}
+	installed, err := s.repo.getInstalledToolByName(ctx, userID, toolInfo.Name)
+	if err != nil {
+		logs.Errorf("get installed tool by name error: %v", err)
+		return nil, errs.DBError
+	}
+	if owned != nil || (installed != nil && installed.ID != toolInfo.ID) {
+		return nil, biz.ErrToolNameExisted
+	}
+	if installed == nil {
+		if err := s.repo.installTool(ctx, userID, toolInfo.ID); err != nil {
+			logs.Errorf("install tool error: %v", err)
+			return nil, errs.DBError
+		}
+		toolInfo.InstallCount++
+	}
+	return newCatalogTool(toolInfo, true), nil
+}
+
+func (s *service) uninstallTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.uninstallTool(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("uninstall tool error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrToolNotExisted
+	}
+	return nil
+}
+
+func (s *service) listInstalledTools(ctx context.Context, userID uuid.UUID) ([]*CatalogTool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	toolList, err := s.repo.listInstalledTools(ctx, userID)
+	if err != nil {
+		logs.Errorf("list installed tools error: %v", err)
+		return nil, errs.DBError
+	}
+	list := make([]*CatalogTool, len(toolList))
+	for i, t := range toolList {
+		list[i] = newCatalogTool(t, true)
+	}
+	return list, nil
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"common/biz"
+	"context"
+	"errors"
+	"fmt"
+	"model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// visibilityRepo 只实现修改可见范围和创建工具用到的方法
+type visibilityRepo struct {
+	repository
+	tool      *model.Tool
+	orgID     *uuid.UUID
+	updated   *model.Tool
+	createErr error
+}
+
+func (f *visibilityRepo) getTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Tool, error) {
+	return f.tool, nil
+}
+
+func (f *visibilityRepo) getUserOrgID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	return f.orgID, nil
+}
+
+func (f *visibilityRepo) updateVisibility(ctx context.Context, tool *model.Tool) error {
+	f.updated = tool
+	return nil
+}
+
+func (f *visibilityRepo) getToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error) {
+	return nil, nil
+}
+
+func (f *visibilityRepo) createTool(ctx context.Context, tool *model.Tool) error {
+	return f.createErr
+}
+
+func TestUpdateVisibility(t *testing.T) {
+	orgID := uuid.New()
+	tests := []struct {
+		name       string
+		toolType   model.ToolType
+		orgID      *uuid.UUID
+		visibility model.ToolVisibility
+		err        error
+	}{
+		{"http public", model.HttpToolType, nil, model.ToolPublic, nil},
+		{"mcp org", model.McpToolType, &orgID, model.ToolOrg, nil},
+		{"org without org", model.HttpToolType, nil, model.ToolOrg, biz.ErrUserNoOrg},
+		{"unknown visibility", model.HttpToolType, nil, "everyone", biz.ErrToolVisibility},
+		{"system public", model.SystemToolType, nil, model.ToolPublic, biz.ErrToolNotInstallable},
+		{"database public", model.DatabaseToolType, nil, model.ToolPublic, biz.ErrToolNotShareable},
+		{"database org", model.DatabaseToolType, &orgID, model.ToolOrg, biz.ErrToolNotShareable},
+		{"database private", model.DatabaseToolType, nil, model.ToolPrivate, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &visibilityRepo{
+				tool:  &model.Tool{ToolType: tt.toolType, Visibility: model.ToolPrivate},
+				orgID: tt.orgID,
+			}
+			s := &service{repo: repo}
+			_, err := s.updateVisibility(context.Background(), uuid.New(), uuid.New(), UpdateVisibilityReq{Visibility: tt.visibility})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil && repo.updated != nil {
+				t.Fatal("visibility should not be saved")
+			}
+			if tt.err == nil && (repo.updated == nil || repo.updated.Visibility != tt.visibility) {
+				t.Fatalf("updated = %+v", repo.updated)
+			}
+		})
+	}
+}
+
+func TestNameConflict(t *testing.T) {
+	conflict := &pgconn.PgError{Code: "23505", ConstraintName: "idx_tools_creator_name"}
+	if err := nameConflict(fmt.Errorf("create: %w", conflict)); !errors.Is(err, errNameConflict) {
+		t.Errorf("nameConflict() = %v, want errNameConflict", err)
+	}
+	other := &pgconn.PgError{Code: "23505", ConstraintName: "tools_pkey"}
+	if err := nameConflict(other); err != other {
+		t.Errorf("nameConflict() = %v, want original error", err)
+	}
+	if err := nameConflict(nil); err != nil {
+		t.Errorf("nameConflict(nil) = %v", err)
+	}
+}
+
+// 并发创建同名工具时，查询时还不存在，由唯一索引拒绝
+func TestCreateToolNameConflict(t *testing.T) {
+	s := &service{repo: &visibilityRepo{createErr: errNameConflict}}
+	_, err := s.createTool(context.Background(), uuid.New(), CreateToolReq{
+		Name:       "search",
+		ToolType:   model.HttpToolType,
+		HttpConfig: &model.HttpConfig{Method: "GET", Url: "https://api.example.com/search"},
+	})
+	if !errors.Is(err, biz.ErrToolNameExisted) {
+		t.Fatalf("err = %v, want ErrToolNameExisted", err)
+	}
+}
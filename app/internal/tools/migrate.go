@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"common/secrets"
+	"context"
+	"model"
+	"time"
+
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// MigrateLegacyCredentials 旧版本在 McpConfig.CredentialType 中直接保存 bearer token，
+// 启动时把 token 加密后移到 Credential，CredentialType 改为 bearer，之后不再识别这种格式
+func MigrateLegacyCredentials() {
+	repo := newModels(database.GetPostgresDB().GormDB)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tools, err := repo.listLegacyMcpTools(ctx)
+	if err != nil {
+		logs.Errorf("list legacy mcp tools error: %v", err)
+		return
+	}
+	for _, tool := range tools {
+		credential, err := secrets.Encrypt(tool.McpConfig.CredentialType)
+		if err != nil {
+			logs.Errorf("encrypt legacy mcp credential of tool %s error: %v", tool.ID, err)
+			continue
+		}
+		tool.McpConfig.Credential = credential
+		tool.McpConfig.CredentialType = model.McpCredentialBearer
+		tool.McpConfig.AuthenticationRequired = true
+		if err := repo.updateMcpConfig(ctx, tool); err != nil {
+			logs.Errorf("migrate legacy mcp credential of tool %s error: %v", tool.ID, err)
+		}
+	}
+	if len(tools) > 0 {
+		logs.Infof("migrated %d legacy mcp credentials", len(tools))
+	}
+}
@@ -2,20 +2,36 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"model"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/mszlu521/thunder/gorms"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type models struct {
 	db *gorm.DB
 }
 
+// errNameConflict 同一个用户下的工具重名，并发创建时由唯一索引兜底
+var errNameConflict = errors.New("tool name conflict")
+
+// nameConflict 把唯一索引冲突转为 errNameConflict
+func nameConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_tools_creator_name" {
+		return errNameConflict
+	}
+	return err
+}
+
 func (m *models) getToolsByIds(ctx context.Context, ids []uuid.UUID) ([]*model.Tool, error) {
 	var tools []*model.Tool
-	return tools, m.db.WithContext(ctx).Where("id in ?", ids).Find(&tools).Error
+	return tools, m.db.WithContext(ctx).Preload("DataSource").Where("id in ?", ids).Find(&tools).Error
 }
 
 func (m *models) deleteTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
@@ -24,7 +40,7 @@ func (m *models) deleteTool(ctx context.Context, userID uuid.UUID, id uuid.UUID)
 
 func (m *models) getTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Tool, error) {
 	var tool model.Tool
-	err := m.db.WithContext(ctx).Where("id = ? and creator_id=?", id, userID).First(&tool).Error
+	err := m.db.WithContext(ctx).Preload("DataSource").Where("id = ? and creator_id=?", id, userID).First(&tool).Error
 	if gorms.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
@@ -32,7 +48,21 @@ func (m *models) getTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*
 }
 
 func (m *models) updateTool(ctx context.Context, info *model.Tool) error {
-	return m.db.WithContext(ctx).Updates(info).Error
+	return nameConflict(m.db.WithContext(ctx).Updates(info).Error)
+}
+
+// listLegacyMcpTools 在 credentialType 中直接保存 token 的旧MCP工具
+func (m *models) listLegacyMcpTools(ctx context.Context) ([]*model.Tool, error) {
+	var tools []*model.Tool
+	err := m.db.WithContext(ctx).Unscoped().
+		Where("tool_type = ? AND COALESCE(mcp_config->>'credentialType', '') NOT IN ?", model.McpToolType,
+			[]string{"", model.McpCredentialBearer, model.McpCredentialHeader, model.McpCredentialOAuth2}).
+		Find(&tools).Error
+	return tools, err
+}
+
+func (m *models) updateMcpConfig(ctx context.Context, tool *model.Tool) error {
+	return m.db.WithContext(ctx).Unscoped().Model(tool).Select("mcp_config").Updates(tool).Error
 }
 
 func (m *models) listTools(ctx context.Context, userID uuid.UUID, filter toolFilter) ([]*model.Tool, int64, error) {
@@ -60,9 +90,9 @@ type toolFilter struct {
 	Offset   int
 }
 
-func (m *models) getToolByName(ctx context.Context, name string) (*model.Tool, error) {
+func (m *models) getToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error) {
 	var tool model.Tool
-	err := m.db.WithContext(ctx).Where("name = ?", name).First(&tool).Error
+	err := m.db.WithContext(ctx).Where("creator_id = ? and name = ?", userID, name).First(&tool).Error
 	if gorms.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
@@ -70,7 +100,150 @@ func (m *models) getToolByName(ctx context.Context, name string) (*model.Tool, e
 }
 
 func (m *models) createTool(ctx context.Context, tool *model.Tool) error {
-	return m.db.WithContext(ctx).Create(tool).Error
+	return nameConflict(m.db.WithContext(ctx).Create(tool).Error)
+}
+
+// createTools 在一个事务中批量创建，任何一个失败全部回滚
+func (m *models) createTools(ctx context.Context, tools []*model.Tool) error {
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, tool := range tools {
+			if err := tx.Create(tool).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return nameConflict(err)
+}
+
+// visibleCondition 其他用户可以看到的工具：公开的，或者和当前用户在同一组织内可见的。
+// 数据库工具使用创建者的数据源连接，即使之前被共享过也不对其他用户开放
+const visibleCondition = "(tools.tool_type <> 'database' AND (tools.visibility = 'public' OR (tools.visibility = 'org' AND tools.org_id = (SELECT org_id FROM users WHERE users.id = ?))))"
+
+// installedCondition 当前用户已安装的工具
+const installedCondition = "tools.id IN (SELECT tool_id FROM tool_installs WHERE user_id = ?)"
+
+func (m *models) getUsableToolsByIds(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.Tool, error) {
+	var tools []*model.Tool
+	err := m.db.WithContext(ctx).Preload("DataSource").
+		Where("tools.id in ?", ids).
+		Where(m.db.Where("tools.creator_id = ?", userID).Or(installedCondition+" AND "+visibleCondition, userID, userID)).
+		Find(&tools).Error
+	return tools, err
+}
+
+// getUsableToolsByNames 自己创建的工具排在安装的工具前面，同名时优先使用自己的
+func (m *models) getUsableToolsByNames(ctx context.Context, userID uuid.UUID, names []string) ([]*model.Tool, error) {
+	var tools []*model.Tool
+	err := m.db.WithContext(ctx).
+		Where("tools.name in ?", names).
+		Where(m.db.Where("tools.creator_id = ?", userID).Or(installedCondition+" AND "+visibleCondition, userID, userID)).
+		Order(clause.Expr{SQL: "tools.creator_id = ? DESC", Vars: []any{userID}}).
+		Find(&tools).Error
+	return tools, err
+}
+
+func (m *models) getUserOrgID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("org_id").Where("id = ?", userID).First(&user).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return user.OrgID, err
+}
+
+func (m *models) updateVisibility(ctx context.Context, tool *model.Tool) error {
+	return m.db.WithContext(ctx).Model(tool).Select("visibility", "org_id", "updated_at").Updates(tool).Error
+}
+
+func (m *models) listCatalog(ctx context.Context, userID uuid.UUID, filter toolFilter) ([]*model.Tool, int64, error) {
+	var tools []*model.Tool
+	var count int64
+	query := m.db.WithContext(ctx).Model(&model.Tool{}).
+		Where("tools.creator_id <> ?", userID).
+		Where(visibleCondition, userID)
+	if filter.Name != "" {
+		query = query.Where("(tools.name ILIKE ? OR tools.description ILIKE ?)", "%"+filter.Name+"%", "%"+filter.Name+"%")
+	}
+	if filter.ToolType != "" {
+		query = query.Where("tools.tool_type = ?", filter.ToolType)
+	}
+	query = query.Count(&count)
+	if filter.Limit != 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	err := query.Order("tools.install_count desc, tools.created_at desc").Find(&tools).Error
+	return tools, count, err
+}
+
+func (m *models) getVisibleTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Tool, error) {
+	var tool model.Tool
+	err := m.db.WithContext(ctx).Where("tools.id = ?", id).Where(visibleCondition, userID).First(&tool).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &tool, err
+}
+
+func (m *models) getInstalledToolIds(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	var installed []uuid.UUID
+	err := m.db.WithContext(ctx).Model(&model.ToolInstall{}).
+		Where("user_id = ? and tool_id in ?", userID, ids).
+		Pluck("tool_id", &installed).Error
+	return installed, err
+}
+
+func (m *models) getInstalledToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error) {
+	var tool model.Tool
+	err := m.db.WithContext(ctx).Where(installedCondition, userID).Where("tools.name = ?", name).First(&tool).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &tool, err
+}
+
+func (m *models) listInstalledTools(ctx context.Context, userID uuid.UUID) ([]*model.Tool, error) {
+	var tools []*model.Tool
+	err := m.db.WithContext(ctx).
+		Where(installedCondition, userID).
+		Where(visibleCondition, userID).
+		Order("tools.name").
+		Find(&tools).Error
+	return tools, err
+}
+
+// installTool 重复安装时不做任何修改
+func (m *models) installTool(ctx context.Context, userID uuid.UUID, toolID uuid.UUID) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ToolInstall{
+			UserID:    userID,
+			ToolID:    toolID,
+			CreatedAt: time.Now(),
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&model.Tool{}).Where("id = ?", toolID).
+			UpdateColumn("install_count", gorm.Expr("install_count + 1")).Error
+	})
+}
+
+// uninstallTool 同时解除该用户的agent和这个工具的关联
+func (m *models) uninstallTool(ctx context.Context, userID uuid.UUID, toolID uuid.UUID) (int64, error) {
+	var rows int64
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? and tool_id = ?", userID, toolID).Delete(&model.ToolInstall{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rows = result.RowsAffected
+		if err := tx.Model(&model.Tool{}).Where("id = ? and install_count > 0", toolID).
+			UpdateColumn("install_count", gorm.Expr("install_count - 1")).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM agent_tools WHERE tool_id = ? AND agent_id IN (SELECT id FROM agents WHERE creator_id = ?)", toolID, userID).Error
+	})
+	return rows, err
 }
 
 func newModels(db *gorm.DB) *models {
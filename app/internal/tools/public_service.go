@@ -5,6 +5,7 @@ import (
 	"context"
 	"model"
 
+	"github.com/google/uuid"
 	"github.com/mszlu521/thunder/database"
 	"github.com/mszlu521/thunder/event"
 )
@@ -18,10 +19,21 @@ func (s *PublicService) GetToolsByIds(e event.Event) (any, error) {
 	if len(request.Ids) == 0 {
 		return []*model.Tool{}, nil
 	}
+	if request.UserID != uuid.Nil {
+		return s.repo.getUsableToolsByIds(context.Background(), request.UserID, request.Ids)
+	}
 	toolsList, err := s.repo.getToolsByIds(context.Background(), request.Ids)
 	return toolsList, err
 }
 
+func (s *PublicService) GetToolsByNames(e event.Event) (any, error) {
+	request := e.Data.(*shared.GetToolsByNamesRequest)
+	if len(request.Names) == 0 {
+		return []*model.Tool{}, nil
+	}
+	return s.repo.getUsableToolsByNames(context.Background(), request.UserID, request.Names)
+}
+
 func NewPublicService() *PublicService {
 	return &PublicService{
 		repo: newModels(database.GetPostgresDB().GormDB),
@@ -8,11 +8,25 @@ import (
 )
 
 type repository interface {
-	getToolByName(ctx context.Context, name string) (*model.Tool, error)
+	getToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error)
 	createTool(ctx context.Context, m *model.Tool) error
+	createTools(ctx context.Context, tools []*model.Tool) error
 	listTools(ctx context.Context, userID uuid.UUID, filter toolFilter) ([]*model.Tool, int64, error)
 	getTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Tool, error)
 	updateTool(ctx context.Context, info *model.Tool) error
 	deleteTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
 	getToolsByIds(ctx context.Context, ids []uuid.UUID) ([]*model.Tool, error)
+	getUsableToolsByIds(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*model.Tool, error)
+	getUsableToolsByNames(ctx context.Context, userID uuid.UUID, names []string) ([]*model.Tool, error)
+	getUserOrgID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error)
+	updateVisibility(ctx context.Context, tool *model.Tool) error
+	listCatalog(ctx context.Context, userID uuid.UUID, filter toolFilter) ([]*model.Tool, int64, error)
+	getVisibleTool(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Tool, error)
+	getInstalledToolIds(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error)
+	getInstalledToolByName(ctx context.Context, userID uuid.UUID, name string) (*model.Tool, error)
+	listInstalledTools(ctx context.Context, userID uuid.UUID) ([]*model.Tool, error)
+	installTool(ctx context.Context, userID uuid.UUID, toolID uuid.UUID) error
+	uninstallTool(ctx context.Context, userID uuid.UUID, toolID uuid.UUID) (int64, error)
+	listLegacyMcpTools(ctx context.Context) ([]*model.Tool, error)
+	updateMcpConfig(ctx context.Context, tool *model.Tool) error
 }
@@ -1,6 +1,10 @@
 package tools
 
-import "model"
+import (
+	"model"
+
+	"github.com/google/uuid"
+)
 
 type CreateToolReq struct {
 	Name        string           `json:"name"`
@@ -8,6 +12,27 @@ type CreateToolReq struct {
 	ToolType    model.ToolType   `json:"toolType"`
 	IsEnable    bool             `json:"isEnable"`
 	McpConfig   *model.McpConfig `json:"mcpConfig"`
+	// HttpConfig 和 ParametersSchema 用于手动定义一个http接口
+	HttpConfig       *model.HttpConfig      `json:"httpConfig"`
+	ParametersSchema model.ParametersSchema `json:"parametersSchema"`
+	// Credential 明文凭证，保存时加密到 McpConfig/HttpConfig 的 Credential 中
+	Credential string `json:"credential"`
+	// DataSourceId database 类型工具查询的数据源
+	DataSourceId *uuid.UUID `json:"dataSourceId"`
+}
+
+// ImportOpenApiReq 从 OpenAPI 3 文档导入http工具，每个接口生成一个工具
+type ImportOpenApiReq struct {
+	// Spec JSON 或 YAML 格式的文档内容
+	Spec string `json:"spec"`
+	// BaseUrl 为空时使用文档中的第一个 server
+	BaseUrl string `json:"baseUrl"`
+	// OperationIds 只导入指定的接口，为空导入全部
+	OperationIds []string          `json:"operationIds"`
+	Headers      map[string]string `json:"headers"`
+	AuthType     string            `json:"authType"`
+	HeaderName   string            `json:"headerName"`
+	Credential   string            `json:"credential"`
 }
 
 type ListToolsReq struct {
@@ -25,3 +50,16 @@ type UpdateToolReq struct {
 type TestToolReq struct {
 	Params map[string]interface{} `json:"params"`
 }
+
+type UpdateVisibilityReq struct {
+	// Visibility 可见范围：private、public、org
+	Visibility model.ToolVisibility `json:"visibility"`
+}
+
+type CatalogReq struct {
+	// Keyword 按名称和描述搜索
+	Keyword  string         `json:"keyword" form:"keyword"`
+	Type     model.ToolType `json:"type" form:"type"`
+	Page     int            `json:"page" form:"page"`
+	PageSize int            `json:"pageSize" form:"pageSize"`
+}
@@ -1,7 +1,68 @@
 package tools
 
+import (
+	"core/ai/mcps"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type TestToolResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Data    any    `json:"data"`
 }
+
+type McpStatusResponse struct {
+	ToolId uuid.UUID `json:"toolId"`
+	Name   string    `json:"name"`
+	mcps.ServerStatus
+}
+
+type McpPromptResponse struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Arguments   []*McpPromptArgument `json:"arguments"`
+}
+
+type McpPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+type ImportOpenApiResponse struct {
+	Tools []*model.Tool `json:"tools"`
+	// Skipped 名称已存在而跳过的接口
+	Skipped []string `json:"skipped"`
+}
+
+// CatalogTool 工具市场中展示的工具，不包含任何配置
+type CatalogTool struct {
+	ID               uuid.UUID              `json:"id"`
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description"`
+	ToolType         model.ToolType         `json:"toolType"`
+	Visibility       model.ToolVisibility   `json:"visibility"`
+	ParametersSchema model.ParametersSchema `json:"parametersSchema"`
+	CreatorID        uuid.UUID              `json:"creatorId"`
+	InstallCount     int64                  `json:"installCount"`
+	Installed        bool                   `json:"installed"`
+	CreatedAt        time.Time              `json:"createdAt"`
+}
+
+func newCatalogTool(tool *model.Tool, installed bool) *CatalogTool {
+	return &CatalogTool{
+		ID:               tool.ID,
+		Name:             tool.Name,
+		Description:      tool.Description,
+		ToolType:         tool.ToolType,
+		Visibility:       tool.Visibility,
+		ParametersSchema: tool.ParametersSchema,
+		CreatorID:        tool.CreatorID,
+		InstallCount:     tool.InstallCount,
+		Installed:        installed,
+		CreatedAt:        tool.CreatedAt,
+	}
+}
@@ -1,18 +1,27 @@
 package tools
 
 import (
+	"app/shared"
 	"common/biz"
+	"common/secrets"
 	"context"
+	"core/ai/dbquery"
+	"core/ai/httptools"
 	"core/ai/mcps"
 	"core/ai/tools"
 	"encoding/json"
+	"errors"
 	"model"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mszlu521/thunder/ai/einos"
 	"github.com/mszlu521/thunder/database"
 	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/event"
 	"github.com/mszlu521/thunder/logs"
 	"github.com/mszlu521/thunder/res"
 )
@@ -25,7 +34,7 @@ func (s *service) createTool(ctx context.Context, userId uuid.UUID, req CreateTo
 	//先查询tool名字是否存在 防止重复
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	toolInfo, err := s.repo.getToolByName(ctx, req.Name)
+	toolInfo, err := s.repo.getToolByName(ctx, userId, req.Name)
 	if err != nil {
 		logs.Errorf("get tool by name error: %v", err)
 		return nil, errs.DBError
@@ -38,20 +47,64 @@ func (s *service) createTool(ctx context.Context, userId uuid.UUID, req CreateTo
 		BaseModel: model.BaseModel{
 			ID: uuid.New(),
 		},
-		ToolType:  req.ToolType,
-		IsEnable:  true,
-		CreatorID: userId,
+		ToolType:   req.ToolType,
+		IsEnable:   true,
+		Visibility: model.ToolPrivate,
+		CreatorID:  userId,
 	}
 	//这个地方我们需要先检查tool是否存在，启动时，我们将tool注册了
 	//注意 这个地方 我们只能注册 我们系统中已经开发好的tool
-	//这个地方因为有mcp工具的存在，所以这里我们先判断一下
-	if req.ToolType == model.McpToolType {
-		if req.McpConfig != nil {
-			tool.McpConfig = req.McpConfig
+	//这个地方因为有mcp和http工具的存在，所以这里我们先判断一下
+	switch req.ToolType {
+	case model.McpToolType:
+		if req.McpConfig == nil {
+			return nil, biz.ErrMcpConfigNotExisted
 		}
+		if err := s.validateMcpConfig(req.McpConfig, req.Credential); err != nil {
+			return nil, err
+		}
+		credential, err := secrets.Encrypt(req.Credential)
+		if err != nil {
+			logs.Errorf("encrypt mcp credential error: %v", err)
+			return nil, biz.ErrCredentialEncrypt
+		}
+		req.McpConfig.Credential = credential
+		tool.McpConfig = req.McpConfig
 		tool.Name = req.Name
 		tool.Description = req.Description
-	} else {
+	case model.HttpToolType:
+		if req.HttpConfig == nil {
+			return nil, biz.ErrHttpConfigInvalid
+		}
+		if err := validateHttpConfig(req.HttpConfig, req.ParametersSchema, req.Credential); err != nil {
+			return nil, err
+		}
+		credential, err := secrets.Encrypt(req.Credential)
+		if err != nil {
+			logs.Errorf("encrypt http credential error: %v", err)
+			return nil, biz.ErrCredentialEncrypt
+		}
+		req.HttpConfig.Credential = credential
+		tool.HttpConfig = req.HttpConfig
+		tool.ParametersSchema = req.ParametersSchema
+		tool.Name = req.Name
+		tool.Description = req.Description
+	case model.DatabaseToolType:
+		if req.DataSourceId == nil || req.Name == "" {
+			return nil, biz.ErrDataSourceInvalid
+		}
+		dataSource, err := s.getDataSource(userId, *req.DataSourceId)
+		if err != nil {
+			logs.Errorf("get data source error: %v", err)
+			return nil, errs.DBError
+		}
+		if dataSource == nil {
+			return nil, biz.ErrDataSourceNotFound
+		}
+		tool.DataSourceID = &dataSource.ID
+		tool.Name = req.Name
+		tool.Description = req.Description
+	default:
 		//这是系统工具
 		invokeParamTool := tools.FindTool(req.Name)
 		if invokeParamTool == nil {
@@ -67,13 +120,90 @@ func (s *service) createTool(ctx context.Context, userId uuid.UUID, req CreateTo
 		tool.ParametersSchema = invokeParamTool.Params()
 	}
 	err = s.repo.createTool(ctx, &tool)
+	if errors.Is(err, errNameConflict) {
+		return nil, biz.ErrToolNameExisted
+	}
 	if err != nil {
 		logs.Errorf("create tool error: %v", err)
 		return nil, errs.DBError
 	}
+	tool.MaskCredentials()
 	return &tool, nil
 }
 
+// validateMcpConfig 校验MCP配置，未指定类型时按地址补全
+func (s *service) validateMcpConfig(config *model.McpConfig, credential string) error {
+	if config.Type == "" && config.Url != "" {
+		if strings.HasSuffix(config.Url, "/sse") {
+			config.Type = model.McpTypeSSE
+		} else {
+			config.Type = model.McpTypeStreamableHTTP
+		}
+	}
+	switch config.Type {
+	case model.McpTypeSSE, model.McpTypeStreamableHTTP:
+		u, err := url.Parse(config.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return biz.ErrMcpUrlInvalid
+		}
+		config.Command = ""
+		config.Args = nil
+		config.Env = nil
+	case model.McpTypeStdio:
+		// stdio 会在服务器上启动子进程，命令、参数和环境变量都必须在白名单中
+		if !shared.AllowStdioServer(config) {
+			return biz.ErrMcpStdioNotAllowed
+		}
+		if config.CredentialType != "" {
+			return biz.ErrMcpCredentialInvalid
+		}
+		config.Url = ""
+	default:
+		return biz.ErrMcpTypeInvalid
+	}
+	switch config.CredentialType {
+	case "":
+		if config.AuthenticationRequired {
+			return biz.ErrMcpCredentialInvalid
+		}
+	case model.McpCredentialBearer:
+		if credential == "" {
+			return biz.ErrMcpCredentialInvalid
+		}
+	case model.McpCredentialHeader:
+		if credential == "" || !isValidHeaderName(config.HeaderName) {
+			return biz.ErrMcpCredentialInvalid
+		}
+	case model.McpCredentialOAuth2:
+		if credential == "" || config.OAuth2 == nil || config.OAuth2.ClientId == "" {
+			return biz.ErrMcpCredentialInvalid
+		}
+		u, err := url.Parse(config.OAuth2.TokenUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return biz.ErrMcpCredentialInvalid
+		}
+	default:
+		return biz.ErrMcpCredentialInvalid
+	}
+	if config.CredentialType != "" {
+		config.AuthenticationRequired = true
+	}
+	return nil
+}
+
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 127 || !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	// Host 等请求头由 http 库管理，不允许覆盖
+	return http.CanonicalHeaderKey(name) != "Host"
+}
+
 func (s *service) listTools(ctx context.Context, userID uuid.UUID, req ListToolsReq) (*res.Page, error) {
 	//构建过滤条件
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -89,6 +219,9 @@ func (s *service) listTools(ctx context.Context, userID uuid.UUID, req ListTools
 		logs.Errorf("list tools error: %v", err)
 		return nil, errs.DBError
 	}
+	for _, t := range toolList {
+		t.MaskCredentials()
+	}
 	return &res.Page{
 		List:        toolList,
 		Total:       total,
@@ -111,7 +244,7 @@ func (s *service) updateTool(ctx context.Context, userID uuid.UUID, id uuid.UUID
 	}
 	//然后判断名字是否重复
 	if req.Name != toolInfo.Name {
-		toolInfo1, err := s.repo.getToolByName(ctx, req.Name)
+		toolInfo1, err := s.repo.getToolByName(ctx, userID, req.Name)
 		if err != nil {
 			logs.Errorf("get tool by name error: %v", err)
 			return nil, errs.DBError
@@ -123,10 +256,14 @@ func (s *service) updateTool(ctx context.Context, userID uuid.UUID, id uuid.UUID
 	toolInfo.Name = req.Name
 	toolInfo.Description = req.Description
 	err = s.repo.updateTool(ctx, toolInfo)
+	if errors.Is(err, errNameConflict) {
+		return nil, biz.ErrToolNameExisted
+	}
 	if err != nil {
 		logs.Errorf("update tool error: %v", err)
 		return nil, errs.DBError
 	}
+	toolInfo.MaskCredentials()
 	return toolInfo, nil
 }
 
@@ -148,8 +285,28 @@ func (s *service) testTool(ctx context.Context, userId uuid.UUID, id uuid.UUID,
 		logs.Errorf("get tool error: %v", err)
 		return nil, errs.DBError
 	}
-	//查找系统中注册的tool
-	invokeParamTool := tools.FindTool(toolInfo.Name)
+	if toolInfo == nil {
+		return nil, biz.ErrToolNotExisted
+	}
+	var invokeParamTool einos.InvokeParamTool
+	if toolInfo.ToolType == model.HttpToolType && toolInfo.HttpConfig != nil {
+		endpoint, err := shared.BuildHttpEndpoint(toolInfo)
+		if err != nil {
+			logs.Errorf("build http endpoint error: %v", err)
+			return nil, biz.ErrHttpConfigInvalid
+		}
+		invokeParamTool = httptools.NewTool(endpoint)
+	} else if toolInfo.ToolType == model.DatabaseToolType && toolInfo.DataSource != nil {
+		source, err := shared.BuildDataSource(toolInfo.DataSource)
+		if err != nil {
+			logs.Errorf("build data source error: %v", err)
+			return nil, biz.ErrDataSourceInvalid
+		}
+		invokeParamTool = dbquery.NewTool(ctx, toolInfo.Name, toolInfo.Description, source)
+	} else {
+		//查找系统中注册的tool
+		invokeParamTool = tools.FindTool(toolInfo.Name)
+	}
 	if invokeParamTool == nil {
 		return nil, biz.ErrToolNotExisted
 	}
@@ -185,13 +342,12 @@ func (s *service) getMcpTools(ctx context.Context, userId uuid.UUID, toolId uuid
 		return nil, biz.ErrMcpConfigNotExisted
 	}
 	//获取mcp的tool列表，这里我们需要用到mcp-go这个库
-	mcpConfig := einos.McpConfig{
-		BaseUrl: config.Url,
-		Token:   config.CredentialType,
-		Name:    "Faber-AI",
-		Version: "1.0.0",
+	mcpConfig, err := shared.BuildMcpServerConfig(config)
+	if err != nil {
+		logs.Errorf("build mcp config error: %v", err)
+		return nil, biz.ErrGetMcpTools
 	}
-	mcpTools, err := mcps.GetMCPTool(ctx, &mcpConfig)
+	mcpTools, err := mcps.GetMCPTool(ctx, mcpConfig)
 	if err != nil {
 		logs.Errorf("get mcp tool error: %v", err)
 		return nil, biz.ErrGetMcpTools
@@ -213,6 +369,88 @@ func (s *service) getMcpTools(ctx context.Context, userId uuid.UUID, toolId uuid
 	return toolList, nil
 }
 
+// getMcpStatus 查询当前用户所有MCP工具对应服务的连接状态
+func (s *service) getMcpStatus(ctx context.Context, userId uuid.UUID) ([]*McpStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	toolList, _, err := s.repo.listTools(ctx, userId, toolFilter{ToolType: model.McpToolType})
+	if err != nil {
+		logs.Errorf("list mcp tools error: %v", err)
+		return nil, errs.DBError
+	}
+	statuses := make([]*McpStatusResponse, 0, len(toolList))
+	for _, t := range toolList {
+		if t.McpConfig == nil {
+			continue
+		}
+		mcpConfig, err := shared.BuildMcpServerConfig(t.McpConfig)
+		if err != nil {
+			logs.Warnf("build mcp config error: %v", err)
+			continue
+		}
+		statuses = append(statuses, &McpStatusResponse{
+			ToolId:       t.ID,
+			Name:         t.Name,
+			ServerStatus: mcps.GetManager().Status(mcpConfig),
+		})
+	}
+	return statuses, nil
+}
+
+func (s *service) getMcpPrompts(ctx context.Context, userId uuid.UUID, toolId uuid.UUID) ([]*McpPromptResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	tool, err := s.repo.getTool(ctx, userId, toolId)
+	if err != nil {
+		logs.Errorf("get tool error: %v", err)
+		return nil, errs.DBError
+	}
+	if tool == nil {
+		return nil, biz.ErrToolNotExisted
+	}
+	if tool.McpConfig == nil {
+		return nil, biz.ErrMcpConfigNotExisted
+	}
+	mcpConfig, err := shared.BuildMcpServerConfig(tool.McpConfig)
+	if err != nil {
+		logs.Errorf("build mcp config error: %v", err)
+		return nil, biz.ErrGetMcpPrompts
+	}
+	prompts, err := mcps.GetManager().ListPrompts(ctx, mcpConfig)
+	if err != nil {
+		logs.Errorf("list mcp prompts error: %v", err)
+		return nil, biz.ErrGetMcpPrompts
+	}
+	list := make([]*McpPromptResponse, 0, len(prompts))
+	for _, prompt := range prompts {
+		item := &McpPromptResponse{
+			Name:        prompt.Name,
+			Description: prompt.Description,
+		}
+		for _, argument := range prompt.Arguments {
+			item.Arguments = append(item.Arguments, &McpPromptArgument{
+				Name:        argument.Name,
+				Description: argument.Description,
+				Required:    argument.Required,
+			})
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// getDataSource 通过事件查询当前用户的数据源，不存在时返回 nil
+func (s *service) getDataSource(userId uuid.UUID, id uuid.UUID) (*model.DataSource, error) {
+	trigger, err := event.Trigger("getDataSourceById", &shared.GetDataSourceRequest{
+		UserID: userId,
+		ID:     id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trigger.(*model.DataSource), nil
+}
+
 func newService() *service {
 	return &service{
 		repo: newModels(database.GetPostgresDB().GormDB),
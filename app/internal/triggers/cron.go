@@ -0,0 +1,48 @@
+package triggers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser 标准的5段 cron 表达式：分 时 日 月 周，支持 @daily 等简写
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+type cronSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// parseCron 解析 cron 表达式，timezone 为空时使用 UTC
+func parseCron(expr string, timezone string) (*cronSchedule, error) {
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", timezone)
+		}
+		location = loc
+	}
+	expr = strings.TrimSpace(expr)
+	// 时区使用单独的字段，@every 按秒计算间隔，都不允许写在表达式里
+	if strings.HasPrefix(expr, "@every") || strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, errors.New("不支持的cron表达式")
+	}
+	if !strings.HasPrefix(expr, "@") && len(strings.Fields(expr)) != 5 {
+		return nil, errors.New("cron表达式需要5段：分 时 日 月 周")
+	}
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("cron表达式无效: %v", err)
+	}
+	return &cronSchedule{schedule: schedule, location: location}, nil
+}
+
+// next 返回 after 之后的下一个运行时间，五年内没有匹配的时间时返回零值
+func (s *cronSchedule) next(after time.Time) time.Time {
+	return s.schedule.Next(after.In(s.location))
+}
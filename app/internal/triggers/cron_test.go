@@ -0,0 +1,61 @@
+package triggers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronNext(t *testing.T) {
+	after := time.Date(2026, 3, 14, 10, 30, 15, 0, time.UTC) // 星期六
+	tests := []struct {
+		expr     string
+		timezone string
+		want     time.Time
+	}{
+		{"* * * * *", "", time.Date(2026, 3, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", "", time.Date(2026, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", "", time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", "", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8 1,15 * *", "", time.Date(2026, 3, 15, 8, 30, 0, 0, time.UTC)},
+		// 日和周都有限制时满足任意一个即可
+		{"0 0 1 * 1", "", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", "", time.Time{}},
+		{"@daily", "", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", "", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// 上海是 UTC+8，10:30 UTC 已经是当天 18:30
+		{"0 9 * * *", "Asia/Shanghai", time.Date(2026, 3, 15, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := parseCron(tt.expr, tt.timezone)
+		if err != nil {
+			t.Errorf("parseCron(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := schedule.next(after); !got.Equal(tt.want) {
+			t.Errorf("parseCron(%q, %q).next() = %v, want %v", tt.expr, tt.timezone, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	tests := []struct {
+		expr     string
+		timezone string
+	}{
+		{"", ""},
+		{"* * * *", ""},
+		{"0 * * * * *", ""},
+		{"60 * * * *", ""},
+		{"* 24 * * *", ""},
+		{"*/0 * * * *", ""},
+		{"5-1 * * * *", ""},
+		{"@every 1s", ""},
+		{"CRON_TZ=UTC * * * * *", ""},
+		{"* * * * *", "Mars/Olympus"},
+	}
+	for _, tt := range tests {
+		if _, err := parseCron(tt.expr, tt.timezone); err == nil {
+			t.Errorf("parseCron(%q, %q) should fail", tt.expr, tt.timezone)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package triggers
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+// maxWebhookBody webhook 请求体的最大长度
+const maxWebhookBody = 1 << 20
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+func (h *Handler) CreateTrigger(c *gin.Context) {
+	var createReq CreateTriggerReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	trigger, err := h.service.createTrigger(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, trigger)
+}
+
+func (h *Handler) ListTriggers(c *gin.Context) {
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listTriggers(c.Request.Context(), userID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) GetTrigger(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	trigger, err := h.service.getTrigger(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, trigger)
+}
+
+func (h *Handler) UpdateTrigger(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var updateReq UpdateTriggerReq
+	if err := req.JsonParam(c, &updateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	trigger, err := h.service.updateTrigger(c.Request.Context(), userID, id, updateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, trigger)
+}
+
+func (h *Handler) DeleteTrigger(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteTrigger(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+func (h *Handler) RotateSecret(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	trigger, err := h.service.rotateSecret(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, trigger)
+}
+
+// RunTrigger 手动运行，可以一次提交多条输入
+func (h *Handler) RunTrigger(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runReq RunTriggerReq
+	if err := req.JsonParam(c, &runReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	runs, err := h.service.runTrigger(c.Request.Context(), userID, id, runReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, runs)
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runsReq RunsReq
+	if err := req.QueryParam(c, &runsReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listRuns(c.Request.Context(), userID, id, runsReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) GetRun(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runID uuid.UUID
+	if err := req.Path(c, "runId", &runID); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.getRun(c.Request.Context(), userID, id, runID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
+
+func (h *Handler) RetryRun(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runID uuid.UUID
+	if err := req.Path(c, "runId", &runID); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.retryRun(c.Request.Context(), userID, id, runID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
+
+// Webhook 外部系统触发，不需要登录，使用 X-Trigger-Secret 或 X-Signature-256 加 X-Trigger-Timestamp 认证
+func (h *Handler) Webhook(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		res.Error(c, errs.ErrParam)
+		return
+	}
+	run, err := h.service.receiveWebhook(c.Request.Context(), id, body,
+		c.GetHeader("X-Trigger-Secret"), c.GetHeader("X-Signature-256"), c.GetHeader("X-Trigger-Timestamp"))
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
@@ -0,0 +1,193 @@
+package triggers
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+type RunFilter struct {
+	Status model.TriggerRunStatus
+	Limit  int
+	Offset int
+}
+
+func (m *models) createTrigger(ctx context.Context, trigger *model.Trigger) error {
+	return m.db.WithContext(ctx).Create(trigger).Error
+}
+
+func (m *models) listTriggers(ctx context.Context, userID uuid.UUID) ([]*model.Trigger, error) {
+	var triggers []*model.Trigger
+	err := m.db.WithContext(ctx).Where("creator_id = ?", userID).Order("created_at desc").Find(&triggers).Error
+	return triggers, err
+}
+
+func (m *models) getTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Trigger, error) {
+	var trigger model.Trigger
+	err := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).First(&trigger).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &trigger, err
+}
+
+func (m *models) getTriggerById(ctx context.Context, id uuid.UUID) (*model.Trigger, error) {
+	var trigger model.Trigger
+	err := m.db.WithContext(ctx).Where("id = ?", id).First(&trigger).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &trigger, err
+}
+
+func (m *models) updateTrigger(ctx context.Context, trigger *model.Trigger) error {
+	return m.db.WithContext(ctx).Model(trigger).
+		Select("name", "target_type", "target_id", "input_template", "cron", "timezone", "webhook_secret",
+			"enabled", "max_retries", "delivery_type", "delivery_url", "next_run_at", "updated_at").
+		Updates(trigger).Error
+}
+
+func (m *models) deleteTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).Delete(&model.Trigger{})
+	return result.RowsAffected, result.Error
+}
+
+// getTargetAgent 触发器只能运行自己的agent，运行时使用创建者的工具和模型凭证
+func (m *models) getTargetAgent(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Agent, error) {
+	var agent model.Agent
+	err := m.db.WithContext(ctx).Select("id", "status").
+		Where("id = ? AND creator_id = ?", id, userID).
+		First(&agent).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &agent, err
+}
+
+func (m *models) workflowExists(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.Workflow{}).
+		Where("id = ? AND creator_id = ?", id, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (m *models) getUserEmail(ctx context.Context, userID uuid.UUID) (string, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("email").Where("id = ?", userID).First(&user).Error
+	return user.Email, err
+}
+
+func (m *models) listDueTriggers(ctx context.Context, now time.Time, limit int) ([]*model.Trigger, error) {
+	var triggers []*model.Trigger
+	err := m.db.WithContext(ctx).
+		Where("type = ? AND enabled = ? AND next_run_at <= ?", model.TriggerSchedule, true, now).
+		Order("next_run_at").Limit(limit).Find(&triggers).Error
+	return triggers, err
+}
+
+// claimSchedule 用 next_run_at 做乐观锁抢占这一次定时运行，多个实例同时调度时只有一个能成功，
+// 抢占成功后在同一个事务里创建运行记录
+func (m *models) claimSchedule(ctx context.Context, trigger *model.Trigger, next *time.Time, run *model.TriggerRun) (bool, error) {
+	claimed := false
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.Trigger{}).
+			Where("id = ? AND next_run_at = ?", trigger.ID, trigger.NextRunAt).
+			Updates(map[string]any{"next_run_at": next, "last_run_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+		return tx.Create(run).Error
+	})
+	return claimed, err
+}
+
+func (m *models) createRuns(ctx context.Context, runs []*model.TriggerRun) error {
+	return m.db.WithContext(ctx).Create(&runs).Error
+}
+
+// listQueuedRuns 等待执行的运行：新创建的、到了重试时间的和租约已经过期的
+func (m *models) listQueuedRuns(ctx context.Context, now time.Time, limit int) ([]*model.TriggerRun, error) {
+	var runs []*model.TriggerRun
+	err := m.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND next_retry_at <= ?) OR (status = ? AND lease_until <= ?)",
+			model.TriggerRunPending, model.TriggerRunRetrying, now, model.TriggerRunRunning, now).
+		Order("created_at").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// claimRun 把运行改为执行中并设置租约，状态或租约已经被其他实例改掉时返回 false
+func (m *models) claimRun(ctx context.Context, run *model.TriggerRun, leaseUntil time.Time) (bool, error) {
+	now := time.Now()
+	query := m.db.WithContext(ctx).Model(&model.TriggerRun{}).Where("id = ? AND status = ?", run.ID, run.Status)
+	if run.LeaseUntil != nil {
+		query = query.Where("lease_until = ?", run.LeaseUntil)
+	}
+	result := query.Updates(map[string]any{
+		"status":      model.TriggerRunRunning,
+		"started_at":  now,
+		"lease_until": leaseUntil,
+		"updated_at":  now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	run.Status = model.TriggerRunRunning
+	run.StartedAt = &now
+	run.LeaseUntil = &leaseUntil
+	return result.RowsAffected > 0, nil
+}
+
+// renewLease 延长执行中的运行的租约
+func (m *models) renewLease(ctx context.Context, id uuid.UUID, leaseUntil time.Time) error {
+	return m.db.WithContext(ctx).Model(&model.TriggerRun{}).
+		Where("id = ? AND status = ?", id, model.TriggerRunRunning).
+		Update("lease_until", leaseUntil).Error
+}
+
+func (m *models) updateRun(ctx context.Context, run *model.TriggerRun) error {
+	return m.db.WithContext(ctx).Model(run).
+		Select("status", "input", "output", "error", "workflow_run_id", "attempts", "next_retry_at",
+			"delivery_error", "started_at", "finished_at", "lease_until", "updated_at").
+		Updates(run).Error
+}
+
+func (m *models) listRuns(ctx context.Context, triggerID uuid.UUID, filter RunFilter) ([]*model.TriggerRun, int64, error) {
+	var runs []*model.TriggerRun
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.TriggerRun{}).Where("trigger_id = ?", triggerID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at desc").Limit(filter.Limit).Offset(filter.Offset).Find(&runs).Error
+	return runs, total, err
+}
+
+func (m *models) getRun(ctx context.Context, triggerID uuid.UUID, id uuid.UUID) (*model.TriggerRun, error) {
+	var run model.TriggerRun
+	err := m.db.WithContext(ctx).Where("id = ? AND trigger_id = ?", id, triggerID).First(&run).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &run, err
+}
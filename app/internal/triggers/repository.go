@@ -0,0 +1,30 @@
+package triggers
+
+import (
+	"context"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createTrigger(ctx context.Context, trigger *model.Trigger) error
+	listTriggers(ctx context.Context, userID uuid.UUID) ([]*model.Trigger, error)
+	getTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Trigger, error)
+	getTriggerById(ctx context.Context, id uuid.UUID) (*model.Trigger, error)
+	updateTrigger(ctx context.Context, trigger *model.Trigger) error
+	deleteTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	getTargetAgent(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Agent, error)
+	workflowExists(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error)
+	getUserEmail(ctx context.Context, userID uuid.UUID) (string, error)
+	listDueTriggers(ctx context.Context, now time.Time, limit int) ([]*model.Trigger, error)
+	claimSchedule(ctx context.Context, trigger *model.Trigger, next *time.Time, run *model.TriggerRun) (bool, error)
+	createRuns(ctx context.Context, runs []*model.TriggerRun) error
+	listQueuedRuns(ctx context.Context, now time.Time, limit int) ([]*model.TriggerRun, error)
+	claimRun(ctx context.Context, run *model.TriggerRun, leaseUntil time.Time) (bool, error)
+	renewLease(ctx context.Context, id uuid.UUID, leaseUntil time.Time) error
+	updateRun(ctx context.Context, run *model.TriggerRun) error
+	listRuns(ctx context.Context, triggerID uuid.UUID, filter RunFilter) ([]*model.TriggerRun, int64, error)
+	getRun(ctx context.Context, triggerID uuid.UUID, id uuid.UUID) (*model.TriggerRun, error)
+}
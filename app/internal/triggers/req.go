@@ -0,0 +1,45 @@
+package triggers
+
+import (
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type CreateTriggerReq struct {
+	Name          string                  `json:"name"`
+	Type          model.TriggerType       `json:"type"`
+	TargetType    model.TriggerTargetType `json:"targetType"`
+	TargetID      uuid.UUID               `json:"targetId"`
+	InputTemplate string                  `json:"inputTemplate"`
+	Cron          string                  `json:"cron"`
+	Timezone      string                  `json:"timezone"`
+	MaxRetries    int                     `json:"maxRetries"`
+	DeliveryType  model.DeliveryType      `json:"deliveryType"`
+	DeliveryUrl   string                  `json:"deliveryUrl"`
+}
+
+// UpdateTriggerReq 触发方式创建后不能修改，Enabled 为 null 时不修改
+type UpdateTriggerReq struct {
+	Name          string                  `json:"name"`
+	TargetType    model.TriggerTargetType `json:"targetType"`
+	TargetID      uuid.UUID               `json:"targetId"`
+	InputTemplate string                  `json:"inputTemplate"`
+	Cron          string                  `json:"cron"`
+	Timezone      string                  `json:"timezone"`
+	Enabled       *bool                   `json:"enabled"`
+	MaxRetries    int                     `json:"maxRetries"`
+	DeliveryType  model.DeliveryType      `json:"deliveryType"`
+	DeliveryUrl   string                  `json:"deliveryUrl"`
+}
+
+// RunTriggerReq 手动运行，每条 payload 创建一次运行，为空时使用空的 payload 运行一次
+type RunTriggerReq struct {
+	Payloads []model.JSON `json:"payloads"`
+}
+
+type RunsReq struct {
+	Status   model.TriggerRunStatus `json:"status" form:"status"`
+	Page     int                    `json:"page" form:"page"`
+	PageSize int                    `json:"pageSize" form:"pageSize"`
+}
@@ -0,0 +1,14 @@
+package triggers
+
+import "model"
+
+type TriggerResponse struct {
+	*model.Trigger
+	// WebhookSecret webhook 密钥明文，只在创建和重置时返回
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+type ListRunResponse struct {
+	Runs  []*model.TriggerRun `json:"runs"`
+	Total int64               `json:"total"`
+}
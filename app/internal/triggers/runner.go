@@ -0,0 +1,255 @@
+package triggers
+
+import (
+	"app/shared"
+	"bytes"
+	"common/configs"
+	"context"
+	"core/ai/webfetch"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"model"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mszlu521/thunder/event"
+	"github.com/mszlu521/thunder/logs"
+)
+
+const (
+	// 第一次重试的等待时间，之后每次翻倍
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+	// deliveryTimeout 投递运行结果的超时时间
+	deliveryTimeout = 10 * time.Second
+)
+
+// deliveryClient 投递地址由用户配置，连接前检查目标地址，不允许访问内网
+var deliveryClient = webfetch.NewClient(deliveryTimeout)
+
+// errRunInterrupted 执行运行的实例退出，租约过期后由其他实例按失败处理
+var errRunInterrupted = errors.New("运行中断：执行的实例已退出")
+
+// executeRun 执行一次运行并保存结果，失败时按触发器的重试次数安排重试，
+// 最终成功或失败后投递结果
+func (s *service) executeRun(ctx context.Context, run *model.TriggerRun) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	trigger, err := s.repo.getTriggerById(dbCtx, run.TriggerID)
+	cancel()
+	if err == nil && trigger == nil {
+		err = errors.New("触发器已删除")
+	}
+	if err != nil {
+		run.Attempts++
+		s.finishRun(nil, run, err)
+		return
+	}
+	output, err := s.invokeTarget(ctx, trigger, run)
+	run.Attempts++
+	run.Output = output
+	s.finishRun(trigger, run, err)
+}
+
+// recoverRun 处理租约过期的运行，这一次按失败处理，由重试次数决定是否再次运行
+func (s *service) recoverRun(run *model.TriggerRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	trigger, err := s.repo.getTriggerById(ctx, run.TriggerID)
+	cancel()
+	if err != nil {
+		// 租约过期后再处理
+		logs.Errorf("get trigger error: %v", err)
+		return
+	}
+	run.Attempts++
+	s.finishRun(trigger, run, errRunInterrupted)
+}
+
+// invokeTarget 渲染输入并运行agent或工作流，返回的输出是文本，工作流的输出会转为 JSON
+func (s *service) invokeTarget(ctx context.Context, trigger *model.Trigger, run *model.TriggerRun) (string, error) {
+	input, err := renderInput(trigger, run.Payload)
+	if err != nil {
+		return "", err
+	}
+	run.Input = input
+	switch trigger.TargetType {
+	case model.TriggerTargetAgent:
+		if strings.TrimSpace(input) == "" {
+			return "", errors.New("没有输入，请配置输入模板或在 payload 中提供 message")
+		}
+		ctx, cancel := context.WithTimeout(ctx, configs.GetConfig().Trigger.GetRunTimeout())
+		defer cancel()
+		answer, err := event.Trigger("invokeAgent", &shared.InvokeAgentRequest{
+			Ctx:     ctx,
+			UserID:  trigger.CreatorID,
+			AgentID: trigger.TargetID,
+			Message: input,
+		})
+		if err != nil {
+			return "", err
+		}
+		return answer.(string), nil
+	case model.TriggerTargetWorkflow:
+		inputs := make(map[string]any)
+		if strings.TrimSpace(input) != "" {
+			if err := json.Unmarshal([]byte(input), &inputs); err != nil {
+				return "", fmt.Errorf("工作流的输入必须是 JSON 对象: %w", err)
+			}
+		}
+		result, err := event.Trigger("runWorkflow", &shared.RunWorkflowRequest{
+			Ctx:        ctx,
+			UserID:     trigger.CreatorID,
+			WorkflowID: trigger.TargetID,
+			Inputs:     inputs,
+		})
+		workflowRun, _ := result.(*model.WorkflowRun)
+		if workflowRun != nil {
+			run.WorkflowRunID = &workflowRun.ID
+		}
+		if err != nil {
+			return "", err
+		}
+		output, err := json.Marshal(workflowRun.Outputs)
+		if err != nil {
+			return "", err
+		}
+		return string(output), nil
+	}
+	return "", fmt.Errorf("不支持的运行对象: %s", trigger.TargetType)
+}
+
+// renderInput 用 payload 渲染输入模板。模板为空时，agent 使用 payload 中的 message，工作流直接使用 payload
+func renderInput(trigger *model.Trigger, payload model.JSON) (string, error) {
+	if trigger.InputTemplate == "" {
+		if trigger.TargetType == model.TriggerTargetAgent {
+			if message, ok := payload["message"].(string); ok {
+				return message, nil
+			}
+			if len(payload) == 0 {
+				return "", nil
+			}
+		}
+		if payload == nil {
+			payload = model.JSON{}
+		}
+		data, err := json.Marshal(payload)
+		return string(data), err
+	}
+	tmpl, err := template.New("input").Option("missingkey=zero").Parse(trigger.InputTemplate)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if location, err := time.LoadLocation(trigger.Timezone); err == nil {
+		now = now.In(location)
+	}
+	var builder strings.Builder
+	err = tmpl.Execute(&builder, map[string]any{
+		"payload": map[string]any(payload),
+		"trigger": trigger.Name,
+		"date":    now.Format(time.DateOnly),
+		"now":     now.Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", fmt.Errorf("输入模板渲染失败: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// finishRun 保存运行结果，trigger 为空表示触发器已经不存在，不再重试也不投递
+func (s *service) finishRun(trigger *model.Trigger, run *model.TriggerRun, runErr error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.UpdatedAt = now
+	run.NextRetryAt = nil
+	run.LeaseUntil = nil
+	run.Error = ""
+	switch {
+	case runErr == nil:
+		run.Status = model.TriggerRunSucceeded
+	case trigger != nil && run.Attempts <= trigger.MaxRetries:
+		run.Status = model.TriggerRunRetrying
+		run.Error = runErr.Error()
+		nextRetryAt := now.Add(retryDelay(run.Attempts))
+		run.NextRetryAt = &nextRetryAt
+	default:
+		run.Status = model.TriggerRunFailed
+		run.Error = runErr.Error()
+	}
+	if trigger != nil && run.Status != model.TriggerRunRetrying {
+		run.DeliveryError = ""
+		if err := s.deliver(trigger, run); err != nil {
+			logs.Warnf("deliver trigger run %s error: %v", run.ID, err)
+			run.DeliveryError = err.Error()
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.updateRun(ctx, run); err != nil {
+		logs.Errorf("update trigger run error: %v", err)
+	}
+}
+
+// retryDelay 第 attempts 次失败后等待的时间
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// DeliveryMessage 投递到 webhook 的运行结果
+type DeliveryMessage struct {
+	TriggerID   string                 `json:"triggerId"`
+	TriggerName string                 `json:"triggerName"`
+	RunID       string                 `json:"runId"`
+	Status      model.TriggerRunStatus `json:"status"`
+	Output      string                 `json:"output,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	FinishedAt  *time.Time             `json:"finishedAt"`
+}
+
+func (s *service) deliver(trigger *model.Trigger, run *model.TriggerRun) error {
+	switch trigger.DeliveryType {
+	case model.DeliveryWebhook:
+		body, err := json.Marshal(&DeliveryMessage{
+			TriggerID:   trigger.ID.String(),
+			TriggerName: trigger.Name,
+			RunID:       run.ID.String(),
+			Status:      run.Status,
+			Output:      run.Output,
+			Error:       run.Error,
+			FinishedAt:  run.FinishedAt,
+		})
+		if err != nil {
+			return err
+		}
+		resp, err := deliveryClient.Post(trigger.DeliveryUrl, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("webhook 返回状态码 %d", resp.StatusCode)
+		}
+		return nil
+	case model.DeliveryEmail:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		email, err := s.repo.getUserEmail(ctx, trigger.CreatorID)
+		cancel()
+		if err != nil {
+			return err
+		}
+		subject := fmt.Sprintf("触发器 %s 运行成功", trigger.Name)
+		body := run.Output
+		if run.Status == model.TriggerRunFailed {
+			subject = fmt.Sprintf("触发器 %s 运行失败", trigger.Name)
+			body = run.Error
+		}
+		return shared.SendMail([]string{email}, subject, body)
+	}
+	return nil
+}
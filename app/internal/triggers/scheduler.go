@@ -0,0 +1,177 @@
+package triggers
+
+import (
+	"common/configs"
+	"context"
+	"model"
+	"sync"
+	"time"
+
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 每一轮最多处理的到期定时触发器数量，剩下的留到下一轮
+const maxDuePerRound = 100
+
+// Scheduler 后台调度器：定时检查到期的定时触发器并创建运行，再按并发限制执行排队中的运行。
+// 运行记录保存在数据库里，抢占使用乐观锁，执行期间持有租约，可以部署多个实例
+type Scheduler struct {
+	service *service
+	kick    chan struct{}
+	slots   chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var defaultScheduler *Scheduler
+
+// StartScheduler 启动全局调度器，服务退出时调用 Stop
+func StartScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		service: newService(),
+		kick:    make(chan struct{}, 1),
+		slots:   make(chan struct{}, configs.GetConfig().Trigger.GetConcurrency()),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	defaultScheduler = s
+	s.wg.Add(1)
+	go s.loop()
+	return s
+}
+
+// Stop 停止调度并取消正在执行的运行，被取消的运行按失败处理，之后会重试
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// kickScheduler 有新的运行排队时立即调度一次，不用等到下一轮
+func kickScheduler() {
+	if defaultScheduler == nil {
+		return
+	}
+	select {
+	case defaultScheduler.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(configs.GetConfig().Trigger.GetPollInterval())
+	defer ticker.Stop()
+	for {
+		s.dispatch()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.kick:
+		}
+	}
+}
+
+func (s *Scheduler) dispatch() {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Errorf("Panic in trigger scheduler: %v", r)
+		}
+	}()
+	now := time.Now()
+	s.enqueueSchedules(now)
+	free := cap(s.slots) - len(s.slots)
+	if free <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+	runs, err := s.service.repo.listQueuedRuns(ctx, now, free)
+	if err != nil {
+		logs.Errorf("list queued trigger runs error: %v", err)
+		return
+	}
+	lease := configs.GetConfig().Trigger.GetLease()
+	for _, run := range runs {
+		expired := run.Status == model.TriggerRunRunning
+		claimed, err := s.service.repo.claimRun(ctx, run, time.Now().Add(lease))
+		if err != nil {
+			logs.Errorf("claim trigger run error: %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if expired {
+			s.service.recoverRun(run)
+			continue
+		}
+		s.slots <- struct{}{}
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logs.Errorf("Panic in trigger run %s: %v", run.ID, r)
+				}
+				<-s.slots
+				s.wg.Done()
+			}()
+			stop := s.keepLease(run, lease)
+			defer stop()
+			s.service.executeRun(s.ctx, run)
+		}()
+	}
+}
+
+// keepLease 执行期间定期续约，返回的函数停止续约
+func (s *Scheduler) keepLease(run *model.TriggerRun, lease time.Duration) func() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	go func() {
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renewCtx, renewCancel := context.WithTimeout(ctx, 5*time.Second)
+				if err := s.service.repo.renewLease(renewCtx, run.ID, time.Now().Add(lease)); err != nil {
+					logs.Warnf("renew trigger run %s lease error: %v", run.ID, err)
+				}
+				renewCancel()
+			}
+		}
+	}()
+	return cancel
+}
+
+// enqueueSchedules 为到期的定时触发器创建运行。错过的多次运行只补一次，下一次时间从现在开始计算
+func (s *Scheduler) enqueueSchedules(now time.Time) {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+	triggers, err := s.service.repo.listDueTriggers(ctx, now, maxDuePerRound)
+	if err != nil {
+		logs.Errorf("list due triggers error: %v", err)
+		return
+	}
+	for _, trigger := range triggers {
+		var nextRunAt *time.Time
+		schedule, err := parseCron(trigger.Cron, trigger.Timezone)
+		if err != nil {
+			// 保存时校验过，这里只有时区数据变化等极端情况，停止调度避免每一轮都报错
+			logs.Warnf("trigger %s cron invalid: %v", trigger.ID, err)
+		} else if next := schedule.next(now); !next.IsZero() {
+			nextRunAt = &next
+		}
+		claimed, err := s.service.repo.claimSchedule(ctx, trigger, nextRunAt, newRun(trigger, trigger.Type, nil))
+		if err != nil {
+			logs.Errorf("claim trigger schedule error: %v", err)
+			continue
+		}
+		if claimed {
+			logs.Infof("trigger %s scheduled, next run at %v", trigger.ID, nextRunAt)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package triggers
+
+import (
+	"context"
+	"core/ai/webfetch"
+	"errors"
+	"model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// runRepo 只实现调度和执行运行用到的方法
+type runRepo struct {
+	repository
+	trigger *model.Trigger
+	queued  []*model.TriggerRun
+	claimed []time.Time
+	updated []*model.TriggerRun
+}
+
+func (f *runRepo) listDueTriggers(ctx context.Context, now time.Time, limit int) ([]*model.Trigger, error) {
+	return nil, nil
+}
+
+func (f *runRepo) listQueuedRuns(ctx context.Context, now time.Time, limit int) ([]*model.TriggerRun, error) {
+	return f.queued, nil
+}
+
+func (f *runRepo) claimRun(ctx context.Context, run *model.TriggerRun, leaseUntil time.Time) (bool, error) {
+	f.claimed = append(f.claimed, leaseUntil)
+	run.Status = model.TriggerRunRunning
+	run.LeaseUntil = &leaseUntil
+	return true, nil
+}
+
+func (f *runRepo) getTriggerById(ctx context.Context, id uuid.UUID) (*model.Trigger, error) {
+	return f.trigger, nil
+}
+
+func (f *runRepo) updateRun(ctx context.Context, run *model.TriggerRun) error {
+	copied := *run
+	f.updated = append(f.updated, &copied)
+	return nil
+}
+
+func newTestScheduler(repo repository) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		service: &service{repo: repo},
+		kick:    make(chan struct{}, 1),
+		slots:   make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// 执行中的实例退出后租约过期，这一次按失败处理并安排重试，不会一直停留在执行中
+func TestDispatchRecoversExpiredRun(t *testing.T) {
+	trigger := &model.Trigger{Name: "daily", MaxRetries: 1}
+	trigger.ID = uuid.New()
+	expired := time.Now().Add(-time.Minute)
+	run := &model.TriggerRun{TriggerID: trigger.ID, Status: model.TriggerRunRunning, Attempts: 0, LeaseUntil: &expired}
+	repo := &runRepo{trigger: trigger, queued: []*model.TriggerRun{run}}
+	s := newTestScheduler(repo)
+	s.dispatch()
+	s.Stop()
+	if len(repo.claimed) != 1 || !repo.claimed[0].After(time.Now()) {
+		t.Fatalf("claimed = %v", repo.claimed)
+	}
+	if len(repo.updated) != 1 {
+		t.Fatalf("updated = %v", repo.updated)
+	}
+	got := repo.updated[0]
+	if got.Status != model.TriggerRunRetrying || got.Attempts != 1 || got.Error != errRunInterrupted.Error() ||
+		got.LeaseUntil != nil || got.NextRetryAt == nil {
+		t.Fatalf("run = %+v", got)
+	}
+	// 重试次数用完后不再重试
+	repo.updated = nil
+	run.Status = model.TriggerRunRunning
+	s = newTestScheduler(repo)
+	s.dispatch()
+	s.Stop()
+	if len(repo.updated) != 1 || repo.updated[0].Status != model.TriggerRunFailed || repo.updated[0].Attempts != 2 {
+		t.Fatalf("updated = %+v", repo.updated)
+	}
+}
+
+// 投递地址由用户填写，不能指向内网
+func TestDeliverBlocksPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+	trigger := &model.Trigger{DeliveryType: model.DeliveryWebhook, DeliveryUrl: server.URL}
+	err := (&service{}).deliver(trigger, &model.TriggerRun{Status: model.TriggerRunSucceeded})
+	if !errors.Is(err, webfetch.ErrBlockedAddress) {
+		t.Fatalf("err = %v, want ErrBlockedAddress", err)
+	}
+	if called {
+		t.Fatal("private server should not be reached")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 10: time.Hour}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
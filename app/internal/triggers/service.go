@@ -0,0 +1,436 @@
+package triggers
+
+import (
+	"common/biz"
+	"common/configs"
+	"common/secrets"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"model"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// webhook 密钥的前缀，方便用户识别
+const secretPrefix = "whsec_"
+
+// signaturePrefix 签名请求头的前缀，格式为 sha256=<hex>
+const signaturePrefix = "sha256="
+
+// signatureTolerance 签名请求的时间戳和服务器时间允许的最大偏差，超出的请求视为重放
+const signatureTolerance = 5 * time.Minute
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+func (s *service) createTrigger(ctx context.Context, userID uuid.UUID, req CreateTriggerReq) (*TriggerResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger := &model.Trigger{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:     userID,
+		Name:          strings.TrimSpace(req.Name),
+		Type:          req.Type,
+		TargetType:    req.TargetType,
+		TargetID:      req.TargetID,
+		InputTemplate: req.InputTemplate,
+		Cron:          strings.TrimSpace(req.Cron),
+		Timezone:      req.Timezone,
+		Enabled:       true,
+		MaxRetries:    req.MaxRetries,
+		DeliveryType:  req.DeliveryType,
+		DeliveryUrl:   strings.TrimSpace(req.DeliveryUrl),
+	}
+	if err := s.validateTrigger(ctx, trigger); err != nil {
+		return nil, err
+	}
+	var plainSecret string
+	if trigger.Type == model.TriggerWebhook {
+		var err error
+		plainSecret, trigger.WebhookSecret, err = newWebhookSecret()
+		if err != nil {
+			logs.Errorf("generate webhook secret error: %v", err)
+			return nil, biz.ErrTokenGenerate
+		}
+	}
+	if err := s.repo.createTrigger(ctx, trigger); err != nil {
+		logs.Errorf("create trigger error: %v", err)
+		return nil, errs.DBError
+	}
+	return &TriggerResponse{Trigger: trigger, WebhookSecret: plainSecret}, nil
+}
+
+func (s *service) listTriggers(ctx context.Context, userID uuid.UUID) ([]*model.Trigger, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	triggers, err := s.repo.listTriggers(ctx, userID)
+	if err != nil {
+		logs.Errorf("list triggers error: %v", err)
+		return nil, errs.DBError
+	}
+	return triggers, nil
+}
+
+func (s *service) getTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Trigger, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger, err := s.repo.getTrigger(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get trigger error: %v", err)
+		return nil, errs.DBError
+	}
+	if trigger == nil {
+		return nil, biz.ErrTriggerNotFound
+	}
+	return trigger, nil
+}
+
+func (s *service) updateTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateTriggerReq) (*model.Trigger, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger, err := s.getTrigger(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	trigger.Name = strings.TrimSpace(req.Name)
+	trigger.TargetType = req.TargetType
+	trigger.TargetID = req.TargetID
+	trigger.InputTemplate = req.InputTemplate
+	trigger.Cron = strings.TrimSpace(req.Cron)
+	trigger.Timezone = req.Timezone
+	trigger.MaxRetries = req.MaxRetries
+	trigger.DeliveryType = req.DeliveryType
+	trigger.DeliveryUrl = strings.TrimSpace(req.DeliveryUrl)
+	if req.Enabled != nil {
+		trigger.Enabled = *req.Enabled
+	}
+	if err := s.validateTrigger(ctx, trigger); err != nil {
+		return nil, err
+	}
+	trigger.UpdatedAt = time.Now()
+	if err := s.repo.updateTrigger(ctx, trigger); err != nil {
+		logs.Errorf("update trigger error: %v", err)
+		return nil, errs.DBError
+	}
+	return trigger, nil
+}
+
+func (s *service) deleteTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteTrigger(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete trigger error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrTriggerNotFound
+	}
+	return nil
+}
+
+// rotateSecret 重置 webhook 密钥，旧密钥立即失效
+func (s *service) rotateSecret(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*TriggerResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger, err := s.getTrigger(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.Type != model.TriggerWebhook {
+		return nil, biz.ErrTriggerInvalid
+	}
+	plainSecret, encrypted, err := newWebhookSecret()
+	if err != nil {
+		logs.Errorf("generate webhook secret error: %v", err)
+		return nil, biz.ErrTokenGenerate
+	}
+	trigger.WebhookSecret = encrypted
+	trigger.UpdatedAt = time.Now()
+	if err := s.repo.updateTrigger(ctx, trigger); err != nil {
+		logs.Errorf("update trigger error: %v", err)
+		return nil, errs.DBError
+	}
+	return &TriggerResponse{Trigger: trigger, WebhookSecret: plainSecret}, nil
+}
+
+// validateTrigger 校验配置，并为启用的定时触发器计算下一次运行时间
+func (s *service) validateTrigger(ctx context.Context, trigger *model.Trigger) error {
+	if trigger.Name == "" {
+		return biz.ErrTriggerInvalid
+	}
+	switch trigger.Type {
+	case model.TriggerSchedule, model.TriggerWebhook, model.TriggerManual:
+	default:
+		return biz.ErrTriggerInvalid
+	}
+	if trigger.TargetType != model.TriggerTargetAgent && trigger.TargetType != model.TriggerTargetWorkflow {
+		return biz.ErrTriggerInvalid
+	}
+	if trigger.MaxRetries < 0 || trigger.MaxRetries > configs.GetConfig().Trigger.GetMaxRetries() {
+		return biz.ErrTriggerInvalid
+	}
+	switch trigger.DeliveryType {
+	case model.DeliveryNone, model.DeliveryEmail:
+	case model.DeliveryWebhook:
+		if !strings.HasPrefix(trigger.DeliveryUrl, "http://") && !strings.HasPrefix(trigger.DeliveryUrl, "https://") {
+			return biz.ErrTriggerInvalid
+		}
+	default:
+		return biz.ErrTriggerInvalid
+	}
+	if trigger.InputTemplate != "" {
+		if _, err := template.New("input").Parse(trigger.InputTemplate); err != nil {
+			return errs.NewError(biz.ErrTriggerInvalid.Code, biz.ErrTriggerInvalid.Msg+"："+err.Error())
+		}
+	}
+	trigger.NextRunAt = nil
+	if trigger.Type == model.TriggerSchedule {
+		schedule, err := parseCron(trigger.Cron, trigger.Timezone)
+		if err != nil {
+			return errs.NewError(biz.ErrTriggerCron.Code, biz.ErrTriggerCron.Msg+"："+err.Error())
+		}
+		next := schedule.next(time.Now())
+		if next.IsZero() {
+			return biz.ErrTriggerCron
+		}
+		if trigger.Enabled {
+			trigger.NextRunAt = &next
+		}
+	}
+	return s.validateTarget(ctx, trigger)
+}
+
+// validateTarget 目标必须是自己的，agent 还需要已经发布，否则每次运行都会失败
+func (s *service) validateTarget(ctx context.Context, trigger *model.Trigger) error {
+	if trigger.TargetType == model.TriggerTargetWorkflow {
+		exists, err := s.repo.workflowExists(ctx, trigger.CreatorID, trigger.TargetID)
+		if err != nil {
+			logs.Errorf("check trigger workflow error: %v", err)
+			return errs.DBError
+		}
+		if !exists {
+			return biz.ErrWorkflowNotFound
+		}
+		return nil
+	}
+	agent, err := s.repo.getTargetAgent(ctx, trigger.CreatorID, trigger.TargetID)
+	if err != nil {
+		logs.Errorf("get trigger agent error: %v", err)
+		return errs.DBError
+	}
+	if agent == nil {
+		return biz.ErrAgentNotFound
+	}
+	if agent.Status != model.AgentStatus(model.Published) {
+		return biz.ErrTriggerAgentDraft
+	}
+	return nil
+}
+
+// runTrigger 手动运行，每条 payload 创建一次运行，由调度器按并发限制执行
+func (s *service) runTrigger(ctx context.Context, userID uuid.UUID, id uuid.UUID, req RunTriggerReq) ([]*model.TriggerRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger, err := s.getTrigger(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	payloads := req.Payloads
+	if len(payloads) == 0 {
+		payloads = []model.JSON{{}}
+	}
+	if len(payloads) > configs.GetConfig().Trigger.GetMaxBatchSize() {
+		return nil, biz.ErrTriggerInvalid
+	}
+	runs := make([]*model.TriggerRun, 0, len(payloads))
+	for _, payload := range payloads {
+		runs = append(runs, newRun(trigger, model.TriggerManual, payload))
+	}
+	if err := s.repo.createRuns(ctx, runs); err != nil {
+		logs.Errorf("create trigger runs error: %v", err)
+		return nil, errs.DBError
+	}
+	kickScheduler()
+	return runs, nil
+}
+
+// receiveWebhook 外部系统调用 webhook，可以直接携带密钥，也可以用密钥对 "时间戳.请求体" 做 HMAC-SHA256 签名
+func (s *service) receiveWebhook(ctx context.Context, id uuid.UUID, body []byte, secret string, signature string, timestamp string) (*model.TriggerRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	trigger, err := s.repo.getTriggerById(ctx, id)
+	if err != nil {
+		logs.Errorf("get trigger error: %v", err)
+		return nil, errs.DBError
+	}
+	if trigger == nil || trigger.Type != model.TriggerWebhook {
+		return nil, biz.ErrTriggerNotFound
+	}
+	plainSecret, err := secrets.Decrypt(trigger.WebhookSecret)
+	if err != nil {
+		logs.Errorf("decrypt webhook secret error: %v", err)
+		return nil, biz.ErrTriggerSecret
+	}
+	if !verifyWebhook(plainSecret, body, secret, signature, timestamp, time.Now()) {
+		return nil, biz.ErrTriggerSecret
+	}
+	if !trigger.Enabled {
+		return nil, biz.ErrTriggerDisabled
+	}
+	run := newRun(trigger, model.TriggerWebhook, parsePayload(body))
+	if err := s.repo.createRuns(ctx, []*model.TriggerRun{run}); err != nil {
+		logs.Errorf("create trigger run error: %v", err)
+		return nil, errs.DBError
+	}
+	kickScheduler()
+	return run, nil
+}
+
+func (s *service) listRuns(ctx context.Context, userID uuid.UUID, id uuid.UUID, req RunsReq) (*ListRunResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getTrigger(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	runs, total, err := s.repo.listRuns(ctx, id, RunFilter{
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	})
+	if err != nil {
+		logs.Errorf("list trigger runs error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListRunResponse{Runs: runs, Total: total}, nil
+}
+
+func (s *service) getRun(ctx context.Context, userID uuid.UUID, id uuid.UUID, runID uuid.UUID) (*model.TriggerRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getTrigger(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	run, err := s.repo.getRun(ctx, id, runID)
+	if err != nil {
+		logs.Errorf("get trigger run error: %v", err)
+		return nil, errs.DBError
+	}
+	if run == nil {
+		return nil, biz.ErrTriggerRunNotFound
+	}
+	return run, nil
+}
+
+// retryRun 手动重试失败的运行，重新排队执行一次
+func (s *service) retryRun(ctx context.Context, userID uuid.UUID, id uuid.UUID, runID uuid.UUID) (*model.TriggerRun, error) {
+	run, err := s.getRun(ctx, userID, id, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != model.TriggerRunFailed {
+		return nil, biz.ErrTriggerRunNotRetry
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	run.Status = model.TriggerRunPending
+	run.NextRetryAt = nil
+	run.UpdatedAt = time.Now()
+	if err := s.repo.updateRun(ctx, run); err != nil {
+		logs.Errorf("update trigger run error: %v", err)
+		return nil, errs.DBError
+	}
+	kickScheduler()
+	return run, nil
+}
+
+func newRun(trigger *model.Trigger, source model.TriggerType, payload model.JSON) *model.TriggerRun {
+	return &model.TriggerRun{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		TriggerID: trigger.ID,
+		CreatorID: trigger.CreatorID,
+		Source:    source,
+		Status:    model.TriggerRunPending,
+		Payload:   payload,
+	}
+}
+
+// parsePayload 请求体是 JSON 对象时直接使用，否则作为 body 字段的字符串
+func parsePayload(body []byte) model.JSON {
+	payload := make(model.JSON)
+	if len(body) == 0 {
+		return payload
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return model.JSON{"body": string(body)}
+	}
+	return payload
+}
+
+// newWebhookSecret 返回密钥明文和加密后的值
+func newWebhookSecret() (string, string, error) {
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+	plain := secretPrefix + hex.EncodeToString(secretBytes)
+	encrypted, err := secrets.Encrypt(plain)
+	if err != nil {
+		return "", "", err
+	}
+	return plain, encrypted, nil
+}
+
+// verifyWebhook 校验密钥或签名，签名必须携带 Unix 秒级时间戳，超出允许偏差的请求拒绝，防止重放
+func verifyWebhook(plainSecret string, body []byte, secret string, signature string, timestamp string, now time.Time) bool {
+	if secret != "" {
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(plainSecret)) == 1
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > signatureTolerance || diff < -signatureTolerance {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(plainSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
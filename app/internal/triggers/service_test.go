@@ -0,0 +1,108 @@
+package triggers
+
+import (
+	"common/biz"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"model"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func sign(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	const secret = "whsec_test"
+	const body = `{"message":"hi"}`
+	now := time.Unix(1_800_000_000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	old := strconv.FormatInt(now.Add(-signatureTolerance-time.Second).Unix(), 10)
+	future := strconv.FormatInt(now.Add(signatureTolerance+time.Second).Unix(), 10)
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      string
+		want      bool
+	}{
+		{"secret", secret, "", "", body, true},
+		{"wrong secret", "whsec_other", "", "", body, false},
+		{"signature", "", sign(secret, ts, body), ts, body, true},
+		{"within tolerance", "", sign(secret, strconv.FormatInt(now.Unix()-60, 10), body), strconv.FormatInt(now.Unix()-60, 10), body, true},
+		{"tampered body", "", sign(secret, ts, body), ts, `{"message":"bye"}`, false},
+		{"missing timestamp", "", sign(secret, "", body), "", body, false},
+		{"replayed", "", sign(secret, old, body), old, body, false},
+		{"future", "", sign(secret, future, body), future, body, false},
+		// 时间戳参与签名，不能只替换时间戳
+		{"swapped timestamp", "", sign(secret, old, body), ts, body, false},
+		{"no prefix", "", sign(secret, ts, body)[len(signaturePrefix):], ts, body, false},
+	}
+	for _, tt := range tests {
+		if got := verifyWebhook(secret, []byte(tt.body), tt.secret, tt.signature, tt.timestamp, now); got != tt.want {
+			t.Errorf("%s: verifyWebhook() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// targetRepo 只实现创建触发器用到的方法，agent 按创建者查找
+type targetRepo struct {
+	repository
+	agent   *model.Agent
+	created *model.Trigger
+}
+
+func (f *targetRepo) getTargetAgent(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Agent, error) {
+	if f.agent == nil || f.agent.ID != id || f.agent.CreatorID != userID {
+		return nil, nil
+	}
+	return f.agent, nil
+}
+
+func (f *targetRepo) createTrigger(ctx context.Context, trigger *model.Trigger) error {
+	f.created = trigger
+	return nil
+}
+
+func TestCreateTriggerTarget(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name      string
+		creatorID uuid.UUID
+		status    model.AgentStatus
+		err       error
+	}{
+		{"published", userID, model.AgentStatus(model.Published), nil},
+		{"draft", userID, model.Draft, biz.ErrTriggerAgentDraft},
+		{"other user", uuid.New(), model.AgentStatus(model.Published), biz.ErrAgentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &model.Agent{BaseModel: model.BaseModel{ID: uuid.New()}, CreatorID: tt.creatorID, Status: tt.status}
+			repo := &targetRepo{agent: agent}
+			s := &service{repo: repo}
+			_, err := s.createTrigger(context.Background(), userID, CreateTriggerReq{
+				Name:       "daily",
+				Type:       model.TriggerManual,
+				TargetType: model.TriggerTargetAgent,
+				TargetID:   agent.ID,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if (repo.created != nil) != (tt.err == nil) {
+				t.Errorf("created = %v", repo.created)
+			}
+		})
+	}
+}
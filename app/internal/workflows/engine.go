@@ -0,0 +1,225 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"model"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+// runState 图运行时的局部状态，记录已经执行的节点输出，供下游节点按引用取值
+type runState struct {
+	Outputs map[string]map[string]any
+}
+
+// recorder 收集节点的执行结果并推送进度，节点可能并发执行
+type recorder struct {
+	mu    sync.Mutex
+	runID string
+	nodes model.WorkflowNodeRuns
+	emit  func(event *RunEvent)
+}
+
+func (r *recorder) start(node *model.WorkflowNode) {
+	r.emit(&RunEvent{
+		Type:     EventNodeStart,
+		RunID:    r.runID,
+		NodeID:   node.ID,
+		NodeType: node.Type,
+		NodeName: node.Name,
+	})
+}
+
+func (r *recorder) finish(node *model.WorkflowNode, startedAt time.Time, outputs map[string]any, err error) {
+	nodeRun := &model.WorkflowNodeRun{
+		NodeID:    node.ID,
+		NodeType:  node.Type,
+		Status:    model.WorkflowRunSucceeded,
+		Outputs:   outputs,
+		StartedAt: startedAt,
+		ElapsedMs: time.Since(startedAt).Milliseconds(),
+	}
+	runEvent := &RunEvent{
+		Type:      EventNodeEnd,
+		RunID:     r.runID,
+		NodeID:    node.ID,
+		NodeType:  node.Type,
+		NodeName:  node.Name,
+		Status:    model.WorkflowRunSucceeded,
+		Outputs:   outputs,
+		ElapsedMs: nodeRun.ElapsedMs,
+	}
+	if err != nil {
+		nodeRun.Status = model.WorkflowRunFailed
+		nodeRun.Error = err.Error()
+		runEvent.Type = EventNodeError
+		runEvent.Status = model.WorkflowRunFailed
+		runEvent.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.nodes = append(r.nodes, nodeRun)
+	r.mu.Unlock()
+	r.emit(runEvent)
+}
+
+func (r *recorder) nodeRuns() model.WorkflowNodeRuns {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.nodes
+}
+
+// nodeKey 图中的节点名，加前缀避免和 compose.START、compose.END 冲突
+func nodeKey(id string) string {
+	return "node_" + id
+}
+
+// compileWorkflow 把校验过的定义编译为 eino 的图：
+// 每个节点是一个 lambda，节点之间按连线连接，条件节点使用多分支，未选中的分支在 DAG 模式下会被跳过。
+// 节点的输出以 {节点ID: 输出} 的形式传给下游，多个上游汇合时不会冲突，实际取值通过局部状态按引用读取
+func compileWorkflow(ctx context.Context, def *model.WorkflowDefinition, exec *executor, rec *recorder) (compose.Runnable[map[string]any, map[string]any], error) {
+	graph := compose.NewGraph[map[string]any, map[string]any](
+		compose.WithGenLocalState(func(ctx context.Context) *runState {
+			return &runState{Outputs: make(map[string]map[string]any)}
+		}),
+	)
+	for _, node := range def.Nodes {
+		if err := graph.AddLambdaNode(nodeKey(node.ID), compose.InvokableLambda(nodeLambda(node, exec, rec)), compose.WithNodeName(node.Name)); err != nil {
+			return nil, err
+		}
+		switch node.Type {
+		case model.WorkflowNodeStart:
+			if err := graph.AddEdge(compose.START, nodeKey(node.ID)); err != nil {
+				return nil, err
+			}
+		case model.WorkflowNodeEnd:
+			if err := graph.AddEdge(nodeKey(node.ID), compose.END); err != nil {
+				return nil, err
+			}
+		}
+	}
+	branchTargets := make(map[string]map[string][]string)
+	added := make(map[string]bool)
+	for _, edge := range def.Edges {
+		// 条件节点的连线通过分支添加
+		if isCondition(def, edge.Source) {
+			if branchTargets[edge.Source] == nil {
+				branchTargets[edge.Source] = make(map[string][]string)
+			}
+			branchTargets[edge.Source][edge.Branch] = append(branchTargets[edge.Source][edge.Branch], nodeKey(edge.Target))
+			continue
+		}
+		key := edge.Source + "\x00" + edge.Target
+		if added[key] {
+			continue
+		}
+		added[key] = true
+		if err := graph.AddEdge(nodeKey(edge.Source), nodeKey(edge.Target)); err != nil {
+			return nil, err
+		}
+	}
+	for _, node := range def.Nodes {
+		if node.Type != model.WorkflowNodeCondition {
+			continue
+		}
+		targets := branchTargets[node.ID]
+		endNodes := make(map[string]bool)
+		for _, keys := range targets {
+			for _, key := range keys {
+				endNodes[key] = true
+			}
+		}
+		nodeID := node.ID
+		branch := compose.NewGraphMultiBranch(func(ctx context.Context, in map[string]any) (map[string]bool, error) {
+			outputs, _ := in[nodeID].(map[string]any)
+			selected, _ := outputs[outputBranch].(string)
+			next := make(map[string]bool)
+			for _, key := range targets[selected] {
+				next[key] = true
+			}
+			return next, nil
+		}, endNodes)
+		if err := graph.AddBranch(nodeKey(node.ID), branch); err != nil {
+			return nil, err
+		}
+	}
+	return graph.Compile(ctx,
+		compose.WithGraphName("workflow"),
+		compose.WithNodeTriggerMode(compose.AllPredecessor),
+	)
+}
+
+func isCondition(def *model.WorkflowDefinition, id string) bool {
+	for _, node := range def.Nodes {
+		if node.ID == id {
+			return node.Type == model.WorkflowNodeCondition
+		}
+	}
+	return false
+}
+
+// nodeLambda 包装节点的执行：从状态中解析输入、执行、记录结果并把输出写回状态
+func nodeLambda(node *model.WorkflowNode, exec *executor, rec *recorder) func(ctx context.Context, in map[string]any) (map[string]any, error) {
+	return func(ctx context.Context, in map[string]any) (map[string]any, error) {
+		rec.start(node)
+		startedAt := time.Now()
+		var outputs map[string]any
+		var err error
+		if node.Type == model.WorkflowNodeStart {
+			// 开始节点的输入就是运行参数
+			outputs, err = startOutputs(node, in)
+		} else {
+			var inputs map[string]any
+			inputs, err = resolveInputs(ctx, node)
+			if err == nil {
+				outputs, err = exec.execute(ctx, node, inputs)
+			}
+		}
+		rec.finish(node, startedAt, outputs, err)
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s 执行失败: %w", nodeLabel(node), err)
+		}
+		err = compose.ProcessState(ctx, func(ctx context.Context, state *runState) error {
+			state.Outputs[node.ID] = outputs
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if node.Type == model.WorkflowNodeEnd {
+			return outputs, nil
+		}
+		return map[string]any{node.ID: outputs}, nil
+	}
+}
+
+// resolveInputs 按引用从已执行节点的输出中取值，引用的节点在未选中的分支上时值为空
+func resolveInputs(ctx context.Context, node *model.WorkflowNode) (map[string]any, error) {
+	inputs := make(map[string]any, len(node.Inputs))
+	err := compose.ProcessState(ctx, func(ctx context.Context, state *runState) error {
+		for _, input := range node.Inputs {
+			if input.Ref == "" {
+				inputs[input.Name] = input.Value
+				continue
+			}
+			sourceID, outputName, _ := strings.Cut(input.Ref, ".")
+			value := state.Outputs[sourceID][outputName]
+			if value != nil && input.Type != "" && !matchType(input.Type, value) {
+				return fmt.Errorf("输入 %s 不是 %s 类型", input.Name, input.Type)
+			}
+			inputs[input.Name] = value
+		}
+		return nil
+	})
+	return inputs, err
+}
+
+func nodeLabel(node *model.WorkflowNode) string {
+	if node.Name != "" {
+		return node.Name
+	}
+	return node.ID
+}
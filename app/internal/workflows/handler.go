@@ -0,0 +1,246 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/logs"
+	"github.com/mszlu521/thunder/req"
+	"github.com/mszlu521/thunder/res"
+)
+
+type Handler struct {
+	service *service
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		service: newService(),
+	}
+}
+
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	var createReq CreateWorkflowReq
+	if err := req.JsonParam(c, &createReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	workflow, err := h.service.createWorkflow(c.Request.Context(), userID, createReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, workflow)
+}
+
+func (h *Handler) ListWorkflows(c *gin.Context) {
+	var searchReq SearchWorkflowReq
+	if err := req.QueryParam(c, &searchReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.listWorkflows(c.Request.Context(), userID, searchReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, list)
+}
+
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	workflow, err := h.service.getWorkflow(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, workflow)
+}
+
+func (h *Handler) UpdateWorkflow(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var updateReq UpdateWorkflowReq
+	if err := req.JsonParam(c, &updateReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	workflow, err := h.service.updateWorkflow(c.Request.Context(), userID, id, updateReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, workflow)
+}
+
+func (h *Handler) DeleteWorkflow(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.deleteWorkflow(c.Request.Context(), userID, id); err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, nil)
+}
+
+// ValidateWorkflow 校验还没有保存的定义，编辑器用来实时提示问题
+func (h *Handler) ValidateWorkflow(c *gin.Context) {
+	var validateReq ValidateWorkflowReq
+	if err := req.JsonParam(c, &validateReq); err != nil {
+		return
+	}
+	res.Success(c, h.service.validateWorkflow(validateReq))
+}
+
+// RunWorkflow 运行工作流，以SSE的方式推送每个节点的进度
+func (h *Handler) RunWorkflow(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runReq RunWorkflowReq
+	if err := req.JsonParam(c, &runReq); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	// 定义有问题时直接返回普通的错误响应，不进入SSE
+	prepared, err := h.service.prepareRun(c.Request.Context(), userID, id, runReq)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	ctx, cancel := h.prepareStream(c)
+	defer cancel()
+	dataChan, errorChan := h.service.runStream(ctx, prepared)
+	h.writeStream(c, ctx, cancel, dataChan, errorChan)
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	runs, err := h.service.listRuns(c.Request.Context(), userID, id)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, runs)
+}
+
+func (h *Handler) GetRun(c *gin.Context) {
+	var id uuid.UUID
+	if err := req.Path(c, "id", &id); err != nil {
+		return
+	}
+	var runID uuid.UUID
+	if err := req.Path(c, "runId", &runID); err != nil {
+		return
+	}
+	userID, ok := req.GetUserIdUUID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.getRun(c.Request.Context(), userID, id, runID)
+	if err != nil {
+		res.Error(c, err)
+		return
+	}
+	res.Success(c, run)
+}
+
+// prepareStream 设置SSE响应头并取消写超时，返回的context在客户端断开时取消
+func (h *Handler) prepareStream(c *gin.Context) (context.Context, context.CancelFunc) {
+	// 工作流可能运行很久，覆盖全局 http.Server 的 WriteTimeout 设置
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logs.Warn("Failed to set write deadline", "err", err)
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	return context.WithCancel(c.Request.Context())
+}
+
+// writeStream 把运行事件按SSE协议写给客户端，直到运行结束或者客户端断开
+func (h *Handler) writeStream(c *gin.Context, ctx context.Context, cancel context.CancelFunc, dataChan <-chan string, errorChan <-chan error) {
+	// 节点执行期间可能长时间没有事件，定时发送心跳防止连接被中间设备断开
+	heartbeat := time.NewTicker(5 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logs.Warnf("context done, 客户端断开连接")
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.Write([]byte(": keep-alive\n\n")); err != nil {
+				logs.Warnf("failed to write heartbeat: %v", err)
+				cancel()
+				return
+			}
+			c.Writer.Flush()
+		case data, ok := <-dataChan:
+			if !ok {
+				if _, err := c.Writer.Write([]byte("[DONE]\n")); err != nil {
+					logs.Warnf("failed to write done: %v", err)
+				}
+				c.Writer.Flush()
+				return
+			}
+			if _, err := c.Writer.Write([]byte(fmt.Sprintf("data: %s\n\n", data))); err != nil {
+				logs.Warnf("failed to write data: %v", err)
+				cancel()
+				return
+			}
+			c.Writer.Flush()
+		case err, ok := <-errorChan:
+			if !ok {
+				// 错误通道关闭后继续把剩余的事件写完，由 dataChan 结束
+				errorChan = nil
+				continue
+			}
+			if _, err := c.Writer.Write([]byte("error: [ERROR]" + err.Error() + "\n\n")); err != nil {
+				logs.Errorf("failed to write error: %v", err)
+				cancel()
+				return
+			}
+			c.Writer.Flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/gorms"
+	"gorm.io/gorm"
+)
+
+type models struct {
+	db *gorm.DB
+}
+
+func newModels(db *gorm.DB) *models {
+	return &models{db: db}
+}
+
+type WorkflowFilter struct {
+	Name   string
+	Limit  int
+	Offset int
+}
+
+func (m *models) createWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	return m.db.WithContext(ctx).Create(workflow).Error
+}
+
+func (m *models) countWorkflows(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Model(&model.Workflow{}).Where("creator_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// listWorkflows 列表不返回定义，定义可能很大
+func (m *models) listWorkflows(ctx context.Context, userID uuid.UUID, filter WorkflowFilter) ([]*model.Workflow, int64, error) {
+	var workflows []*model.Workflow
+	var total int64
+	query := m.db.WithContext(ctx).Model(&model.Workflow{}).Where("creator_id = ?", userID)
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.Omit("definition").Order("updated_at desc").Find(&workflows).Error
+	return workflows, total, err
+}
+
+func (m *models) getWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Workflow, error) {
+	var workflow model.Workflow
+	err := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).First(&workflow).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &workflow, err
+}
+
+func (m *models) updateWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	return m.db.WithContext(ctx).Model(workflow).
+		Select("name", "description", "definition", "updated_at").
+		Updates(workflow).Error
+}
+
+func (m *models) deleteWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error) {
+	result := m.db.WithContext(ctx).Where("id = ? AND creator_id = ?", id, userID).Delete(&model.Workflow{})
+	return result.RowsAffected, result.Error
+}
+
+func (m *models) getUserPlan(ctx context.Context, userID uuid.UUID) (model.SubscriptionPlan, error) {
+	var user model.User
+	err := m.db.WithContext(ctx).Select("current_plan").Where("id = ?", userID).First(&user).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return model.FreePlan, nil
+	}
+	return user.CurrentPlan, err
+}
+
+func (m *models) createRun(ctx context.Context, run *model.WorkflowRun) error {
+	return m.db.WithContext(ctx).Create(run).Error
+}
+
+func (m *models) updateRun(ctx context.Context, run *model.WorkflowRun) error {
+	return m.db.WithContext(ctx).Model(run).
+		Select("status", "outputs", "error", "nodes", "finished_at", "updated_at").
+		Updates(run).Error
+}
+
+func (m *models) listRuns(ctx context.Context, workflowID uuid.UUID, limit int) ([]*model.WorkflowRun, error) {
+	var runs []*model.WorkflowRun
+	err := m.db.WithContext(ctx).Where("workflow_id = ?", workflowID).
+		Omit("nodes").Order("created_at desc").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+func (m *models) getRun(ctx context.Context, workflowID uuid.UUID, id uuid.UUID) (*model.WorkflowRun, error) {
+	var run model.WorkflowRun
+	err := m.db.WithContext(ctx).Where("id = ? AND workflow_id = ?", id, workflowID).First(&run).Error
+	if gorms.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &run, err
+}
@@ -0,0 +1,409 @@
+package workflows
+
+import (
+	"app/shared"
+	"common/biz"
+	"context"
+	"core/ai/httptools"
+	"core/ai/sandbox"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"model"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/event"
+)
+
+// 固定的节点输出名
+const (
+	outputText   = "text"
+	outputResult = "result"
+	outputBody   = "body"
+	outputBranch = "branch"
+)
+
+// agentMessageInput agent节点中作为用户消息的输入
+const agentMessageInput = "message"
+
+// executor 执行单个节点，userID 是运行工作流的用户，节点只能使用该用户有权限的模型、agent和工具
+type executor struct {
+	userID uuid.UUID
+}
+
+func (e *executor) execute(ctx context.Context, node *model.WorkflowNode, inputs map[string]any) (map[string]any, error) {
+	switch node.Type {
+	case model.WorkflowNodeEnd:
+		return inputs, nil
+	case model.WorkflowNodeLLM:
+		return e.runLLM(ctx, node.LLM, inputs)
+	case model.WorkflowNodeAgent:
+		return e.runAgent(ctx, node.Agent, inputs)
+	case model.WorkflowNodeTool:
+		return e.runTool(ctx, node.Tool, inputs)
+	case model.WorkflowNodeCondition:
+		return map[string]any{outputBranch: evaluateCondition(node.Condition, inputs)}, nil
+	case model.WorkflowNodeCode:
+		return e.runCode(ctx, node, inputs)
+	case model.WorkflowNodeHttp:
+		return e.runHttp(ctx, node, inputs)
+	}
+	return nil, fmt.Errorf("不支持的节点类型: %s", node.Type)
+}
+
+// startOutputs 检查运行参数是否符合 start 节点的定义，未定义的参数会被忽略
+func startOutputs(node *model.WorkflowNode, params map[string]any) (map[string]any, error) {
+	outputs := make(map[string]any)
+	for _, variable := range node.Outputs {
+		value, ok := params[variable.Name]
+		if !ok || value == nil {
+			if variable.Required {
+				return nil, fmt.Errorf("缺少参数 %s", variable.Name)
+			}
+			continue
+		}
+		if !matchType(variable.Type, value) {
+			return nil, fmt.Errorf("参数 %s 不是 %s 类型", variable.Name, variable.Type)
+		}
+		outputs[variable.Name] = value
+	}
+	return outputs, nil
+}
+
+func (e *executor) runLLM(ctx context.Context, config *model.LLMNodeConfig, inputs map[string]any) (map[string]any, error) {
+	trigger, err := event.Trigger("getProviderConfigByProvider", &shared.GetProviderConfigRequest{
+		LLMType:   model.LLMTypeChat,
+		Provider:  config.ModelProvider,
+		ModelName: config.ModelName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	providerConfig := trigger.(*model.ProviderConfig)
+	if providerConfig.Provider == "" {
+		return nil, biz.ProviderConfigNotFound
+	}
+	chatModel, err := shared.BuildChatModel(ctx, providerConfig, config.ModelName, config.ModelParameters)
+	if err != nil {
+		return nil, err
+	}
+	var templates []*schema.Message
+	if config.SystemPrompt != "" {
+		templates = append(templates, schema.SystemMessage(config.SystemPrompt))
+	}
+	templates = append(templates, schema.UserMessage(config.Prompt))
+	messages := make([]*schema.Message, 0, len(templates))
+	for _, template := range templates {
+		formatted, err := template.Format(ctx, inputs, schema.GoTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("提示词渲染失败: %w", err)
+		}
+		messages = append(messages, formatted...)
+	}
+	answer, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{outputText: answer.Content}, nil
+}
+
+func (e *executor) runAgent(ctx context.Context, config *model.AgentNodeConfig, inputs map[string]any) (map[string]any, error) {
+	message, _ := inputs[agentMessageInput].(string)
+	variables := make(map[string]any)
+	for name, value := range inputs {
+		if name != agentMessageInput {
+			variables[name] = value
+		}
+	}
+	trigger, err := event.Trigger("invokeAgent", &shared.InvokeAgentRequest{
+		Ctx:       ctx,
+		UserID:    e.userID,
+		AgentID:   config.AgentID,
+		Message:   message,
+		Variables: variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{outputText: trigger.(string)}, nil
+}
+
+// runTool 节点的输入作为工具的参数；MCP工具会展开为多个工具，按 ToolName 选择
+func (e *executor) runTool(ctx context.Context, config *model.ToolNodeConfig, inputs map[string]any) (map[string]any, error) {
+	trigger, err := event.Trigger("getToolsByIds", &shared.GetToolsByIdsRequest{
+		Ids:    []uuid.UUID{config.ToolID},
+		UserID: e.userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	toolList := trigger.([]*model.Tool)
+	if len(toolList) == 0 {
+		return nil, biz.ErrToolNotExisted
+	}
+	name := config.ToolName
+	if name == "" {
+		name = toolList[0].Name
+	}
+	var selected tool.InvokableTool
+	for _, baseTool := range shared.BuildTools(toolList) {
+		info, err := baseTool.Info(ctx)
+		if err != nil || info.Name != name {
+			continue
+		}
+		if invokable, ok := baseTool.(tool.InvokableTool); ok {
+			selected = invokable
+			break
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("工具 %s 不可用", name)
+	}
+	arguments, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := selected.InvokableRun(ctx, string(arguments))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{outputResult: result}, nil
+}
+
+// runCode 输入以同名变量注入脚本；定义了输出时脚本需要把输出作为 JSON 对象打印到标准输出
+func (e *executor) runCode(ctx context.Context, node *model.WorkflowNode, inputs map[string]any) (map[string]any, error) {
+	prelude, err := codePrelude(node.Code.Language, inputs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sandbox.Run(ctx, node.Code.Language, prelude+node.Code.Code, sandbox.DefaultLimits)
+	if err != nil {
+		return nil, err
+	}
+	if result.TimedOut {
+		return nil, errors.New("代码执行超时")
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("代码执行失败，退出码 %d：%s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	stdout := strings.TrimSpace(result.Stdout)
+	if len(node.Outputs) == 0 {
+		return map[string]any{outputResult: stdout}, nil
+	}
+	// 只取最后一行，前面的输出可以用来调试
+	if i := strings.LastIndex(stdout, "\n"); i >= 0 {
+		stdout = stdout[i+1:]
+	}
+	var printed map[string]any
+	if err := json.Unmarshal([]byte(stdout), &printed); err != nil {
+		return nil, errors.New("代码的最后一行输出必须是 JSON 对象")
+	}
+	outputs := make(map[string]any)
+	for _, variable := range node.Outputs {
+		value, ok := printed[variable.Name]
+		if !ok {
+			return nil, fmt.Errorf("代码没有输出 %s", variable.Name)
+		}
+		if !matchType(variable.Type, value) {
+			return nil, fmt.Errorf("输出 %s 不是 %s 类型", variable.Name, variable.Type)
+		}
+		outputs[variable.Name] = value
+	}
+	return outputs, nil
+}
+
+// codePrelude 生成给输入变量赋值的代码，值都经过 JSON 编码，避免拼接代码时被注入
+func codePrelude(language string, inputs map[string]any) (string, error) {
+	var builder strings.Builder
+	if language == sandbox.LangPython {
+		builder.WriteString("import json\n")
+	}
+	for name, value := range inputs {
+		if !isIdentifier(name) {
+			return "", fmt.Errorf("输入名 %s 不能作为变量名", name)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		if language == sandbox.LangPython {
+			literal, _ := json.Marshal(string(data))
+			fmt.Fprintf(&builder, "%s = json.loads(%s)\n", name, literal)
+		} else {
+			fmt.Fprintf(&builder, "const %s = %s;\n", name, data)
+		}
+	}
+	return builder.String(), nil
+}
+
+func isIdentifier(name string) bool {
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return name != ""
+}
+
+// runHttp 复用HTTP工具的请求逻辑，参数定义由节点的输入生成
+func (e *executor) runHttp(ctx context.Context, node *model.WorkflowNode, inputs map[string]any) (map[string]any, error) {
+	config := node.Http
+	params := make(map[string]*schema.ParameterInfo)
+	for _, input := range node.Inputs {
+		params[input.Name] = &schema.ParameterInfo{Type: parameterType(input.Type)}
+	}
+	method := strings.ToUpper(config.Method)
+	if method == "" {
+		method = "GET"
+	}
+	httpTool := httptools.NewTool(&httptools.Endpoint{
+		Name:           node.ID,
+		Method:         method,
+		Url:            config.Url,
+		Headers:        config.Headers,
+		Params:         params,
+		ParamLocations: config.ParamLocations,
+		BodyTemplate:   config.BodyTemplate,
+		ResponsePath:   config.ResponsePath,
+		Timeout:        time.Duration(config.TimeoutSeconds) * time.Second,
+	})
+	arguments, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, err
+	}
+	body, err := httpTool.InvokableRun(ctx, string(arguments))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{outputBody: body}, nil
+}
+
+func parameterType(typ string) schema.DataType {
+	switch typ {
+	case model.VariableTypeNumber:
+		return schema.Number
+	case model.VariableTypeBoolean:
+		return schema.Boolean
+	case model.VariableTypeObject:
+		return schema.Object
+	case model.VariableTypeArray:
+		return schema.Array
+	}
+	return schema.String
+}
+
+// evaluateCondition 按顺序返回第一个满足的分支，都不满足时返回 else
+func evaluateCondition(config *model.ConditionNodeConfig, inputs map[string]any) string {
+	for _, branch := range config.Branches {
+		matched := branch.Logic != model.ConditionLogicOr
+		for _, condition := range branch.Conditions {
+			ok := compare(inputs[condition.Left], condition.Operator, condition.Right)
+			if branch.Logic == model.ConditionLogicOr {
+				matched = matched || ok
+			} else {
+				matched = matched && ok
+			}
+		}
+		if matched {
+			return branch.ID
+		}
+	}
+	return model.WorkflowBranchElse
+}
+
+func compare(left any, operator string, right any) bool {
+	switch operator {
+	case OperatorEmpty:
+		return isEmpty(left)
+	case OperatorNotEmpty:
+		return !isEmpty(left)
+	case OperatorEq:
+		return equal(left, right)
+	case OperatorNe:
+		return !equal(left, right)
+	case OperatorContains:
+		return contains(left, right)
+	case OperatorNotContains:
+		return !contains(left, right)
+	}
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return false
+	}
+	switch operator {
+	case OperatorGt:
+		return l > r
+	case OperatorGe:
+		return l >= r
+	case OperatorLt:
+		return l < r
+	case OperatorLe:
+		return l <= r
+	}
+	return false
+}
+
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// equal 数字按数值比较，其他按字符串比较，这样 "1" 和 1 是相等的
+func equal(left any, right any) bool {
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if lok && rok {
+		return l == r
+	}
+	return fmt.Sprint(left) == fmt.Sprint(right)
+}
+
+func contains(left any, right any) bool {
+	switch v := left.(type) {
+	case string:
+		return strings.Contains(v, fmt.Sprint(right))
+	case []any:
+		for _, item := range v {
+			if equal(item, right) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := v[fmt.Sprint(right)]
+		return ok
+	}
+	return false
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
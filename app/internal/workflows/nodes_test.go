@@ -0,0 +1,28 @@
+package workflows
+
+import (
+	"core/ai/sandbox"
+	"strings"
+	"testing"
+)
+
+func TestCodePrelude(t *testing.T) {
+	inputs := map[string]any{"text": "\"); evil(); (\"", "n": 1}
+	js, err := codePrelude(sandbox.LangJavaScript, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(js, `const text = "\"); evil(); (\"";`) || !strings.Contains(js, "const n = 1;") {
+		t.Errorf("javascript prelude = %q", js)
+	}
+	py, err := codePrelude(sandbox.LangPython, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(py, "import json\n") || !strings.Contains(py, `n = json.loads("1")`) {
+		t.Errorf("python prelude = %q", py)
+	}
+	if _, err := codePrelude(sandbox.LangJavaScript, map[string]any{"a-b": 1}); err == nil {
+		t.Error("invalid identifiers should be rejected")
+	}
+}
@@ -0,0 +1,28 @@
+package workflows
+
+import (
+	"app/shared"
+	"context"
+
+	"github.com/mszlu521/thunder/event"
+)
+
+type PublicService struct {
+	service *service
+}
+
+// RunWorkflow 同步运行用户自己的工作流，返回运行记录，运行失败时同时返回错误
+func (s *PublicService) RunWorkflow(e event.Event) (any, error) {
+	request := e.Data.(*shared.RunWorkflowRequest)
+	ctx := request.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return s.service.runWorkflow(ctx, request.UserID, request.WorkflowID, request.Inputs)
+}
+
+func NewPublicService() *PublicService {
+	return &PublicService{
+		service: newService(),
+	}
+}
@@ -0,0 +1,22 @@
+package workflows
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+)
+
+type repository interface {
+	createWorkflow(ctx context.Context, workflow *model.Workflow) error
+	countWorkflows(ctx context.Context, userID uuid.UUID) (int64, error)
+	listWorkflows(ctx context.Context, userID uuid.UUID, filter WorkflowFilter) ([]*model.Workflow, int64, error)
+	getWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Workflow, error)
+	updateWorkflow(ctx context.Context, workflow *model.Workflow) error
+	deleteWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (int64, error)
+	getUserPlan(ctx context.Context, userID uuid.UUID) (model.SubscriptionPlan, error)
+	createRun(ctx context.Context, run *model.WorkflowRun) error
+	updateRun(ctx context.Context, run *model.WorkflowRun) error
+	listRuns(ctx context.Context, workflowID uuid.UUID, limit int) ([]*model.WorkflowRun, error)
+	getRun(ctx context.Context, workflowID uuid.UUID, id uuid.UUID) (*model.WorkflowRun, error)
+}
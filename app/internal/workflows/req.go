@@ -0,0 +1,31 @@
+package workflows
+
+import "model"
+
+type CreateWorkflowReq struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Definition  model.WorkflowDefinition `json:"definition"`
+}
+
+// UpdateWorkflowReq Description 和 Definition 为 null 时不修改
+type UpdateWorkflowReq struct {
+	Name        string                    `json:"name"`
+	Description *string                   `json:"description"`
+	Definition  *model.WorkflowDefinition `json:"definition"`
+}
+
+type SearchWorkflowReq struct {
+	Name     string `json:"name" form:"name"`
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+type ValidateWorkflowReq struct {
+	Definition model.WorkflowDefinition `json:"definition"`
+}
+
+// RunWorkflowReq Inputs 对应 start 节点定义的变量
+type RunWorkflowReq struct {
+	Inputs map[string]any `json:"inputs"`
+}
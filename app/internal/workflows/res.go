@@ -0,0 +1,41 @@
+package workflows
+
+import "model"
+
+type ListWorkflowResponse struct {
+	Workflows []*model.Workflow `json:"workflows"`
+	Total     int64             `json:"total"`
+}
+
+// ValidationIssue 校验发现的问题，NodeID 为空表示整个工作流的问题
+type ValidationIssue struct {
+	NodeID  string `json:"nodeId,omitempty"`
+	Message string `json:"message"`
+}
+
+type ValidateWorkflowResponse struct {
+	Valid  bool               `json:"valid"`
+	Issues []*ValidationIssue `json:"issues"`
+}
+
+// 运行过程中推送的事件类型
+const (
+	EventWorkflowStart = "workflow_start"
+	EventNodeStart     = "node_start"
+	EventNodeEnd       = "node_end"
+	EventNodeError     = "node_error"
+	EventWorkflowEnd   = "workflow_end"
+)
+
+// RunEvent 运行过程中推送给前端的事件
+type RunEvent struct {
+	Type      string                  `json:"type"`
+	RunID     string                  `json:"runId"`
+	NodeID    string                  `json:"nodeId,omitempty"`
+	NodeType  model.WorkflowNodeType  `json:"nodeType,omitempty"`
+	NodeName  string                  `json:"nodeName,omitempty"`
+	Status    model.WorkflowRunStatus `json:"status,omitempty"`
+	Outputs   map[string]any          `json:"outputs,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+	ElapsedMs int64                   `json:"elapsedMs,omitempty"`
+}
@@ -0,0 +1,344 @@
+package workflows
+
+import (
+	"common/biz"
+	"common/configs"
+	"context"
+	"encoding/json"
+	"errors"
+	"model"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/google/uuid"
+	"github.com/mszlu521/thunder/database"
+	"github.com/mszlu521/thunder/errs"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 运行记录列表返回的最大条数
+const maxRunList = 50
+
+type service struct {
+	repo repository
+}
+
+func newService() *service {
+	return &service{
+		repo: newModels(database.GetPostgresDB().GormDB),
+	}
+}
+
+// createWorkflow 保存时允许定义不完整，方便分多次编辑，运行前再做完整校验
+func (s *service) createWorkflow(ctx context.Context, userID uuid.UUID, req CreateWorkflowReq) (*model.Workflow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, biz.ErrWorkflowInvalid
+	}
+	if len(req.Definition.Nodes) > configs.GetConfig().Workflow.GetMaxNodes() {
+		return nil, biz.ErrWorkflowInvalid
+	}
+	plan, err := s.repo.getUserPlan(ctx, userID)
+	if err != nil {
+		logs.Errorf("get user plan error: %v", err)
+		return nil, errs.DBError
+	}
+	count, err := s.repo.countWorkflows(ctx, userID)
+	if err != nil {
+		logs.Errorf("count workflows error: %v", err)
+		return nil, errs.DBError
+	}
+	if count >= configs.GetConfig().Workflow.GetMaxWorkflows(string(plan)) {
+		return nil, biz.ErrWorkflowLimit
+	}
+	workflow := &model.Workflow{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		CreatorID:   userID,
+		Name:        name,
+		Description: req.Description,
+		Definition:  req.Definition,
+	}
+	if err := s.repo.createWorkflow(ctx, workflow); err != nil {
+		logs.Errorf("create workflow error: %v", err)
+		return nil, errs.DBError
+	}
+	return workflow, nil
+}
+
+func (s *service) listWorkflows(ctx context.Context, userID uuid.UUID, req SearchWorkflowReq) (*ListWorkflowResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	workflows, total, err := s.repo.listWorkflows(ctx, userID, WorkflowFilter{
+		Name:   req.Name,
+		Limit:  req.PageSize,
+		Offset: (req.Page - 1) * req.PageSize,
+	})
+	if err != nil {
+		logs.Errorf("list workflows error: %v", err)
+		return nil, errs.DBError
+	}
+	return &ListWorkflowResponse{
+		Workflows: workflows,
+		Total:     total,
+	}, nil
+}
+
+func (s *service) getWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Workflow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	workflow, err := s.repo.getWorkflow(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("get workflow error: %v", err)
+		return nil, errs.DBError
+	}
+	if workflow == nil {
+		return nil, biz.ErrWorkflowNotFound
+	}
+	return workflow, nil
+}
+
+func (s *service) updateWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateWorkflowReq) (*model.Workflow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	workflow, err := s.getWorkflow(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		workflow.Name = name
+	}
+	if req.Description != nil {
+		workflow.Description = *req.Description
+	}
+	if req.Definition != nil {
+		if len(req.Definition.Nodes) > configs.GetConfig().Workflow.GetMaxNodes() {
+			return nil, biz.ErrWorkflowInvalid
+		}
+		workflow.Definition = *req.Definition
+	}
+	workflow.UpdatedAt = time.Now()
+	if err := s.repo.updateWorkflow(ctx, workflow); err != nil {
+		logs.Errorf("update workflow error: %v", err)
+		return nil, errs.DBError
+	}
+	return workflow, nil
+}
+
+func (s *service) deleteWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := s.repo.deleteWorkflow(ctx, userID, id)
+	if err != nil {
+		logs.Errorf("delete workflow error: %v", err)
+		return errs.DBError
+	}
+	if rows == 0 {
+		return biz.ErrWorkflowNotFound
+	}
+	return nil
+}
+
+func (s *service) validateWorkflow(req ValidateWorkflowReq) *ValidateWorkflowResponse {
+	issues := validateDefinition(&req.Definition)
+	return &ValidateWorkflowResponse{
+		Valid:  len(issues) == 0,
+		Issues: issues,
+	}
+}
+
+// workflowRun 一次准备好的运行，编译在开始推送之前完成，定义有问题时直接返回错误
+type workflowRun struct {
+	run      *model.WorkflowRun
+	runnable compose.Runnable[map[string]any, map[string]any]
+	recorder *recorder
+	events   chan string
+	// done 客户端断开时关闭，之后的事件不再推送
+	done <-chan struct{}
+}
+
+func workflowInvalidError(message string) error {
+	return errs.NewError(biz.ErrWorkflowInvalid.Code, biz.ErrWorkflowInvalid.Msg+"："+message)
+}
+
+// prepareRun 校验并编译工作流，创建运行记录
+func (s *service) prepareRun(ctx context.Context, userID uuid.UUID, id uuid.UUID, req RunWorkflowReq) (*workflowRun, error) {
+	workflow, err := s.getWorkflow(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if issues := validateDefinition(&workflow.Definition); len(issues) > 0 {
+		message := issues[0].Message
+		if issues[0].NodeID != "" {
+			message = issues[0].NodeID + ": " + message
+		}
+		return nil, workflowInvalidError(message)
+	}
+	run := &model.WorkflowRun{
+		BaseModel: model.BaseModel{
+			ID: uuid.New(),
+		},
+		WorkflowID: workflow.ID,
+		CreatorID:  userID,
+		Status:     model.WorkflowRunRunning,
+		Inputs:     req.Inputs,
+	}
+	prepared := &workflowRun{
+		run: run,
+	}
+	prepared.recorder = &recorder{
+		runID: run.ID.String(),
+		emit:  prepared.emit,
+	}
+	prepared.runnable, err = compileWorkflow(ctx, &workflow.Definition, &executor{userID: userID}, prepared.recorder)
+	if err != nil {
+		logs.Errorf("compile workflow error: %v", err)
+		return nil, workflowInvalidError(err.Error())
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.repo.createRun(dbCtx, run); err != nil {
+		logs.Errorf("create workflow run error: %v", err)
+		return nil, errs.DBError
+	}
+	return prepared, nil
+}
+
+// emit 推送事件，客户端断开后运行会被取消，剩余的事件直接丢弃
+func (r *workflowRun) emit(event *RunEvent) {
+	// 同步运行时没有人接收事件
+	if r.events == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logs.Warnf("marshal workflow event error: %v", err)
+		return
+	}
+	select {
+	case r.events <- string(data):
+	case <-r.done:
+	}
+}
+
+// runStream 在协程中运行工作流，按节点推送进度，最后推送整个工作流的结果
+func (s *service) runStream(ctx context.Context, prepared *workflowRun) (<-chan string, <-chan error) {
+	errorChan := make(chan error, 1)
+	prepared.events = make(chan string, 100)
+	prepared.done = ctx.Done()
+	go func() {
+		run := prepared.run
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Errorf("Panic in workflow run: %v", r)
+				run.Status = model.WorkflowRunFailed
+				run.Error = "internal server error"
+				s.finishRun(run, prepared.recorder)
+				errorChan <- errors.New("internal server error")
+			}
+			close(prepared.events)
+			close(errorChan)
+		}()
+		prepared.emit(&RunEvent{Type: EventWorkflowStart, RunID: run.ID.String(), Status: model.WorkflowRunRunning})
+		outputs, _ := s.execute(ctx, prepared)
+		prepared.emit(&RunEvent{
+			Type:    EventWorkflowEnd,
+			RunID:   run.ID.String(),
+			Status:  run.Status,
+			Outputs: outputs,
+			Error:   run.Error,
+		})
+	}()
+	return prepared.events, errorChan
+}
+
+// runWorkflow 同步运行工作流，不推送进度，供触发器等后台任务使用
+func (s *service) runWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID, inputs map[string]any) (*model.WorkflowRun, error) {
+	prepared, err := s.prepareRun(ctx, userID, id, RunWorkflowReq{Inputs: inputs})
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.execute(ctx, prepared)
+	return prepared.run, err
+}
+
+// execute 运行编译好的图并保存结果，运行失败时返回的错误与 run.Error 一致
+func (s *service) execute(ctx context.Context, prepared *workflowRun) (map[string]any, error) {
+	run := prepared.run
+	runCtx, cancel := context.WithTimeout(ctx, configs.GetConfig().Workflow.GetRunTimeout())
+	defer cancel()
+	inputs := map[string]any(run.Inputs)
+	if inputs == nil {
+		inputs = make(map[string]any)
+	}
+	outputs, err := prepared.runnable.Invoke(runCtx, inputs)
+	run.Status = model.WorkflowRunSucceeded
+	if err != nil {
+		run.Status = model.WorkflowRunFailed
+		run.Error = err.Error()
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			run.Error = "工作流运行超时"
+		}
+	} else {
+		run.Outputs = outputs
+	}
+	s.finishRun(run, prepared.recorder)
+	if run.Status == model.WorkflowRunFailed {
+		return nil, errs.NewError(biz.ErrWorkflowRun.Code, biz.ErrWorkflowRun.Msg+"："+run.Error)
+	}
+	return outputs, nil
+}
+
+// finishRun 保存运行结果，客户端可能已经断开，使用新的context
+func (s *service) finishRun(run *model.WorkflowRun, rec *recorder) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	now := time.Now()
+	run.FinishedAt = &now
+	run.UpdatedAt = now
+	run.Nodes = rec.nodeRuns()
+	if err := s.repo.updateRun(ctx, run); err != nil {
+		logs.Errorf("update workflow run error: %v", err)
+	}
+}
+
+func (s *service) listRuns(ctx context.Context, userID uuid.UUID, id uuid.UUID) ([]*model.WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getWorkflow(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	runs, err := s.repo.listRuns(ctx, id, maxRunList)
+	if err != nil {
+		logs.Errorf("list workflow runs error: %v", err)
+		return nil, errs.DBError
+	}
+	return runs, nil
+}
+
+func (s *service) getRun(ctx context.Context, userID uuid.UUID, id uuid.UUID, runID uuid.UUID) (*model.WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.getWorkflow(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	run, err := s.repo.getRun(ctx, id, runID)
+	if err != nil {
+		logs.Errorf("get workflow run error: %v", err)
+		return nil, errs.DBError
+	}
+	if run == nil {
+		return nil, biz.ErrWorkflowRunNotFound
+	}
+	return run, nil
+}
@@ -0,0 +1,45 @@
+package workflows
+
+import (
+	"context"
+	"model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// workflowRepo 只实现查询和修改工作流用到的方法
+type workflowRepo struct {
+	repository
+	workflow *model.Workflow
+	updated  *model.Workflow
+}
+
+func (f *workflowRepo) getWorkflow(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Workflow, error) {
+	return f.workflow, nil
+}
+
+func (f *workflowRepo) updateWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	f.updated = workflow
+	return nil
+}
+
+func TestUpdateWorkflowKeepsUnsetFields(t *testing.T) {
+	repo := &workflowRepo{workflow: &model.Workflow{Name: "report", Description: "daily report"}}
+	s := &service{repo: repo}
+	workflow, err := s.updateWorkflow(context.Background(), uuid.New(), uuid.New(), UpdateWorkflowReq{Name: "weekly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workflow.Name != "weekly" || workflow.Description != "daily report" {
+		t.Fatalf("workflow = %+v", workflow)
+	}
+	empty := ""
+	workflow, err = s.updateWorkflow(context.Background(), uuid.New(), uuid.New(), UpdateWorkflowReq{Description: &empty})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workflow.Name != "weekly" || workflow.Description != "" || repo.updated != workflow {
+		t.Fatalf("workflow = %+v", workflow)
+	}
+}
@@ -0,0 +1,548 @@
+package workflows
+
+import (
+	"common/configs"
+	"core/ai/httptools"
+	"core/ai/sandbox"
+	"fmt"
+	"model"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// 条件节点支持的比较方式
+const (
+	OperatorEq          = "eq"
+	OperatorNe          = "ne"
+	OperatorGt          = "gt"
+	OperatorGe          = "ge"
+	OperatorLt          = "lt"
+	OperatorLe          = "le"
+	OperatorContains    = "contains"
+	OperatorNotContains = "not_contains"
+	OperatorEmpty       = "empty"
+	OperatorNotEmpty    = "not_empty"
+)
+
+var operators = []string{
+	OperatorEq, OperatorNe, OperatorGt, OperatorGe, OperatorLt, OperatorLe,
+	OperatorContains, OperatorNotContains, OperatorEmpty, OperatorNotEmpty,
+}
+
+var variableTypes = []string{
+	model.VariableTypeString, model.VariableTypeNumber, model.VariableTypeBoolean,
+	model.VariableTypeObject, model.VariableTypeArray,
+}
+
+var httpMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// validator 一次校验的上下文，问题都收集到 issues 中而不是遇到第一个就返回，方便前端一次性标出所有问题
+type validator struct {
+	def    *model.WorkflowDefinition
+	nodes  map[string]*model.WorkflowNode
+	issues []*ValidationIssue
+}
+
+// validateDefinition 校验工作流定义，返回发现的所有问题，没有问题时返回空列表
+func validateDefinition(def *model.WorkflowDefinition) []*ValidationIssue {
+	v := &validator{
+		def:    def,
+		nodes:  make(map[string]*model.WorkflowNode),
+		issues: make([]*ValidationIssue, 0),
+	}
+	if !v.checkNodes() {
+		return v.issues
+	}
+	if !v.checkEdges() {
+		return v.issues
+	}
+	order, ok := v.checkCycles()
+	if !ok {
+		return v.issues
+	}
+	v.checkReachable()
+	v.checkInputs(order)
+	for _, node := range def.Nodes {
+		v.checkConfig(node)
+	}
+	return v.issues
+}
+
+func (v *validator) addf(nodeID string, format string, args ...any) {
+	v.issues = append(v.issues, &ValidationIssue{NodeID: nodeID, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkNodes 检查节点本身，节点ID有问题时后面的检查没有意义
+func (v *validator) checkNodes() bool {
+	nodes := v.def.Nodes
+	if len(nodes) == 0 {
+		v.addf("", "工作流没有节点")
+		return false
+	}
+	if maxNodes := configs.GetConfig().Workflow.GetMaxNodes(); len(nodes) > maxNodes {
+		v.addf("", "节点数量不能超过 %d 个", maxNodes)
+		return false
+	}
+	var starts, ends int
+	ok := true
+	for _, node := range nodes {
+		if node == nil || strings.TrimSpace(node.ID) == "" {
+			v.addf("", "节点ID不能为空")
+			ok = false
+			continue
+		}
+		if _, exists := v.nodes[node.ID]; exists {
+			v.addf(node.ID, "节点ID重复")
+			ok = false
+			continue
+		}
+		v.nodes[node.ID] = node
+		switch node.Type {
+		case model.WorkflowNodeStart:
+			starts++
+		case model.WorkflowNodeEnd:
+			ends++
+		case model.WorkflowNodeLLM, model.WorkflowNodeAgent, model.WorkflowNodeTool, model.WorkflowNodeKnowledge,
+			model.WorkflowNodeCondition, model.WorkflowNodeCode, model.WorkflowNodeHttp:
+		default:
+			v.addf(node.ID, "不支持的节点类型: %s", node.Type)
+			ok = false
+		}
+	}
+	if starts != 1 {
+		v.addf("", "工作流必须有且只有一个开始节点")
+		ok = false
+	}
+	if ends != 1 {
+		v.addf("", "工作流必须有且只有一个结束节点")
+		ok = false
+	}
+	return ok
+}
+
+// checkEdges 检查悬空的连线和条件分支
+func (v *validator) checkEdges() bool {
+	ok := true
+	seen := make(map[string]bool)
+	for _, edge := range v.def.Edges {
+		if edge == nil {
+			v.addf("", "连线不能为空")
+			ok = false
+			continue
+		}
+		source, target := v.nodes[edge.Source], v.nodes[edge.Target]
+		if source == nil || target == nil {
+			v.addf(edge.Source, "连线 %s -> %s 指向不存在的节点", edge.Source, edge.Target)
+			ok = false
+			continue
+		}
+		if edge.Source == edge.Target {
+			v.addf(edge.Source, "节点不能连接到自己")
+			ok = false
+			continue
+		}
+		if target.Type == model.WorkflowNodeStart {
+			v.addf(edge.Target, "开始节点不能有输入连线")
+			ok = false
+		}
+		if source.Type == model.WorkflowNodeEnd {
+			v.addf(edge.Source, "结束节点不能有输出连线")
+			ok = false
+		}
+		if source.Type != model.WorkflowNodeCondition && edge.Branch != "" {
+			v.addf(edge.Source, "只有条件节点的连线可以指定分支")
+			ok = false
+		}
+		key := edge.Source + "\x00" + edge.Target + "\x00" + edge.Branch
+		if seen[key] {
+			v.addf(edge.Source, "连线 %s -> %s 重复", edge.Source, edge.Target)
+			ok = false
+		}
+		seen[key] = true
+	}
+	for _, node := range v.def.Nodes {
+		if node.Type == model.WorkflowNodeCondition && node.Condition != nil {
+			if !v.checkBranches(node) {
+				ok = false
+			}
+		}
+	}
+	return ok
+}
+
+// checkBranches 每个分支（包括 else）都必须连到下一个节点，连线上的分支必须是已定义的
+func (v *validator) checkBranches(node *model.WorkflowNode) bool {
+	ok := true
+	branches := []string{model.WorkflowBranchElse}
+	for _, branch := range node.Condition.Branches {
+		if branch == nil || strings.TrimSpace(branch.ID) == "" {
+			v.addf(node.ID, "分支ID不能为空")
+			ok = false
+			continue
+		}
+		if slices.Contains(branches, branch.ID) {
+			v.addf(node.ID, "分支ID重复: %s", branch.ID)
+			ok = false
+			continue
+		}
+		branches = append(branches, branch.ID)
+	}
+	connected := make(map[string]bool)
+	for _, edge := range v.def.Edges {
+		if edge == nil || edge.Source != node.ID {
+			continue
+		}
+		if !slices.Contains(branches, edge.Branch) {
+			v.addf(node.ID, "连线 %s -> %s 的分支 %q 不存在", edge.Source, edge.Target, edge.Branch)
+			ok = false
+			continue
+		}
+		connected[edge.Branch] = true
+	}
+	for _, branch := range branches {
+		if !connected[branch] {
+			v.addf(node.ID, "分支 %s 没有连接下一个节点", branch)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// checkCycles 拓扑排序，有环时返回 false，否则返回节点的拓扑顺序
+func (v *validator) checkCycles() ([]*model.WorkflowNode, bool) {
+	inDegree := make(map[string]int)
+	next := make(map[string][]string)
+	for _, edge := range v.def.Edges {
+		// 条件节点的多个分支可能连到同一个节点，只算一次
+		if slices.Contains(next[edge.Source], edge.Target) {
+			continue
+		}
+		next[edge.Source] = append(next[edge.Source], edge.Target)
+		inDegree[edge.Target]++
+	}
+	var queue []string
+	for _, node := range v.def.Nodes {
+		if inDegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
+		}
+	}
+	order := make([]*model.WorkflowNode, 0, len(v.def.Nodes))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, v.nodes[id])
+		for _, target := range next[id] {
+			inDegree[target]--
+			if inDegree[target] == 0 {
+				queue = append(queue, target)
+			}
+		}
+	}
+	if len(order) != len(v.def.Nodes) {
+		for _, node := range v.def.Nodes {
+			if inDegree[node.ID] > 0 {
+				v.addf(node.ID, "节点处于循环中或依赖循环中的节点")
+			}
+		}
+		return nil, false
+	}
+	return order, true
+}
+
+// checkReachable 每个节点都必须从开始节点可达，并且能够到达结束节点
+func (v *validator) checkReachable() {
+	next := make(map[string][]string)
+	prev := make(map[string][]string)
+	var startID, endID string
+	for _, node := range v.def.Nodes {
+		switch node.Type {
+		case model.WorkflowNodeStart:
+			startID = node.ID
+		case model.WorkflowNodeEnd:
+			endID = node.ID
+		}
+	}
+	for _, edge := range v.def.Edges {
+		next[edge.Source] = append(next[edge.Source], edge.Target)
+		prev[edge.Target] = append(prev[edge.Target], edge.Source)
+	}
+	fromStart := walk(startID, next)
+	toEnd := walk(endID, prev)
+	for _, node := range v.def.Nodes {
+		if !fromStart[node.ID] {
+			v.addf(node.ID, "节点无法从开始节点到达")
+		} else if !toEnd[node.ID] {
+			v.addf(node.ID, "节点无法到达结束节点")
+		}
+	}
+}
+
+func walk(from string, adjacent map[string][]string) map[string]bool {
+	visited := map[string]bool{from: true}
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, n := range adjacent[id] {
+			if !visited[n] {
+				visited[n] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+	return visited
+}
+
+// checkInputs 按拓扑顺序检查输入：引用只能指向上游节点的输出，类型必须一致
+func (v *validator) checkInputs(order []*model.WorkflowNode) {
+	prev := make(map[string][]string)
+	for _, edge := range v.def.Edges {
+		prev[edge.Target] = append(prev[edge.Target], edge.Source)
+	}
+	for _, node := range order {
+		if node.Type == model.WorkflowNodeStart {
+			v.checkVariables(node.ID, node.Outputs, "参数")
+			if len(node.Inputs) > 0 {
+				v.addf(node.ID, "开始节点不能有输入，请使用输出定义工作流的参数")
+			}
+			continue
+		}
+		if node.Type == model.WorkflowNodeCode {
+			v.checkVariables(node.ID, node.Outputs, "输出")
+		}
+		ancestors := walk(node.ID, prev)
+		names := make(map[string]bool)
+		for _, input := range node.Inputs {
+			if input == nil || strings.TrimSpace(input.Name) == "" {
+				v.addf(node.ID, "输入名不能为空")
+				continue
+			}
+			if names[input.Name] {
+				v.addf(node.ID, "输入 %s 重复", input.Name)
+				continue
+			}
+			names[input.Name] = true
+			if input.Type != "" && !slices.Contains(variableTypes, input.Type) {
+				v.addf(node.ID, "输入 %s 的类型 %s 不支持", input.Name, input.Type)
+				continue
+			}
+			if input.Ref == "" {
+				if input.Value != nil && input.Type != "" && !matchType(input.Type, input.Value) {
+					v.addf(node.ID, "输入 %s 的值不是 %s 类型", input.Name, input.Type)
+				}
+				continue
+			}
+			sourceID, outputName, found := strings.Cut(input.Ref, ".")
+			source := v.nodes[sourceID]
+			if !found || source == nil {
+				v.addf(node.ID, "输入 %s 引用的 %s 不存在", input.Name, input.Ref)
+				continue
+			}
+			if sourceID == node.ID || !ancestors[sourceID] {
+				v.addf(node.ID, "输入 %s 只能引用上游节点的输出", input.Name)
+				continue
+			}
+			output := findVariable(nodeOutputs(source), outputName)
+			if output == nil {
+				v.addf(node.ID, "输入 %s 引用的 %s 不存在", input.Name, input.Ref)
+				continue
+			}
+			if input.Type != "" && output.Type != input.Type {
+				v.addf(node.ID, "输入 %s 的类型 %s 与 %s 的类型 %s 不匹配", input.Name, input.Type, input.Ref, output.Type)
+			}
+		}
+	}
+}
+
+func (v *validator) checkVariables(nodeID string, variables []*model.WorkflowVariable, kind string) {
+	names := make(map[string]bool)
+	for _, variable := range variables {
+		if variable == nil || strings.TrimSpace(variable.Name) == "" {
+			v.addf(nodeID, "%s名不能为空", kind)
+			continue
+		}
+		if names[variable.Name] {
+			v.addf(nodeID, "%s %s 重复", kind, variable.Name)
+		}
+		names[variable.Name] = true
+		if !slices.Contains(variableTypes, variable.Type) {
+			v.addf(nodeID, "%s %s 的类型 %s 不支持", kind, variable.Name, variable.Type)
+		}
+	}
+}
+
+// checkConfig 检查每种节点的配置
+func (v *validator) checkConfig(node *model.WorkflowNode) {
+	switch node.Type {
+	case model.WorkflowNodeLLM:
+		if node.LLM == nil || node.LLM.ModelProvider == "" || node.LLM.ModelName == "" {
+			v.addf(node.ID, "请选择模型")
+		} else if strings.TrimSpace(node.LLM.Prompt) == "" {
+			v.addf(node.ID, "提示词不能为空")
+		}
+	case model.WorkflowNodeAgent:
+		if node.Agent == nil || node.Agent.AgentID == uuid.Nil {
+			v.addf(node.ID, "请选择agent")
+		}
+		input := findInput(node.Inputs, agentMessageInput)
+		if input == nil {
+			v.addf(node.ID, "agent节点需要输入 %s 作为用户消息", agentMessageInput)
+		} else if input.Type != "" && input.Type != model.VariableTypeString {
+			v.addf(node.ID, "输入 %s 必须是 string 类型", agentMessageInput)
+		}
+	case model.WorkflowNodeTool:
+		if node.Tool == nil || node.Tool.ToolID == uuid.Nil {
+			v.addf(node.ID, "请选择工具")
+		}
+	case model.WorkflowNodeKnowledge:
+		// 知识库模块还没有上线，先在校验时拒绝，避免保存后运行才失败
+		v.addf(node.ID, "知识库检索节点暂不可用")
+	case model.WorkflowNodeCode:
+		if node.Code == nil || strings.TrimSpace(node.Code.Code) == "" {
+			v.addf(node.ID, "代码不能为空")
+		} else if !slices.Contains(sandbox.Languages(), node.Code.Language) {
+			v.addf(node.ID, "不支持的语言: %s", node.Code.Language)
+		}
+		for _, input := range node.Inputs {
+			if input != nil && !isIdentifier(input.Name) {
+				v.addf(node.ID, "输入名 %s 不能作为变量名", input.Name)
+			}
+		}
+	case model.WorkflowNodeHttp:
+		v.checkHttp(node)
+	case model.WorkflowNodeCondition:
+		v.checkCondition(node)
+	}
+}
+
+func (v *validator) checkHttp(node *model.WorkflowNode) {
+	config := node.Http
+	if config == nil || strings.TrimSpace(config.Url) == "" {
+		v.addf(node.ID, "请求地址不能为空")
+		return
+	}
+	if !strings.HasPrefix(config.Url, "http://") && !strings.HasPrefix(config.Url, "https://") {
+		v.addf(node.ID, "请求地址必须以 http:// 或 https:// 开头")
+	}
+	method := strings.ToUpper(config.Method)
+	if method != "" && !slices.Contains(httpMethods, method) {
+		v.addf(node.ID, "不支持的请求方法: %s", config.Method)
+	}
+	if config.ResponsePath != "" {
+		if err := httptools.ValidatePath(config.ResponsePath); err != nil {
+			v.addf(node.ID, "响应路径无效: %v", err)
+		}
+	}
+	for name, location := range config.ParamLocations {
+		if findInput(node.Inputs, name) == nil {
+			v.addf(node.ID, "参数 %s 不是节点的输入", name)
+		}
+		switch location {
+		case httptools.InPath, httptools.InQuery, httptools.InHeader, httptools.InBody:
+		default:
+			v.addf(node.ID, "参数 %s 的位置 %s 无效", name, location)
+		}
+	}
+}
+
+func (v *validator) checkCondition(node *model.WorkflowNode) {
+	if node.Condition == nil || len(node.Condition.Branches) == 0 {
+		v.addf(node.ID, "条件节点至少需要一个分支")
+		return
+	}
+	for _, branch := range node.Condition.Branches {
+		if branch == nil {
+			continue
+		}
+		if branch.Logic != "" && branch.Logic != model.ConditionLogicAnd && branch.Logic != model.ConditionLogicOr {
+			v.addf(node.ID, "分支 %s 的组合方式 %s 无效", branch.ID, branch.Logic)
+		}
+		if len(branch.Conditions) == 0 {
+			v.addf(node.ID, "分支 %s 没有条件", branch.ID)
+		}
+		for _, condition := range branch.Conditions {
+			if condition == nil {
+				continue
+			}
+			if findInput(node.Inputs, condition.Left) == nil {
+				v.addf(node.ID, "分支 %s 的条件引用了不存在的输入 %s", branch.ID, condition.Left)
+			}
+			if !slices.Contains(operators, condition.Operator) {
+				v.addf(node.ID, "分支 %s 的比较方式 %s 无效", branch.ID, condition.Operator)
+			}
+		}
+	}
+}
+
+// nodeOutputs 节点的输出定义，start 和 code 节点由用户定义，其他节点是固定的
+func nodeOutputs(node *model.WorkflowNode) []*model.WorkflowVariable {
+	text := func(name string) []*model.WorkflowVariable {
+		return []*model.WorkflowVariable{{Name: name, Type: model.VariableTypeString}}
+	}
+	switch node.Type {
+	case model.WorkflowNodeStart:
+		return node.Outputs
+	case model.WorkflowNodeCode:
+		if len(node.Outputs) > 0 {
+			return node.Outputs
+		}
+		return text(outputResult)
+	case model.WorkflowNodeLLM, model.WorkflowNodeAgent:
+		return text(outputText)
+	case model.WorkflowNodeTool:
+		return text(outputResult)
+	case model.WorkflowNodeHttp:
+		return text(outputBody)
+	case model.WorkflowNodeCondition:
+		return text(outputBranch)
+	}
+	return nil
+}
+
+func findVariable(variables []*model.WorkflowVariable, name string) *model.WorkflowVariable {
+	for _, variable := range variables {
+		if variable != nil && variable.Name == name {
+			return variable
+		}
+	}
+	return nil
+}
+
+func findInput(inputs []*model.WorkflowInput, name string) *model.WorkflowInput {
+	for _, input := range inputs {
+		if input != nil && input.Name == name {
+			return input
+		}
+	}
+	return nil
+}
+
+// matchType 判断 JSON 解码后的值是否符合变量类型
+func matchType(typ string, value any) bool {
+	switch typ {
+	case model.VariableTypeString:
+		_, ok := value.(string)
+		return ok
+	case model.VariableTypeNumber:
+		switch value.(type) {
+		case float64, float32, int, int64, int32:
+			return true
+		}
+		return false
+	case model.VariableTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case model.VariableTypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case model.VariableTypeArray:
+		_, ok := value.([]any)
+		return ok
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"app/internal/inits"
+	"common/configs"
+	"log"
 
 	"github.com/mszlu521/thunder/config"
 	"github.com/mszlu521/thunder/logs"
@@ -10,12 +12,22 @@ import (
 
 func main() {
 	//1. 加载配置  默认是 etc/config.yml
-	config.Init()
+	v := config.Init()
 	conf := config.GetConfig()
+	// 业务相关的配置
+	configs.Init(v)
+	// 工具凭证等敏感数据用 secret.key 加密，没有配置或者使用示例密钥时不能启动
+	if err := configs.GetConfig().Secret.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
 	//2. 加载日志
 	logs.Init(conf.Log)
 	//3. 初始化Gin服务
 	s := server.NewServer(conf)
+	// 只信任配置的反向代理设置的 X-Forwarded-For，访客限流按真实的IP计算
+	if err := s.Engine.SetTrustedProxies(configs.GetConfig().Proxy.GetTrustedProxies()); err != nil {
+		log.Fatalf("invalid proxy.trustedProxies: %v", err)
+	}
 	//4. 初始化模块
 	inits.Init(s, conf)
 	s.Start()
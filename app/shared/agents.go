@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// InvokeAgentRequest 工作流等模块调用agent，只能调用用户自己的或公开的已发布agent，返回最终回答
+type InvokeAgentRequest struct {
+	// Ctx 调用方的context，取消时agent停止运行
+	Ctx       context.Context `json:"-"`
+	UserID    uuid.UUID       `json:"userId"`
+	AgentID   uuid.UUID       `json:"agentId"`
+	Message   string          `json:"message"`
+	Variables map[string]any  `json:"variables"`
+}
+
+// EvaluateAgentRequest 评测时运行agent，只能运行用户自己的agent，Version 为 0 时使用当前草稿
+type EvaluateAgentRequest struct {
+	Ctx       context.Context `json:"-"`
+	UserID    uuid.UUID       `json:"userId"`
+	AgentID   uuid.UUID       `json:"agentId"`
+	Version   uint            `json:"version"`
+	Message   string          `json:"message"`
+	Variables map[string]any  `json:"variables"`
+}
+
+// AgentEvaluation agent的最终回答和运行过程中调用过的工具
+type AgentEvaluation struct {
+	Answer    string   `json:"answer"`
+	ToolCalls []string `json:"toolCalls"`
+}
+
+// GetChatSessionRequest 查询用户的对话会话，不存在或不属于该用户时返回 nil
+type GetChatSessionRequest struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
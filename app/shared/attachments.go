@@ -0,0 +1,20 @@
+package shared
+
+import "github.com/google/uuid"
+
+// GetAttachmentsRequest 按ID查询用户上传的附件，返回的附件包含文件内容
+type GetAttachmentsRequest struct {
+	UserID uuid.UUID
+	IDs    []uuid.UUID
+}
+
+// GetSessionFilesRequest 查询会话中的文件，返回的文件包含解析出的文本，不包含原始内容
+type GetSessionFilesRequest struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
+
+// DeleteSessionFilesRequest 会话删除时删除其中的文件
+type DeleteSessionFilesRequest struct {
+	SessionID uuid.UUID
+}
@@ -0,0 +1,141 @@
+package shared
+
+import (
+	"common/configs"
+	"context"
+	"core/ai/fakemodel"
+	"encoding/json"
+	"errors"
+	"model"
+
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino-ext/components/model/qwen"
+	aiModel "github.com/cloudwego/eino/components/model"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/eino-contrib/ollama/api"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// errFakeModelDisabled 没有在配置中打开 fake 模型
+var errFakeModelDisabled = errors.New("fake model is disabled")
+
+// ChatModelOptions 创建聊天模型的可选配置
+type ChatModelOptions struct {
+	// OutputSchema 要求模型按这个 JSON Schema 输出，提供商不支持时忽略，由调用方在提示词中说明并校验
+	OutputSchema model.JSON
+}
+
+// SupportsStructuredOutput 提供商是否支持原生的 JSON Schema 结构化输出。
+// 其他提供商走 OpenAI 兼容接口，不一定支持 response_format，只用提示词约束
+func SupportsStructuredOutput(provider string) bool {
+	switch provider {
+	case model.OpenAIProvider, model.QwenProvider, model.OllamaProvider:
+		return true
+	}
+	return false
+}
+
+// SupportsImageInput 提供商的接口能否接收图片（多模态的用户消息）。
+// 能否理解图片还取决于具体的模型，由模型的类型是否为图像模型决定
+func SupportsImageInput(provider string) bool {
+	return provider != model.FakeProvider
+}
+
+// BuildChatModel 按模型提供商的配置创建聊天模型，params 是agent或工作流节点上的模型参数
+func BuildChatModel(ctx context.Context, config *model.ProviderConfig, modelName string, params model.JSON) (aiModel.ToolCallingChatModel, error) {
+	return BuildChatModelWithOptions(ctx, config, modelName, params, ChatModelOptions{})
+}
+
+// BuildChatModelWithOptions 和 BuildChatModel 相同，可以要求结构化输出
+func BuildChatModelWithOptions(ctx context.Context, config *model.ProviderConfig, modelName string, params model.JSON, options ChatModelOptions) (aiModel.ToolCallingChatModel, error) {
+	var chatModel aiModel.ToolCallingChatModel
+	var err error
+	modelParams := params.ToModelParams()
+	temperature := float32(modelParams.Temperature)
+	topP := float32(modelParams.TopP)
+	maxTokens := modelParams.MaxTokens
+
+	var responseFormat *openai.ChatCompletionResponseFormat
+	var ollamaFormat json.RawMessage
+	if len(options.OutputSchema) > 0 && SupportsStructuredOutput(config.Provider) {
+		if config.Provider == model.OllamaProvider {
+			ollamaFormat, err = json.Marshal(options.OutputSchema)
+		} else {
+			responseFormat, err = buildResponseFormat(options.OutputSchema)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 打印配置信息以便调试
+	logs.Infof("Building chat model - Provider: %s, BaseURL: %s, Model: %s", config.Provider, config.APIBase, modelName)
+
+	if config.Provider == model.FakeProvider {
+		if !configs.GetConfig().Evaluation.FakeModelEnabled() {
+			return nil, errFakeModelDisabled
+		}
+		chatModel, err = fakemodel.FromParams(params)
+	} else if config.Provider == model.OllamaProvider {
+		// 创建聊天模型
+		chatModel, err = ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
+			BaseURL: config.APIBase,
+			Model:   modelName,
+			Format:  ollamaFormat,
+			Options: &api.Options{
+				Temperature: temperature,
+				TopP:        topP,
+				Runner: api.Runner{
+					NumCtx: maxTokens,
+				},
+			},
+		})
+	} else if config.Provider == model.QwenProvider {
+		chatModel, err = qwen.NewChatModel(ctx, &qwen.ChatModelConfig{
+			BaseURL:        config.APIBase,
+			APIKey:         config.APIKey,
+			Model:          modelName,
+			MaxTokens:      &maxTokens,
+			Temperature:    &temperature,
+			TopP:           &topP,
+			ResponseFormat: responseFormat,
+		})
+	} else {
+		chatModel, err = openai.NewChatModel(ctx, &openai.ChatModelConfig{
+			BaseURL:             config.APIBase,
+			APIKey:              config.APIKey,
+			Model:               modelName,
+			MaxCompletionTokens: &maxTokens,
+			Temperature:         &temperature,
+			TopP:                &topP,
+			ResponseFormat:      responseFormat,
+		})
+	}
+	if err != nil {
+		logs.Error("Failed to create chat model", "err", err)
+		return nil, err
+	}
+	return chatModel, nil
+
+}
+
+// buildResponseFormat OpenAI 兼容接口的 json_schema 输出格式。
+// 不使用 strict 模式，strict 要求所有字段必填且不能有额外字段，很多 Schema 不满足
+func buildResponseFormat(outputSchema model.JSON) (*openai.ChatCompletionResponseFormat, error) {
+	data, err := json.Marshal(outputSchema)
+	if err != nil {
+		return nil, err
+	}
+	var js jsonschema.Schema
+	if err := json.Unmarshal(data, &js); err != nil {
+		return nil, err
+	}
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:       "final_answer",
+			JSONSchema: &js,
+		},
+	}, nil
+}
@@ -0,0 +1,16 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"model"
+	"testing"
+)
+
+// fake 模型默认关闭，只能在配置中打开
+func TestBuildChatModelFakeDisabled(t *testing.T) {
+	_, err := BuildChatModel(context.Background(), &model.ProviderConfig{Provider: model.FakeProvider}, "fake", nil)
+	if !errors.Is(err, errFakeModelDisabled) {
+		t.Fatalf("err = %v, want errFakeModelDisabled", err)
+	}
+}
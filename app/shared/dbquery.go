@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"common/secrets"
+	"core/ai/dbquery"
+	"model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GetDataSourceRequest struct {
+	UserID uuid.UUID `json:"userId"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// BuildDataSource 将数据源转换为查询配置，连接串在这里解密
+func BuildDataSource(ds *model.DataSource) (*dbquery.Source, error) {
+	dsn, err := secrets.Decrypt(ds.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &dbquery.Source{
+		Type:    ds.Type,
+		Dsn:     dsn,
+		Tables:  ds.Tables,
+		MaxRows: ds.MaxRows,
+		Timeout: time.Duration(ds.TimeoutSeconds) * time.Second,
+	}, nil
+}
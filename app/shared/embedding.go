@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"model"
+	"net/http"
+	"net/url"
+
+	acl "github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/eino-contrib/ollama/api"
+)
+
+// BuildEmbedder 按模型提供商的配置创建向量模型。Ollama 使用原生接口，其他提供商使用 OpenAI 兼容接口
+func BuildEmbedder(ctx context.Context, config *model.ProviderConfig, modelName string) (embedding.Embedder, error) {
+	if config.Provider == model.FakeProvider {
+		return nil, errors.New("fake provider does not support embedding")
+	}
+	if config.Provider == model.OllamaProvider {
+		base, err := url.Parse(config.APIBase)
+		if err != nil {
+			return nil, err
+		}
+		return &ollamaEmbedder{client: api.NewClient(base, http.DefaultClient), model: modelName}, nil
+	}
+	return acl.NewEmbeddingClient(ctx, &acl.EmbeddingConfig{
+		APIKey:  config.APIKey,
+		BaseURL: config.APIBase,
+		Model:   modelName,
+	})
+}
+
+type ollamaEmbedder struct {
+	client *api.Client
+	model  string
+}
+
+func (e *ollamaEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	resp, err := e.client.Embed(ctx, &api.EmbedRequest{
+		Model: e.model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float64, len(resp.Embeddings))
+	for i, values := range resp.Embeddings {
+		vectors[i] = make([]float64, len(values))
+		for j, v := range values {
+			vectors[i][j] = float64(v)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"common/secrets"
+	"core/ai/httptools"
+	"model"
+	"time"
+)
+
+// BuildHttpEndpoint 将http类型的工具转换为调用配置，凭证在这里解密
+func BuildHttpEndpoint(tool *model.Tool) (*httptools.Endpoint, error) {
+	config := tool.HttpConfig
+	credential, err := secrets.Decrypt(config.Credential)
+	if err != nil {
+		return nil, err
+	}
+	return &httptools.Endpoint{
+		Name:           tool.Name,
+		Description:    tool.Description,
+		Method:         config.Method,
+		Url:            config.Url,
+		Headers:        config.Headers,
+		Params:         tool.ParametersSchema,
+		ParamLocations: config.ParamLocations,
+		BodyTemplate:   config.BodyTemplate,
+		ResponsePath:   config.ResponsePath,
+		AuthType:       config.AuthType,
+		HeaderName:     config.HeaderName,
+		Credential:     credential,
+		Timeout:        time.Duration(config.TimeoutSeconds) * time.Second,
+	}, nil
+}
@@ -1,9 +1,38 @@
 package shared
 
-import "model"
+import (
+	"model"
+
+	"github.com/google/uuid"
+)
 
 type GetProviderConfigRequest struct {
 	LLMType   model.LLMType
 	Provider  string
 	ModelName string
 }
+
+// GetVisionModelRequest 查询处理图片使用的模型，Provider 和 ModelName 是对话使用的主模型
+type GetVisionModelRequest struct {
+	UserID    uuid.UUID
+	Provider  string
+	ModelName string
+}
+
+// VisionModel 处理图片的模型。Native 为 true 时主模型本身支持图片，直接把图片发给主模型；
+// 否则由 ProviderConfig 和 ModelName 指定的图像模型先理解图片
+type VisionModel struct {
+	Native         bool
+	ProviderConfig *model.ProviderConfig
+	ModelName      string
+}
+
+// GetEmbeddingModelRequest 查询用户配置的向量模型
+type GetEmbeddingModelRequest struct {
+	UserID uuid.UUID
+}
+
+type EmbeddingModel struct {
+	ProviderConfig *model.ProviderConfig
+	ModelName      string
+}
@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/mszlu521/thunder/config"
+)
+
+// ErrEmailNotConfigured 没有配置邮件服务
+var ErrEmailNotConfigured = errors.New("email not configured")
+
+// SendMail 使用配置文件中的SMTP服务发送纯文本邮件
+func SendMail(to []string, subject string, body string) error {
+	emailConfig := config.GetConfig().Email
+	if emailConfig.Host == nil || emailConfig.Port == nil {
+		return ErrEmailNotConfigured
+	}
+	// Set up authentication information
+	auth := smtp.PlainAuth("", emailConfig.GetUsername(), emailConfig.GetPassword(), emailConfig.GetHost())
+
+	// Connect to the server, authenticate, and send the email
+	msg := []byte("To: " + strings.Join(to, ",") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" +
+		body + "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", emailConfig.GetHost(), emailConfig.GetPort())
+	if err := smtp.SendMail(addr, auth, emailConfig.GetFrom(), to, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
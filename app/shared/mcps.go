@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"common/biz"
+	"common/configs"
+	"common/secrets"
+	"core/ai/mcps"
+	"model"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BuildMcpServerConfig 将数据库中的MCP配置转换为连接配置，凭证在这里解密
+func BuildMcpServerConfig(config *model.McpConfig) (*mcps.ServerConfig, error) {
+	serverConfig := &mcps.ServerConfig{
+		Name:      "FaberAI",
+		Version:   "1.0.0",
+		Transport: config.Type,
+		Url:       config.Url,
+		Command:   config.Command,
+		Args:      config.Args,
+	}
+	// 环境变量排序后再拼接，保证同一份配置得到同一个连接
+	keys := make([]string, 0, len(config.Env))
+	for k := range config.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		serverConfig.Env = append(serverConfig.Env, k+"="+config.Env[k])
+	}
+	credential, err := secrets.Decrypt(config.Credential)
+	if err != nil {
+		return nil, err
+	}
+	if config.Type == model.McpTypeStdio && !AllowStdioServer(config) {
+		// 配置保存之后管理员可能收紧了白名单，启动前再检查一次
+		return nil, biz.ErrMcpStdioNotAllowed
+	}
+	switch config.CredentialType {
+	case "":
+	case model.McpCredentialBearer:
+		serverConfig.Headers = map[string]string{"Authorization": "Bearer " + credential}
+	case model.McpCredentialHeader:
+		serverConfig.Headers = map[string]string{config.HeaderName: credential}
+	case model.McpCredentialOAuth2:
+		if config.OAuth2 != nil {
+			serverConfig.OAuth2 = &mcps.OAuth2Config{
+				TokenUrl:     config.OAuth2.TokenUrl,
+				ClientId:     config.OAuth2.ClientId,
+				ClientSecret: credential,
+				Scopes:       config.OAuth2.Scopes,
+			}
+		}
+	default:
+		// 旧数据在 CredentialType 中保存 token，启动时已经迁移为 bearer，这里不再猜测
+		return nil, biz.ErrMcpCredentialInvalid
+	}
+	return serverConfig, nil
+}
+
+// 无论白名单如何都不允许设置的环境变量，它们能让解释器或动态链接器加载任意代码
+var (
+	deniedStdioEnv         = []string{"PATH", "HOME", "NODE_OPTIONS", "NODE_PATH", "PYTHONPATH", "PYTHONHOME", "PYTHONSTARTUP", "BASH_ENV", "ENV", "LD_PRELOAD", "LD_LIBRARY_PATH", "LD_AUDIT"}
+	deniedStdioEnvPrefixes = []string{"DYLD_", "NPM_CONFIG_", "UV_", "PIP_"}
+)
+
+// AllowStdioServer 检查 stdio 配置的命令、参数和环境变量是否在管理员配置的白名单中
+func AllowStdioServer(config *model.McpConfig) bool {
+	for _, server := range configs.GetConfig().Mcp.GetStdioServers() {
+		if matchStdioServer(server, config) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchStdioServer(server configs.StdioServer, config *model.McpConfig) bool {
+	if config.Command == "" || config.Command != server.Command {
+		return false
+	}
+	if len(config.Args) < len(server.Args) || !slices.Equal(config.Args[:len(server.Args)], server.Args) {
+		return false
+	}
+	if len(config.Args) > len(server.Args) && !server.ExtraArgs {
+		return false
+	}
+	for name := range config.Env {
+		if !slices.Contains(server.Env, name) || deniedEnv(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func deniedEnv(name string) bool {
+	name = strings.ToUpper(name)
+	if slices.Contains(deniedStdioEnv, name) {
+		return true
+	}
+	for _, prefix := range deniedStdioEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
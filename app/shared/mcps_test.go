@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"common/biz"
+	"common/configs"
+	"model"
+	"testing"
+)
+
+func TestMatchStdioServer(t *testing.T) {
+	server := configs.StdioServer{
+		Command: "npx",
+		Args:    []string{"-y", "@modelcontextprotocol/server-github"},
+		Env:     []string{"GITHUB_TOKEN", "NODE_OPTIONS"},
+	}
+	extra := server
+	extra.ExtraArgs = true
+	tests := []struct {
+		name   string
+		server configs.StdioServer
+		config model.McpConfig
+		ok     bool
+	}{
+		{"exact", server, model.McpConfig{Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"}}, true},
+		{"allowed env", server, model.McpConfig{Command: "npx", Args: server.Args, Env: map[string]string{"GITHUB_TOKEN": "x"}}, true},
+		{"other command", server, model.McpConfig{Command: "uvx", Args: server.Args}, false},
+		{"other package", server, model.McpConfig{Command: "npx", Args: []string{"-y", "evil-package"}}, false},
+		{"missing fixed args", server, model.McpConfig{Command: "npx"}, false},
+		{"extra args not allowed", server, model.McpConfig{Command: "npx", Args: append(server.Args, "--flag")}, false},
+		{"extra args allowed", extra, model.McpConfig{Command: "npx", Args: append(server.Args, "--flag")}, true},
+		{"env not listed", server, model.McpConfig{Command: "npx", Args: server.Args, Env: map[string]string{"OTHER": "x"}}, false},
+		{"denied env even if listed", server, model.McpConfig{Command: "npx", Args: server.Args, Env: map[string]string{"NODE_OPTIONS": "--require /tmp/x.js"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchStdioServer(tt.server, &tt.config); got != tt.ok {
+				t.Fatalf("matchStdioServer() = %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDeniedEnv(t *testing.T) {
+	for _, name := range []string{"LD_PRELOAD", "ld_preload", "DYLD_INSERT_LIBRARIES", "NPM_CONFIG_PREFIX", "PYTHONPATH"} {
+		if !deniedEnv(name) {
+			t.Errorf("%s should be denied", name)
+		}
+	}
+	if deniedEnv("GITHUB_TOKEN") {
+		t.Error("GITHUB_TOKEN should not be denied")
+	}
+}
+
+func TestBuildMcpServerConfigRejectsUnknownCredentialType(t *testing.T) {
+	_, err := BuildMcpServerConfig(&model.McpConfig{
+		Type:           model.McpTypeStreamableHTTP,
+		Url:            "https://example.com/mcp",
+		CredentialType: "legacy-token",
+	})
+	if err != biz.ErrMcpCredentialInvalid {
+		t.Fatalf("error = %v, want ErrMcpCredentialInvalid", err)
+	}
+}
+
+func TestBuildMcpServerConfigRechecksStdio(t *testing.T) {
+	// 没有配置任何 stdio 服务时，已经保存的 stdio 配置也不能启动
+	_, err := BuildMcpServerConfig(&model.McpConfig{Type: model.McpTypeStdio, Command: "npx"})
+	if err != biz.ErrMcpStdioNotAllowed {
+		t.Fatalf("error = %v, want ErrMcpStdioNotAllowed", err)
+	}
+}
@@ -0,0 +1,29 @@
+package shared
+
+import (
+	"context"
+	"model"
+
+	"github.com/google/uuid"
+)
+
+// SearchMemoriesRequest 检索用户在agent下和本次消息相关的记忆
+type SearchMemoriesRequest struct {
+	// Ctx 调用方的context，向量模型的请求使用
+	Ctx     context.Context `json:"-"`
+	UserID  uuid.UUID
+	AgentID uuid.UUID
+	Query   string
+}
+
+// ExtractMemoriesRequest 一轮对话结束后，从中提取需要长期记住的信息
+type ExtractMemoriesRequest struct {
+	UserID         uuid.UUID
+	AgentID        uuid.UUID
+	ConversationID *uuid.UUID
+	// ProviderConfig、ModelName 提取使用的模型，即agent自己的模型
+	ProviderConfig *model.ProviderConfig
+	ModelName      string
+	Message        string
+	Answer         string
+}
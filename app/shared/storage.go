@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PutBlobRequest 把文件保存到文件存储，按所有者的套餐检查单个文件的大小和总容量
+type PutBlobRequest struct {
+	Ctx     context.Context `json:"-"`
+	OwnerID uuid.UUID
+	Kind    model.BlobKind
+	RefID   uuid.UUID
+	// FileName 原始文件名，内容不能判断类型时按扩展名判断
+	FileName string
+	// ContentType 调用方已经按内容判断的类型，为空时由存储判断
+	ContentType string
+	Data        []byte
+}
+
+// ReadBlobRequest 读取对象的内容
+type ReadBlobRequest struct {
+	Ctx context.Context `json:"-"`
+	ID  uuid.UUID
+}
+
+// GetBlobURLRequest 生成对象的签名下载地址
+type GetBlobURLRequest struct {
+	Ctx context.Context `json:"-"`
+	ID  uuid.UUID
+}
+
+// ReadFormFile 读取表单中的 file 字段，超过 maxSize 时返回 tooLarge，其他错误返回 invalid
+func ReadFormFile(c *gin.Context, maxSize int64, tooLarge error, invalid error) ([]byte, string, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, "", tooLarge
+		}
+		return nil, "", invalid
+	}
+	if fileHeader.Size > maxSize {
+		return nil, "", tooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, "", invalid
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, "", invalid
+	}
+	return data, fileHeader.Filename, nil
+}
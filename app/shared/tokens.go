@@ -0,0 +1,25 @@
+package shared
+
+import (
+	"time"
+
+	"github.com/mszlu521/thunder/tools/jwt"
+)
+
+// RefreshTokenSubject 刷新令牌的 sub 声明，访问令牌没有 sub，用来区分两种令牌
+const RefreshTokenSubject = "refresh"
+
+// GenRefreshToken 生成刷新令牌，刷新令牌只能用来换取新的令牌，不能访问接口
+func GenRefreshToken(userId string, username string, expire time.Duration) (string, error) {
+	claims := jwt.CustomClaims{
+		UserId:   userId,
+		Username: username,
+	}
+	claims.Subject = RefreshTokenSubject
+	return jwt.GenerateToken(claims, expire)
+}
+
+// IsRefreshToken 解析出的令牌是否是刷新令牌
+func IsRefreshToken(claims *jwt.CustomClaims) bool {
+	return claims.Subject == RefreshTokenSubject
+}
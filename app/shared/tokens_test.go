@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mszlu521/thunder/tools/jwt"
+)
+
+func TestRefreshTokenIsDistinguishable(t *testing.T) {
+	jwt.Init("0123456789abcdef")
+	access, err := jwt.GenToken("u1", "alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err := GenRefreshToken("u1", "alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwt.ParseToken(access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsRefreshToken(claims) {
+		t.Error("access token treated as refresh token")
+	}
+	claims, err = jwt.ParseToken(refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsRefreshToken(claims) {
+		t.Error("refresh token not recognized")
+	}
+	if claims.UserId != "u1" || claims.Username != "alice" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
@@ -4,4 +4,12 @@ import "github.com/google/uuid"
 
 type GetToolsByIdsRequest struct {
 	Ids []uuid.UUID `json:"ids"`
+	// UserID 不为空时只返回该用户自己创建的和已安装的工具
+	UserID uuid.UUID `json:"userId"`
+}
+
+// GetToolsByNamesRequest 按名称查找用户可以使用的工具，导入agent时用来重新关联工具
+type GetToolsByNamesRequest struct {
+	Names  []string  `json:"names"`
+	UserID uuid.UUID `json:"userId"`
 }
@@ -0,0 +1,80 @@
+package shared
+
+import (
+	"context"
+	"core/ai/dbquery"
+	"core/ai/httptools"
+	"core/ai/mcps"
+	"core/ai/tools"
+	"model"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// BuildTools 把工具配置转为 eino 工具，调用方需要先通过 getToolsByIds 事件确认使用者仍然可以使用这些工具。
+// 单个工具配置有问题时只记录日志，不影响其他工具
+func BuildTools(toolList []*model.Tool) []tool.BaseTool {
+	var agentTools []tool.BaseTool
+	var mcpConfigs []*mcps.ServerConfig
+	for _, v := range toolList {
+		// 工具类型又system和mcp两种
+		switch v.ToolType {
+		case model.McpToolType:
+			// 查询出MCP工具列表，转为Eino中的BaseTool
+			if v.McpConfig == nil {
+				logs.Warnf("MCP工具缺少配置: %s", v.Name)
+				continue
+			}
+			mcpConfig, err := BuildMcpServerConfig(v.McpConfig)
+			if err != nil {
+				logs.Warnf("解析MCP配置时出错: %v", err)
+				continue
+			}
+			baseTools, err := mcps.GetEinoBaseTools(context.Background(), mcpConfig)
+			if err != nil {
+				logs.Warnf("获取MCP工具列表时出错: %v", err)
+				continue
+			}
+			agentTools = append(agentTools, baseTools...)
+			mcpConfigs = append(mcpConfigs, mcpConfig)
+		case model.HttpToolType:
+			if v.HttpConfig == nil {
+				logs.Warnf("Http工具缺少配置: %s", v.Name)
+				continue
+			}
+			endpoint, err := BuildHttpEndpoint(v)
+			if err != nil {
+				logs.Warnf("解析Http工具配置时出错: %v", err)
+				continue
+			}
+			agentTools = append(agentTools, httptools.NewTool(endpoint))
+		case model.DatabaseToolType:
+			if v.DataSource == nil {
+				logs.Warnf("数据库工具缺少数据源: %s", v.Name)
+				continue
+			}
+			source, err := BuildDataSource(v.DataSource)
+			if err != nil {
+				logs.Warnf("解析数据源配置时出错: %v", err)
+				continue
+			}
+			agentTools = append(agentTools, dbquery.NewTool(context.Background(), v.Name, v.Description, source))
+		case model.SystemToolType:
+			// 根据名称获取工具
+			systemTool := tools.FindTool(v.Name)
+			if systemTool == nil {
+				logs.Warnf("加载系统工具时，找不到工具: %s", v.Name)
+				continue
+			}
+			agentTools = append(agentTools, systemTool)
+		default:
+			logs.Warnf("Unknown tool type: %s", v.ToolType)
+		}
+	}
+	// MCP服务提供了资源时，额外给agent一个读取资源的工具
+	if resourceTool := mcps.NewReadResourceTool(context.Background(), mcpConfigs); resourceTool != nil {
+		agentTools = append(agentTools, resourceTool)
+	}
+	return agentTools
+}
@@ -0,0 +1,16 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// RunWorkflowRequest 触发器等模块同步运行工作流，Inputs 对应 start 节点定义的变量
+type RunWorkflowRequest struct {
+	// Ctx 调用方的context，取消时工作流停止运行
+	Ctx        context.Context `json:"-"`
+	UserID     uuid.UUID       `json:"userId"`
+	WorkflowID uuid.UUID       `json:"workflowId"`
+	Inputs     map[string]any  `json:"inputs"`
+}
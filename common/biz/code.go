@@ -16,18 +16,51 @@ var (
 	ErrCodeExpired      = errs.NewError(1011, "验证码已过期")
 	ErrPasswordProcess  = errs.NewError(1012, "密码处理失败")
 	ErrResetPwd         = errs.NewError(1013, "更新密码失败")
+	ErrApiTokenName     = errs.NewError(1014, "API令牌名称不能为空")
+	ErrApiTokenNotFound = errs.NewError(1015, "API令牌不存在")
 )
 
 var (
-	ErrAgentNotFound       = errs.NewError(2001, "Agent不存在")
-	ProviderConfigNotFound = errs.NewError(2002, "ProviderConfig不存在")
+	ErrAgentNotFound         = errs.NewError(2001, "Agent不存在")
+	ProviderConfigNotFound   = errs.NewError(2002, "ProviderConfig不存在")
+	ErrAgentInvoke           = errs.NewError(2003, "Agent调用失败")
+	ErrAgentNotPublishable   = errs.NewError(2004, "Agent未配置模型，不能发布")
+	ErrAgentVersionNotFound  = errs.NewError(2005, "Agent版本不存在")
+	ErrAgentVisibility       = errs.NewError(2006, "Agent可见范围错误")
+	ErrAgentNotShared        = errs.NewError(2007, "Agent未分享或分享已失效")
+	ErrAgentShareNotFound    = errs.NewError(2008, "分享链接不存在")
+	ErrGuestRateLimited      = errs.NewError(2009, "请求过于频繁，请稍后再试")
+	ErrGuestQuotaExceeded    = errs.NewError(2010, "Agent今日访客额度已用完")
+	ErrEmbedNotFound         = errs.NewError(2011, "嵌入密钥不存在或已失效")
+	ErrEmbedOrigin           = errs.NewError(2012, "嵌入来源配置错误")
+	ErrConversationNotFound  = errs.NewError(2013, "会话不存在")
+	ErrAgentBundleInvalid    = errs.NewError(2014, "导入的agent文件格式错误")
+	ErrAgentTemplateNotFound = errs.NewError(2015, "模板不存在")
+	ErrAgentPromptTemplate   = errs.NewError(2016, "提示词模板错误")
+	ErrPromptVariable        = errs.NewError(2017, "提示词变量错误")
+	ErrAgentOutputSchema     = errs.NewError(2018, "输出格式定义错误")
+	ErrAgentOutputInvalid    = errs.NewError(2019, "Agent的回答不符合输出格式")
+	ErrAgentStatusChange     = errs.NewError(2020, "Agent状态只能通过发布和回滚修改")
+	ErrGuestMessageInvalid   = errs.NewError(2021, "消息为空或超过长度限制")
 )
 
 var (
-	ErrToolNameExisted     = errs.NewError(3001, "工具名称已存在")
-	ErrToolNotExisted      = errs.NewError(3002, "工具不存在")
-	ErrMcpConfigNotExisted = errs.NewError(3003, "McpConfig不存在")
-	ErrGetMcpTools         = errs.NewError(3004, "获取McpTools失败")
+	ErrToolNameExisted      = errs.NewError(3001, "工具名称已存在")
+	ErrToolNotExisted       = errs.NewError(3002, "工具不存在")
+	ErrMcpConfigNotExisted  = errs.NewError(3003, "McpConfig不存在")
+	ErrGetMcpTools          = errs.NewError(3004, "获取McpTools失败")
+	ErrMcpTypeInvalid       = errs.NewError(3005, "Mcp传输类型错误")
+	ErrMcpUrlInvalid        = errs.NewError(3006, "Mcp地址错误")
+	ErrMcpStdioNotAllowed   = errs.NewError(3007, "Mcp stdio命令不在白名单中")
+	ErrMcpCredentialInvalid = errs.NewError(3008, "Mcp凭证配置错误")
+	ErrCredentialEncrypt    = errs.NewError(3009, "凭证加密失败")
+	ErrGetMcpPrompts        = errs.NewError(3010, "获取McpPrompts失败")
+	ErrHttpConfigInvalid    = errs.NewError(3011, "Http工具配置错误")
+	ErrOpenApiInvalid       = errs.NewError(3012, "OpenAPI文档解析失败")
+	ErrToolVisibility       = errs.NewError(3013, "工具可见范围错误")
+	ErrToolNotInstallable   = errs.NewError(3014, "工具不能安装")
+	ErrUserNoOrg            = errs.NewError(3015, "用户不属于任何组织")
+	ErrToolNotShareable     = errs.NewError(3016, "数据库工具不能共享")
 )
 var (
 	ErrKnowledgeBaseNotFound   = errs.NewError(4001, "知识库不存在")
@@ -37,3 +70,50 @@ var (
 	ErrEmbedding               = errs.NewError(4005, "Embedding错误")
 	ErrRetriever               = errs.NewError(4006, "Retriever错误")
 )
+var (
+	ErrDataSourceNotFound = errs.NewError(5001, "数据源不存在")
+	ErrDataSourceInvalid  = errs.NewError(5002, "数据源配置错误")
+	ErrDataSourceConnect  = errs.NewError(5003, "数据源连接失败")
+	ErrDataSourceQuery    = errs.NewError(5004, "数据源查询失败")
+
+	ErrWorkflowNotFound    = errs.NewError(6001, "工作流不存在")
+	ErrWorkflowInvalid     = errs.NewError(6002, "工作流定义错误")
+	ErrWorkflowLimit       = errs.NewError(6003, "工作流数量已达到套餐上限")
+	ErrWorkflowRunNotFound = errs.NewError(6004, "运行记录不存在")
+	ErrWorkflowRun         = errs.NewError(6005, "工作流运行失败")
+
+	ErrTriggerNotFound    = errs.NewError(7001, "触发器不存在")
+	ErrTriggerInvalid     = errs.NewError(7002, "触发器配置错误")
+	ErrTriggerCron        = errs.NewError(7003, "cron表达式错误")
+	ErrTriggerSecret      = errs.NewError(7004, "webhook密钥错误")
+	ErrTriggerDisabled    = errs.NewError(7005, "触发器已停用")
+	ErrTriggerRunNotFound = errs.NewError(7006, "触发记录不存在")
+	ErrTriggerRunNotRetry = errs.NewError(7007, "只有失败的运行可以重试")
+	ErrTriggerAgentDraft  = errs.NewError(7008, "Agent未发布，不能设置触发器")
+
+	ErrEvalDatasetNotFound = errs.NewError(8001, "评测数据集不存在")
+	ErrEvalCaseNotFound    = errs.NewError(8002, "评测用例不存在")
+	ErrEvalInvalid         = errs.NewError(8003, "评测配置错误")
+	ErrEvalCaseLimit       = errs.NewError(8004, "用例数量已达到上限")
+	ErrEvalDatasetEmpty    = errs.NewError(8005, "数据集没有用例")
+	ErrEvalRunNotFound     = errs.NewError(8006, "评测记录不存在")
+	ErrEvalRunFinished     = errs.NewError(8007, "评测已经结束")
+	ErrEvalCompare         = errs.NewError(8008, "只能对比同一个数据集的评测")
+
+	ErrAttachmentNotFound = errs.NewError(9001, "附件不存在")
+	ErrAttachmentInvalid  = errs.NewError(9002, "附件格式不支持")
+	ErrAttachmentTooLarge = errs.NewError(9003, "附件超过大小限制")
+	ErrAttachmentLimit    = errs.NewError(9004, "附件数量超过限制")
+	ErrAttachmentFetch    = errs.NewError(9005, "下载图片失败")
+	ErrVisionNotSupported = errs.NewError(9006, "当前模型不支持图片，且没有配置图像模型")
+	ErrAttachmentParse    = errs.NewError(9007, "文件解析失败")
+	ErrSessionNotFound    = errs.NewError(9008, "会话不存在")
+
+	ErrMemoryNotFound = errs.NewError(10001, "记忆不存在")
+
+	ErrBlobNotFound  = errs.NewError(11001, "文件不存在")
+	ErrBlobInvalid   = errs.NewError(11002, "文件格式不支持")
+	ErrBlobTooLarge  = errs.NewError(11003, "文件超过当前套餐的大小限制")
+	ErrStorageQuota  = errs.NewError(11004, "存储空间已达到当前套餐的上限")
+	ErrStorageFailed = errs.NewError(11005, "文件存储失败")
+)
@@ -0,0 +1,567 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config 业务相关的配置，框架相关的配置由 thunder 的 config.Config 负责
+type Config struct {
+	Secret     *Secret     `mapstructure:"secret"`
+	Mcp        *Mcp        `mapstructure:"mcp"`
+	McpServer  *McpServer  `mapstructure:"mcpServer"`
+	Share      *Share      `mapstructure:"share"`
+	Workflow   *Workflow   `mapstructure:"workflow"`
+	Trigger    *Trigger    `mapstructure:"trigger"`
+	Evaluation *Evaluation `mapstructure:"evaluation"`
+	Attachment *Attachment `mapstructure:"attachment"`
+	Memory     *Memory     `mapstructure:"memory"`
+	Storage    *Storage    `mapstructure:"storage"`
+	Proxy      *Proxy      `mapstructure:"proxy"`
+}
+
+var (
+	conf    atomic.Pointer[Config]
+	configV *viper.Viper
+)
+
+// SecretKeyEnv 设置 secret.key 的环境变量，优先于配置文件
+const SecretKeyEnv = "FABER_SECRET_KEY"
+
+// defaultSecretKey 之前配置文件中提交过的示例密钥，不能在部署中使用
+const defaultSecretKey = "faber-ai-secret!"
+
+// Secret 敏感数据加密配置
+type Secret struct {
+	// Key AES密钥，必须是16字节，用于加密工具凭证等敏感信息
+	Key *string `mapstructure:"key"`
+}
+
+func (s *Secret) GetKey() string {
+	if s == nil || s.Key == nil {
+		return ""
+	}
+	return *s.Key
+}
+
+// Validate 检查密钥已经配置、不是示例密钥并且长度正确，服务启动时调用
+func (s *Secret) Validate() error {
+	key := s.GetKey()
+	switch {
+	case key == "":
+		return fmt.Errorf("secret.key 未配置，请在配置文件或环境变量 %s 中设置", SecretKeyEnv)
+	case key == defaultSecretKey:
+		return errors.New("secret.key 不能使用示例密钥，请重新生成")
+	case len(key) != 16:
+		return errors.New("secret.key 必须是16字节")
+	}
+	return nil
+}
+
+// Mcp MCP客户端相关配置
+type Mcp struct {
+	// StdioServers 允许以 stdio 方式启动的MCP服务，为空表示不允许 stdio
+	StdioServers []StdioServer `mapstructure:"stdioServers"`
+}
+
+// StdioServer 允许以 stdio 方式启动的一个MCP服务。npx/uvx 这类命令的参数决定了执行哪个包，
+// 所以命令和参数都由管理员固定，用户只能在允许时追加参数，只能设置列出的环境变量
+type StdioServer struct {
+	Command string `mapstructure:"command"`
+	// Args 固定的参数，用户配置的参数开头必须和它完全一致，例如 ["-y", "@modelcontextprotocol/server-github"]
+	Args []string `mapstructure:"args"`
+	// ExtraArgs 是否允许在固定参数之后追加参数
+	ExtraArgs bool `mapstructure:"extraArgs"`
+	// Env 允许用户设置的环境变量名称
+	Env []string `mapstructure:"env"`
+}
+
+func (m *Mcp) GetStdioServers() []StdioServer {
+	if m == nil {
+		return nil
+	}
+	return m.StdioServers
+}
+
+// McpServer mcp-server 服务相关配置
+type McpServer struct {
+	// AppUrl FaberAI 后端服务地址，agent 的列表和调用通过后端的开放接口完成
+	AppUrl *string `mapstructure:"appUrl"`
+	// BaseUrl mcp-server 对外的访问地址，SSE 会用它生成消息端点的地址
+	BaseUrl *string `mapstructure:"baseUrl"`
+	// DisabledTools 不对外暴露的系统工具名称，修改后无需重启
+	DisabledTools []string `mapstructure:"disabledTools"`
+}
+
+func (m *McpServer) GetAppUrl() string {
+	if m == nil || m.AppUrl == nil {
+		return "http://127.0.0.1:8888"
+	}
+	return strings.TrimSuffix(*m.AppUrl, "/")
+}
+
+func (m *McpServer) GetBaseUrl() string {
+	if m == nil || m.BaseUrl == nil {
+		return "http://localhost:7777"
+	}
+	return strings.TrimSuffix(*m.BaseUrl, "/")
+}
+
+func (m *McpServer) GetDisabledTools() []string {
+	if m == nil || m.DisabledTools == nil {
+		return []string{}
+	}
+	return m.DisabledTools
+}
+
+// Share 访客通过分享链接或公开目录使用agent的限制
+type Share struct {
+	// RatePerMinute 每个访客（按IP）每分钟对同一个agent最多发送的消息数
+	RatePerMinute *int64 `mapstructure:"ratePerMinute"`
+	// DailyQuota 按创建者的套餐，每天最多可以被访客消耗的消息数，未配置的套餐使用 free 的值
+	DailyQuota map[string]int64 `mapstructure:"dailyQuota"`
+	// MaxMessageLength 访客单条消息的最大字符数
+	MaxMessageLength *int `mapstructure:"maxMessageLength"`
+}
+
+func (s *Share) GetRatePerMinute() int64 {
+	if s == nil || s.RatePerMinute == nil {
+		return 10
+	}
+	return *s.RatePerMinute
+}
+
+func (s *Share) GetDailyQuota(plan string) int64 {
+	if s != nil {
+		if quota, ok := s.DailyQuota[plan]; ok {
+			return quota
+		}
+		if quota, ok := s.DailyQuota["free"]; ok {
+			return quota
+		}
+	}
+	return 100
+}
+
+func (s *Share) GetMaxMessageLength() int {
+	if s == nil || s.MaxMessageLength == nil {
+		return 4000
+	}
+	return *s.MaxMessageLength
+}
+
+// Proxy 服务前面的反向代理
+type Proxy struct {
+	// TrustedProxies 可信的反向代理地址或网段，只有来自这些地址的 X-Forwarded-For 才用来识别访客IP，
+	// 为空时直接使用连接的地址，否则访客可以伪造请求头绕过按IP的限流
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+}
+
+func (p *Proxy) GetTrustedProxies() []string {
+	if p == nil {
+		return nil
+	}
+	return p.TrustedProxies
+}
+
+// Workflow 工作流的限制
+type Workflow struct {
+	// MaxWorkflows 按套餐每个用户最多可以创建的工作流数量，未配置的套餐使用 free 的值
+	MaxWorkflows map[string]int64 `mapstructure:"maxWorkflows"`
+	// MaxNodes 单个工作流最多的节点数
+	MaxNodes *int `mapstructure:"maxNodes"`
+	// RunTimeoutSeconds 单次运行的最长时间
+	RunTimeoutSeconds *int `mapstructure:"runTimeoutSeconds"`
+}
+
+func (w *Workflow) GetMaxWorkflows(plan string) int64 {
+	if w != nil {
+		if limit, ok := w.MaxWorkflows[plan]; ok {
+			return limit
+		}
+		if limit, ok := w.MaxWorkflows["free"]; ok {
+			return limit
+		}
+	}
+	return 10
+}
+
+func (w *Workflow) GetMaxNodes() int {
+	if w == nil || w.MaxNodes == nil {
+		return 100
+	}
+	return *w.MaxNodes
+}
+
+func (w *Workflow) GetRunTimeout() time.Duration {
+	if w == nil || w.RunTimeoutSeconds == nil {
+		return 10 * time.Minute
+	}
+	return time.Duration(*w.RunTimeoutSeconds) * time.Second
+}
+
+// Trigger 定时和 webhook 触发的运行配置
+type Trigger struct {
+	// PollIntervalSeconds 调度器检查到期任务的间隔
+	PollIntervalSeconds *int `mapstructure:"pollIntervalSeconds"`
+	// Concurrency 同时执行的运行数，超出的任务留到下一轮
+	Concurrency *int `mapstructure:"concurrency"`
+	// MaxBatchSize 手动批量运行一次最多提交的输入条数
+	MaxBatchSize *int `mapstructure:"maxBatchSize"`
+	// MaxRetries 触发器允许配置的最大重试次数
+	MaxRetries *int `mapstructure:"maxRetries"`
+	// RunTimeoutSeconds 运行agent的最长时间，工作流使用工作流自己的超时
+	RunTimeoutSeconds *int `mapstructure:"runTimeoutSeconds"`
+	// LeaseSeconds 执行中的运行的租约时长，执行期间定期续约，实例退出后租约过期的运行会被重新处理
+	LeaseSeconds *int `mapstructure:"leaseSeconds"`
+}
+
+func (t *Trigger) GetPollInterval() time.Duration {
+	if t == nil || t.PollIntervalSeconds == nil || *t.PollIntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(*t.PollIntervalSeconds) * time.Second
+}
+
+func (t *Trigger) GetConcurrency() int {
+	if t == nil || t.Concurrency == nil || *t.Concurrency <= 0 {
+		return 5
+	}
+	return *t.Concurrency
+}
+
+func (t *Trigger) GetMaxBatchSize() int {
+	if t == nil || t.MaxBatchSize == nil {
+		return 100
+	}
+	return *t.MaxBatchSize
+}
+
+func (t *Trigger) GetMaxRetries() int {
+	if t == nil || t.MaxRetries == nil {
+		return 5
+	}
+	return *t.MaxRetries
+}
+
+func (t *Trigger) GetRunTimeout() time.Duration {
+	if t == nil || t.RunTimeoutSeconds == nil {
+		return 5 * time.Minute
+	}
+	return time.Duration(*t.RunTimeoutSeconds) * time.Second
+}
+
+func (t *Trigger) GetLease() time.Duration {
+	if t == nil || t.LeaseSeconds == nil || *t.LeaseSeconds <= 0 {
+		return 2 * time.Minute
+	}
+	return time.Duration(*t.LeaseSeconds) * time.Second
+}
+
+// Evaluation agent评测的配置
+type Evaluation struct {
+	// MaxConcurrency 一次评测最多同时运行的用例数，评测请求中的并发数不能超过这个值
+	MaxConcurrency *int `mapstructure:"maxConcurrency"`
+	// MaxCases 一个数据集最多的用例数
+	MaxCases *int `mapstructure:"maxCases"`
+	// CaseTimeoutSeconds 单个用例运行agent和评分的最长时间
+	CaseTimeoutSeconds *int `mapstructure:"caseTimeoutSeconds"`
+	// FakeModel 允许使用内置的 fake 模型，只在测试和离线评测环境打开，默认关闭
+	FakeModel bool `mapstructure:"fakeModel"`
+}
+
+func (e *Evaluation) GetMaxConcurrency() int {
+	if e == nil || e.MaxConcurrency == nil || *e.MaxConcurrency <= 0 {
+		return 5
+	}
+	return *e.MaxConcurrency
+}
+
+func (e *Evaluation) GetMaxCases() int {
+	if e == nil || e.MaxCases == nil {
+		return 500
+	}
+	return *e.MaxCases
+}
+
+func (e *Evaluation) FakeModelEnabled() bool {
+	return e != nil && e.FakeModel
+}
+
+func (e *Evaluation) GetCaseTimeout() time.Duration {
+	if e == nil || e.CaseTimeoutSeconds == nil {
+		return 3 * time.Minute
+	}
+	return time.Duration(*e.CaseTimeoutSeconds) * time.Second
+}
+
+// Attachment 对话附件的配置
+type Attachment struct {
+	// MaxImageSize 单张图片的最大字节数
+	MaxImageSize *int64 `mapstructure:"maxImageSize"`
+	// MaxImages 一条消息最多附带的图片数
+	MaxImages *int `mapstructure:"maxImages"`
+	// MaxFileSize 会话中单个文件的最大字节数
+	MaxFileSize *int64 `mapstructure:"maxFileSize"`
+	// MaxSessionFiles 一个会话最多上传的文件数
+	MaxSessionFiles *int `mapstructure:"maxSessionFiles"`
+	// InlineChars 文件总字符数不超过这个值的部分直接放入上下文，超出的文件分块检索
+	InlineChars *int `mapstructure:"inlineChars"`
+	// ChunkSize、ChunkOverlap 检索时分块的字符数和相邻块重叠的字符数
+	ChunkSize    *int `mapstructure:"chunkSize"`
+	ChunkOverlap *int `mapstructure:"chunkOverlap"`
+	// TopK 每次对话检索的块数
+	TopK *int `mapstructure:"topK"`
+	// MaxCachedRetrievers 内存中最多缓存的会话检索器数量，超出时淘汰最久没有使用的
+	MaxCachedRetrievers *int `mapstructure:"maxCachedRetrievers"`
+}
+
+func (a *Attachment) GetMaxImageSize() int64 {
+	if a == nil || a.MaxImageSize == nil || *a.MaxImageSize <= 0 {
+		return 10 << 20
+	}
+	return *a.MaxImageSize
+}
+
+func (a *Attachment) GetMaxImages() int {
+	if a == nil || a.MaxImages == nil || *a.MaxImages <= 0 {
+		return 4
+	}
+	return *a.MaxImages
+}
+
+func (a *Attachment) GetMaxFileSize() int64 {
+	if a == nil || a.MaxFileSize == nil || *a.MaxFileSize <= 0 {
+		return 20 << 20
+	}
+	return *a.MaxFileSize
+}
+
+func (a *Attachment) GetMaxSessionFiles() int {
+	if a == nil || a.MaxSessionFiles == nil || *a.MaxSessionFiles <= 0 {
+		return 10
+	}
+	return *a.MaxSessionFiles
+}
+
+func (a *Attachment) GetInlineChars() int {
+	if a == nil || a.InlineChars == nil || *a.InlineChars < 0 {
+		return 8000
+	}
+	return *a.InlineChars
+}
+
+func (a *Attachment) GetChunkSize() int {
+	if a == nil || a.ChunkSize == nil || *a.ChunkSize <= 0 {
+		return 800
+	}
+	return *a.ChunkSize
+}
+
+func (a *Attachment) GetChunkOverlap() int {
+	if a == nil || a.ChunkOverlap == nil || *a.ChunkOverlap < 0 {
+		return 100
+	}
+	return *a.ChunkOverlap
+}
+
+func (a *Attachment) GetTopK() int {
+	if a == nil || a.TopK == nil || *a.TopK <= 0 {
+		return 4
+	}
+	return *a.TopK
+}
+
+func (a *Attachment) GetMaxCachedRetrievers() int {
+	if a == nil || a.MaxCachedRetrievers == nil || *a.MaxCachedRetrievers <= 0 {
+		return 200
+	}
+	return *a.MaxCachedRetrievers
+}
+
+// Memory 用户长期记忆的配置
+type Memory struct {
+	// Disabled 关闭后对话中不再提取和使用记忆，已有的记忆仍然可以查看和删除
+	Disabled bool `mapstructure:"disabled"`
+	// MaxMemories 每个用户在每个agent下最多保存的记忆数，超过时删除最早的
+	MaxMemories *int `mapstructure:"maxMemories"`
+	// TopK 每次对话放入提示词的记忆数
+	TopK *int `mapstructure:"topK"`
+	// MinScore 使用向量检索时，相似度低于这个值的记忆不放入提示词
+	MinScore *float64 `mapstructure:"minScore"`
+}
+
+func (m *Memory) IsEnabled() bool {
+	return m == nil || !m.Disabled
+}
+
+func (m *Memory) GetMaxMemories() int {
+	if m == nil || m.MaxMemories == nil || *m.MaxMemories <= 0 {
+		return 100
+	}
+	return *m.MaxMemories
+}
+
+func (m *Memory) GetTopK() int {
+	if m == nil || m.TopK == nil || *m.TopK <= 0 {
+		return 5
+	}
+	return *m.TopK
+}
+
+func (m *Memory) GetMinScore() float64 {
+	if m == nil || m.MinScore == nil {
+		return 0.3
+	}
+	return *m.MinScore
+}
+
+// Storage 文件存储的配置
+type Storage struct {
+	// Driver 存储后端：local 本地磁盘，s3 S3 兼容的对象存储
+	Driver string        `mapstructure:"driver"`
+	Local  *LocalStorage `mapstructure:"local"`
+	S3     *S3Storage    `mapstructure:"s3"`
+	// SignedURLTTLSeconds 签名下载地址的有效期
+	SignedURLTTLSeconds *int `mapstructure:"signedUrlTtlSeconds"`
+	// MaxObjectSize 按套餐单个文件的最大字节数，未配置的套餐使用 free 的值
+	MaxObjectSize map[string]int64 `mapstructure:"maxObjectSize"`
+	// Quota 按套餐每个用户最多使用的存储字节数，未配置的套餐使用 free 的值
+	Quota map[string]int64 `mapstructure:"quota"`
+	// GCIntervalMinutes 清理孤立对象的间隔
+	GCIntervalMinutes *int `mapstructure:"gcIntervalMinutes"`
+	// GCGraceMinutes 新上传的对象在这段时间内不清理，等待引用它的记录保存
+	GCGraceMinutes *int `mapstructure:"gcGraceMinutes"`
+}
+
+// LocalStorage 本地磁盘存储
+type LocalStorage struct {
+	// Root 文件保存的目录
+	Root string `mapstructure:"root"`
+	// BaseURL 服务对外的地址，签名下载地址由它加上下载接口的路径组成
+	BaseURL string `mapstructure:"baseUrl"`
+}
+
+// S3Storage S3 兼容的对象存储
+type S3Storage struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	// PathStyle 使用 endpoint/bucket/key 形式的地址，MinIO 等自建服务通常需要开启
+	PathStyle bool `mapstructure:"pathStyle"`
+}
+
+func (s *Storage) GetDriver() string {
+	if s == nil || s.Driver == "" {
+		return "local"
+	}
+	return s.Driver
+}
+
+func (s *Storage) GetLocal() *LocalStorage {
+	local := LocalStorage{Root: "data/blobs", BaseURL: "http://localhost:8888"}
+	if s != nil && s.Local != nil {
+		if s.Local.Root != "" {
+			local.Root = s.Local.Root
+		}
+		if s.Local.BaseURL != "" {
+			local.BaseURL = strings.TrimSuffix(s.Local.BaseURL, "/")
+		}
+	}
+	return &local
+}
+
+func (s *Storage) GetS3() *S3Storage {
+	if s == nil || s.S3 == nil {
+		return &S3Storage{}
+	}
+	return s.S3
+}
+
+func (s *Storage) GetSignedURLTTL() time.Duration {
+	if s == nil || s.SignedURLTTLSeconds == nil || *s.SignedURLTTLSeconds <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(*s.SignedURLTTLSeconds) * time.Second
+}
+
+func (s *Storage) GetMaxObjectSize(plan string) int64 {
+	if s != nil {
+		if limit, ok := s.MaxObjectSize[plan]; ok {
+			return limit
+		}
+		if limit, ok := s.MaxObjectSize["free"]; ok {
+			return limit
+		}
+	}
+	return 10 << 20
+}
+
+func (s *Storage) GetQuota(plan string) int64 {
+	if s != nil {
+		if quota, ok := s.Quota[plan]; ok {
+			return quota
+		}
+		if quota, ok := s.Quota["free"]; ok {
+			return quota
+		}
+	}
+	return 100 << 20
+}
+
+func (s *Storage) GetGCInterval() time.Duration {
+	if s == nil || s.GCIntervalMinutes == nil || *s.GCIntervalMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(*s.GCIntervalMinutes) * time.Minute
+}
+
+func (s *Storage) GetGCGrace() time.Duration {
+	if s == nil || s.GCGraceMinutes == nil || *s.GCGraceMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(*s.GCGraceMinutes) * time.Minute
+}
+
+// Init 从 thunder 加载好的 viper 中解析业务配置
+// 注意：viper 只保存一个 OnConfigChange 回调，已被 thunder 占用，这里不注册回调；
+// thunder 热加载时已经把新内容读入了 viper，需要最新配置的地方调用 Reload 即可
+func Init(v *viper.Viper) {
+	configV = v
+	if _, err := Reload(); err != nil {
+		log.Fatalf("configs unmarshal failed, err:%v", err)
+	}
+}
+
+// Reload 重新从 viper 解析业务配置，解析失败时保留原来的配置
+func Reload() (*Config, error) {
+	c := new(Config)
+	if err := configV.Unmarshal(c); err != nil {
+		return GetConfig(), err
+	}
+	if key := os.Getenv(SecretKeyEnv); key != "" {
+		if c.Secret == nil {
+			c.Secret = new(Secret)
+		}
+		c.Secret.Key = &key
+	}
+	conf.Store(c)
+	return c, nil
+}
+
+// GetConfig 返回业务配置，调用前必须先调用 Init
+func GetConfig() *Config {
+	if c := conf.Load(); c != nil {
+		return c
+	}
+	return new(Config)
+}
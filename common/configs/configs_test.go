@@ -0,0 +1,85 @@
+package configs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSecretValidate(t *testing.T) {
+	key := func(s string) *Secret { return &Secret{Key: &s} }
+	tests := []struct {
+		name   string
+		secret *Secret
+		ok     bool
+	}{
+		{"missing section", nil, false},
+		{"empty", key(""), false},
+		{"sample key", key(defaultSecretKey), false},
+		{"wrong length", key("too-short"), false},
+		{"valid", key("0123456789abcdef"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.secret.Validate(); (err == nil) != tt.ok {
+				t.Fatalf("Validate() error = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestShareAndProxyDefaults(t *testing.T) {
+	var share *Share
+	if got := share.GetMaxMessageLength(); got != 4000 {
+		t.Errorf("GetMaxMessageLength() = %d, want 4000", got)
+	}
+	length := 100
+	if got := (&Share{MaxMessageLength: &length}).GetMaxMessageLength(); got != 100 {
+		t.Errorf("GetMaxMessageLength() = %d, want 100", got)
+	}
+	// 没有配置时不信任任何代理
+	var proxy *Proxy
+	if got := proxy.GetTrustedProxies(); got != nil {
+		t.Errorf("GetTrustedProxies() = %v, want nil", got)
+	}
+}
+
+func TestTriggerLeaseDefault(t *testing.T) {
+	var trigger *Trigger
+	if got := trigger.GetLease(); got != 2*time.Minute {
+		t.Errorf("GetLease() = %v, want 2m", got)
+	}
+	seconds := 30
+	if got := (&Trigger{LeaseSeconds: &seconds}).GetLease(); got != 30*time.Second {
+		t.Errorf("GetLease() = %v, want 30s", got)
+	}
+}
+
+func TestFakeModelDisabledByDefault(t *testing.T) {
+	var evaluation *Evaluation
+	if evaluation.FakeModelEnabled() || (&Evaluation{}).FakeModelEnabled() {
+		t.Error("fake model should be disabled by default")
+	}
+	if !(&Evaluation{FakeModel: true}).FakeModelEnabled() {
+		t.Error("fake model should be enabled")
+	}
+}
+
+func TestStoragePlanLimitsFallBackToFree(t *testing.T) {
+	storage := &Storage{
+		Quota:         map[string]int64{"free": 1 << 20, "pro": 1 << 30},
+		MaxObjectSize: map[string]int64{"free": 1 << 10},
+	}
+	if got := storage.GetQuota("pro"); got != 1<<30 {
+		t.Errorf("GetQuota(pro) = %d", got)
+	}
+	if got := storage.GetQuota("team"); got != 1<<20 {
+		t.Errorf("GetQuota(team) = %d, want free quota", got)
+	}
+	if got := storage.GetMaxObjectSize("pro"); got != 1<<10 {
+		t.Errorf("GetMaxObjectSize(pro) = %d, want free limit", got)
+	}
+	var empty *Storage
+	if empty.GetQuota("pro") != 100<<20 || empty.GetMaxObjectSize("pro") != 10<<20 {
+		t.Error("nil storage should use the defaults")
+	}
+}
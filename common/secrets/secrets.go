@@ -0,0 +1,34 @@
+package secrets
+
+import (
+	"common/configs"
+	"errors"
+
+	"github.com/mszlu521/thunder/tools/crypro"
+)
+
+var ErrSecretKeyMissing = errors.New("secret.key 未配置")
+
+// Encrypt 使用配置中的密钥加密敏感信息，空字符串不加密
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	key := configs.GetConfig().Secret.GetKey()
+	if key == "" {
+		return "", ErrSecretKeyMissing
+	}
+	return crypro.EncryptString(key, plaintext)
+}
+
+// Decrypt 解密 Encrypt 加密的内容
+func Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	key := configs.GetConfig().Secret.GetKey()
+	if key == "" {
+		return "", ErrSecretKeyMissing
+	}
+	return crypro.DecryptString(key, ciphertext)
+}
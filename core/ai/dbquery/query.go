@@ -0,0 +1,146 @@
+package dbquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 单元格在 markdown 表格中显示的最大长度
+const maxCellLen = 200
+
+// Result 查询结果
+type Result struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	// Truncated 结果超过了行数限制，只返回了前面部分
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Query 校验并在只读事务中执行查询，最多读取 MaxRows 行
+func Query(ctx context.Context, source *Source, query string) (*Result, error) {
+	query, err := ValidateSelect(source.Type, query)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, source.timeout())
+	defer cancel()
+	tx, err := source.readOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Columns: columns, Rows: [][]any{}}
+	maxRows := source.maxRows()
+	for rows.Next() {
+		if len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			values[i] = normalize(v)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil && !result.Truncated {
+		return nil, err
+	}
+	return result, nil
+}
+
+// normalize 把驱动返回的值转为可以序列化的形式
+func normalize(v any) any {
+	switch t := v.(type) {
+	case []byte:
+		if utf8.Valid(t) {
+			return string(t)
+		}
+		return fmt.Sprintf("<binary %d bytes>", len(t))
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+	return v
+}
+
+// Markdown 把结果转为 markdown 表格
+func (r *Result) Markdown() string {
+	if len(r.Columns) == 0 {
+		return "(no columns)"
+	}
+	var builder strings.Builder
+	builder.WriteString("| " + strings.Join(escapeCells(r.Columns), " | ") + " |\n")
+	builder.WriteString("|" + strings.Repeat(" --- |", len(r.Columns)) + "\n")
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = formatCell(v)
+		}
+		builder.WriteString("| " + strings.Join(escapeCells(cells), " | ") + " |\n")
+	}
+	if len(r.Rows) == 0 {
+		builder.WriteString("\n(0 rows)")
+	} else if r.Truncated {
+		builder.WriteString(fmt.Sprintf("\n(只显示了前 %d 行，请缩小查询范围或使用聚合)", len(r.Rows)))
+	}
+	return builder.String()
+}
+
+// JSON 把结果转为对象数组
+func (r *Result) JSON() (string, error) {
+	records := make([]map[string]any, len(r.Rows))
+	for i, row := range r.Rows {
+		record := make(map[string]any, len(r.Columns))
+		for j, column := range r.Columns {
+			record[column] = row[j]
+		}
+		records[i] = record
+	}
+	data, err := json.Marshal(map[string]any{
+		"rows":      records,
+		"truncated": r.Truncated,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func formatCell(v any) string {
+	if v == nil {
+		return "NULL"
+	}
+	s := fmt.Sprint(v)
+	if utf8.RuneCountInString(s) > maxCellLen {
+		s = string([]rune(s)[:maxCellLen]) + "..."
+	}
+	return s
+}
+
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		escaped[i] = strings.Join(strings.Fields(cell), " ")
+	}
+	return escaped
+}
@@ -0,0 +1,127 @@
+package dbquery
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSQLiteSource 创建包含测试数据的 SQLite 数据源
+func newSQLiteSource(t *testing.T) *Source {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, comment TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amount REAL)",
+		"INSERT INTO users (name, comment) VALUES ('alice', 'a'), ('bob', NULL), ('carol', 'c')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := &Source{Type: SQLite, Dsn: dsn}
+	t.Cleanup(func() { Release(source) })
+	return source
+}
+
+func TestQuerySQLite(t *testing.T) {
+	source := newSQLiteSource(t)
+	result, err := Query(context.Background(), source, "SELECT id, replace(name, 'a', 'A') AS name, comment FROM users ORDER BY id;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Columns, ",") != "id,name,comment" {
+		t.Errorf("columns = %v", result.Columns)
+	}
+	if len(result.Rows) != 3 || result.Rows[0][1] != "Alice" || result.Rows[1][2] != nil {
+		t.Errorf("rows = %v", result.Rows)
+	}
+}
+
+func TestQueryMaxRows(t *testing.T) {
+	source := newSQLiteSource(t)
+	source.MaxRows = 2
+	result, err := Query(context.Background(), source, "SELECT name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 2 || !result.Truncated {
+		t.Errorf("rows = %v, truncated = %v", result.Rows, result.Truncated)
+	}
+}
+
+func TestQueryIsReadOnly(t *testing.T) {
+	source := newSQLiteSource(t)
+	if _, err := Query(context.Background(), source, "DELETE FROM users"); err == nil {
+		t.Fatal("delete should be rejected")
+	}
+	// 绕过校验直接在只读事务中执行写操作，也会被数据库拒绝
+	tx, err := source.readOnlyTx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM users"); err == nil {
+		t.Fatal("write in read-only transaction should fail")
+	}
+}
+
+func TestSchemaSQLite(t *testing.T) {
+	source := newSQLiteSource(t)
+	tables, err := Schema(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].Name != "orders" || tables[1].Name != "users" {
+		t.Fatalf("tables = %+v", tables)
+	}
+	users := tables[1]
+	if len(users.Columns) != 3 || users.Columns[1].Name != "name" || users.Columns[1].Nullable {
+		t.Errorf("users columns = %+v", users.Columns)
+	}
+	source.Tables = []string{"users"}
+	tables, err = Schema(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].Name != "users" {
+		t.Errorf("filtered tables = %+v", tables)
+	}
+	if text := FormatSchema(tables); !strings.Contains(text, "users(id INTEGER, name TEXT NOT NULL, comment TEXT)") {
+		t.Errorf("FormatSchema() = %q", text)
+	}
+}
+
+func TestReleaseClosesPool(t *testing.T) {
+	source := newSQLiteSource(t)
+	if _, err := Query(context.Background(), source, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	poolsMu.Lock()
+	db := pools[source.key()]
+	poolsMu.Unlock()
+	if db == nil {
+		t.Fatal("pool should be cached")
+	}
+	Release(source)
+	poolsMu.Lock()
+	_, ok := pools[source.key()]
+	poolsMu.Unlock()
+	if ok {
+		t.Error("pool should be removed")
+	}
+	if err := db.Ping(); err == nil {
+		t.Error("released pool should be closed")
+	}
+	// 再次查询时重新建立连接池
+	if _, err := Query(context.Background(), source, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+package dbquery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaCacheTTL 表结构缓存时间，表结构很少变化，不必每次构建 agent 都查询
+const schemaCacheTTL = 10 * time.Minute
+
+// Table 一张表的结构
+type Table struct {
+	Schema  string    `json:"schema"`
+	Name    string    `json:"name"`
+	Columns []*Column `json:"columns"`
+}
+
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// FullName 带 schema 的表名，PostgreSQL 的 public 下的表省略 schema
+func (t *Table) FullName() string {
+	if t.Schema == "" || t.Schema == "public" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+const postgresColumnsQuery = `SELECT table_schema, table_name, column_name, data_type, is_nullable
+FROM information_schema.columns
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema') AND table_schema NOT LIKE 'pg_toast%'
+ORDER BY table_schema, table_name, ordinal_position`
+
+const mysqlColumnsQuery = `SELECT table_schema, table_name, column_name, column_type, is_nullable
+FROM information_schema.columns
+WHERE table_schema = DATABASE()
+ORDER BY table_name, ordinal_position`
+
+const sqliteColumnsQuery = `SELECT '', m.name, p.name, p.type, CASE WHEN p."notnull" = 0 THEN 'YES' ELSE 'NO' END
+FROM sqlite_master m JOIN pragma_table_info(m.name) p
+WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+ORDER BY m.name, p.cid`
+
+type cachedSchema struct {
+	tables   []*Table
+	loadedAt time.Time
+}
+
+var (
+	schemaMu    sync.Mutex
+	schemaCache = make(map[string]*cachedSchema)
+)
+
+// Schema 读取数据源中的表结构，配置了 Tables 时只返回其中的表
+func Schema(ctx context.Context, source *Source) ([]*Table, error) {
+	key := source.key()
+	schemaMu.Lock()
+	cached, ok := schemaCache[key]
+	schemaMu.Unlock()
+	var tables []*Table
+	if ok && time.Since(cached.loadedAt) < schemaCacheTTL {
+		tables = cached.tables
+	} else {
+		var err error
+		if tables, err = loadSchema(ctx, source); err != nil {
+			return nil, err
+		}
+		schemaMu.Lock()
+		schemaCache[key] = &cachedSchema{tables: tables, loadedAt: time.Now()}
+		schemaMu.Unlock()
+	}
+	if len(source.Tables) == 0 {
+		return tables, nil
+	}
+	var filtered []*Table
+	for _, table := range tables {
+		if slices.Contains(source.Tables, table.Name) || slices.Contains(source.Tables, table.FullName()) {
+			filtered = append(filtered, table)
+		}
+	}
+	return filtered, nil
+}
+
+func loadSchema(ctx context.Context, source *Source) ([]*Table, error) {
+	query := postgresColumnsQuery
+	switch source.Type {
+	case MySQL:
+		query = mysqlColumnsQuery
+	case SQLite:
+		query = sqliteColumnsQuery
+	}
+	ctx, cancel := context.WithTimeout(ctx, source.timeout())
+	defer cancel()
+	tx, err := source.readOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []*Table
+	var current *Table
+	for rows.Next() {
+		var schemaName, tableName, columnName, dataType, nullable string
+		if err := rows.Scan(&schemaName, &tableName, &columnName, &dataType, &nullable); err != nil {
+			return nil, err
+		}
+		if source.Type == MySQL {
+			// MySQL 只查询当前库，表名不需要带库名
+			schemaName = ""
+		}
+		if current == nil || current.Schema != schemaName || current.Name != tableName {
+			current = &Table{Schema: schemaName, Name: tableName}
+			tables = append(tables, current)
+		}
+		current.Columns = append(current.Columns, &Column{
+			Name:     columnName,
+			Type:     dataType,
+			Nullable: nullable == "YES",
+		})
+	}
+	return tables, rows.Err()
+}
+
+// FormatSchema 把表结构格式化为给模型看的文本
+func FormatSchema(tables []*Table) string {
+	var builder strings.Builder
+	for _, table := range tables {
+		columns := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			columns[i] = column.Name + " " + column.Type
+			if !column.Nullable {
+				columns[i] += " NOT NULL"
+			}
+		}
+		builder.WriteString(fmt.Sprintf("- %s(%s)\n", table.FullName(), strings.Join(columns, ", ")))
+	}
+	return builder.String()
+}
@@ -0,0 +1,171 @@
+package dbquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// 支持的数据库类型
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	// SQLite 的 DSN 是服务器上的文件路径，只用于本地数据和测试，不应开放给用户配置
+	SQLite = "sqlite"
+)
+
+const (
+	DefaultMaxRows = 100
+	// MaxRowsLimit 用户可以配置的最大行数
+	MaxRowsLimit   = 1000
+	DefaultTimeout = 10 * time.Second
+)
+
+// Source 一个可查询的数据源，Dsn 是解密后的明文
+type Source struct {
+	Type string
+	Dsn  string
+	// Tables 暴露给模型的表，为空表示全部
+	Tables  []string
+	MaxRows int
+	Timeout time.Duration
+}
+
+func (s *Source) maxRows() int {
+	if s.MaxRows <= 0 {
+		return DefaultMaxRows
+	}
+	return min(s.MaxRows, MaxRowsLimit)
+}
+
+func (s *Source) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return s.Timeout
+}
+
+func driverName(sourceType string) (string, error) {
+	switch sourceType {
+	case Postgres:
+		return "pgx", nil
+	case MySQL:
+		return "mysql", nil
+	case SQLite:
+		return "sqlite3", nil
+	}
+	return "", fmt.Errorf("unsupported data source type: %s", sourceType)
+}
+
+// key 数据源的唯一标识，用 DSN 的哈希避免在内存中多保存一份明文
+func (s *Source) key() string {
+	sum := sha256.Sum256([]byte(s.Type + "\x00" + s.Dsn))
+	return hex.EncodeToString(sum[:])
+}
+
+// 每个数据源共用一个连接池
+var (
+	poolsMu sync.Mutex
+	pools   = make(map[string]*sql.DB)
+)
+
+func (s *Source) db() (*sql.DB, error) {
+	driver, err := driverName(s.Type)
+	if err != nil {
+		return nil, err
+	}
+	key := s.key()
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if db, ok := pools[key]; ok {
+		return db, nil
+	}
+	db, err := sql.Open(driver, s.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(3)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	pools[key] = db
+	return db, nil
+}
+
+// Ping 测试数据源是否可以连接
+func Ping(ctx context.Context, source *Source) error {
+	driver, err := driverName(source.Type)
+	if err != nil {
+		return err
+	}
+	// 测试时不放进连接池，DSN 填错的数据源不需要保留连接
+	db, err := sql.Open(driver, source.Dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(ctx, source.timeout())
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// Release 关闭数据源的连接池，数据源修改了 DSN 或被删除后调用，
+// 正在执行的查询结束后连接才会真正关闭
+func Release(source *Source) {
+	key := source.key()
+	poolsMu.Lock()
+	db, ok := pools[key]
+	delete(pools, key)
+	poolsMu.Unlock()
+	schemaMu.Lock()
+	delete(schemaCache, key)
+	schemaMu.Unlock()
+	if ok {
+		_ = db.Close()
+	}
+}
+
+// Close 关闭所有连接池
+func Close() error {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	for key, db := range pools {
+		_ = db.Close()
+		delete(pools, key)
+	}
+	return nil
+}
+
+// readOnlyTx 开启只读事务并设置语句超时，调用方负责回滚
+func (s *Source) readOnlyTx(ctx context.Context) (*sql.Tx, error) {
+	db, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	switch s.Type {
+	case Postgres:
+		// 客户端取消之外再让服务端也限制执行时间
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", s.timeout().Milliseconds())); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	case SQLite:
+		// SQLite 的驱动忽略只读事务选项，改为在连接上禁止写入
+		if _, err := tx.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,96 @@
+package dbquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 工具描述中表结构的最大长度，表太多时模型可以自己查询 information_schema
+const maxSchemaDescLen = 8 * 1024
+
+// 返回格式
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+type queryParams struct {
+	Sql    string `json:"sql"`
+	Format string `json:"format"`
+}
+
+// Tool 在数据源上执行只读查询的 eino 工具，工具描述中包含表结构
+type Tool struct {
+	name   string
+	desc   string
+	source *Source
+}
+
+// NewTool 创建查询工具，读取表结构失败时仍然可以使用，只是描述中没有表结构
+func NewTool(ctx context.Context, name string, description string, source *Source) *Tool {
+	var builder strings.Builder
+	if description != "" {
+		builder.WriteString(description + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("在 %s 数据库上执行只读 SQL 查询，只允许单条 SELECT 语句，最多返回 %d 行，需要统计时请使用聚合函数。", source.Type, source.maxRows()))
+	tables, err := Schema(ctx, source)
+	if err != nil {
+		logs.Warnf("load data source schema error: %v", err)
+	} else if len(tables) > 0 {
+		text := FormatSchema(tables)
+		if len(text) > maxSchemaDescLen {
+			text = text[:strings.LastIndex(text[:maxSchemaDescLen], "\n")+1] + "...（更多表请查询 information_schema）\n"
+		}
+		builder.WriteString("\n可用的表：\n" + text)
+	}
+	return &Tool{
+		name:   name,
+		desc:   builder.String(),
+		source: source,
+	}
+}
+
+func (t *Tool) Params() map[string]*schema.ParameterInfo {
+	return map[string]*schema.ParameterInfo{
+		"sql": {
+			Type:     schema.String,
+			Desc:     "要执行的 SELECT 语句",
+			Required: true,
+		},
+		"format": {
+			Type: schema.String,
+			Desc: "结果格式，默认 markdown 表格",
+			Enum: []string{FormatMarkdown, FormatJSON},
+		},
+	}
+}
+
+func (t *Tool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        t.name,
+		Desc:        t.desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.Params()),
+	}, nil
+}
+
+func (t *Tool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var params queryParams
+	if err := json.Unmarshal([]byte(argumentsInJSON), &params); err != nil {
+		return "", err
+	}
+	result, err := Query(ctx, t.source, params.Sql)
+	if err != nil {
+		// 语句错误交给模型修正
+		return fmt.Sprintf("查询失败：%v", err), nil
+	}
+	if params.Format == FormatJSON {
+		return result.JSON()
+	}
+	return result.Markdown(), nil
+}
@@ -0,0 +1,233 @@
+package dbquery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrEmptyQuery     = errors.New("query is empty")
+	ErrMultiStatement = errors.New("only a single statement is allowed")
+	ErrNotSelect      = errors.New("only SELECT statements are allowed")
+)
+
+// 只能作为一条语句开头的关键字：写操作、DDL、权限、会话设置等。
+// 它们也常作为函数名（REPLACE）或列名（comment、share）出现，所以只在可以开始一条语句的位置检查
+var statementKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true, "REPLACE": true,
+	"DROP": true, "ALTER": true, "CREATE": true, "TRUNCATE": true, "RENAME": true, "COMMENT": true,
+	"GRANT": true, "REVOKE": true, "COPY": true, "CALL": true, "EXEC": true, "EXECUTE": true, "DO": true,
+	"LOCK": true, "UNLOCK": true, "SET": true, "RESET": true, "VACUUM": true, "ANALYZE": true,
+	"REINDEX": true, "CLUSTER": true, "LOAD": true, "HANDLER": true, "PREPARE": true, "DEALLOCATE": true,
+	"LISTEN": true, "NOTIFY": true, "BEGIN": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true,
+	"ATTACH": true, "DETACH": true, "PRAGMA": true, "EXPLAIN": true,
+}
+
+// WITH 查询中主语句可以是写操作，这些关键字在 WITH 查询的最外层出现即拒绝
+var dmlKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+}
+
+// 锁定读取的子句 FOR UPDATE、FOR SHARE、FOR NO KEY UPDATE、FOR KEY SHARE
+var lockStrengths = map[string]bool{
+	"UPDATE": true, "SHARE": true, "NO": true, "KEY": true,
+}
+
+// 有副作用或能读取服务器文件的函数
+var forbiddenFunctions = map[string]bool{
+	"PG_READ_FILE": true, "PG_READ_BINARY_FILE": true, "PG_LS_DIR": true, "PG_STAT_FILE": true,
+	"LO_IMPORT": true, "LO_EXPORT": true, "LO_UNLINK": true, "DBLINK": true, "DBLINK_EXEC": true,
+	"SET_CONFIG": true, "NEXTVAL": true, "SETVAL": true, "PG_TERMINATE_BACKEND": true,
+	"PG_CANCEL_BACKEND": true, "PG_RELOAD_CONF": true, "PG_ROTATE_LOGFILE": true,
+	"PG_ADVISORY_LOCK": true, "PG_ADVISORY_XACT_LOCK": true, "PG_SLEEP": true,
+	"LOAD_FILE": true, "SLEEP": true, "BENCHMARK": true, "GET_LOCK": true,
+	"LOAD_EXTENSION": true, "READFILE": true, "WRITEFILE": true, "FTS3_TOKENIZER": true,
+}
+
+// ValidateSelect 校验语句是只读的单条 SELECT（可以带 WITH），返回去掉末尾分号的语句。
+// 这里只做词法层面的检查，执行时还会放在只读事务中
+func ValidateSelect(dialect string, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	words, err := scanWords(dialect, query)
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", ErrEmptyQuery
+	}
+	first := words[0]
+	if first.quoted || (first.text != "SELECT" && first.text != "WITH") {
+		return "", ErrNotSelect
+	}
+	with := first.text == "WITH"
+	// withHeader WITH 查询还没有到主语句
+	withHeader := with
+	depth := 0
+	for i, w := range words {
+		var prev, prev2, next word
+		if i > 0 {
+			prev = words[i-1]
+		}
+		if i > 1 {
+			prev2 = words[i-2]
+		}
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+		switch w.text {
+		case ";":
+			// 只允许出现在末尾
+			if i != len(words)-1 {
+				return "", ErrMultiStatement
+			}
+			query = strings.TrimSpace(query[:w.pos])
+			continue
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if w.quoted {
+			if forbiddenFunctions[w.text] && next.text == "(" {
+				return "", fmt.Errorf("function %s is not allowed", strings.ToLower(w.text))
+			}
+			continue
+		}
+		// CTE 的主体 AS (...) 和 WITH 之后的主语句可以是一条完整的语句
+		cteBody := prev.text == "(" && !prev2.quoted && (prev2.text == "AS" || prev2.text == "MATERIALIZED")
+		mainStatement := false
+		if withHeader && depth == 0 && prev.text == ")" && w.text != "," && w.text != "AS" {
+			withHeader = false
+			mainStatement = true
+		}
+		if (cteBody || mainStatement) && statementKeywords[w.text] && next.text != "(" {
+			return "", fmt.Errorf("%w: %s is not allowed", ErrNotSelect, w.text)
+		}
+		if with && depth == 0 && dmlKeywords[w.text] {
+			return "", fmt.Errorf("%w: %s is not allowed", ErrNotSelect, w.text)
+		}
+		switch {
+		case w.text == "INTO":
+			// SELECT ... INTO 会建表或写文件
+			return "", fmt.Errorf("%w: INTO is not allowed", ErrNotSelect)
+		case w.text == "FOR" && !next.quoted && lockStrengths[next.text],
+			w.text == "LOCK" && next.text == "IN":
+			return "", fmt.Errorf("%w: locking reads are not allowed", ErrNotSelect)
+		case forbiddenFunctions[w.text] && next.text == "(":
+			return "", fmt.Errorf("function %s is not allowed", strings.ToLower(w.text))
+		}
+	}
+	return query, nil
+}
+
+type word struct {
+	text string
+	pos  int
+	// quoted 带引号的标识符，不会是关键字
+	quoted bool
+}
+
+// scanWords 把语句切分为关键字、标识符和符号，跳过注释，字符串和数字只保留占位。
+// 几种数据库的注释、字符串和标识符语法不同，按方言处理，否则可能把真正会执行的内容当成注释跳过
+func scanWords(dialect string, query string) ([]word, error) {
+	mysql := dialect == MySQL
+	sqlite := dialect == SQLite
+	var words []word
+	runes := []rune(query)
+	// pos 记录的是字节位置，用于截取原语句
+	bytePos := func(i int) int { return len(string(runes[:i])) }
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-' && (!mysql || i+2 >= len(runes) || unicode.IsSpace(runes[i+2])),
+			c == '#' && mysql:
+			// MySQL 中 -- 后面必须跟空白才是注释
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			// MySQL 的 /*! ... */ 注释中的内容会被执行
+			if i+2 < len(runes) && runes[i+2] == '!' {
+				return nil, errors.New("executable comments are not allowed")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+		case c == '\'' || c == '"' || (c == '`' && !isPostgres(dialect)) || (c == '[' && sqlite):
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			// MySQL 的字符串和 PostgreSQL 的 E'' 字符串中反斜杠是转义符
+			escapes := c == '\'' && (mysql || (!sqlite && i > 0 && (runes[i-1] == 'E' || runes[i-1] == 'e')))
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == '\\' && escapes {
+					j++
+					continue
+				}
+				if runes[j] == closing {
+					// 两个连续的引号是转义
+					if closing != ']' && j+1 < len(runes) && runes[j+1] == closing {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(runes) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			// MySQL 中双引号是字符串，其它情况是标识符
+			if c == '\'' || (c == '"' && mysql) {
+				words = append(words, word{text: "'", pos: bytePos(i)})
+			} else {
+				words = append(words, word{text: strings.ToUpper(string(runes[i+1 : j])), pos: bytePos(i), quoted: true})
+			}
+			i = j + 1
+		case c == '$' && isPostgres(dialect):
+			// PostgreSQL 的 $tag$ ... $tag$ 字符串
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			if j < len(runes) && runes[j] == '$' {
+				tag := string(runes[i : j+1])
+				end := strings.Index(string(runes[j+1:]), tag)
+				if end < 0 {
+					return nil, errors.New("unterminated dollar-quoted string")
+				}
+				words = append(words, word{text: "'", pos: bytePos(i)})
+				i = j + 1 + len([]rune(string(runes[j+1:])[:end])) + len([]rune(tag))
+				continue
+			}
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			words = append(words, word{text: strings.ToUpper(string(runes[start:i])), pos: bytePos(start)})
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			words = append(words, word{text: "0", pos: bytePos(start)})
+		default:
+			words = append(words, word{text: string(c), pos: bytePos(i)})
+			i++
+		}
+	}
+	return words, nil
+}
+
+func isPostgres(dialect string) bool {
+	return dialect != MySQL && dialect != SQLite
+}
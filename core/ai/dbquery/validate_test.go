@@ -0,0 +1,96 @@
+package dbquery
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSelectAllows(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+		want    string
+	}{
+		{Postgres, "SELECT 1;", "SELECT 1"},
+		{Postgres, "  select id, name from users where id = 1  ", "select id, name from users where id = 1"},
+		// 常见的函数名和列名和写操作关键字重名
+		{Postgres, "SELECT replace(name, 'a', 'b'), comment, share, analyze FROM t", ""},
+		{MySQL, "SELECT REPLACE(title, 'x', 'y') AS `set` FROM posts ORDER BY `comment`", ""},
+		{Postgres, `SELECT "update", "delete" FROM audit`, ""},
+		{Postgres, "SELECT substring(name FROM 1 FOR 3) FROM t", ""},
+		{Postgres, "WITH recent AS (SELECT * FROM orders) SELECT count(*) FROM recent", ""},
+		{Postgres, "WITH RECURSIVE t(n) AS (VALUES (1) UNION ALL SELECT n + 1 FROM t WHERE n < 5) SELECT n FROM t", ""},
+		// 字符串和注释中的内容不检查
+		{Postgres, "SELECT 'DROP TABLE x; DELETE' -- INSERT\nFROM t", ""},
+		{Postgres, "SELECT $tag$; INSERT INTO x$tag$", ""},
+		{MySQL, "SELECT 'it\\'s; DROP' # DELETE\nFROM t", ""},
+		{SQLite, "SELECT [order], `group` FROM t WHERE name = 'a''b'", ""},
+	}
+	for _, tt := range tests {
+		got, err := ValidateSelect(tt.dialect, tt.query)
+		if err != nil {
+			t.Errorf("ValidateSelect(%s, %q) error: %v", tt.dialect, tt.query, err)
+			continue
+		}
+		if tt.want != "" && got != tt.want {
+			t.Errorf("ValidateSelect(%s, %q) = %q, want %q", tt.dialect, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSelectRejects(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+		err     error
+	}{
+		{Postgres, "", ErrEmptyQuery},
+		{Postgres, "-- only a comment", ErrEmptyQuery},
+		{Postgres, "DELETE FROM users", ErrNotSelect},
+		{Postgres, `"select" 1`, ErrNotSelect},
+		{Postgres, "SELECT 1; DROP TABLE users", ErrMultiStatement},
+		{Postgres, "SELECT 1; SELECT 2", ErrMultiStatement},
+		// 会写入数据的 WITH
+		{Postgres, "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", ErrNotSelect},
+		{Postgres, "WITH d AS MATERIALIZED (UPDATE users SET a = 1 RETURNING *) SELECT 1", ErrNotSelect},
+		{Postgres, "WITH x AS (SELECT 1) DELETE FROM users", ErrNotSelect},
+		{Postgres, "WITH x AS (SELECT 1), y AS (SELECT 2) INSERT INTO t SELECT * FROM x", ErrNotSelect},
+		{Postgres, "WITH RECURSIVE t AS (SELECT 1) CYCLE a SET b USING p DELETE FROM users", ErrNotSelect},
+		// 建表、写文件和锁定读取
+		{Postgres, "SELECT * INTO backup FROM users", ErrNotSelect},
+		{MySQL, "SELECT * FROM users INTO OUTFILE '/tmp/x'", ErrNotSelect},
+		{Postgres, "SELECT * FROM users FOR UPDATE", ErrNotSelect},
+		{Postgres, "SELECT * FROM users FOR NO KEY UPDATE", ErrNotSelect},
+		{MySQL, "SELECT * FROM users LOCK IN SHARE MODE", ErrNotSelect},
+	}
+	for _, tt := range tests {
+		if _, err := ValidateSelect(tt.dialect, tt.query); !errors.Is(err, tt.err) {
+			t.Errorf("ValidateSelect(%s, %q) error = %v, want %v", tt.dialect, tt.query, err, tt.err)
+		}
+	}
+}
+
+func TestValidateSelectRejectsSyntax(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+	}{
+		{Postgres, "SELECT pg_sleep(10)"},
+		{Postgres, `SELECT "pg_read_file"('/etc/passwd')`},
+		{Postgres, "SELECT pg_catalog.pg_read_file('/etc/passwd')"},
+		{MySQL, "SELECT SLEEP(5)"},
+		{MySQL, "SELECT `sleep`(5)"},
+		{SQLite, "SELECT load_extension('/tmp/evil.so')"},
+		{MySQL, "SELECT /*! SLEEP(5) */ 1"},
+		{Postgres, "SELECT 'unterminated"},
+		{Postgres, "SELECT 1 /* unterminated"},
+		{Postgres, "SELECT $a$ unterminated"},
+		// MySQL 中 # 是注释，PostgreSQL 中不是，不能按错误的方言跳过后面的内容
+		{MySQL, "SELECT 1 -- x\n; DROP TABLE t"},
+	}
+	for _, tt := range tests {
+		if _, err := ValidateSelect(tt.dialect, tt.query); err == nil {
+			t.Errorf("ValidateSelect(%s, %q) should fail", tt.dialect, tt.query)
+		}
+	}
+}
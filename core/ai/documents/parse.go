@@ -0,0 +1,116 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+// 文档格式
+const (
+	FormatPDF  = "pdf"
+	FormatCSV  = "csv"
+	FormatText = "text"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// 按扩展名识别的文本格式
+var textExtensions = map[string]string{
+	".csv":      FormatCSV,
+	".txt":      FormatText,
+	".md":       FormatText,
+	".markdown": FormatText,
+	".json":     FormatText,
+	".log":      FormatText,
+}
+
+// Document 解析后的文档
+type Document struct {
+	Format string
+	Text   string
+	// Header CSV 的表头，分块时每块都带上，其他格式为空
+	Header string
+}
+
+// Parse 按文件名和内容识别格式并提取文本。文本文件不是 UTF-8 时按检测到的编码转换（如 Excel 导出的 GBK CSV）
+func Parse(fileName string, data []byte) (*Document, error) {
+	format, ok := textExtensions[strings.ToLower(path.Ext(fileName))]
+	if !ok {
+		if http.DetectContentType(data) != "application/pdf" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, fileName)
+		}
+		format = FormatPDF
+	}
+	if format == FormatPDF {
+		text, err := extractPDF(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Format: FormatPDF, Text: text}, nil
+	}
+	text, err := decodeText(data)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatCSV {
+		return parseCSV(text)
+	}
+	return &Document{Format: FormatText, Text: strings.TrimSpace(text)}, nil
+}
+
+func decodeText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	enc, name, _ := charset.DetermineEncoding(data, "")
+	// 没有 BOM 和 meta 时 DetermineEncoding 默认 windows-1252，中文文件大多是 GBK
+	if name == "windows-1252" {
+		enc, _ = charset.Lookup("gbk")
+	}
+	decoded, _, err := transform.Bytes(enc.NewDecoder(), data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// parseCSV 检查 CSV 格式，统一成逗号分隔、\n 换行，第一行作为表头
+func parseCSV(text string) (*Document, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	var header string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse csv: %w", err)
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+		writer.Flush()
+		if header == "" {
+			header = strings.TrimSuffix(buf.String(), "\n")
+		}
+	}
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return &Document{Format: FormatCSV, Text: strings.TrimSpace(buf.String()), Header: header}, nil
+}
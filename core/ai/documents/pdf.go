@@ -0,0 +1,67 @@
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDF 的文字使用 ledongthuc/pdf 按页提取。有密码的文档不支持，扫描件没有文字可以提取
+
+var (
+	ErrPDFEncrypted = errors.New("encrypted pdf is not supported")
+	ErrPDFNoText    = errors.New("no text found in pdf, it may be a scanned document")
+	ErrPDFInvalid   = errors.New("invalid pdf")
+)
+
+// maxPDFText 提取的文字上限，超出后不再读取后面的页
+const maxPDFText = 16 << 20
+
+func extractPDF(data []byte) (text string, err error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", ErrUnsupportedFormat
+	}
+	// 解析损坏的文档时库可能 panic
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("%w: %v", ErrPDFInvalid, r)
+		}
+	}()
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return "", ErrPDFEncrypted
+		}
+		return "", fmt.Errorf("%w: %v", ErrPDFInvalid, err)
+	}
+	var sb strings.Builder
+	for i := 1; i <= reader.NumPage() && sb.Len() < maxPDFText; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		// 字体名只在页内唯一，每页单独解析
+		fonts := make(map[string]*pdf.Font)
+		for _, name := range page.Fonts() {
+			font := page.Font(name)
+			fonts[name] = &font
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("%w: page %d: %v", ErrPDFInvalid, i, err)
+		}
+		if pageText = strings.TrimSpace(pageText); pageText != "" {
+			if sb.Len() > 0 {
+				sb.WriteString("\n\n")
+			}
+			sb.WriteString(pageText)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", ErrPDFNoText
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,78 @@
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF 生成每页一段文字的最小 PDF，xref 中的偏移按实际内容计算
+func buildPDF(pages ...string) []byte {
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, text := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 712 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestParsePDF(t *testing.T) {
+	doc, err := Parse("report.pdf", buildPDF("Quarterly report", "Revenue grew 12%"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Format != FormatPDF || !strings.Contains(doc.Text, "Quarterly report") || !strings.Contains(doc.Text, "Revenue grew 12%") {
+		t.Fatalf("doc = %+v", doc)
+	}
+	if strings.Index(doc.Text, "Quarterly") > strings.Index(doc.Text, "Revenue") {
+		t.Errorf("pages out of order: %q", doc.Text)
+	}
+	// 没有扩展名时按内容识别
+	if doc, err := Parse("upload", buildPDF("hello")); err != nil || doc.Format != FormatPDF {
+		t.Errorf("Parse(upload) = %+v, %v", doc, err)
+	}
+}
+
+func TestParsePDFErrors(t *testing.T) {
+	if _, err := Parse("empty.pdf", buildPDF("")); !errors.Is(err, ErrPDFNoText) {
+		t.Errorf("empty pdf error = %v, want ErrPDFNoText", err)
+	}
+	if _, err := Parse("broken.pdf", []byte("%PDF-1.4\nnot really a pdf")); !errors.Is(err, ErrPDFInvalid) {
+		t.Errorf("broken pdf error = %v, want ErrPDFInvalid", err)
+	}
+	// 截断的文档不能让解析 panic
+	data := buildPDF("truncated")
+	if _, err := Parse("cut.pdf", data[:len(data)/2]); err == nil {
+		t.Error("truncated pdf should fail")
+	}
+	if _, err := Parse("notes.pdf", []byte("plain text")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("non-pdf error = %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,74 @@
+package documents
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Split 把文档切成不超过 size 个字符的块，相邻的块重叠 overlap 个字符。
+// 优先在空行、换行和句号处切分；CSV 按行切分，不重叠，每块都以表头开始
+func Split(doc *Document, size int, overlap int) []string {
+	if size <= 0 {
+		return []string{doc.Text}
+	}
+	if doc.Format == FormatCSV {
+		return splitRows(doc, size)
+	}
+	overlap = min(max(overlap, 0), size/2)
+	runes := []rune(doc.Text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = breakPoint(runes, start+size/2, end)
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end >= len(runes) {
+			break
+		}
+		start = max(end-overlap, start+1)
+	}
+	return chunks
+}
+
+// 切分点的优先级，越靠前越优先
+var breakSeparators = []string{"\n\n", "\n", "。", "！", "？", ". ", "；", "，", " "}
+
+// breakPoint 在 [from, end) 中找最靠后的切分点，找不到时直接在 end 处切分
+func breakPoint(runes []rune, from int, end int) int {
+	window := string(runes[from:end])
+	for _, sep := range breakSeparators {
+		if i := strings.LastIndex(window, sep); i >= 0 {
+			return from + utf8.RuneCountInString(window[:i+len(sep)])
+		}
+	}
+	return end
+}
+
+func splitRows(doc *Document, size int) []string {
+	lines := strings.Split(doc.Text, "\n")
+	if len(lines) > 0 && lines[0] == doc.Header {
+		lines = lines[1:]
+	}
+	headerSize := utf8.RuneCountInString(doc.Header)
+	var chunks []string
+	var sb strings.Builder
+	chunkSize := 0
+	for _, line := range lines {
+		n := utf8.RuneCountInString(line) + 1
+		if chunkSize > 0 && headerSize+chunkSize+n > size {
+			chunks = append(chunks, doc.Header+"\n"+strings.TrimSuffix(sb.String(), "\n"))
+			sb.Reset()
+			chunkSize = 0
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+		chunkSize += n
+	}
+	if chunkSize > 0 {
+		chunks = append(chunks, doc.Header+"\n"+strings.TrimSuffix(sb.String(), "\n"))
+	}
+	return chunks
+}
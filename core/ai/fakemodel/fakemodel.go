@@ -0,0 +1,122 @@
+package fakemodel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Rule 脚本化的回复：最后一条用户消息包含 Match 时返回 Content，或者调用 ToolCalls 中的工具。
+// Match 为空时匹配所有消息
+type Rule struct {
+	Match     string     `json:"match"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"toolCalls"`
+}
+
+type ToolCall struct {
+	Name string `json:"name"`
+	// Arguments JSON 格式的参数，为空时使用 {}
+	Arguments string `json:"arguments"`
+}
+
+// ChatModel 不调用任何服务的确定性模型，用于评测和测试：
+//   - 最后一条消息是工具结果时，直接把工具结果作为回答，保证工具调用能结束
+//   - 否则按顺序找到第一条匹配的规则
+//   - 没有匹配的规则时原样返回用户消息
+type ChatModel struct {
+	rules []Rule
+	tools map[string]bool
+}
+
+func New(rules []Rule) *ChatModel {
+	return &ChatModel{rules: rules}
+}
+
+// FromParams 从模型参数的 responses 字段读取规则
+func FromParams(params map[string]any) (*ChatModel, error) {
+	var rules []Rule
+	if responses, ok := params["responses"]; ok && responses != nil {
+		data, err := json.Marshal(responses)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("fake模型的 responses 格式错误: %w", err)
+		}
+	}
+	return New(rules), nil
+}
+
+func (m *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.reply(input)
+}
+
+func (m *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.reply(input)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	bound := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		bound[t.Name] = true
+	}
+	return &ChatModel{rules: m.rules, tools: bound}, nil
+}
+
+func (m *ChatModel) reply(input []*schema.Message) (*schema.Message, error) {
+	if len(input) == 0 {
+		return schema.AssistantMessage("", nil), nil
+	}
+	// 连续的工具结果合并为回答
+	if input[len(input)-1].Role == schema.Tool {
+		var results []string
+		for i := len(input) - 1; i >= 0 && input[i].Role == schema.Tool; i-- {
+			results = append([]string{input[i].Content}, results...)
+		}
+		return schema.AssistantMessage(strings.Join(results, "\n"), nil), nil
+	}
+	var question string
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i].Role == schema.User {
+			question = input[i].Content
+			break
+		}
+	}
+	for _, rule := range m.rules {
+		if !strings.Contains(question, rule.Match) {
+			continue
+		}
+		if len(rule.ToolCalls) == 0 {
+			return schema.AssistantMessage(rule.Content, nil), nil
+		}
+		calls := make([]schema.ToolCall, 0, len(rule.ToolCalls))
+		for i, call := range rule.ToolCalls {
+			if !m.tools[call.Name] {
+				return nil, fmt.Errorf("工具 %s 没有绑定到模型", call.Name)
+			}
+			arguments := call.Arguments
+			if arguments == "" {
+				arguments = "{}"
+			}
+			calls = append(calls, schema.ToolCall{
+				ID:   fmt.Sprintf("call_%d", i+1),
+				Type: "function",
+				Function: schema.FunctionCall{
+					Name:      call.Name,
+					Arguments: arguments,
+				},
+			})
+		}
+		return schema.AssistantMessage(rule.Content, calls), nil
+	}
+	return schema.AssistantMessage(question, nil), nil
+}
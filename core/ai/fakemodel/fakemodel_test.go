@@ -0,0 +1,76 @@
+package fakemodel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestReplyRules(t *testing.T) {
+	m, err := FromParams(map[string]any{"responses": []any{
+		map[string]any{"match": "weather", "toolCalls": []any{map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`}}},
+		map[string]any{"match": "hello", "content": "hi there"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	tests := []struct {
+		question string
+		want     string
+	}{
+		{"hello bot", "hi there"},
+		// 没有匹配的规则时原样返回
+		{"echo me", "echo me"},
+	}
+	for _, tt := range tests {
+		msg, err := m.Generate(ctx, []*schema.Message{schema.SystemMessage("sys"), schema.UserMessage(tt.question)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Content != tt.want || len(msg.ToolCalls) != 0 {
+			t.Errorf("Generate(%q) = %+v, want %q", tt.question, msg, tt.want)
+		}
+	}
+	// 没有绑定的工具不能调用
+	if _, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("weather?")}); err == nil {
+		t.Error("unbound tool call should fail")
+	}
+	bound, err := m.WithTools([]*schema.ToolInfo{{Name: "get_weather"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bound.Generate(ctx, []*schema.Message{schema.UserMessage("weather?")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "get_weather" || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("tool calls = %+v", msg.ToolCalls)
+	}
+	// 工具结果直接作为回答，保证调用能结束
+	msg, err = bound.Generate(ctx, []*schema.Message{
+		schema.UserMessage("weather?"), msg,
+		schema.ToolMessage("sunny", "call_1"), schema.ToolMessage("20C", "call_2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "sunny\n20C" {
+		t.Errorf("answer = %q", msg.Content)
+	}
+}
+
+func TestStreamAndInvalidParams(t *testing.T) {
+	stream, err := New(nil).Stream(context.Background(), []*schema.Message{schema.UserMessage("ping")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := stream.Recv()
+	if err != nil || msg.Content != "ping" {
+		t.Fatalf("Recv() = %v, %v", msg, err)
+	}
+	if _, err := FromParams(map[string]any{"responses": "not a list"}); err == nil {
+		t.Error("invalid responses should fail")
+	}
+}
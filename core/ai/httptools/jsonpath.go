@@ -0,0 +1,127 @@
+package httptools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathToken JSONPath 中的一段：对象字段、数组下标或通配符
+type pathToken struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Extract 按 JSONPath 提取数据，支持 $、.key、['key']、[n]、[*] 和 .*，
+// 路径中出现通配符时返回数组
+func Extract(data any, path string) (any, error) {
+	tokens, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := []any{data}
+	multi := false
+	for _, token := range tokens {
+		var next []any
+		for _, value := range current {
+			switch v := value.(type) {
+			case map[string]any:
+				if token.wildcard {
+					for _, item := range v {
+						next = append(next, item)
+					}
+				} else if !token.isIndex {
+					if item, ok := v[token.key]; ok {
+						next = append(next, item)
+					}
+				}
+			case []any:
+				if token.wildcard {
+					next = append(next, v...)
+				} else if token.isIndex {
+					index := token.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		if token.wildcard {
+			multi = true
+		}
+		current = next
+	}
+	if multi {
+		if current == nil {
+			return []any{}, nil
+		}
+		return current, nil
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("path %s not found", path)
+	}
+	return current[0], nil
+}
+
+// ValidatePath 检查 JSONPath 语法是否正确
+func ValidatePath(path string) error {
+	_, err := parsePath(path)
+	return err
+}
+
+func parsePath(path string) ([]pathToken, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	var tokens []pathToken
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				i++
+			}
+			key := path[start:i]
+			if key == "" {
+				return nil, fmt.Errorf("invalid json path: %s", path)
+			}
+			if key == "*" {
+				tokens = append(tokens, pathToken{wildcard: true})
+			} else {
+				tokens = append(tokens, pathToken{key: key})
+			}
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path: %s", path)
+			}
+			content := strings.TrimSpace(path[i+1 : i+end])
+			i += end + 1
+			switch {
+			case content == "*":
+				tokens = append(tokens, pathToken{wildcard: true})
+			case len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0]:
+				tokens = append(tokens, pathToken{key: content[1 : len(content)-1]})
+			default:
+				index, err := strconv.Atoi(content)
+				if err != nil {
+					return nil, fmt.Errorf("invalid json path index: %s", content)
+				}
+				tokens = append(tokens, pathToken{index: index, isIndex: true})
+			}
+		default:
+			// 兼容不带 $ 和点号开头的写法，如 data.items
+			if i == 0 {
+				path = "." + path
+				continue
+			}
+			return nil, fmt.Errorf("invalid json path: %s", path)
+		}
+	}
+	return tokens, nil
+}
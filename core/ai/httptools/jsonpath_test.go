@@ -0,0 +1,51 @@
+package httptools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(`{"data":{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"my key":"v"}}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$", data},
+		{"$.data.items[0].name", "a"},
+		{"data.items[-1].id", float64(2)},
+		{"$.data['my key']", "v"},
+		{"$.data.items[*].name", []any{"a", "b"}},
+		{"$.data.missing[*]", []any{}},
+	}
+	for _, tt := range tests {
+		got, err := Extract(data, tt.path)
+		if err != nil {
+			t.Errorf("Extract(%q) error: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if _, err := Extract(data, "$.data.missing"); err == nil {
+		t.Error("missing path should return an error")
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	for _, path := range []string{"$.a.b", "$['a'][0]", "$.a[*]"} {
+		if err := ValidatePath(path); err != nil {
+			t.Errorf("ValidatePath(%q) error: %v", path, err)
+		}
+	}
+	for _, path := range []string{"$.a[", "$.a[x]", "$['a"} {
+		if err := ValidatePath(path); err == nil {
+			t.Errorf("ValidatePath(%q) should fail", path)
+		}
+	}
+}
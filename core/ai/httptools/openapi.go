@@ -0,0 +1,329 @@
+package httptools
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// $ref 解析的最大深度，防止循环引用
+const maxRefDepth = 8
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Operation OpenAPI 文档中的一个接口
+type Operation struct {
+	OperationId    string
+	Name           string
+	Description    string
+	Method         string
+	Url            string
+	Params         map[string]*schema.ParameterInfo
+	ParamLocations map[string]string
+}
+
+// 只解析生成工具需要的字段，yaml 解析器同样可以解析 JSON 格式的文档
+type openAPIDoc struct {
+	OpenAPI    string                  `yaml:"openapi"`
+	Servers    []openAPIServer         `yaml:"servers"`
+	Paths      map[string]*openAPIPath `yaml:"paths"`
+	Components openAPIComponents       `yaml:"components"`
+}
+
+type openAPIServer struct {
+	Url string `yaml:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas       map[string]*jsonSchema       `yaml:"schemas"`
+	Parameters    map[string]*openAPIParameter `yaml:"parameters"`
+	RequestBodies map[string]*openAPIBody      `yaml:"requestBodies"`
+}
+
+type openAPIPath struct {
+	Parameters []*openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation   `yaml:"get"`
+	Put        *openAPIOperation   `yaml:"put"`
+	Post       *openAPIOperation   `yaml:"post"`
+	Delete     *openAPIOperation   `yaml:"delete"`
+	Patch      *openAPIOperation   `yaml:"patch"`
+}
+
+type openAPIOperation struct {
+	OperationId string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Description string              `yaml:"description"`
+	Parameters  []*openAPIParameter `yaml:"parameters"`
+	RequestBody *openAPIBody        `yaml:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref         string      `yaml:"$ref"`
+	Name        string      `yaml:"name"`
+	In          string      `yaml:"in"`
+	Description string      `yaml:"description"`
+	Required    bool        `yaml:"required"`
+	Schema      *jsonSchema `yaml:"schema"`
+}
+
+type openAPIBody struct {
+	Ref         string                   `yaml:"$ref"`
+	Description string                   `yaml:"description"`
+	Required    bool                     `yaml:"required"`
+	Content     map[string]*openAPIMedia `yaml:"content"`
+}
+
+type openAPIMedia struct {
+	Schema *jsonSchema `yaml:"schema"`
+}
+
+type jsonSchema struct {
+	Ref         string                 `yaml:"$ref"`
+	Type        any                    `yaml:"type"` // 3.1 中可以是数组，如 [string, "null"]
+	Description string                 `yaml:"description"`
+	Enum        []any                  `yaml:"enum"`
+	Items       *jsonSchema            `yaml:"items"`
+	Properties  map[string]*jsonSchema `yaml:"properties"`
+	Required    []string               `yaml:"required"`
+	AllOf       []*jsonSchema          `yaml:"allOf"`
+}
+
+// ParseOpenAPI 解析 OpenAPI 3 文档，每个接口生成一个 Operation；
+// baseUrl 为空时使用文档中的第一个 server
+func ParseOpenAPI(spec []byte, baseUrl string) ([]*Operation, error) {
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("only openapi 3.x is supported, got %q", doc.OpenAPI)
+	}
+	if baseUrl == "" && len(doc.Servers) > 0 {
+		baseUrl = doc.Servers[0].Url
+	}
+	if !strings.HasPrefix(baseUrl, "http://") && !strings.HasPrefix(baseUrl, "https://") {
+		return nil, fmt.Errorf("invalid base url: %q", baseUrl)
+	}
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var operations []*Operation
+	for _, path := range paths {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, m := range []struct {
+			method string
+			op     *openAPIOperation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPost, item.Post},
+			{http.MethodPut, item.Put},
+			{http.MethodPatch, item.Patch},
+			{http.MethodDelete, item.Delete},
+		} {
+			if m.op == nil {
+				continue
+			}
+			operations = append(operations, doc.buildOperation(baseUrl, path, m.method, item.Parameters, m.op))
+		}
+	}
+	return operations, nil
+}
+
+func (d *openAPIDoc) buildOperation(baseUrl string, path string, method string, common []*openAPIParameter, op *openAPIOperation) *Operation {
+	operation := &Operation{
+		OperationId:    op.OperationId,
+		Method:         method,
+		Url:            baseUrl + path,
+		Params:         make(map[string]*schema.ParameterInfo),
+		ParamLocations: make(map[string]string),
+	}
+	name := op.OperationId
+	if name == "" {
+		name = strings.ToLower(method) + "_" + strings.Trim(invalidNameChars.ReplaceAllString(path, "_"), "_")
+	}
+	operation.Name = strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+	operation.Description = strings.TrimSpace(op.Summary)
+	if op.Description != "" {
+		if operation.Description != "" {
+			operation.Description += "。"
+		}
+		operation.Description += strings.TrimSpace(op.Description)
+	}
+	if operation.Description == "" {
+		operation.Description = method + " " + path
+	}
+	// 接口上的参数覆盖路径上的同名参数
+	for _, p := range append(append([]*openAPIParameter{}, common...), op.Parameters...) {
+		p = d.resolveParameter(p, 0)
+		if p == nil || p.Name == "" {
+			continue
+		}
+		switch p.In {
+		case InPath, InQuery, InHeader:
+		default:
+			continue // cookie 参数不支持
+		}
+		param := d.toParameterInfo(p.Schema, 0)
+		if p.Description != "" {
+			param.Desc = p.Description
+		}
+		param.Required = p.Required || p.In == InPath
+		operation.Params[p.Name] = param
+		operation.ParamLocations[p.Name] = p.In
+	}
+	d.addBodyParams(operation, op.RequestBody)
+	return operation
+}
+
+// addBodyParams 对象类型的 JSON 请求体展开为多个参数，其它类型作为一个 body 参数
+func (d *openAPIDoc) addBodyParams(operation *Operation, body *openAPIBody) {
+	body = d.resolveBody(body, 0)
+	if body == nil {
+		return
+	}
+	media, ok := body.Content["application/json"]
+	if !ok || media == nil || media.Schema == nil {
+		return
+	}
+	bodySchema := d.resolveSchema(media.Schema, 0)
+	if bodySchema != nil && schemaType(bodySchema) == schema.Object && len(bodySchema.Properties) > 0 {
+		params := d.toParameterInfo(bodySchema, 0)
+		for name, param := range params.SubParams {
+			if _, exists := operation.Params[name]; exists {
+				continue
+			}
+			operation.Params[name] = param
+			operation.ParamLocations[name] = InBody
+		}
+		return
+	}
+	param := d.toParameterInfo(media.Schema, 0)
+	param.Required = body.Required
+	if body.Description != "" {
+		param.Desc = body.Description
+	}
+	operation.Params["body"] = param
+	operation.ParamLocations["body"] = InBody
+}
+
+func (d *openAPIDoc) toParameterInfo(s *jsonSchema, depth int) *schema.ParameterInfo {
+	s = d.resolveSchema(s, depth)
+	if s == nil || depth > maxRefDepth {
+		return &schema.ParameterInfo{Type: schema.String}
+	}
+	param := &schema.ParameterInfo{
+		Type: schemaType(s),
+		Desc: s.Description,
+	}
+	for _, e := range s.Enum {
+		param.Enum = append(param.Enum, fmt.Sprint(e))
+	}
+	switch param.Type {
+	case schema.Array:
+		param.ElemInfo = d.toParameterInfo(s.Items, depth+1)
+	case schema.Object:
+		if len(s.Properties) > 0 {
+			param.SubParams = make(map[string]*schema.ParameterInfo, len(s.Properties))
+			for name, property := range s.Properties {
+				sub := d.toParameterInfo(property, depth+1)
+				for _, required := range s.Required {
+					if required == name {
+						sub.Required = true
+					}
+				}
+				param.SubParams[name] = sub
+			}
+		}
+	}
+	return param
+}
+
+func (d *openAPIDoc) resolveSchema(s *jsonSchema, depth int) *jsonSchema {
+	for s != nil && s.Ref != "" {
+		if depth > maxRefDepth {
+			return nil
+		}
+		depth++
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+	// allOf 合并所有子 schema 的字段
+	merged := &jsonSchema{
+		Type:        "object",
+		Description: s.Description,
+		Properties:  make(map[string]*jsonSchema),
+		Required:    append([]string{}, s.Required...),
+	}
+	for name, property := range s.Properties {
+		merged.Properties[name] = property
+	}
+	for _, sub := range s.AllOf {
+		sub = d.resolveSchema(sub, depth+1)
+		if sub == nil {
+			continue
+		}
+		for name, property := range sub.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, sub.Required...)
+	}
+	return merged
+}
+
+func (d *openAPIDoc) resolveParameter(p *openAPIParameter, depth int) *openAPIParameter {
+	for p != nil && p.Ref != "" {
+		if depth > maxRefDepth {
+			return nil
+		}
+		depth++
+		p = d.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+	}
+	return p
+}
+
+func (d *openAPIDoc) resolveBody(b *openAPIBody, depth int) *openAPIBody {
+	for b != nil && b.Ref != "" {
+		if depth > maxRefDepth {
+			return nil
+		}
+		depth++
+		b = d.Components.RequestBodies[strings.TrimPrefix(b.Ref, "#/components/requestBodies/")]
+	}
+	return b
+}
+
+func schemaType(s *jsonSchema) schema.DataType {
+	var t string
+	switch v := s.Type.(type) {
+	case string:
+		t = v
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok && str != "null" {
+				t = str
+				break
+			}
+		}
+	}
+	switch schema.DataType(t) {
+	case schema.Object, schema.Number, schema.Integer, schema.String, schema.Array, schema.Boolean:
+		return schema.DataType(t)
+	}
+	if len(s.Properties) > 0 {
+		return schema.Object
+	}
+	return schema.String
+}
@@ -0,0 +1,114 @@
+package httptools
+
+import (
+	"net/http"
+	"testing"
+)
+
+const petStore = `
+openapi: 3.0.0
+servers:
+  - url: https://api.example.com/v1/
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      operationId: getPet
+      summary: 查询宠物
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: string
+        - name: session
+          in: cookie
+          schema:
+            type: string
+    delete:
+      summary: 删除宠物
+  /pets:
+    post:
+      operationId: create.pet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      schema:
+        type: integer
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        tags:
+          type: array
+          items:
+            type: string
+`
+
+func TestParseOpenAPI(t *testing.T) {
+	operations, err := ParseOpenAPI([]byte(petStore), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*Operation)
+	for _, op := range operations {
+		byName[op.Name] = op
+	}
+	if len(byName) != 3 {
+		t.Fatalf("got %d operations: %v", len(operations), byName)
+	}
+
+	get := byName["getPet"]
+	if get == nil || get.Method != http.MethodGet || get.Url != "https://api.example.com/v1/pets/{petId}" {
+		t.Fatalf("unexpected getPet: %+v", get)
+	}
+	if get.ParamLocations["petId"] != InPath || !get.Params["petId"].Required {
+		t.Errorf("petId should be a required path param: %+v", get.ParamLocations)
+	}
+	if get.ParamLocations["fields"] != InQuery {
+		t.Errorf("fields should be a query param: %+v", get.ParamLocations)
+	}
+	if _, ok := get.Params["session"]; ok {
+		t.Error("cookie params should be skipped")
+	}
+
+	if del := byName["delete_pets_petId"]; del == nil || del.Description != "删除宠物" {
+		t.Errorf("operation without id should be named from method and path: %v", byName)
+	}
+
+	create := byName["create_pet"]
+	if create == nil || create.OperationId != "create.pet" {
+		t.Fatalf("operation id should be sanitized into the name: %v", byName)
+	}
+	if create.ParamLocations["name"] != InBody || !create.Params["name"].Required {
+		t.Errorf("object body should be expanded into required body params: %+v", create.ParamLocations)
+	}
+	if create.ParamLocations["tags"] != InBody {
+		t.Errorf("tags should be a body param: %+v", create.ParamLocations)
+	}
+}
+
+func TestParseOpenAPIRejects(t *testing.T) {
+	tests := map[string]string{
+		"swagger 2":   "swagger: '2.0'\npaths: {}",
+		"no base url": "openapi: 3.0.0\npaths: {}",
+		"not yaml":    "openapi: [",
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseOpenAPI([]byte(spec), ""); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
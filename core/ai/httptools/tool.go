@@ -0,0 +1,278 @@
+package httptools
+
+import (
+	"context"
+	"core/ai/webfetch"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 参数位置
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InBody   = "body"
+)
+
+// 认证方式
+const (
+	AuthBearer = "bearer" // Authorization: Bearer <credential>
+	AuthHeader = "header" // <headerName>: <credential>
+	AuthBasic  = "basic"  // credential 为 username:password
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// maxResponseLen 返回给模型的响应最大长度
+	maxResponseLen = 32 * 1024
+	// maxResponseBody 读取响应体的上限
+	maxResponseBody = 1 << 20
+)
+
+var (
+	pathParamPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+	bodyParamPattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+)
+
+// Endpoint 一个HTTP接口的完整调用信息，凭证已经是解密后的明文
+type Endpoint struct {
+	Name        string
+	Description string
+	Method      string
+	// Url 请求地址，路径参数使用 {name} 占位
+	Url     string
+	Headers map[string]string
+	// Params 参数定义，ParamLocations 记录每个参数放在请求的哪个位置
+	Params         map[string]*schema.ParameterInfo
+	ParamLocations map[string]string
+	// BodyTemplate 请求体模板，{{name}} 会被替换为参数的 JSON 值；为空时 body 参数组成 JSON 对象
+	BodyTemplate string
+	// ResponsePath 从 JSON 响应中提取结果的 JSONPath，为空返回完整响应
+	ResponsePath string
+	AuthType     string
+	HeaderName   string
+	Credential   string
+	Timeout      time.Duration
+}
+
+// Tool 调用HTTP接口的 eino 工具
+type Tool struct {
+	endpoint   *Endpoint
+	httpClient *http.Client
+}
+
+func NewTool(endpoint *Endpoint) *Tool {
+	timeout := endpoint.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Tool{
+		endpoint:   endpoint,
+		// 接口地址由用户配置，请求时拒绝连接内网地址
+		httpClient: webfetch.NewClient(timeout),
+	}
+}
+
+func (t *Tool) Params() map[string]*schema.ParameterInfo {
+	return t.endpoint.Params
+}
+
+func (t *Tool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        t.endpoint.Name,
+		Desc:        t.endpoint.Description,
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.endpoint.Params),
+	}, nil
+}
+
+func (t *Tool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	args := make(map[string]any)
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	req, err := t.buildRequest(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request %s: %w", t.endpoint.Name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	// 接口返回错误时把状态码和内容交给模型，由模型决定如何处理
+	if resp.StatusCode >= http.StatusBadRequest {
+		return truncate(fmt.Sprintf("请求失败，状态码 %d：%s", resp.StatusCode, string(body))), nil
+	}
+	return truncate(t.extract(body)), nil
+}
+
+func (t *Tool) buildRequest(ctx context.Context, args map[string]any) (*http.Request, error) {
+	e := t.endpoint
+	for name, param := range e.Params {
+		if _, ok := args[name]; !ok && param.Required {
+			return nil, fmt.Errorf("missing required parameter: %s", name)
+		}
+	}
+	var missing []string
+	rawUrl := pathParamPattern.ReplaceAllStringFunc(e.Url, func(match string) string {
+		name := match[1 : len(match)-1]
+		value, ok := args[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return url.PathEscape(stringify(value))
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing path parameters: %s", strings.Join(missing, ","))
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	query := u.Query()
+	headers := make(http.Header)
+	for k, v := range e.Headers {
+		headers.Set(k, v)
+	}
+	bodyParams := make(map[string]any)
+	for name, value := range args {
+		switch e.ParamLocations[name] {
+		case InPath:
+		case InQuery:
+			if values, ok := value.([]any); ok {
+				for _, item := range values {
+					query.Add(name, stringify(item))
+				}
+			} else {
+				query.Set(name, stringify(value))
+			}
+		case InHeader:
+			headers.Set(name, stringify(value))
+		default:
+			bodyParams[name] = value
+		}
+	}
+	u.RawQuery = query.Encode()
+	body, err := t.buildBody(args, bodyParams)
+	if err != nil {
+		return nil, err
+	}
+	method := strings.ToUpper(e.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = strings.NewReader(string(body))
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", "application/json")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	switch e.AuthType {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+e.Credential)
+	case AuthHeader:
+		req.Header.Set(e.HeaderName, e.Credential)
+	case AuthBasic:
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(e.Credential)))
+	}
+	return req, nil
+}
+
+// buildBody 有模板时按模板渲染，否则 body 参数组成 JSON 对象；
+// 只有一个名为 body 的参数时直接把它作为请求体
+func (t *Tool) buildBody(args map[string]any, bodyParams map[string]any) ([]byte, error) {
+	if t.endpoint.BodyTemplate != "" {
+		var renderErr error
+		rendered := bodyParamPattern.ReplaceAllStringFunc(t.endpoint.BodyTemplate, func(match string) string {
+			name := bodyParamPattern.FindStringSubmatch(match)[1]
+			bs, err := json.Marshal(args[name])
+			if err != nil {
+				renderErr = err
+				return match
+			}
+			return string(bs)
+		})
+		return []byte(rendered), renderErr
+	}
+	if len(bodyParams) == 0 {
+		return nil, nil
+	}
+	if value, ok := bodyParams["body"]; ok && len(bodyParams) == 1 {
+		return json.Marshal(value)
+	}
+	return json.Marshal(bodyParams)
+}
+
+func (t *Tool) extract(body []byte) string {
+	if t.endpoint.ResponsePath == "" {
+		return string(body)
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		// 不是JSON响应时无法提取，返回原始内容
+		return string(body)
+	}
+	value, err := Extract(data, t.endpoint.ResponsePath)
+	if err != nil {
+		return string(body)
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	bs, _ := json.Marshal(value)
+	return string(bs)
+}
+
+func stringify(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		bs, _ := json.Marshal(v)
+		return string(bs)
+	}
+}
+
+// truncate 超过 maxResponseLen 字节时截断，截断位置退到字符边界，不会拆开一个 UTF-8 字符
+func truncate(text string) string {
+	if len(text) <= maxResponseLen {
+		return text
+	}
+	cut := maxResponseLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n...[内容过长已截断]"
+}
@@ -0,0 +1,48 @@
+package httptools
+
+import (
+	"context"
+	"core/ai/webfetch"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short"); got != "short" {
+		t.Fatalf("short text changed: %q", got)
+	}
+	// 前面补一个字节，让 maxResponseLen 落在汉字中间
+	text := "a" + strings.Repeat("响应", maxResponseLen/3)
+	got := truncate(text)
+	if !utf8.ValidString(got) {
+		t.Fatal("truncated text is not valid UTF-8")
+	}
+	body, ok := strings.CutSuffix(got, "\n...[内容过长已截断]")
+	if !ok {
+		t.Fatalf("missing truncation marker")
+	}
+	if len(body) > maxResponseLen || len(body) < maxResponseLen-utf8.UTFMax {
+		t.Fatalf("truncated length = %d, want close to %d", len(body), maxResponseLen)
+	}
+}
+
+// 用户配置的接口地址指向内网时拒绝连接
+func TestInvokableRunBlocksPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+	tool := NewTool(&Endpoint{Name: "internal", Method: "GET", Url: server.URL})
+	_, err := tool.InvokableRun(context.Background(), "")
+	if !errors.Is(err, webfetch.ErrBlockedAddress) {
+		t.Fatalf("err = %v, want ErrBlockedAddress", err)
+	}
+	if called {
+		t.Fatal("private server should not be reached")
+	}
+}
@@ -0,0 +1,104 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// 使用 santhosh-tekuri/jsonschema 校验，schema 没有声明 $schema 时按最新的草案处理。
+// schema 由用户填写，$ref 只能引用 schema 内部的定义，不加载文件和网络地址
+
+// schemaURL 编译时 schema 使用的地址，只用于内部定位
+const schemaURL = "mem:///schema.json"
+
+var errExternalRef = errors.New("不支持引用外部 schema")
+
+// noLoader 拒绝加载所有外部资源
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("%w: %s", errExternalRef, url)
+}
+
+func compile(schema map[string]any) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(noLoader{})
+	if err := compiler.AddResource(schemaURL, schema); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(schemaURL)
+}
+
+// Check 检查 schema 本身是否可用，保存 schema 时调用
+func Check(schema map[string]any) error {
+	if _, err := compile(schema); err != nil {
+		return fmt.Errorf("schema 无效: %v", err)
+	}
+	return nil
+}
+
+// Validate 校验 value 是否符合 schema，value 是 json.Unmarshal 到 any 的结果，返回所有错误
+func Validate(schema map[string]any, value any) []string {
+	compiled, err := compile(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("schema 无效: %v", err)}
+	}
+	err = compiled.Validate(value)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []string{err.Error()}
+	}
+	var issues []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		issues = append(issues, "$"+strings.ReplaceAll(unit.InstanceLocation, "/", ".")+": "+unit.Error.String())
+	}
+	if len(issues) == 0 {
+		issues = append(issues, validationErr.Error())
+	}
+	sort.Strings(issues)
+	return issues
+}
+
+// Extract 模型经常把 JSON 放在代码块里或者前后加说明，取出其中的 JSON 部分
+func Extract(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		return strings.TrimSpace(text)
+	}
+	if json.Valid([]byte(text)) {
+		return text
+	}
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	end := strings.LastIndexAny(text, "}]")
+	if end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+// ValidateJSON 解析 JSON 文本后校验
+func ValidateJSON(schema map[string]any, data string) (any, []string) {
+	var value any
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, []string{"不是有效的JSON: " + err.Error()}
+	}
+	return value, Validate(schema, value)
+}
+
@@ -0,0 +1,104 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, text string) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"tags": {"type": "array", "items": {"enum": ["a", "b"]}}
+	},
+	"required": ["name"],
+	"additionalProperties": false
+}`
+
+func TestValidateJSON(t *testing.T) {
+	schema := parse(t, personSchema)
+	tests := []struct {
+		data   string
+		issues []string
+	}{
+		{`{"name": "alice", "age": 3, "tags": ["a"]}`, nil},
+		{`{"name": "alice", "age": 3.0}`, nil},
+		{`{"age": 3}`, []string{"$: missing property 'name'"}},
+		{`{"name": "", "age": -1}`, []string{"$.age: ", "$.name: "}},
+		{`{"name": "bob", "tags": ["c"]}`, []string{"$.tags.0: "}},
+		{`{"name": "bob", "extra": 1}`, []string{"$: "}},
+		{`["name"]`, []string{"$: "}},
+		{`{"name": `, []string{"不是有效的JSON"}},
+	}
+	for _, tt := range tests {
+		_, issues := ValidateJSON(schema, tt.data)
+		if len(issues) != len(tt.issues) {
+			t.Errorf("ValidateJSON(%s) = %q, want %d issues", tt.data, issues, len(tt.issues))
+			continue
+		}
+		for i, prefix := range tt.issues {
+			if !strings.HasPrefix(issues[i], prefix) {
+				t.Errorf("ValidateJSON(%s) issue %d = %q, want prefix %q", tt.data, i, issues[i], prefix)
+			}
+		}
+	}
+}
+
+func TestValidateRef(t *testing.T) {
+	schema := parse(t, `{
+		"$defs": {"id": {"type": "string", "pattern": "^[a-z]+$"}},
+		"type": "object",
+		"properties": {"id": {"$ref": "#/$defs/id"}}
+	}`)
+	if issues := Validate(schema, map[string]any{"id": "abc"}); len(issues) != 0 {
+		t.Errorf("Validate() = %q", issues)
+	}
+	if issues := Validate(schema, map[string]any{"id": "ABC"}); len(issues) != 1 {
+		t.Errorf("Validate() = %q, want 1 issue", issues)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(parse(t, personSchema)); err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	invalid := []string{
+		`{"type": "text"}`,
+		`{"type": "string", "pattern": "("}`,
+		`{"properties": []}`,
+		`{"minLength": -1}`,
+		// 不能通过 $ref 读取本地文件或访问网络
+		`{"$ref": "file:///etc/passwd"}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+	}
+	for _, text := range invalid {
+		if err := Check(parse(t, text)); err == nil {
+			t.Errorf("Check(%s) should fail", text)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := map[string]string{
+		"{\"a\": 1}":               "{\"a\": 1}",
+		"```json\n{\"a\": 1}\n```": "{\"a\": 1}",
+		"结果如下：{\"a\": [1]} 以上":     "{\"a\": [1]}",
+		"没有 JSON":                  "没有 JSON",
+	}
+	for text, want := range tests {
+		if got := Extract(text); got != want {
+			t.Errorf("Extract(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
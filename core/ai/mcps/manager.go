@@ -0,0 +1,479 @@
+package mcps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mszlu521/thunder/logs"
+)
+
+var ErrManagerClosed = errors.New("mcp manager closed")
+
+// ConnStatus MCP连接状态
+type ConnStatus string
+
+const (
+	ConnStatusIdle      ConnStatus = "idle"      // 还未建立过连接
+	ConnStatusHealthy   ConnStatus = "healthy"   // 已初始化，心跳正常
+	ConnStatusUnhealthy ConnStatus = "unhealthy" // 连接失败或心跳失败，等待重连
+	ConnStatusClosed    ConnStatus = "closed"    // 管理器已关闭
+)
+
+// ManagerConfig 连接管理器的配置
+type ManagerConfig struct {
+	// PingInterval 健康检查间隔
+	PingInterval time.Duration
+	// PingTimeout 单次 ping 超时时间
+	PingTimeout time.Duration
+	// InitTimeout 建立连接并 Initialize 的超时时间
+	InitTimeout time.Duration
+	// MinBackoff/MaxBackoff 重连退避的上下限，失败一次翻倍
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// IdleTimeout 超过该时间没有被使用的连接会被关闭回收
+	IdleTimeout time.Duration
+}
+
+func DefaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		PingInterval: 30 * time.Second,
+		PingTimeout:  5 * time.Second,
+		InitTimeout:  10 * time.Second,
+		MinBackoff:   time.Second,
+		MaxBackoff:   2 * time.Minute,
+		IdleTimeout:  30 * time.Minute,
+	}
+}
+
+// ServerStatus 单个MCP服务的连接状态，不包含凭证信息
+type ServerStatus struct {
+	Url         string     `json:"url"`
+	Status      ConnStatus `json:"status"`
+	LastError   string     `json:"lastError,omitempty"`
+	Failures    int        `json:"failures"`
+	ConnectedAt *time.Time `json:"connectedAt,omitempty"`
+	LastPingAt  *time.Time `json:"lastPingAt,omitempty"`
+	NextRetryAt *time.Time `json:"nextRetryAt,omitempty"`
+}
+
+// conn 一个MCP服务对应的共享连接。mu 只保护状态字段，建连、ping 和关闭客户端都在锁外进行，
+// 一个响应慢的服务不会阻塞查询状态和等待同一连接的其他请求
+type conn struct {
+	mu          sync.Mutex
+	key         string
+	config      ServerConfig
+	cli         *client.Client
+	cancel      context.CancelFunc
+	resources   *resourceCache
+	status      ConnStatus
+	lastErr     error
+	failures    int
+	connectedAt time.Time
+	lastPing    time.Time
+	lastUsed    time.Time
+	nextRetry   time.Time
+	// connecting 不为空时表示正在建立连接，建立完成后关闭，其他请求等待它而不是重复建连
+	connecting chan struct{}
+}
+
+// Manager 按 服务地址+凭证 复用已经初始化好的MCP客户端，
+// 负责心跳检查、失败退避重连以及关闭时释放所有连接
+type Manager struct {
+	config ManagerConfig
+	mu     sync.Mutex
+	conns  map[string]*conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var (
+	defaultManager *Manager
+	managerOnce    sync.Once
+)
+
+// InitManager 初始化全局连接管理器，只有第一次调用生效
+func InitManager(config ManagerConfig) *Manager {
+	managerOnce.Do(func() {
+		defaultManager = NewManager(config)
+	})
+	return defaultManager
+}
+
+// GetManager 获取全局连接管理器，未初始化时使用默认配置
+func GetManager() *Manager {
+	return InitManager(DefaultManagerConfig())
+}
+
+func NewManager(config ManagerConfig) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		config: config,
+		conns:  make(map[string]*conn),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	m.wg.Add(1)
+	go m.healthLoop()
+	return m
+}
+
+// GetClient 获取一个已经初始化好的共享客户端，调用方不需要也不应该 Close 它
+func (m *Manager) GetClient(ctx context.Context, config *ServerConfig) (*client.Client, error) {
+	cli, _, err := m.acquire(ctx, config)
+	return cli, err
+}
+
+// acquire 获取共享客户端以及该连接对应的资源缓存
+func (m *Manager) acquire(ctx context.Context, config *ServerConfig) (*client.Client, *resourceCache, error) {
+	if m.ctx.Err() != nil {
+		return nil, nil, ErrManagerClosed
+	}
+	c := m.getOrCreate(config)
+	for {
+		c.mu.Lock()
+		c.lastUsed = time.Now()
+		if c.status == ConnStatusHealthy && c.cli != nil {
+			cli, resources := c.cli, c.resources
+			c.mu.Unlock()
+			return cli, resources, nil
+		}
+		if c.status == ConnStatusClosed {
+			// 空闲回收的连接已经从管理器中移除，重新创建
+			c.mu.Unlock()
+			if m.ctx.Err() != nil {
+				return nil, nil, ErrManagerClosed
+			}
+			c = m.getOrCreate(config)
+			continue
+		}
+		if connecting := c.connecting; connecting != nil {
+			c.mu.Unlock()
+			select {
+			case <-connecting:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		if time.Now().Before(c.nextRetry) {
+			err := fmt.Errorf("mcp server %s unavailable, retry after %s: %w",
+				c.config.Address(), c.nextRetry.Format(time.DateTime), c.lastErr)
+			c.mu.Unlock()
+			return nil, nil, err
+		}
+		c.mu.Unlock()
+		if err := m.connect(ctx, c); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Status 查询某个MCP服务的连接状态
+func (m *Manager) Status(config *ServerConfig) ServerStatus {
+	m.mu.Lock()
+	c, ok := m.conns[config.key()]
+	m.mu.Unlock()
+	if !ok {
+		return ServerStatus{Url: config.Address(), Status: ConnStatusIdle}
+	}
+	return c.snapshot()
+}
+
+// Statuses 所有已知MCP服务的连接状态
+func (m *Manager) Statuses() []ServerStatus {
+	var statuses []ServerStatus
+	for _, c := range m.connList() {
+		statuses = append(statuses, c.snapshot())
+	}
+	return statuses
+}
+
+// Close 停止健康检查并关闭所有连接
+func (m *Manager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+	m.mu.Lock()
+	conns := m.conns
+	m.conns = make(map[string]*conn)
+	m.mu.Unlock()
+	for _, c := range conns {
+		c.mu.Lock()
+		closeClient := c.detachClient()
+		c.status = ConnStatusClosed
+		c.mu.Unlock()
+		closeClient()
+	}
+	return nil
+}
+
+func (m *Manager) getOrCreate(config *ServerConfig) *conn {
+	key := config.key()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.conns[key]
+	if !ok {
+		c = &conn{
+			key:      key,
+			config:   *config,
+			status:   ConnStatusIdle,
+			lastUsed: time.Now(),
+		}
+		m.conns[key] = c
+	}
+	return c
+}
+
+func (m *Manager) connList() []*conn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*conn, 0, len(m.conns))
+	for _, c := range m.conns {
+		list = append(list, c)
+	}
+	return list
+}
+
+// connect 建立连接并初始化，调用方不能持有 c.mu。
+// 已经有其他请求在建连，或者连接已经可用、已经关闭时直接返回，由调用方重新检查状态
+func (m *Manager) connect(ctx context.Context, c *conn) error {
+	c.mu.Lock()
+	if c.connecting != nil || c.status == ConnStatusClosed || (c.status == ConnStatusHealthy && c.cli != nil) {
+		c.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	c.connecting = done
+	closeOld := c.detachClient()
+	config := c.config
+	c.mu.Unlock()
+	closeOld()
+
+	cli, connCancel, err := m.dial(ctx, &config)
+
+	c.mu.Lock()
+	closeClient := func() {}
+	if err == nil && (m.ctx.Err() != nil || c.status == ConnStatusClosed) {
+		// 建连期间管理器关闭或者连接被回收，新连接不再使用
+		connCancel()
+		closeClient = func() { _ = cli.Close() }
+		err = ErrManagerClosed
+	}
+	if err == nil {
+		m.attach(c, cli, connCancel)
+	} else if c.status != ConnStatusClosed {
+		closeClient = m.markFailed(c, err)
+	}
+	c.connecting = nil
+	close(done)
+	c.mu.Unlock()
+	closeClient()
+	return err
+}
+
+// dial 创建客户端并完成 Initialize，失败时已经释放客户端
+func (m *Manager) dial(ctx context.Context, config *ServerConfig) (*client.Client, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.config.InitTimeout)
+	defer cancel()
+	// 长连接使用管理器派生的context，不能跟随单次请求的context结束
+	connCtx, connCancel := context.WithCancel(m.ctx)
+	cli, err := newStartedClient(connCtx, ctx, config)
+	if err != nil {
+		connCancel()
+		return nil, nil, err
+	}
+	if err := initialize(ctx, cli, config); err != nil {
+		connCancel()
+		_ = cli.Close()
+		return nil, nil, err
+	}
+	return cli, connCancel, nil
+}
+
+// attach 使用新建立的客户端，调用方需要持有 c.mu
+func (m *Manager) attach(c *conn, cli *client.Client, connCancel context.CancelFunc) {
+	cli.OnConnectionLost(func(err error) {
+		// 回调可能发生在 transport 的读协程中，异步处理避免和 Close 互相等待
+		go m.markLost(c, cli, err)
+	})
+	// 订阅关系跟随连接，重连后缓存也要重建
+	resources := newResourceCache()
+	cli.OnNotification(func(notification mcp.JSONRPCNotification) {
+		go m.handleNotification(cli, resources, notification)
+	})
+	c.cli = cli
+	c.cancel = connCancel
+	c.resources = resources
+	c.status = ConnStatusHealthy
+	c.lastErr = nil
+	c.failures = 0
+	c.connectedAt = time.Now()
+	c.lastPing = c.connectedAt
+	c.nextRetry = time.Time{}
+	logs.Infof("mcp server connected: %s", c.config.Address())
+}
+
+// markFailed 记录失败并计算下一次允许重连的时间，调用方需要持有 c.mu，
+// 返回的函数在释放锁之后调用，关闭失败的客户端
+func (m *Manager) markFailed(c *conn, err error) func() {
+	closeClient := c.detachClient()
+	c.status = ConnStatusUnhealthy
+	c.lastErr = err
+	c.failures++
+	backoff := m.config.MinBackoff << (c.failures - 1)
+	if backoff <= 0 || backoff > m.config.MaxBackoff {
+		backoff = m.config.MaxBackoff
+	}
+	c.nextRetry = time.Now().Add(backoff)
+	logs.Warnf("mcp server %s failed %d times, retry in %s: %v", c.config.Address(), c.failures, backoff, err)
+	return closeClient
+}
+
+func (m *Manager) markLost(c *conn, cli *client.Client, err error) {
+	c.mu.Lock()
+	// 已经被替换掉的旧连接不用处理
+	if c.cli != cli {
+		c.mu.Unlock()
+		return
+	}
+	if err == nil {
+		err = errors.New("connection lost")
+	}
+	closeClient := m.markFailed(c, err)
+	c.mu.Unlock()
+	closeClient()
+}
+
+func (m *Manager) healthLoop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+func (m *Manager) checkAll() {
+	var wg sync.WaitGroup
+	for _, c := range m.connList() {
+		wg.Add(1)
+		go func(c *conn) {
+			defer wg.Done()
+			m.check(c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// check 对单个连接做健康检查，空闲过期的连接关闭并从管理器中移除
+func (m *Manager) check(c *conn) {
+	c.mu.Lock()
+	if c.connecting != nil {
+		c.mu.Unlock()
+		return
+	}
+	if time.Since(c.lastUsed) > m.config.IdleTimeout {
+		closeClient := c.detachClient()
+		c.status = ConnStatusClosed
+		m.remove(c)
+		c.mu.Unlock()
+		closeClient()
+		return
+	}
+	switch c.status {
+	case ConnStatusHealthy:
+		cli := c.cli
+		c.mu.Unlock()
+		ctx, cancel := context.WithTimeout(m.ctx, m.config.PingTimeout)
+		err := cli.Ping(ctx)
+		cancel()
+		c.mu.Lock()
+		// ping 期间连接已经被替换或者关闭，结果不再适用
+		if c.cli != cli {
+			c.mu.Unlock()
+			return
+		}
+		if err != nil {
+			closeClient := m.markFailed(c, fmt.Errorf("ping failed: %w", err))
+			c.mu.Unlock()
+			closeClient()
+			return
+		}
+		c.lastPing = time.Now()
+		c.mu.Unlock()
+	case ConnStatusUnhealthy:
+		// 到了重连时间就在后台重连，避免下一次对话请求承担建连耗时
+		retry := !time.Now().Before(c.nextRetry)
+		c.mu.Unlock()
+		if retry {
+			_ = m.connect(m.ctx, c)
+		}
+	default:
+		c.mu.Unlock()
+	}
+}
+
+// remove 从管理器中移除连接，调用方持有 c.mu 时也可以调用
+func (m *Manager) remove(c *conn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conns[c.key] == c {
+		delete(m.conns, c.key)
+	}
+}
+
+// detachClient 取下底层客户端并取消它的context，调用方需要持有 c.mu。
+// 关闭客户端可能要等待网络或子进程退出，返回的函数在释放锁之后调用
+func (c *conn) detachClient() func() {
+	cli, cancel := c.cli, c.cancel
+	c.cli, c.cancel = nil, nil
+	if cli == nil {
+		return func() {}
+	}
+	// 先取消context，stdio 子进程不响应 stdin 关闭时 Close 也不会一直阻塞
+	if cancel != nil {
+		cancel()
+	}
+	address := c.config.Address()
+	return func() {
+		if err := cli.Close(); err != nil {
+			logs.Warnf("close mcp client %s error: %v", address, err)
+		}
+	}
+}
+
+func (c *conn) snapshot() ServerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := ServerStatus{
+		Url:      c.config.Address(),
+		Status:   c.status,
+		Failures: c.failures,
+	}
+	if c.lastErr != nil {
+		status.LastError = c.lastErr.Error()
+	}
+	if !c.connectedAt.IsZero() {
+		connectedAt := c.connectedAt
+		status.ConnectedAt = &connectedAt
+	}
+	if !c.lastPing.IsZero() {
+		lastPing := c.lastPing
+		status.LastPingAt = &lastPing
+	}
+	if !c.nextRetry.IsZero() {
+		nextRetry := c.nextRetry
+		status.NextRetryAt = &nextRetry
+	}
+	return status
+}
@@ -0,0 +1,129 @@
+package mcps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func testManagerConfig() ManagerConfig {
+	config := DefaultManagerConfig()
+	config.PingInterval = time.Hour
+	config.InitTimeout = 5 * time.Second
+	config.MinBackoff = time.Minute
+	return config
+}
+
+func TestManagerReusesClient(t *testing.T) {
+	srv := server.NewTestStreamableHTTPServer(server.NewMCPServer("test", "1.0.0"))
+	defer srv.Close()
+	m := NewManager(testManagerConfig())
+	defer m.Close()
+
+	config := &ServerConfig{Url: srv.URL + "/mcp", Transport: TransportStreamableHTTP}
+	first, err := m.GetClient(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	second, err := m.GetClient(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if first != second {
+		t.Fatal("expected the same shared client")
+	}
+	if status := m.Status(config); status.Status != ConnStatusHealthy {
+		t.Fatalf("status = %s, want healthy", status.Status)
+	}
+}
+
+func TestManagerSlowServerDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+	defer close(release)
+	m := NewManager(testManagerConfig())
+	defer m.Close()
+
+	config := &ServerConfig{Url: slow.URL + "/mcp", Transport: TransportStreamableHTTP}
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = m.GetClient(context.Background(), config)
+		}()
+	}
+	// 等第一个请求开始建连
+	deadline := time.Now().Add(2 * time.Second)
+	for requests.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done := make(chan ServerStatus)
+	go func() { done <- m.Status(config) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Status blocked while a connection was being established")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.GetClient(ctx, config); err != context.DeadlineExceeded {
+		t.Fatalf("waiting caller error = %v, want deadline exceeded", err)
+	}
+
+	release <- struct{}{}
+	wg.Wait()
+	for _, err := range errs {
+		if err == nil {
+			t.Fatal("expected connection error")
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("server received %d connection attempts, want 1", n)
+	}
+}
+
+func TestManagerBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL + "/mcp"
+	srv.Close()
+	m := NewManager(testManagerConfig())
+	defer m.Close()
+
+	config := &ServerConfig{Url: url, Transport: TransportStreamableHTTP}
+	if _, err := m.GetClient(context.Background(), config); err == nil {
+		t.Fatal("expected connection error")
+	}
+	_, err := m.GetClient(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "retry after") {
+		t.Fatalf("second call error = %v, want backoff error", err)
+	}
+	status := m.Status(config)
+	if status.Status != ConnStatusUnhealthy || status.Failures != 1 || status.NextRetryAt == nil {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestManagerClosed(t *testing.T) {
+	m := NewManager(testManagerConfig())
+	_ = m.Close()
+	if _, err := m.GetClient(context.Background(), &ServerConfig{Url: "http://127.0.0.1:1/mcp"}); err != ErrManagerClosed {
+		t.Fatalf("error = %v, want ErrManagerClosed", err)
+	}
+}
@@ -2,53 +2,83 @@ package mcps
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
+	"time"
 
 	mcpp "github.com/cloudwego/eino-ext/components/tool/mcp"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mszlu521/thunder/ai/einos"
+	"github.com/mszlu521/thunder/logs"
 )
 
-func GetEinoBaseTools(ctx context.Context, config *einos.McpConfig) ([]tool.BaseTool, error) {
-	headers := make(map[string]string)
-	if config.Token != "" {
-		headers["Authorization"] = fmt.Sprintf("Bearer %s", config.Token)
-	}
-	options := transport.WithHeaders(headers)
-	url := config.BaseUrl
-	//支持streamable http
-	var cli *client.Client
-	var err error
-	if strings.HasSuffix(url, "/sse") {
-		// SSE方式
-		cli, err = client.NewSSEMCPClient(url, options)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// Streamable HTTP
-		cli, err = client.NewStreamableHttpClient(url, transport.WithHTTPHeaders(headers))
-		if err != nil {
-			return nil, err
-		}
+// MCP 传输方式
+const (
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable_http"
+	TransportStdio          = "stdio"
+)
+
+// ServerConfig 连接一个MCP服务需要的全部信息，凭证已经是解密后的明文
+type ServerConfig struct {
+	// Name/Version 作为 clientInfo 发送给服务端
+	Name    string
+	Version string
+	// Transport 传输方式，为空时按 Url 后缀判断
+	Transport string
+	Url       string
+	// Headers 每个请求都会带上的请求头，bearer token 和自定义请求头都放在这里
+	Headers map[string]string
+	// OAuth2 client credentials 方式获取 access token
+	OAuth2 *OAuth2Config
+	// stdio 方式启动的命令、参数以及环境变量（KEY=VALUE）
+	Command string
+	Args    []string
+	Env     []string
+}
+
+// GetTransport 返回实际使用的传输方式
+func (c *ServerConfig) GetTransport() string {
+	if c.Transport != "" {
+		return c.Transport
 	}
-	err = cli.Start(ctx)
-	if err != nil {
-		return nil, err
+	//兼容旧数据：没有指定类型时按地址判断
+	if strings.HasSuffix(c.Url, "/sse") {
+		return TransportSSE
 	}
+	return TransportStreamableHTTP
+}
 
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = mcp.Implementation{
-		Name:    config.Name,
-		Version: config.Version,
+// Address 用于展示的服务地址，不包含凭证
+func (c *ServerConfig) Address() string {
+	if c.GetTransport() == TransportStdio {
+		return strings.TrimSpace("stdio://" + c.Command + " " + strings.Join(c.Args, " "))
 	}
+	return c.Url
+}
+
+// key 连接的唯一标识，凭证参与计算但不以明文保存
+func (c *ServerConfig) key() string {
+	identity := *c
+	identity.Name = ""
+	identity.Version = ""
+	identity.Transport = c.GetTransport()
+	bytes, _ := json.Marshal(identity)
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:])
+}
 
-	_, err = cli.Initialize(ctx, initRequest)
+func GetEinoBaseTools(ctx context.Context, config *ServerConfig) ([]tool.BaseTool, error) {
+	// 客户端由连接管理器复用，这里不需要关闭
+	cli, err := GetManager().GetClient(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -60,32 +90,101 @@ func GetEinoBaseTools(ctx context.Context, config *einos.McpConfig) ([]tool.Base
 	return tools, nil
 }
 
-func GetMCPTool(ctx context.Context, config *einos.McpConfig) ([]mcp.Tool, error) {
-	headers := make(map[string]string)
-	if config.Token != "" {
-		headers["Authorization"] = fmt.Sprintf("Bearer %s", config.Token)
+func GetMCPTool(ctx context.Context, config *ServerConfig) ([]mcp.Tool, error) {
+	cli, err := GetManager().GetClient(ctx, config)
+	if err != nil {
+		return nil, err
 	}
-	options := transport.WithHeaders(headers)
-	url := config.BaseUrl
-	//支持streamable http
-	var cli *client.Client
+	tools, err := cli.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return tools.Tools, nil
+}
+
+// newStartedClient 根据传输方式创建客户端并启动 transport
+// ctx 决定了长连接（SSE流、stdio子进程）的生命周期，initCtx 只用于建连阶段
+func newStartedClient(ctx context.Context, initCtx context.Context, config *ServerConfig) (*client.Client, error) {
+	var trans transport.Interface
 	var err error
-	if strings.HasSuffix(url, "/sse") {
-		cli, err = client.NewSSEMCPClient(url, options)
-		if err != nil {
+	switch config.GetTransport() {
+	case TransportStdio:
+		if config.Command == "" {
+			return nil, errors.New("stdio command is empty")
+		}
+		trans = transport.NewStdioWithOptions(config.Command, config.Env, config.Args,
+			transport.WithCommandFunc(stdioCommand))
+	case TransportSSE:
+		var headerFunc transport.HTTPHeaderFunc
+		if headerFunc, err = buildHeaderFunc(initCtx, config); err != nil {
 			return nil, err
 		}
-	} else {
-		cli, err = client.NewStreamableHttpClient(url, transport.WithHTTPHeaders(headers))
-		if err != nil {
+		if trans, err = transport.NewSSE(config.Url, transport.WithHeaderFunc(headerFunc)); err != nil {
+			return nil, err
+		}
+	case TransportStreamableHTTP:
+		var headerFunc transport.HTTPHeaderFunc
+		if headerFunc, err = buildHeaderFunc(initCtx, config); err != nil {
 			return nil, err
 		}
+		if trans, err = transport.NewStreamableHTTP(config.Url, transport.WithHTTPHeaderFunc(headerFunc)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown mcp transport: %s", config.Transport)
 	}
+	cli := client.NewClient(trans)
 	err = cli.Start(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return cli, nil
+}
 
+// buildHeaderFunc 每次请求时生成请求头，OAuth2 的 token 过期后会自动刷新
+func buildHeaderFunc(ctx context.Context, config *ServerConfig) (transport.HTTPHeaderFunc, error) {
+	headers := make(map[string]string, len(config.Headers)+1)
+	for k, v := range config.Headers {
+		headers[k] = v
+	}
+	if config.OAuth2 == nil {
+		return func(context.Context) map[string]string {
+			return headers
+		}, nil
+	}
+	source := newClientCredentialsSource(config.OAuth2)
+	// 建连前先取一次 token，凭证错误可以直接返回给调用方
+	if _, err := source.Token(ctx); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) map[string]string {
+		token, err := source.Token(ctx)
+		if err != nil {
+			logs.Warnf("get mcp oauth2 token error: %v", err)
+			return headers
+		}
+		withToken := make(map[string]string, len(headers)+1)
+		for k, v := range headers {
+			withToken[k] = v
+		}
+		withToken["Authorization"] = "Bearer " + token
+		return withToken
+	}, nil
+}
+
+// stdioCommand 启动 stdio 子进程，只传递 PATH/HOME 和配置的环境变量，避免泄露服务端的环境变量
+func stdioCommand(ctx context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Env = append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + os.Getenv("HOME"),
+	}, env...)
+	cmd.WaitDelay = 5 * time.Second
+	return cmd, nil
+}
+
+func initialize(ctx context.Context, cli *client.Client, config *ServerConfig) error {
 	initRequest := mcp.InitializeRequest{}
 	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
 	initRequest.Params.ClientInfo = mcp.Implementation{
@@ -93,14 +192,6 @@ func GetMCPTool(ctx context.Context, config *einos.McpConfig) ([]mcp.Tool, error
 		Version: config.Version,
 	}
 
-	_, err = cli.Initialize(ctx, initRequest)
-	if err != nil {
-		return nil, err
-	}
-	tools, err := cli.ListTools(ctx, mcp.ListToolsRequest{})
-	if err != nil {
-		return nil, err
-	}
-
-	return tools.Tools, nil
+	_, err := cli.Initialize(ctx, initRequest)
+	return err
 }
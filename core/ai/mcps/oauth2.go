@@ -0,0 +1,89 @@
+package mcps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Config OAuth2 client credentials 模式的配置
+type OAuth2Config struct {
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// clientCredentialsSource 获取并缓存 access token，过期前30秒刷新
+type clientCredentialsSource struct {
+	config     *OAuth2Config
+	httpClient *http.Client
+	mu         sync.Mutex
+	token      string
+	expiry     time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newClientCredentialsSource(config *OAuth2Config) *clientCredentialsSource {
+	return &clientCredentialsSource{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *clientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(30*time.Second).Before(s.expiry)) {
+		return s.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 要求授权服务器必须支持 HTTP Basic 方式传递 client 凭证
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientId), url.QueryEscape(s.config.ClientSecret))
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read oauth2 token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request oauth2 token: status %d: %s", resp.StatusCode, string(body))
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("parse oauth2 token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("oauth2 token response has no access_token")
+	}
+	s.token = token.AccessToken
+	s.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return s.token, nil
+}
@@ -0,0 +1,107 @@
+package mcps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/logs"
+)
+
+const ReadResourceToolName = "read_resource"
+
+// 工具描述中最多列出的资源数量，资源太多时模型可以直接传入已知的 uri
+const maxListedResources = 50
+
+// ReadResourceTool 让 agent 按 uri 读取所绑定MCP服务提供的资源
+type ReadResourceTool struct {
+	servers map[string]*ServerConfig // uri -> 提供该资源的服务
+	configs []*ServerConfig
+	desc    string
+}
+
+type readResourceParams struct {
+	Uri string `json:"uri"`
+}
+
+// NewReadResourceTool 汇总多个MCP服务的资源列表，没有任何服务提供资源时返回 nil
+func NewReadResourceTool(ctx context.Context, configs []*ServerConfig) *ReadResourceTool {
+	t := &ReadResourceTool{
+		servers: make(map[string]*ServerConfig),
+	}
+	var builder strings.Builder
+	builder.WriteString("读取MCP服务提供的资源内容（文件、文档、数据库记录等），参数 uri 为资源地址。可用资源：\n")
+	listed := 0
+	for _, config := range configs {
+		resources, err := GetManager().ListResources(ctx, config)
+		if err != nil {
+			logs.Warnf("获取MCP资源列表时出错: %s, %v", config.Address(), err)
+			continue
+		}
+		if len(resources) == 0 {
+			continue
+		}
+		t.configs = append(t.configs, config)
+		for _, resource := range resources {
+			t.servers[resource.URI] = config
+			if listed >= maxListedResources {
+				continue
+			}
+			listed++
+			builder.WriteString(fmt.Sprintf("- %s: %s", resource.URI, resource.Name))
+			if resource.Description != "" {
+				builder.WriteString("，" + resource.Description)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	if len(t.configs) == 0 {
+		return nil
+	}
+	t.desc = builder.String()
+	return t
+}
+
+func (t *ReadResourceTool) Params() map[string]*schema.ParameterInfo {
+	return map[string]*schema.ParameterInfo{
+		"uri": {
+			Type:     schema.String,
+			Desc:     "资源的 uri",
+			Required: true,
+		},
+	}
+}
+
+func (t *ReadResourceTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        ReadResourceToolName,
+		Desc:        t.desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.Params()),
+	}, nil
+}
+
+func (t *ReadResourceTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var params readResourceParams
+	if err := json.Unmarshal([]byte(argumentsInJSON), &params); err != nil {
+		return "", err
+	}
+	if params.Uri == "" {
+		return "", fmt.Errorf("uri is required")
+	}
+	if config, ok := t.servers[params.Uri]; ok {
+		return GetManager().ReadResource(ctx, config, params.Uri)
+	}
+	// 不在列表里的 uri（比如资源模板生成的）依次尝试每个服务
+	var lastErr error
+	for _, config := range t.configs {
+		text, err := GetManager().ReadResource(ctx, config, params.Uri)
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("read resource %s: %w", params.Uri, lastErr)
+}
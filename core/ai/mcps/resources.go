@@ -0,0 +1,242 @@
+package mcps
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// 未订阅的资源内容缓存时间，订阅了的资源在收到更新通知前一直有效
+const resourceCacheTTL = 5 * time.Minute
+
+// maxResourceTextLen 返回给模型的资源内容最大长度
+const maxResourceTextLen = 32 * 1024
+
+// resourceCache 一个连接上的资源列表和资源内容缓存
+type resourceCache struct {
+	mu       sync.Mutex
+	list     []mcp.Resource
+	listAt   time.Time
+	contents map[string]*cachedContent
+}
+
+type cachedContent struct {
+	text       string
+	readAt     time.Time
+	subscribed bool
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		contents: make(map[string]*cachedContent),
+	}
+}
+
+func (r *resourceCache) getList() ([]mcp.Resource, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listAt.IsZero() || time.Since(r.listAt) > resourceCacheTTL {
+		return nil, false
+	}
+	return r.list, true
+}
+
+func (r *resourceCache) setList(list []mcp.Resource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.list = list
+	r.listAt = time.Now()
+}
+
+func (r *resourceCache) invalidateList() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listAt = time.Time{}
+}
+
+func (r *resourceCache) getContent(uri string) (*cachedContent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	content, ok := r.contents[uri]
+	if !ok {
+		return nil, false
+	}
+	if !content.subscribed && time.Since(content.readAt) > resourceCacheTTL {
+		return content, false
+	}
+	return content, true
+}
+
+func (r *resourceCache) setContent(uri string, text string, subscribed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contents[uri] = &cachedContent{
+		text:       text,
+		readAt:     time.Now(),
+		subscribed: subscribed,
+	}
+}
+
+// ListResources 获取MCP服务提供的资源列表，服务端不支持资源时返回空
+func (m *Manager) ListResources(ctx context.Context, config *ServerConfig) ([]mcp.Resource, error) {
+	cli, cache, err := m.acquire(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if cli.GetServerCapabilities().Resources == nil {
+		return nil, nil
+	}
+	if list, ok := cache.getList(); ok {
+		return list, nil
+	}
+	result, err := cli.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	cache.setList(result.Resources)
+	return result.Resources, nil
+}
+
+// ReadResource 读取资源内容并转为文本，服务端支持订阅时会订阅该资源，更新通知到达后刷新缓存
+func (m *Manager) ReadResource(ctx context.Context, config *ServerConfig, uri string) (string, error) {
+	cli, cache, err := m.acquire(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	content, ok := cache.getContent(uri)
+	if ok {
+		return content.text, nil
+	}
+	text, err := readResourceText(ctx, cli, uri)
+	if err != nil {
+		return "", err
+	}
+	subscribed := content != nil && content.subscribed
+	if !subscribed {
+		capabilities := cli.GetServerCapabilities()
+		if capabilities.Resources != nil && capabilities.Resources.Subscribe {
+			subscribeRequest := mcp.SubscribeRequest{}
+			subscribeRequest.Params.URI = uri
+			if err := cli.Subscribe(ctx, subscribeRequest); err != nil {
+				logs.Warnf("subscribe mcp resource %s error: %v", uri, err)
+			} else {
+				subscribed = true
+			}
+		}
+	}
+	cache.setContent(uri, text, subscribed)
+	return text, nil
+}
+
+// handleNotification 处理服务端推送的资源变更通知
+func (m *Manager) handleNotification(cli *client.Client, cache *resourceCache, notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case mcp.MethodNotificationResourcesListChanged:
+		cache.invalidateList()
+	case mcp.MethodNotificationResourceUpdated:
+		uri, _ := notification.Params.AdditionalFields["uri"].(string)
+		if uri == "" {
+			return
+		}
+		if _, ok := cache.getContent(uri); !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(m.ctx, m.config.InitTimeout)
+		defer cancel()
+		text, err := readResourceText(ctx, cli, uri)
+		if err != nil {
+			logs.Warnf("refresh mcp resource %s error: %v", uri, err)
+			return
+		}
+		cache.setContent(uri, text, true)
+	}
+}
+
+func readResourceText(ctx context.Context, cli *client.Client, uri string) (string, error) {
+	readRequest := mcp.ReadResourceRequest{}
+	readRequest.Params.URI = uri
+	result, err := cli.ReadResource(ctx, readRequest)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	for _, content := range result.Contents {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		if text, ok := mcp.AsTextResourceContents(content); ok {
+			builder.WriteString(text.Text)
+			continue
+		}
+		if blob, ok := mcp.AsBlobResourceContents(content); ok {
+			// 二进制内容模型无法直接使用，只给出说明
+			size := base64.StdEncoding.DecodedLen(len(blob.Blob))
+			builder.WriteString(fmt.Sprintf("[二进制资源 %s，类型 %s，约 %d 字节]", blob.URI, blob.MIMEType, size))
+		}
+	}
+	return truncateText(builder.String(), maxResourceTextLen), nil
+}
+
+// truncateText 超过 maxLen 字节时截断，截断位置退到字符边界，不会拆开一个 UTF-8 字符
+func truncateText(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n...[内容过长已截断]"
+}
+
+// ListPrompts 获取MCP服务提供的提示词模板
+func (m *Manager) ListPrompts(ctx context.Context, config *ServerConfig) ([]mcp.Prompt, error) {
+	cli, err := m.GetClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if cli.GetServerCapabilities().Prompts == nil {
+		return nil, nil
+	}
+	result, err := cli.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Prompts, nil
+}
+
+// GetPromptText 使用参数渲染MCP提示词模板，并把所有消息拼接为文本
+func (m *Manager) GetPromptText(ctx context.Context, config *ServerConfig, name string, arguments map[string]string) (string, error) {
+	cli, err := m.GetClient(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	promptRequest := mcp.GetPromptRequest{}
+	promptRequest.Params.Name = name
+	promptRequest.Params.Arguments = arguments
+	result, err := cli.GetPrompt(ctx, promptRequest)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, message := range result.Messages {
+		if text, ok := mcp.AsTextContent(message.Content); ok {
+			parts = append(parts, text.Text)
+			continue
+		}
+		if embedded, ok := mcp.AsEmbeddedResource(message.Content); ok {
+			if text, ok := mcp.AsTextResourceContents(embedded.Resource); ok {
+				parts = append(parts, text.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
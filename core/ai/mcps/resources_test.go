@@ -0,0 +1,24 @@
+package mcps
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("short", 10); got != "short" {
+		t.Fatalf("short text changed: %q", got)
+	}
+	// 每个汉字 3 个字节，10 字节落在第 4 个字的中间
+	got := truncateText(strings.Repeat("资源", 5), 10)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncated text is not valid UTF-8: %q", got)
+	}
+	if want := "资源资"; !strings.HasPrefix(got, want) || strings.HasPrefix(got, want+"源") {
+		t.Fatalf("truncateText() = %q, want prefix %q", got, want)
+	}
+	if !strings.HasSuffix(got, "[内容过长已截断]") {
+		t.Fatalf("missing truncation marker: %q", got)
+	}
+}
@@ -0,0 +1,231 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultTopK = 4
+	// embedBatchSize 每次请求向量模型的文本数，很多接口限制了单次的数量
+	embedBatchSize = 10
+)
+
+// MemoryRetriever 内存中的临时检索器，用于对话中上传的文件这类不需要持久化的文档。
+// 配置了向量模型时按余弦相似度检索，否则按 BM25 关键词得分检索
+type MemoryRetriever struct {
+	docs     []*schema.Document
+	embedder embedding.Embedder
+	vectors  [][]float64
+	index    *keywordIndex
+	topK     int
+}
+
+var _ retriever.Retriever = (*MemoryRetriever)(nil)
+
+// NewMemoryRetriever embedder 为 nil 时使用关键词检索，topK 不大于 0 时默认返回 4 条
+func NewMemoryRetriever(ctx context.Context, embedder embedding.Embedder, docs []*schema.Document, topK int) (*MemoryRetriever, error) {
+	if embedder == nil {
+		return &MemoryRetriever{docs: docs, index: newKeywordIndex(docs), topK: defaultIfZero(topK)}, nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	vectors, err := EmbedTexts(ctx, embedder, texts)
+	if err != nil {
+		return nil, err
+	}
+	return NewVectorRetriever(embedder, docs, vectors, topK), nil
+}
+
+// NewVectorRetriever 使用已经计算好的向量，vectors 和 docs 按顺序一一对应，embedder 只用于查询
+func NewVectorRetriever(embedder embedding.Embedder, docs []*schema.Document, vectors [][]float64, topK int) *MemoryRetriever {
+	return &MemoryRetriever{docs: docs, embedder: embedder, vectors: vectors, topK: defaultIfZero(topK)}
+}
+
+// EmbedTexts 分批计算文本的向量
+func EmbedTexts(ctx context.Context, embedder embedding.Embedder, texts []string) ([][]float64, error) {
+	var result [][]float64
+	for start := 0; start < len(texts); start += embedBatchSize {
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		vectors, err := embedder.EmbedStrings(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("embedding returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+		result = append(result, vectors...)
+	}
+	return result, nil
+}
+
+func defaultIfZero(topK int) int {
+	if topK <= 0 {
+		return defaultTopK
+	}
+	return topK
+}
+
+func (r *MemoryRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK := r.topK
+	options := retriever.GetCommonOptions(&retriever.Options{TopK: &topK}, opts...)
+	var scores []float64
+	if r.embedder != nil {
+		vectors, err := r.embedder.EmbedStrings(ctx, []string{query})
+		if err != nil {
+			return nil, err
+		}
+		scores = make([]float64, len(r.docs))
+		for i, vector := range r.vectors {
+			if len(vectors) > 0 {
+				scores[i] = cosine(vectors[0], vector)
+			}
+		}
+	} else {
+		scores = r.index.score(query)
+	}
+	order := make([]int, len(r.docs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	var result []*schema.Document
+	for _, i := range order {
+		if options.TopK != nil && len(result) >= *options.TopK {
+			break
+		}
+		if options.ScoreThreshold != nil && scores[i] < *options.ScoreThreshold {
+			break
+		}
+		// 关键词检索时没有命中任何词的块没有意义
+		if r.embedder == nil && scores[i] <= 0 {
+			break
+		}
+		doc := *r.docs[i]
+		result = append(result, doc.WithScore(scores[i]))
+	}
+	return result, nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// keywordIndex BM25 关键词索引。英文和数字按单词切分，中日韩文字按相邻两个字切分
+type keywordIndex struct {
+	terms  []map[string]int
+	lens   []int
+	avgLen float64
+	df     map[string]int
+}
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+func newKeywordIndex(docs []*schema.Document) *keywordIndex {
+	index := &keywordIndex{df: make(map[string]int)}
+	total := 0
+	for _, doc := range docs {
+		tokens := tokenize(doc.Content)
+		counts := make(map[string]int, len(tokens))
+		for _, token := range tokens {
+			counts[token]++
+		}
+		for token := range counts {
+			index.df[token]++
+		}
+		index.terms = append(index.terms, counts)
+		index.lens = append(index.lens, len(tokens))
+		total += len(tokens)
+	}
+	if len(docs) > 0 {
+		index.avgLen = float64(total) / float64(len(docs))
+	}
+	return index
+}
+
+func (k *keywordIndex) score(query string) []float64 {
+	scores := make([]float64, len(k.terms))
+	n := float64(len(k.terms))
+	seen := make(map[string]bool)
+	for _, token := range tokenize(query) {
+		if seen[token] || k.df[token] == 0 {
+			continue
+		}
+		seen[token] = true
+		df := float64(k.df[token])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, counts := range k.terms {
+			tf := float64(counts[token])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(k.lens[i])/math.Max(k.avgLen, 1)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return scores
+}
+
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var prevCJK rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			if prevCJK != 0 {
+				tokens = append(tokens, string([]rune{prevCJK, r}))
+			} else {
+				tokens = append(tokens, string(r))
+			}
+			prevCJK = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+		prevCJK = 0
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,177 @@
+package retrieval
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+func docs(texts ...string) []*schema.Document {
+	result := make([]*schema.Document, len(texts))
+	for i, text := range texts {
+		result[i] = &schema.Document{ID: string(rune('a' + i)), Content: text}
+	}
+	return result
+}
+
+func ids(docs []*schema.Document) string {
+	var sb strings.Builder
+	for _, doc := range docs {
+		sb.WriteString(doc.ID)
+	}
+	return sb.String()
+}
+
+func TestTokenize(t *testing.T) {
+	tests := map[string][]string{
+		"Hello, World 2024!": {"hello", "world", "2024"},
+		"季度报告":               {"季", "季度", "度报", "报告"},
+		"GPU使用率 99%":         {"gpu", "使", "使用", "用率", "99"},
+		"  ":                  nil,
+	}
+	for text, want := range tests {
+		if got := tokenize(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("tokenize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestBM25Score(t *testing.T) {
+	index := newKeywordIndex(docs("apple banana", "apple apple cherry date", "banana"))
+	scores := index.score("apple")
+	// apple 出现在 3 篇中的 2 篇，平均长度 7/3
+	idf := math.Log(1 + (3-2+0.5)/(2+0.5))
+	bm25 := func(tf, length float64) float64 {
+		norm := 1 - bm25B + bm25B*length/(7.0/3)
+		return idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+	}
+	want := []float64{bm25(1, 2), bm25(2, 4), 0}
+	for i := range want {
+		if math.Abs(scores[i]-want[i]) > 1e-9 {
+			t.Errorf("score[%d] = %v, want %v", i, scores[i], want[i])
+		}
+	}
+	// 重复的查询词只计算一次，不认识的词不影响得分
+	if repeated := index.score("apple APPLE unknown"); !reflect.DeepEqual(repeated, scores) {
+		t.Errorf("repeated query scores = %v, want %v", repeated, scores)
+	}
+	// 罕见的词权重更高
+	if rare := index.score("cherry"); rare[1] <= scores[1] {
+		t.Errorf("rare term score %v should exceed common term score %v", rare[1], scores[1])
+	}
+	if empty := newKeywordIndex(nil).score("apple"); len(empty) != 0 {
+		t.Errorf("empty index scores = %v", empty)
+	}
+}
+
+func TestKeywordRetrieve(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewMemoryRetriever(ctx, nil, docs(
+		"退货政策：收到商品七天内可以退货",
+		"发货时间：下单后两天内发货",
+		"Shipping takes two days",
+		"会员积分可以抵扣运费",
+	), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		opts  []retriever.Option
+		want  string
+	}{
+		{"怎么退货", nil, "a"},
+		{"发货要多久", nil, "b"},
+		{"shipping days", nil, "c"},
+		// 词频相同时较短的块得分更高
+		{"天内", nil, "ba"},
+		{"天内", []retriever.Option{retriever.WithTopK(1)}, "b"},
+		// 没有命中任何词时不返回
+		{"invoice", nil, ""},
+	}
+	for _, tt := range tests {
+		result, err := r.Retrieve(ctx, tt.query, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(result); got != tt.want {
+			t.Errorf("Retrieve(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+// fakeEmbedder 按文本中是否包含关键词生成向量
+type fakeEmbedder struct {
+	keywords []string
+	calls    [][]string
+}
+
+func (e *fakeEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	e.calls = append(e.calls, texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(e.keywords))
+		for j, keyword := range e.keywords {
+			if strings.Contains(text, keyword) {
+				vectors[i][j] = 1
+			}
+		}
+	}
+	return vectors, nil
+}
+
+func TestVectorRetrieve(t *testing.T) {
+	ctx := context.Background()
+	embedder := &fakeEmbedder{keywords: []string{"cat", "dog", "fish"}}
+	texts := make([]string, 0, 12)
+	for i := 0; i < 11; i++ {
+		texts = append(texts, "fish")
+	}
+	texts = append(texts, "cat and dog")
+	r, err := NewMemoryRetriever(ctx, embedder, docs(texts...), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分批计算向量
+	if len(embedder.calls) != 2 || len(embedder.calls[0]) != embedBatchSize || len(embedder.calls[1]) != 2 {
+		t.Fatalf("embed calls = %d", len(embedder.calls))
+	}
+	result, err := r.Retrieve(ctx, "dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0].ID != "l" || math.Abs(result[0].Score()-1/math.Sqrt2) > 1e-9 {
+		t.Fatalf("result = %+v", result)
+	}
+	result, err = r.Retrieve(ctx, "dog", retriever.WithScoreThreshold(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 {
+		t.Errorf("threshold result = %d docs, want 1", len(result))
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{1, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0},
+		{[]float64{1, 1}, []float64{-1, -1}, -1},
+		{[]float64{0, 0}, []float64{1, 1}, 0},
+		{[]float64{1}, []float64{1, 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("cosine(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
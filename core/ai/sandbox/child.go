@@ -0,0 +1,103 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// childEnv 设置了这个环境变量的进程是沙箱子进程，值为要执行的语言
+	childEnv  = "FABER_SANDBOX_CHILD"
+	limitsEnv = "FABER_SANDBOX_LIMITS"
+	// baseEnv 宿主机上的临时目录，包含工作目录和根文件系统的挂载点
+	baseEnv = "FABER_SANDBOX_BASE"
+	// runnerEnv 设置了这个环境变量的进程已经在沙箱中，直接执行内置的 JavaScript
+	runnerEnv = "FABER_SANDBOX_RUNNER"
+	// childErrPrefix 子进程搭建沙箱失败时输出的前缀
+	childErrPrefix = "sandbox: "
+)
+
+// 沙箱子进程复用当前的可执行文件，在包初始化时进入子进程模式，不会执行原程序的 main
+func init() {
+	if os.Getenv(runnerEnv) != "" {
+		os.Exit(runRunner())
+	}
+	language := os.Getenv(childEnv)
+	if language == "" {
+		return
+	}
+	os.Exit(runChild(language))
+}
+
+// runChild 在新的命名空间中搭建根文件系统，设置资源限制并丢掉特权后执行运行时
+func runChild(language string) int {
+	base := os.Getenv(baseEnv)
+	limits := decodeLimits(os.Getenv(limitsEnv))
+	os.Unsetenv(childEnv)
+	os.Unsetenv(limitsEnv)
+	os.Unsetenv(baseEnv)
+	// 内置 JavaScript 由当前程序执行，切换根目录后程序文件不可见，需要先打开
+	var exe *os.File
+	if language == LangJavaScript {
+		var err error
+		if exe, err = os.Open(selfExe); err != nil {
+			fmt.Fprintf(os.Stderr, childErrPrefix+"open self: %v\n", err)
+			return exitSetupFailed
+		}
+	}
+	if err := setupRoot(base); err != nil {
+		fmt.Fprintf(os.Stderr, childErrPrefix+"setup root: %v\n", err)
+		return exitSetupFailed
+	}
+	if err := applyLimits(limits); err != nil {
+		fmt.Fprintf(os.Stderr, childErrPrefix+"apply limits: %v\n", err)
+		return exitSetupFailed
+	}
+	if exe == nil && len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, childErrPrefix+"missing runtime")
+		return exitSetupFailed
+	}
+	if err := dropPrivileges(); err != nil {
+		fmt.Fprintf(os.Stderr, childErrPrefix+"drop privileges: %v\n", err)
+		return exitSetupFailed
+	}
+	// 替换为真正的运行时，资源限制会被继承
+	if exe != nil {
+		os.Setenv(runnerEnv, language)
+		err := execFile(exe, []string{runnerName}, os.Environ())
+		fmt.Fprintf(os.Stderr, childErrPrefix+"exec runner: %v\n", err)
+		return exitSetupFailed
+	}
+	err := execRuntime(os.Args[1], os.Args[1:], os.Environ())
+	fmt.Fprintf(os.Stderr, childErrPrefix+"exec %s: %v\n", os.Args[1], err)
+	return exitSetupFailed
+}
+
+// runRunner 在沙箱中执行工作目录中的 JavaScript 脚本
+func runRunner() int {
+	os.Unsetenv(runnerEnv)
+	code, err := os.ReadFile(jsScript)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := runJavaScript(string(code), os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func decodeLimits(s string) Limits {
+	var limits Limits
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return DefaultLimits
+	}
+	limits.CPUSeconds, _ = strconv.Atoi(parts[0])
+	limits.MemoryBytes, _ = strconv.ParseInt(parts[1], 10, 64)
+	limits.FileBytes, _ = strconv.ParseInt(parts[2], 10, 64)
+	return limits
+}
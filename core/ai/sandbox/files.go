@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+const (
+	// maxFiles 最多返回的文件数量
+	maxFiles = 20
+	// maxInlineFile 文本文件内容直接返回的最大大小
+	maxInlineFile = 16 * 1024
+)
+
+// File 执行过程中在工作目录里产生的文件
+type File struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Content 小的文本文件直接返回内容，二进制或较大的文件只返回名称和大小
+	Content string `json:"content,omitempty"`
+	// Data 文件的原始内容，不返回给模型
+	Data []byte `json:"-"`
+}
+
+// writeInputs 把输入文件写入工作目录，文件名只保留最后一段，不能和脚本重名
+func writeInputs(workDir string, script string, inputs []*File) (map[string]bool, error) {
+	names := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		name := filepath.Base(filepath.Clean("/" + input.Name))
+		if name == "/" || name == script || names[name] {
+			return nil, fmt.Errorf("invalid input file name: %s", input.Name)
+		}
+		if err := os.WriteFile(filepath.Join(workDir, name), input.Data, 0o644); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, nil
+}
+
+// collectFiles 收集工作目录中除脚本和输入文件以外的文件
+func collectFiles(workDir string, script string, inputs map[string]bool) ([]*File, error) {
+	var files []*File
+	err := filepath.WalkDir(workDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if len(files) >= maxFiles {
+			return filepath.SkipAll
+		}
+		// 只收集普通文件，符号链接可能指向工作目录以外
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(workDir, path)
+		if err != nil || name == script || inputs[filepath.ToSlash(name)] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		file := &File{Name: filepath.ToSlash(name), Size: info.Size()}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file.Data = data
+		if info.Size() <= maxInlineFile && utf8.Valid(data) {
+			file.Content = string(data)
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
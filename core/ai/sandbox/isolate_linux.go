@@ -0,0 +1,249 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// 以 root 运行时沙箱映射到的宿主机用户，没有任何文件和特权
+const nobodyId = 65534
+
+// 只读挂载到沙箱中的系统目录，外部运行时和它们依赖的动态库需要在这些目录下
+var systemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64"}
+
+// 挂载到沙箱中的设备
+var devices = []string{"null", "zero", "random", "urandom"}
+
+// 保留源挂载点上的这些标志，用户命名空间中重新挂载时不能去掉它们
+const lockedMountFlags = unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC |
+	unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME
+
+// securebits，见 linux/securebits.h
+const (
+	secbitNoroot                  = 1 << 0
+	secbitNorootLocked            = 1 << 1
+	secbitNoSetuidFixup           = 1 << 2
+	secbitNoSetuidFixupLocked     = 1 << 3
+	secbitKeepCapsLocked          = 1 << 5
+	secbitNoCapAmbientRaise       = 1 << 6
+	secbitNoCapAmbientRaiseLocked = 1 << 7
+)
+
+// hostIds 沙箱内的 root 对应的宿主机用户。非特权用户只能映射自己；
+// 以 root 运行时映射到 nobody，避免沙箱进程在宿主机上拥有 root 的文件
+func hostIds() (int, int) {
+	if os.Getuid() == 0 {
+		return nobodyId, nobodyId
+	}
+	return os.Getuid(), os.Getgid()
+}
+
+// prepareDirs 把工作目录交给沙箱用户，沙箱中的进程需要能访问它们
+func prepareDirs(dirs ...string) error {
+	uid, gid := hostIds()
+	if uid == os.Getuid() {
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := os.Chown(dir, uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isolate 子进程放到新的用户、挂载、PID、网络、IPC 和 UTS 命名空间中。
+// 子进程在命名空间中是 root，只用来搭建根文件系统，执行代码前会丢掉全部能力；
+// 网络命名空间里只有未启用的回环网卡，因此无法访问网络；
+// 同时单独成组，超时后连同其创建的子进程一起杀掉。
+// 系统不支持用户命名空间时启动失败，不会退回到不隔离的方式执行
+func isolate(cmd *exec.Cmd) {
+	uid, gid := hostIds()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: uid, Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: gid, Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+		// 切换到命名空间中的 root，映射到 nobody 时宿主机上的身份随之改变
+		Credential: &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true},
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// setupRoot 在 tmpfs 上搭建新的根文件系统并切换过去：只有可写的工作目录 /work、
+// 只读的系统目录和几个设备文件，宿主机的其它文件都不可见
+func setupRoot(base string) error {
+	root := filepath.Join(base, rootDir)
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=755"); err != nil {
+		return fmt.Errorf("mount tmpfs: %w", err)
+	}
+	for _, dir := range systemDirs {
+		info, err := os.Lstat(dir)
+		if err != nil {
+			continue
+		}
+		target := filepath.Join(root, dir)
+		// 合并了 /usr 的系统中 /bin、/lib 是指向 /usr 的符号链接
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(dir)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := bindMount(dir, target, true, unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+			return err
+		}
+	}
+	if err := bindMount(filepath.Join(base, workDirName), filepath.Join(root, workDirName), true, unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+		return err
+	}
+	for _, name := range devices {
+		if err := bindMount("/dev/"+name, filepath.Join(root, "dev", name), false, unix.MS_NOSUID|unix.MS_NOEXEC); err != nil {
+			return err
+		}
+	}
+	oldRoot := filepath.Join(root, ".oldroot")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount("/.oldroot", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Remove("/.oldroot"); err != nil {
+		return err
+	}
+	if err := unix.Mount("", "/", "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+	return os.Chdir("/" + workDirName)
+}
+
+// bindMount 把 source 绑定挂载到 target 并加上 flags，target 不存在时按 source 的类型创建
+func bindMount(source string, target string, dir bool, flags uintptr) error {
+	if dir {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, nil, 0o644); err != nil {
+			return err
+		}
+	}
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	var stat unix.Statfs_t
+	if err := unix.Statfs(target, &stat); err != nil {
+		return err
+	}
+	flags |= uintptr(stat.Flags) & lockedMountFlags
+	if err := unix.Mount("", target, "", unix.MS_BIND|unix.MS_REMOUNT|flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", source, err)
+	}
+	return nil
+}
+
+// dropPrivileges 丢掉命名空间中 root 的全部能力并禁止再获得，之后 execve 的程序没有任何特权。
+// 能力是线程级别的，调用后当前线程需要直接 execve
+func dropPrivileges() error {
+	goruntime.LockOSThread()
+	bits := secbitNoroot | secbitNorootLocked | secbitNoSetuidFixup | secbitNoSetuidFixupLocked |
+		secbitKeepCapsLocked | secbitNoCapAmbientRaise | secbitNoCapAmbientRaiseLocked
+	if err := unix.Prctl(unix.PR_SET_SECUREBITS, uintptr(bits), 0, 0, 0); err != nil {
+		return fmt.Errorf("set securebits: %w", err)
+	}
+	for c := 0; ; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil {
+			if errors.Is(err, unix.EINVAL) {
+				break
+			}
+			return fmt.Errorf("drop capability %d: %w", c, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	var data [2]unix.CapUserData
+	if err := unix.Capset(&unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}, &data[0]); err != nil {
+		return fmt.Errorf("clear capabilities: %w", err)
+	}
+	return nil
+}
+
+func applyLimits(limits Limits) error {
+	set := func(resource int, value uint64) error {
+		if value == 0 {
+			return nil
+		}
+		return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+	}
+	if err := set(syscall.RLIMIT_CPU, uint64(limits.CPUSeconds)); err != nil {
+		return err
+	}
+	if err := set(syscall.RLIMIT_DATA, uint64(limits.MemoryBytes)); err != nil {
+		return err
+	}
+	if err := set(syscall.RLIMIT_FSIZE, uint64(limits.FileBytes)); err != nil {
+		return err
+	}
+	return set(syscall.RLIMIT_NOFILE, 256)
+}
+
+func execRuntime(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env)
+}
+
+// execFile 执行已打开的程序文件，文件可以在当前根目录以外
+func execFile(file *os.File, args []string, env []string) error {
+	empty, err := syscall.BytePtrFromString("")
+	if err != nil {
+		return err
+	}
+	argv, err := syscall.SlicePtrFromStrings(args)
+	if err != nil {
+		return err
+	}
+	envv, err := syscall.SlicePtrFromStrings(env)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall6(unix.SYS_EXECVEAT, file.Fd(), uintptr(unsafe.Pointer(empty)),
+		uintptr(unsafe.Pointer(&argv[0])), uintptr(unsafe.Pointer(&envv[0])), unix.AT_EMPTY_PATH, 0)
+	return errno
+}
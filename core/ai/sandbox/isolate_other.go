@@ -0,0 +1,38 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errUnsupported = errors.New("sandbox is only supported on linux")
+
+// isolate 非 linux 系统没有可用的隔离手段，子进程直接拒绝执行
+func isolate(cmd *exec.Cmd) {}
+
+func prepareDirs(dirs ...string) error {
+	return nil
+}
+
+func setupRoot(base string) error {
+	return errUnsupported
+}
+
+func dropPrivileges() error {
+	return errUnsupported
+}
+
+func applyLimits(limits Limits) error {
+	return errUnsupported
+}
+
+func execRuntime(path string, args []string, env []string) error {
+	return errUnsupported
+}
+
+func execFile(file *os.File, args []string, env []string) error {
+	return errUnsupported
+}
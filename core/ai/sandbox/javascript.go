@@ -0,0 +1,73 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// runJavaScript 用 goja 执行脚本，提供 console.log、console.error 以及读写工作目录文件的
+// readFile(name)、writeFile(name, content)；最后一条表达式的值不是 undefined 时会被输出
+func runJavaScript(code string, stdout io.Writer, stderr io.Writer) error {
+	vm := goja.New()
+	console := vm.NewObject()
+	if err := console.Set("log", printer(vm, stdout)); err != nil {
+		return err
+	}
+	if err := console.Set("error", printer(vm, stderr)); err != nil {
+		return err
+	}
+	if err := vm.Set("console", console); err != nil {
+		return err
+	}
+	if err := vm.Set("readFile", func(name string) (string, error) {
+		data, err := os.ReadFile(name)
+		return string(data), err
+	}); err != nil {
+		return err
+	}
+	if err := vm.Set("writeFile", func(name string, content string) error {
+		return os.WriteFile(name, []byte(content), 0o644)
+	}); err != nil {
+		return err
+	}
+	value, err := vm.RunScript(jsScript, code)
+	if err != nil {
+		return err
+	}
+	if value != nil && !goja.IsUndefined(value) {
+		_, err = fmt.Fprintln(stdout, formatJS(vm, value))
+	}
+	return err
+}
+
+// printer 参数之间用空格分隔输出一行
+func printer(vm *goja.Runtime, w io.Writer) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			parts[i] = formatJS(vm, arg)
+		}
+		fmt.Fprintln(w, strings.Join(parts, " "))
+		return goja.Undefined()
+	}
+}
+
+// formatJS 字符串原样输出，对象和数组输出为 JSON
+func formatJS(vm *goja.Runtime, value goja.Value) string {
+	if _, ok := value.Export().(string); ok || goja.IsUndefined(value) || goja.IsNull(value) {
+		return value.String()
+	}
+	if _, ok := value.(*goja.Object); ok {
+		if _, isFunc := goja.AssertFunction(value); !isFunc {
+			data, err := value.ToObject(vm).MarshalJSON()
+			if err == nil {
+				return string(data)
+			}
+		}
+	}
+	return value.String()
+}
@@ -0,0 +1,48 @@
+package sandbox
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunJavaScriptInProcess(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		code   string
+		stdout string
+	}{
+		{`console.log("a", 1, true, null, [1, "b"], {k: "v"})`, "a 1 true null [1,\"b\"] {\"k\":\"v\"}\n"},
+		{`const x = [3, 1, 2]; x.sort(); x`, "[1,2,3]\n"},
+		{`"text"`, "text\n"},
+		{`var unused = 1`, ""},
+		{`writeFile("f.txt", "hello"); readFile("f.txt").toUpperCase()`, "HELLO\n"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := runJavaScript(tt.code, &stdout, &stderr); err != nil {
+			t.Errorf("runJavaScript(%q) error: %v", tt.code, err)
+			continue
+		}
+		if stdout.String() != tt.stdout {
+			t.Errorf("runJavaScript(%q) stdout = %q, want %q", tt.code, stdout.String(), tt.stdout)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "f.txt")); err != nil {
+		t.Errorf("writeFile did not write into the working directory: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := runJavaScript(`readFile("missing.txt")`, &stdout, &stderr); err == nil {
+		t.Error("reading a missing file should throw")
+	}
+}
@@ -0,0 +1,221 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持的语言
+const (
+	// LangJavaScript 使用内置的 goja 执行，不依赖外部运行时
+	LangJavaScript = "javascript"
+	LangPython     = "python"
+)
+
+// runtime 外部语言的运行时，运行时不存在时对应语言不可用
+type runtime struct {
+	command string
+	script  string
+}
+
+var runtimes = map[string]runtime{
+	LangPython: {command: "python3", script: "main.py"},
+}
+
+const (
+	// jsScript 内置 JavaScript 的脚本文件名
+	jsScript = "main.js"
+	// sandboxPath 沙箱中的 PATH，外部运行时只在这些只读挂载的系统目录中查找
+	sandboxPath = "/usr/local/bin:/usr/bin:/bin"
+	// workDirName 工作目录在沙箱根目录下的名称，宿主机上位于临时目录中
+	workDirName = "work"
+	// rootDir 宿主机临时目录中沙箱根文件系统的挂载点
+	rootDir = "root"
+	// runnerName 沙箱中执行内置 JavaScript 的进程名
+	runnerName = "sandbox-runner"
+	// selfExe 当前程序
+	selfExe = "/proc/self/exe"
+	// exitSetupFailed 子进程搭建沙箱失败时的退出码，此时代码没有执行
+	exitSetupFailed = 126
+)
+
+// Limits 单次执行的资源限制
+type Limits struct {
+	// Timeout 墙上时间，超时后整个进程组会被杀掉
+	Timeout time.Duration
+	// CPUSeconds CPU时间
+	CPUSeconds int
+	// MemoryBytes 数据段大小
+	MemoryBytes int64
+	// FileBytes 单个文件的最大大小
+	FileBytes int64
+	// MaxOutput stdout、stderr 各自保留的最大长度
+	MaxOutput int
+}
+
+var DefaultLimits = Limits{
+	Timeout:     30 * time.Second,
+	CPUSeconds:  20,
+	MemoryBytes: 512 << 20,
+	FileBytes:   16 << 20,
+	MaxOutput:   16 * 1024,
+}
+
+// Result 执行结果
+type Result struct {
+	Stdout   string  `json:"stdout"`
+	Stderr   string  `json:"stderr"`
+	ExitCode int     `json:"exitCode"`
+	TimedOut bool    `json:"timedOut,omitempty"`
+	Files    []*File `json:"files,omitempty"`
+}
+
+// Languages 返回当前环境可用的语言
+func Languages() []string {
+	languages := []string{LangJavaScript}
+	if _, err := lookRuntime(runtimes[LangPython].command); err == nil {
+		languages = append(languages, LangPython)
+	}
+	return languages
+}
+
+// lookRuntime 在 sandboxPath 中查找外部运行时，其它位置的程序在沙箱中不可见
+func lookRuntime(command string) (string, error) {
+	for _, dir := range filepath.SplitList(sandboxPath) {
+		path := filepath.Join(dir, command)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0 {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in %s", command, sandboxPath)
+}
+
+// Run 在隔离的子进程中执行代码：只能看到独立的临时工作目录和只读的系统目录、没有网络、受 limits 限制，
+// 执行结束后返回输出和工作目录中产生的文件，工作目录随后删除
+func Run(ctx context.Context, language string, code string, limits Limits) (*Result, error) {
+	return RunWithFiles(ctx, language, code, limits, nil)
+}
+
+// RunWithFiles 和 Run 相同，执行前把 inputs 写入工作目录，代码可以按文件名读取。
+// 输入文件不会出现在返回的文件中
+func RunWithFiles(ctx context.Context, language string, code string, limits Limits, inputs []*File) (*Result, error) {
+	var script string
+	var args []string
+	if language == LangJavaScript {
+		script = jsScript
+	} else {
+		rt, ok := runtimes[language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported language: %s", language)
+		}
+		path, err := lookRuntime(rt.command)
+		if err != nil {
+			return nil, fmt.Errorf("%s runtime is not available", language)
+		}
+		script = rt.script
+		args = []string{path, script}
+	}
+	base, err := os.MkdirTemp("", "faber-sandbox-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(base)
+	workDir := filepath.Join(base, workDirName)
+	for _, dir := range []string{workDir, filepath.Join(base, rootDir)} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if err := prepareDirs(base, workDir); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, script), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+	inputNames, err := writeInputs(workDir, script, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	// 先启动自身进入子进程模式，搭建好隔离的根文件系统、设置好资源限制后再执行真正的运行时
+	// 通过 /proc/self/exe 启动，沙箱用户不需要能访问程序所在的目录
+	cmd := exec.CommandContext(ctx, selfExe, args...)
+	cmd.Dir = workDir
+	cmd.Env = []string{
+		childEnv + "=" + language,
+		limitsEnv + "=" + encodeLimits(limits),
+		baseEnv + "=" + base,
+		"PATH=" + sandboxPath,
+		"HOME=/" + workDirName,
+		"TMPDIR=/" + workDirName,
+		"LANG=C.UTF-8",
+		"PYTHONDONTWRITEBYTECODE=1",
+	}
+	stdout := &limitedBuffer{limit: limits.MaxOutput}
+	stderr := &limitedBuffer{limit: limits.MaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	isolate(cmd)
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	result := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !result.TimedOut {
+		return nil, fmt.Errorf("start sandbox: %w", err)
+	}
+	if result.ExitCode == exitSetupFailed && strings.HasPrefix(result.Stderr, childErrPrefix) {
+		return nil, fmt.Errorf("start sandbox: %s", strings.TrimSpace(strings.TrimPrefix(result.Stderr, childErrPrefix)))
+	}
+	files, err := collectFiles(workDir, script, inputNames)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = files
+	return result, nil
+}
+
+// limitedBuffer 只保留前 limit 个字节的输出，超出部分丢弃但不报错，避免子进程因管道写失败而退出
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remain := b.limit - b.buf.Len()
+	if len(p) > remain {
+		b.truncated = true
+	}
+	if remain > 0 {
+		b.buf.Write(p[:min(len(p), remain)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n...[输出过长已截断]"
+	}
+	return b.buf.String()
+}
+
+func encodeLimits(limits Limits) string {
+	return strconv.Itoa(limits.CPUSeconds) + "," +
+		strconv.FormatInt(limits.MemoryBytes, 10) + "," +
+		strconv.FormatInt(limits.FileBytes, 10)
+}
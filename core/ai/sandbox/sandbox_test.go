@@ -0,0 +1,161 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requireSandbox 当前环境不能创建用户命名空间时跳过，沙箱本身在这种环境下拒绝执行
+func requireSandbox(t *testing.T) {
+	t.Helper()
+	if _, err := Run(context.Background(), LangJavaScript, "1", DefaultLimits); err != nil {
+		t.Skipf("sandbox is not available: %v", err)
+	}
+}
+
+func TestRunJavaScript(t *testing.T) {
+	requireSandbox(t)
+	result, err := Run(context.Background(), LangJavaScript, `
+console.log("sum", [1, 2, 3].reduce((a, b) => a + b, 0));
+console.error("warn");
+({answer: 42})`, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("exit code %d, stderr: %s", result.ExitCode, result.Stderr)
+	}
+	if result.Stdout != "sum 6\n{\"answer\":42}\n" {
+		t.Errorf("stdout = %q", result.Stdout)
+	}
+	if result.Stderr != "warn\n" {
+		t.Errorf("stderr = %q", result.Stderr)
+	}
+}
+
+func TestRunJavaScriptError(t *testing.T) {
+	requireSandbox(t)
+	result, err := Run(context.Background(), LangJavaScript, `undefinedFunction()`, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 1 || !strings.Contains(result.Stderr, "ReferenceError") {
+		t.Errorf("exit code %d, stderr %q", result.ExitCode, result.Stderr)
+	}
+}
+
+func TestRunWithFiles(t *testing.T) {
+	requireSandbox(t)
+	inputs := []*File{{Name: "../data.csv", Data: []byte("a,b\n1,2\n")}}
+	result, err := RunWithFiles(context.Background(), LangJavaScript, `
+const rows = readFile("data.csv").trim().split("\n");
+writeFile("out.txt", rows.length + " rows");`, DefaultLimits, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("exit code %d, stderr: %s", result.ExitCode, result.Stderr)
+	}
+	if len(result.Files) != 1 || result.Files[0].Name != "out.txt" || result.Files[0].Content != "2 rows" {
+		t.Fatalf("files = %+v", result.Files)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	requireSandbox(t)
+	limits := DefaultLimits
+	limits.Timeout = time.Second
+	result, err := Run(context.Background(), LangJavaScript, `while (true) {}`, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut {
+		t.Errorf("expected timeout, got %+v", result)
+	}
+}
+
+// 沙箱中只能看到工作目录和只读的系统目录
+func TestRunIsolatesFilesystem(t *testing.T) {
+	requireSandbox(t)
+	secret, err := os.CreateTemp("", "sandbox-secret-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(secret.Name())
+	secret.Close()
+	for _, path := range []string{secret.Name(), "/etc/passwd", "/proc/self/environ"} {
+		result, err := Run(context.Background(), LangJavaScript, `readFile(`+strconvQuote(path)+`)`, DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ExitCode == 0 {
+			t.Errorf("%s should not be readable, stdout: %q", path, result.Stdout)
+		}
+	}
+	result, err := Run(context.Background(), LangJavaScript, `writeFile("/usr/evil", "x")`, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("system directories should be read-only")
+	}
+}
+
+func TestRunPythonIsolation(t *testing.T) {
+	requireSandbox(t)
+	if !slices.Contains(Languages(), LangPython) {
+		t.Skip("python3 is not available")
+	}
+	result, err := Run(context.Background(), LangPython, `
+import os, socket
+print(sorted(os.listdir("/")))
+try:
+    socket.create_connection(("1.1.1.1", 53), timeout=2)
+    print("network")
+except OSError:
+    print("no network")
+try:
+    os.setuid(1)
+    print("setuid")
+except OSError:
+    print("no setuid")
+`, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("exit code %d, stderr: %s", result.ExitCode, result.Stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("stdout = %q", result.Stdout)
+	}
+	for _, name := range []string{"'etc'", "'home'", "'root'", "'tmp'", "'proc'"} {
+		if strings.Contains(lines[0], name) {
+			t.Errorf("root should not contain %s: %s", name, lines[0])
+		}
+	}
+	if lines[1] != "no network" || lines[2] != "no setuid" {
+		t.Errorf("stdout = %q", result.Stdout)
+	}
+}
+
+func TestLookRuntime(t *testing.T) {
+	if _, err := lookRuntime("definitely-not-a-runtime"); err == nil {
+		t.Error("expected error for missing runtime")
+	}
+	if path, err := exec.LookPath("sh"); err == nil && strings.HasPrefix(path, "/usr/bin") {
+		if _, err := lookRuntime("sh"); err != nil {
+			t.Errorf("lookRuntime(sh) error: %v", err)
+		}
+	}
+}
+
+func strconvQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -1,5 +1,10 @@
 package ai
 
+import (
+	"strconv"
+	"strings"
+)
+
 const BaseSystemPrompt = `
 # 角色与目标
 你是一个AI智能助手。你的任务是理解用户的需求，通过一系列的“思考”和“行动”来解决问题。
@@ -9,6 +14,7 @@ const BaseSystemPrompt = `
 -------如果以下知识库有内容，优先匹配知识库数据进行回答----------
 {ragContext}
 -----------------
+{memories}
 
 # 能力清单
 你必须调用提供的skill列表，选择合适的进行调用，严禁编造任何事实。
@@ -16,3 +22,97 @@ const BaseSystemPrompt = `
 -----------------
 {agentsInfo}
 `
+
+// BuildSystemPrompt 替换 BaseSystemPrompt 中的占位符。
+// 替换的内容中可能有 JSON 示例之类的花括号，不能再作为模板解析，所以只做一次字符串替换
+func BuildSystemPrompt(role string, ragContext string, memories string, toolsInfo string, agentsInfo string) string {
+	return strings.NewReplacer(
+		"{role}", role,
+		"{ragContext}", ragContext,
+		"{memories}", memories,
+		"{toolsInfo}", toolsInfo,
+		"{agentsInfo}", agentsInfo,
+	).Replace(BaseSystemPrompt)
+}
+
+const OutputSchemaPrompt = `
+# 输出格式
+最终回答必须是一个符合以下 JSON Schema 的 JSON，不要输出 JSON 以外的任何内容，也不要使用代码块：
+{schema}
+`
+
+const OutputRetryPrompt = `你的回答不符合要求的输出格式，问题如下：
+{issues}
+请修正后重新回答，只输出符合 JSON Schema 的 JSON。`
+
+// BuildOutputSchemaPrompt 附加在系统提示词后面，说明最终回答的格式
+func BuildOutputSchemaPrompt(schema string) string {
+	return strings.Replace(OutputSchemaPrompt, "{schema}", schema, 1)
+}
+
+// BuildOutputRetryPrompt 回答不符合格式时，带上校验错误重新提问
+func BuildOutputRetryPrompt(issues []string) string {
+	return strings.Replace(OutputRetryPrompt, "{issues}", "- "+strings.Join(issues, "\n- "), 1)
+}
+
+const ImageDescriptionPrompt = `你负责为另一个不能查看图片的AI助手描述用户发送的图片。
+结合用户的问题，详细、客观地描述每张图片中与问题相关的内容，包括其中的文字、数据、物体和布局。
+不要回答用户的问题，只描述图片。多张图片时按顺序分别描述，以“图片1：”“图片2：”开头。`
+
+const ImageContextPrompt = `{message}
+
+（用户随消息发送了 {count} 张图片，以下是图片内容的描述）
+{description}`
+
+// BuildImageContextPrompt 主模型不支持图片时，把图像模型对图片的描述附加在用户消息后面
+func BuildImageContextPrompt(message string, count int, description string) string {
+	return strings.NewReplacer("{message}", message, "{count}", strconv.Itoa(count), "{description}", description).
+		Replace(ImageContextPrompt)
+}
+
+const SessionFilesPrompt = `用户在本次会话中上传了以下文件：
+{files}
+需要用代码处理文件时，调用 code_interpreter 并在 files 参数中传入文件ID，文件会以原文件名放在代码的工作目录中。
+{contents}`
+
+// BuildSessionFilesPrompt 会话中的文件列表和内容，放在系统提示词的知识库部分
+func BuildSessionFilesPrompt(files string, contents string) string {
+	return strings.NewReplacer("{files}", files, "{contents}", contents).Replace(SessionFilesPrompt)
+}
+
+const MemoryPrompt = `
+# 关于用户的记忆
+以下是之前的对话中记住的关于当前用户的信息，和问题相关时参考，不相关时忽略：
+{memories}`
+
+// BuildMemoryPrompt 检索到的用户记忆，每条一行
+func BuildMemoryPrompt(memories []string) string {
+	var sb strings.Builder
+	for _, memory := range memories {
+		sb.WriteString("- ")
+		sb.WriteString(memory)
+		sb.WriteString("\n")
+	}
+	return strings.Replace(MemoryPrompt, "{memories}", sb.String(), 1)
+}
+
+const MemoryExtractionPrompt = `你负责从对话中提取值得长期记住的关于用户的信息，例如用户的偏好、身份、习惯、正在进行的项目，以及用户明确要求记住的事情。
+只提取关于用户本人、在以后的对话中仍然有用的事实；不要提取一次性的问题、闲聊、助手回答中的知识，也不要提取密码、密钥等敏感信息。
+每条信息是一句完整、独立的陈述，以“用户”开头。已经记住的信息不要重复提取；新信息和已有的某条信息矛盾或者是它的更新时，在 replaces 中填写那条信息的ID。
+只输出 JSON 数组，不要使用代码块，格式为 [{"content": "用户……", "replaces": ""}]，没有需要记住的信息时输出 []。
+
+已经记住的信息：
+{memories}
+
+本轮对话：
+用户：{message}
+助手：{answer}`
+
+// BuildMemoryExtractionPrompt existing 是已有的记忆，每条一行，带有ID
+func BuildMemoryExtractionPrompt(existing string, message string, answer string) string {
+	if existing == "" {
+		existing = "无"
+	}
+	return strings.NewReplacer("{memories}", existing, "{message}", message, "{answer}", answer).
+		Replace(MemoryExtractionPrompt)
+}
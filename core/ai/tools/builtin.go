@@ -0,0 +1,18 @@
+package tools
+
+import (
+	"core/ai/sandbox"
+	"core/ai/webfetch"
+
+	"github.com/mszlu521/thunder/ai/einos"
+)
+
+// BuiltinTools 所有内置的系统工具，新增系统工具时加到这里，
+// 后端注册和 mcp-server 对外暴露都以这个列表为准
+func BuiltinTools() []einos.InvokeParamTool {
+	return []einos.InvokeParamTool{
+		NewWeatherTool(&WeatherConfig{ApiKey: ApiKey}),
+		NewCodeInterpreterTool(sandbox.DefaultLimits),
+		NewWebFetchTool(webfetch.DefaultOptions),
+	}
+}
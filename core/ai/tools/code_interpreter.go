@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"core/ai/sandbox"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/ai/einos"
+)
+
+const CodeInterpreterToolName = "code_interpreter"
+
+const codeInterpreterDesc = `在隔离的沙箱中执行代码，适合计算和数据分析。沙箱没有网络，有时间和内存限制，每次执行都是全新的临时目录，写入该目录的文件会随结果返回。
+可用语言：%s。
+javascript 是内置的 ES5.1 运行时（支持部分 ES6），没有 Node.js 模块；用 console.log 输出，readFile(name) 读取、writeFile(name, content) 写入工作目录中的文件；最后一条表达式的值会被输出。`
+
+type codeInterpreterParams struct {
+	Language string   `json:"language"`
+	Code     string   `json:"code"`
+	Files    []string `json:"files"`
+}
+
+// CodeInterpreterTool 在沙箱子进程中执行模型编写的代码
+type CodeInterpreterTool struct {
+	limits    sandbox.Limits
+	languages []string
+}
+
+func NewCodeInterpreterTool(limits sandbox.Limits) einos.InvokeParamTool {
+	return &CodeInterpreterTool{
+		limits:    limits,
+		languages: sandbox.Languages(),
+	}
+}
+
+func (t *CodeInterpreterTool) Params() map[string]*schema.ParameterInfo {
+	return map[string]*schema.ParameterInfo{
+		"language": {
+			Type:     schema.String,
+			Desc:     "代码语言",
+			Enum:     t.languages,
+			Required: true,
+		},
+		"code": {
+			Type:     schema.String,
+			Desc:     "要执行的代码，通过标准输出打印结果",
+			Required: true,
+		},
+		"files": {
+			Type:     schema.Array,
+			Desc:     "需要使用的对话文件ID，执行前按原文件名写入工作目录",
+			ElemInfo: &schema.ParameterInfo{Type: schema.String},
+		},
+	}
+}
+
+func (t *CodeInterpreterTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        CodeInterpreterToolName,
+		Desc:        fmt.Sprintf(codeInterpreterDesc, strings.Join(t.languages, "、")),
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.Params()),
+	}, nil
+}
+
+func (t *CodeInterpreterTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var params codeInterpreterParams
+	if err := json.Unmarshal([]byte(argumentsInJSON), &params); err != nil {
+		return "", err
+	}
+	if params.Language == "" {
+		params.Language = sandbox.LangJavaScript
+	}
+	if !slices.Contains(t.languages, params.Language) {
+		return "", fmt.Errorf("unsupported language: %s, available: %s", params.Language, strings.Join(t.languages, ", "))
+	}
+	if strings.TrimSpace(params.Code) == "" {
+		return "", fmt.Errorf("code is required")
+	}
+	inputs, err := resolveFiles(ctx, params.Files)
+	if err != nil {
+		return "", err
+	}
+	result, err := sandbox.RunWithFiles(ctx, params.Language, params.Code, t.limits, inputs)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// resolveFiles 读取模型引用的对话文件
+func resolveFiles(ctx context.Context, refs []string) ([]*sandbox.File, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	resolver := fileResolverFrom(ctx)
+	if resolver == nil {
+		return nil, fmt.Errorf("no files are available in this conversation")
+	}
+	files := make([]*sandbox.File, 0, len(refs))
+	for _, ref := range refs {
+		file, err := resolver(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("file %s: %w", ref, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
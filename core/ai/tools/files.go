@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"context"
+	"core/ai/sandbox"
+)
+
+// FileResolver 按引用读取对话中的文件，引用是文件的ID。由运行agent的一方放在 context 中，
+// 工具通过引用使用文件，文件内容不经过模型
+type FileResolver func(ctx context.Context, ref string) (*sandbox.File, error)
+
+type fileResolverKey struct{}
+
+func WithFileResolver(ctx context.Context, resolver FileResolver) context.Context {
+	return context.WithValue(ctx, fileResolverKey{}, resolver)
+}
+
+func fileResolverFrom(ctx context.Context) FileResolver {
+	resolver, _ := ctx.Value(fileResolverKey{}).(FileResolver)
+	return resolver
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"core/ai/webfetch"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mszlu521/thunder/ai/einos"
+)
+
+const WebFetchToolName = "web_fetch"
+
+// 返回给模型的正文默认最大长度
+const defaultWebFetchLength = 20000
+
+type webFetchParams struct {
+	Url          string `json:"url"`
+	IncludeLinks bool   `json:"include_links"`
+	MaxLength    int    `json:"max_length"`
+}
+
+// WebFetchTool 抓取网页并提取为 markdown
+type WebFetchTool struct {
+	fetcher *webfetch.Fetcher
+}
+
+func NewWebFetchTool(options webfetch.Options) einos.InvokeParamTool {
+	return &WebFetchTool{
+		fetcher: webfetch.NewFetcher(options),
+	}
+}
+
+func (t *WebFetchTool) Params() map[string]*schema.ParameterInfo {
+	return map[string]*schema.ParameterInfo{
+		"url": {
+			Type:     schema.String,
+			Desc:     "要读取的网页地址，只支持 http 和 https",
+			Required: true,
+		},
+		"include_links": {
+			Type: schema.Boolean,
+			Desc: "是否在结果末尾列出页面中的链接",
+		},
+		"max_length": {
+			Type: schema.Integer,
+			Desc: fmt.Sprintf("返回正文的最大字符数，默认 %d", defaultWebFetchLength),
+		},
+	}
+}
+
+func (t *WebFetchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        WebFetchToolName,
+		Desc:        "读取网页内容：抓取指定地址的页面，提取标题和正文并转为 markdown。用户提供了链接或需要查看某个网页时使用",
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.Params()),
+	}, nil
+}
+
+func (t *WebFetchTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var params webFetchParams
+	if err := json.Unmarshal([]byte(argumentsInJSON), &params); err != nil {
+		return "", err
+	}
+	if params.Url == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	maxLength := params.MaxLength
+	if maxLength <= 0 || maxLength > defaultWebFetchLength*5 {
+		maxLength = defaultWebFetchLength
+	}
+	page, err := t.fetcher.Fetch(ctx, params.Url)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	if page.Title != "" {
+		builder.WriteString("# " + page.Title + "\n\n")
+	}
+	builder.WriteString("URL: " + page.Url + "\n\n")
+	content := []rune(page.Markdown)
+	if len(content) > maxLength {
+		builder.WriteString(string(content[:maxLength]))
+		builder.WriteString("\n\n...[内容过长已截断]")
+	} else {
+		builder.WriteString(page.Markdown)
+		if page.Truncated {
+			builder.WriteString("\n\n...[页面过大，只读取了前面部分]")
+		}
+	}
+	if params.IncludeLinks && len(page.Links) > 0 {
+		builder.WriteString("\n\n## Links\n")
+		for _, link := range page.Links {
+			builder.WriteString(fmt.Sprintf("- [%s](%s)\n", link.Text, link.Url))
+		}
+	}
+	return builder.String(), nil
+}
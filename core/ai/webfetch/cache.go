@@ -0,0 +1,57 @@
+package webfetch
+
+import (
+	"sync"
+	"time"
+)
+
+// cache 按地址缓存抓取结果，条目满了之后淘汰最早写入的
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]*cacheEntry
+	order   []string
+}
+
+type cacheEntry struct {
+	page      *Page
+	expiresAt time.Time
+}
+
+func newCache(ttl time.Duration, max int) *cache {
+	return &cache{
+		ttl:     ttl,
+		max:     max,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+func (c *cache) get(key string) (*Page, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.page, true
+}
+
+func (c *cache) set(key string, page *Page) {
+	if c.ttl <= 0 || c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = &cacheEntry{page: page, expiresAt: time.Now().Add(c.ttl)}
+	for len(c.order) > c.max {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
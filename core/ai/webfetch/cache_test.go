@@ -0,0 +1,29 @@
+package webfetch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsOldest(t *testing.T) {
+	c := newCache(time.Minute, 2)
+	for _, key := range []string{"a", "b", "a", "c"} {
+		c.set(key, &Page{Url: key})
+	}
+	if _, ok := c.get("a"); ok {
+		t.Error("a should be evicted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if page, ok := c.get(key); !ok || page.Url != key {
+			t.Errorf("get(%s) = %v, %v", key, page, ok)
+		}
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c := newCache(0, 10)
+	c.set("a", &Page{})
+	if _, ok := c.get("a"); ok {
+		t.Error("cache with zero ttl should not store entries")
+	}
+}
@@ -0,0 +1,62 @@
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrTooLarge = errors.New("response body exceeds the size limit")
+
+// File 下载的原始文件
+type File struct {
+	Url         string
+	ContentType string
+	Data        []byte
+}
+
+// Download 下载原始内容，不转换也不缓存，用于图片等二进制文件。
+// 和 Fetch 一样检查目标地址，超过 MaxBodySize 时返回 ErrTooLarge 而不是截断
+func (f *Fetcher) Download(ctx context.Context, rawUrl string) (*File, error) {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return nil, ErrInvalidUrl
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.options.UserAgent)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("download %s: status %d", u, resp.StatusCode)
+	}
+	if resp.ContentLength > f.options.MaxBodySize {
+		return nil, ErrTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.options.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.options.MaxBodySize {
+		return nil, ErrTooLarge
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return &File{
+		Url:         resp.Request.URL.String(),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,263 @@
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrBlockedAddress   = errors.New("access to private or reserved address is not allowed")
+	ErrUnsupportedType  = errors.New("unsupported content type")
+	ErrInvalidUrl       = errors.New("only http and https urls are supported")
+	errTooManyRedirects = errors.New("stopped after too many redirects")
+)
+
+// Options 抓取的限制
+type Options struct {
+	Timeout time.Duration
+	// MaxBodySize 读取响应体的上限，超出部分丢弃
+	MaxBodySize  int64
+	MaxRedirects int
+	// CacheTTL 为 0 时不缓存
+	CacheTTL     time.Duration
+	CacheEntries int
+	// AllowPrivateNetworks 允许访问内网地址，只应在测试中打开（httptest 监听在回环地址上）
+	AllowPrivateNetworks bool
+	UserAgent            string
+}
+
+var DefaultOptions = Options{
+	Timeout:      15 * time.Second,
+	MaxBodySize:  2 << 20,
+	MaxRedirects: 5,
+	CacheTTL:     10 * time.Minute,
+	CacheEntries: 200,
+	UserAgent:    "FaberAI-WebFetch/1.0",
+}
+
+// Page 抓取并提取后的页面
+type Page struct {
+	Url         string  `json:"url"`
+	Title       string  `json:"title"`
+	ContentType string  `json:"contentType"`
+	Markdown    string  `json:"markdown"`
+	Links       []*Link `json:"links,omitempty"`
+	// Truncated 响应体超过了大小限制
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type Link struct {
+	Text string `json:"text"`
+	Url  string `json:"url"`
+}
+
+// Fetcher 抓取网页，连接时检查目标地址，重定向和 DNS 解析后的地址同样会被检查
+type Fetcher struct {
+	options Options
+	client  *http.Client
+	cache   *cache
+	// allowed 额外放行的地址，测试中用来只放行某一个 httptest 服务
+	allowed map[netip.AddrPort]bool
+}
+
+func NewFetcher(options Options) *Fetcher {
+	f := &Fetcher{
+		options: options,
+		cache:   newCache(options.CacheTTL, options.CacheEntries),
+	}
+	f.client = newClient(options.Timeout, options.MaxRedirects, f.checkAddress)
+	return f
+}
+
+// NewClient 返回访问外部地址用的 HTTP 客户端，连接前检查实际要连接的 IP，
+// 用户配置的接口地址都应通过它请求，防止访问内网
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, DefaultOptions.MaxRedirects, CheckAddress)
+}
+
+func newClient(timeout time.Duration, maxRedirects int, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// 不走代理，否则检查的是代理的地址
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return errTooManyRedirects
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+// Fetch 抓取网页并转为 markdown，相同地址在缓存有效期内直接返回缓存
+func (f *Fetcher) Fetch(ctx context.Context, rawUrl string) (*Page, error) {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return nil, ErrInvalidUrl
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+	key := u.String()
+	if page, ok := f.cache.get(key); ok {
+		return page, nil
+	}
+	page, err := f.fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	f.cache.set(key, page)
+	return page, nil
+}
+
+func (f *Fetcher) fetch(ctx context.Context, u *url.URL) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.options.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("fetch %s: status %d", u, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.options.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Url: resp.Request.URL.String()}
+	if int64(len(body)) > f.options.MaxBodySize {
+		body = body[:f.options.MaxBodySize]
+		page.Truncated = true
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	page.ContentType = mediaType
+	text, err := decode(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		extracted, err := extract(text, resp.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		page.Title = extracted.title
+		page.Markdown = extracted.markdown
+		page.Links = extracted.links
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") || mediaType == "application/xml":
+		page.Markdown = text
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, mediaType)
+	}
+	return page, nil
+}
+
+// decode 按响应声明或页面 meta 中的编码转为 UTF-8
+func decode(body []byte, contentType string) (string, error) {
+	if utf8.Valid(body) {
+		return string(body), nil
+	}
+	reader, err := charset.NewReader(strings.NewReader(string(body)), contentType)
+	if err != nil {
+		return string(body), nil
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func checkScheme(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidUrl
+	}
+	return nil
+}
+
+// checkAddress 在建立连接前检查实际要连接的 IP，防止通过 DNS 解析或重定向访问内网
+func (f *Fetcher) checkAddress(network, address string, c syscall.RawConn) error {
+	if f.options.AllowPrivateNetworks {
+		return nil
+	}
+	if addr, err := netip.ParseAddrPort(address); err == nil && f.allowed[addr] {
+		return nil
+	}
+	return CheckAddress(network, address, c)
+}
+
+// CheckAddress 用作 net.Dialer 的 Control，拒绝连接内网和保留地址
+func CheckAddress(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if isBlocked(addr.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addr.Addr())
+	}
+	return nil
+}
+
+// 除了 netip 能判断的类型之外还需要屏蔽的保留网段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+func isBlocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestFetcher 默认拦截内网地址，只放行给定的 httptest 服务
+func newTestFetcher(t *testing.T, options Options, servers ...*httptest.Server) *Fetcher {
+	t.Helper()
+	f := NewFetcher(options)
+	f.allowed = make(map[netip.AddrPort]bool)
+	for _, server := range servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.allowed[netip.MustParseAddrPort(u.Host)] = true
+	}
+	return f
+}
+
+func TestIsBlocked(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"::ffff:127.0.0.1": true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"8.8.8.8":          false,
+		"2606:4700::1111":  false,
+	}
+	for ip, want := range tests {
+		if got := isBlocked(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("isBlocked(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestFetchBlocksPrivateAddress(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+	f := NewFetcher(DefaultOptions)
+	if _, err := f.Fetch(context.Background(), server.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Fetch() error = %v, want ErrBlockedAddress", err)
+	}
+	if _, err := f.Download(context.Background(), server.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Download() error = %v, want ErrBlockedAddress", err)
+	}
+	if hits.Load() != 0 {
+		t.Fatal("blocked server should not receive requests")
+	}
+}
+
+func TestFetchRefusesRedirectToLoopback(t *testing.T) {
+	var internalHits atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits.Add(1)
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/metadata", http.StatusFound)
+	}))
+	defer public.Close()
+
+	f := newTestFetcher(t, DefaultOptions, public)
+	if _, err := f.Fetch(context.Background(), public.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Fetch() error = %v, want ErrBlockedAddress", err)
+	}
+	if internalHits.Load() != 0 {
+		t.Fatal("redirect target should not receive requests")
+	}
+}
+
+func TestFetchRejectsUnsupportedUrl(t *testing.T) {
+	f := NewFetcher(DefaultOptions)
+	for _, rawUrl := range []string{"file:///etc/passwd", "gopher://example.com", "http://"} {
+		if _, err := f.Fetch(context.Background(), rawUrl); !errors.Is(err, ErrInvalidUrl) {
+			t.Errorf("Fetch(%q) error = %v, want ErrInvalidUrl", rawUrl, err)
+		}
+	}
+}
+
+func TestFetchTooManyRedirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, DefaultOptions, server)
+	if _, err := f.Fetch(context.Background(), server.URL+"/"); !errors.Is(err, errTooManyRedirects) {
+		t.Fatalf("Fetch() error = %v, want errTooManyRedirects", err)
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	body := strings.Repeat("a", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		// 不设置 Content-Length，分块传输时只能边读边限制
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	options := DefaultOptions
+	options.MaxBodySize = 1024
+	options.CacheTTL = 0
+	f := newTestFetcher(t, options, server)
+
+	page, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !page.Truncated || len(page.Markdown) != 1024 {
+		t.Errorf("page truncated = %v, length = %d", page.Truncated, len(page.Markdown))
+	}
+	for _, path := range []string{"/", "/chunked"} {
+		if _, err := f.Download(context.Background(), server.URL+path); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Download(%s) error = %v, want ErrTooLarge", path, err)
+		}
+	}
+}
+
+func TestFetchHtml(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>标题</title><script>alert(1)</script></head>
+<body><h1>Hello</h1><p>See <a href="/next">next</a>.</p></body></html>`))
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, DefaultOptions, server)
+	page, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "标题" || !strings.Contains(page.Markdown, "# Hello") || strings.Contains(page.Markdown, "alert") {
+		t.Errorf("unexpected page: %+v", page)
+	}
+	if len(page.Links) != 1 || page.Links[0].Url != server.URL+"/next" {
+		t.Errorf("links = %+v", page.Links)
+	}
+}
+
+func TestNewClientBlocksPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	resp, err := NewClient(DefaultOptions.Timeout).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("err = %v, want ErrBlockedAddress", err)
+	}
+}
@@ -0,0 +1,377 @@
+package webfetch
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxLinks 最多返回的链接数量
+const maxLinks = 100
+
+var (
+	spacePattern     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// 不属于正文的元素，整体跳过
+var skipTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Footer: true, atom.Header: true, atom.Aside: true,
+	atom.Form: true, atom.Svg: true, atom.Iframe: true, atom.Button: true,
+	atom.Select: true, atom.Textarea: true, atom.Input: true, atom.Head: true,
+}
+
+type extracted struct {
+	title    string
+	markdown string
+	links    []*Link
+}
+
+// extract 解析 html，提取标题、正文 markdown 和链接；有 article 或 main 时只取其中的内容
+func extract(source string, base *url.URL) (*extracted, error) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return nil, err
+	}
+	result := &extracted{}
+	if title := findFirst(doc, atom.Title); title != nil {
+		result.title = strings.TrimSpace(spacePattern.ReplaceAllString(textContent(title), " "))
+	}
+	root := findFirst(doc, atom.Article)
+	if root == nil {
+		root = findFirst(doc, atom.Main)
+	}
+	if root == nil {
+		root = findFirst(doc, atom.Body)
+	}
+	if root == nil {
+		root = doc
+	}
+	w := &mdWriter{base: base, seen: make(map[string]bool)}
+	w.children(root)
+	result.markdown = strings.TrimSpace(blankLinePattern.ReplaceAllString(w.builder.String(), "\n\n"))
+	result.links = w.links
+	if result.title == "" {
+		if h1 := findFirst(root, atom.H1); h1 != nil {
+			result.title = strings.TrimSpace(spacePattern.ReplaceAllString(textContent(h1), " "))
+		}
+	}
+	return result, nil
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var builder strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		builder.WriteString(textContent(c))
+	}
+	return builder.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// mdWriter 把 html 节点树转为 markdown
+type mdWriter struct {
+	builder strings.Builder
+	base    *url.URL
+	links   []*Link
+	seen    map[string]bool
+	// pre 在 pre 元素内部，保留原始空白
+	pre int
+	// listDepth 列表嵌套层级，用于缩进
+	listDepth int
+}
+
+func (w *mdWriter) write(s string) {
+	w.builder.WriteString(s)
+}
+
+// block 块级元素前后需要空行
+func (w *mdWriter) block() {
+	s := w.builder.String()
+	switch {
+	case s == "" || strings.HasSuffix(s, "\n\n"):
+	case strings.HasSuffix(s, "\n"):
+		w.write("\n")
+	default:
+		w.write("\n\n")
+	}
+}
+
+func (w *mdWriter) newline() {
+	if s := w.builder.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		w.write("\n")
+	}
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// inline 把子节点单独渲染为一行文本，用于链接文字、标题等
+func (w *mdWriter) inline(n *html.Node) string {
+	sub := &mdWriter{base: w.base, seen: w.seen}
+	sub.children(n)
+	w.links = append(w.links, sub.links...)
+	return strings.TrimSpace(spacePattern.ReplaceAllString(sub.builder.String(), " "))
+}
+
+func (w *mdWriter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if w.base != nil {
+		u = w.base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+func (w *mdWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.write(n.Data)
+			return
+		}
+		text := spacePattern.ReplaceAllString(n.Data, " ")
+		// 行首不写多余的空格
+		if s := w.builder.String(); s == "" || strings.HasSuffix(s, "\n") || strings.HasSuffix(s, " ") {
+			text = strings.TrimLeft(text, " ")
+		}
+		w.write(text)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+	if skipTags[n.DataAtom] || attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := w.inline(n)
+		if text == "" {
+			return
+		}
+		level := int(n.Data[1] - '0')
+		w.block()
+		w.write(strings.Repeat("#", level) + " " + text)
+		w.block()
+	case atom.P, atom.Div, atom.Section, atom.Figure, atom.Figcaption, atom.Dl, atom.Details, atom.Summary:
+		w.block()
+		w.children(n)
+		w.block()
+	case atom.Dt:
+		w.newline()
+		w.write("**" + w.inline(n) + "**")
+		w.newline()
+	case atom.Dd:
+		w.newline()
+		w.children(n)
+		w.newline()
+	case atom.Br:
+		w.write("\n")
+	case atom.Hr:
+		w.block()
+		w.write("---")
+		w.block()
+	case atom.Strong, atom.B:
+		if text := w.inline(n); text != "" {
+			w.write("**" + text + "**")
+		}
+	case atom.Em, atom.I:
+		if text := w.inline(n); text != "" {
+			w.write("*" + text + "*")
+		}
+	case atom.Code:
+		if w.pre > 0 {
+			w.children(n)
+			return
+		}
+		if text := textContent(n); text != "" {
+			w.write("`" + text + "`")
+		}
+	case atom.Pre:
+		w.block()
+		w.write("```" + codeLanguage(n) + "\n")
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.newline()
+		w.write("```")
+		w.block()
+	case atom.Blockquote:
+		sub := &mdWriter{base: w.base, seen: w.seen}
+		sub.children(n)
+		w.links = append(w.links, sub.links...)
+		text := strings.TrimSpace(blankLinePattern.ReplaceAllString(sub.builder.String(), "\n\n"))
+		if text == "" {
+			return
+		}
+		w.block()
+		w.write("> " + strings.ReplaceAll(text, "\n", "\n> "))
+		w.block()
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		src := w.resolve(attr(n, "src"))
+		if src == "" {
+			return
+		}
+		w.write(fmt.Sprintf("![%s](%s)", strings.TrimSpace(attr(n, "alt")), src))
+	case atom.Table:
+		w.table(n)
+	default:
+		w.children(n)
+	}
+}
+
+func (w *mdWriter) link(n *html.Node) {
+	text := w.inline(n)
+	href := w.resolve(attr(n, "href"))
+	if href == "" {
+		w.write(text)
+		return
+	}
+	if text == "" {
+		return
+	}
+	if !w.seen[href] && len(w.links) < maxLinks {
+		w.seen[href] = true
+		w.links = append(w.links, &Link{Text: text, Url: href})
+	}
+	w.write("[" + text + "](" + href + ")")
+}
+
+func (w *mdWriter) list(n *html.Node) {
+	w.block()
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+	indent := strings.Repeat("  ", w.listDepth)
+	w.listDepth++
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		w.newline()
+		w.write(indent + marker)
+		for item := c.FirstChild; item != nil; item = item.NextSibling {
+			// 嵌套列表另起一行
+			if item.Type == html.ElementNode && (item.DataAtom == atom.Ul || item.DataAtom == atom.Ol) {
+				w.newline()
+				w.list(item)
+				continue
+			}
+			if item.Type == html.ElementNode && item.DataAtom == atom.P {
+				w.children(item)
+				continue
+			}
+			w.node(item)
+		}
+	}
+	w.listDepth--
+	w.block()
+}
+
+func (w *mdWriter) table(n *html.Node) {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.DataAtom != atom.Tr {
+				walk(c)
+				continue
+			}
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					row = append(row, strings.ReplaceAll(w.inline(cell), "|", "\\|"))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	w.block()
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		w.write("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			w.write("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	w.block()
+}
+
+// codeLanguage 从 class="language-go" 这样的属性中取出代码语言
+func codeLanguage(pre *html.Node) string {
+	for _, n := range []*html.Node{pre, pre.FirstChild} {
+		if n == nil || n.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(n, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 按路径风格地址保存对象的内存 S3，只实现存储用到的接口
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != "bucket" {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteString(`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2025-01-02T03:04:05.000Z</LastModified></Contents>", k, len(f.objects[k]))
+		}
+		sb.WriteString("</ListBucketResult>")
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = io.WriteString(w, sb.String())
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	s, err := NewS3(S3Options{Endpoint: server.URL, Region: "eu-west-1", Bucket: "bucket", AccessKey: "AKID", SecretKey: "secret", PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, fake
+}
+
+func TestS3RoundTrip(t *testing.T) {
+	s, fake := newTestS3(t)
+	ctx := context.Background()
+	for _, key := range []string{"a/1.txt", "a/2.txt", "b/1.txt"} {
+		if err := s.Put(ctx, key, []byte("hello "+key), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reader, object, err := s.Get(ctx, "a/1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "hello a/1.txt" || object.Size != int64(len(data)) || object.ContentType != "text/plain" {
+		t.Errorf("Get() = %q, %+v", data, object)
+	}
+	var keys []string
+	err = s.List(ctx, "a/", func(object *Object) error {
+		keys = append(keys, object.Key)
+		if object.LastModified.IsZero() {
+			t.Errorf("%s has no LastModified", object.Key)
+		}
+		return nil
+	})
+	if err != nil || strings.Join(keys, ",") != "a/1.txt,a/2.txt" {
+		t.Errorf("List() = %v, %v", keys, err)
+	}
+	if err := s.Delete(ctx, "a/1.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(ctx, "a/1.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after delete err = %v, want ErrNotFound", err)
+	}
+	for _, auth := range fake.auth {
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			t.Fatalf("Authorization = %q", auth)
+		}
+	}
+}
+
+func TestS3InvalidKey(t *testing.T) {
+	s, fake := newTestS3(t)
+	if err := s.Put(context.Background(), "../a", []byte("x"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put() err = %v, want ErrInvalidKey", err)
+	}
+	if len(fake.auth) != 0 {
+		t.Error("invalid key should not be sent")
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	s, _ := newTestS3(t)
+	tests := []struct {
+		expires time.Duration
+		want    string
+	}{
+		{15 * time.Minute, "900"},
+		{30 * 24 * time.Hour, "604800"},
+	}
+	for _, tt := range tests {
+		signed, err := s.SignedURL(context.Background(), "a/1.txt", tt.expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := u.Query()
+		if u.Path != "/bucket/a/1.txt" || query.Get("X-Amz-Expires") != tt.want || query.Get("X-Amz-Signature") == "" ||
+			!strings.HasPrefix(query.Get("X-Amz-Credential"), "AKID/") {
+			t.Errorf("SignedURL(%v) = %s", tt.expires, signed)
+		}
+	}
+}
+
+func TestNewS3RequiresOptions(t *testing.T) {
+	if _, err := NewS3(S3Options{Endpoint: "http://127.0.0.1:9000", Bucket: "bucket"}); err == nil {
+		t.Error("missing credentials should fail")
+	}
+	if _, err := NewS3(S3Options{Endpoint: "127.0.0.1", Bucket: "bucket", AccessKey: "a", SecretKey: "b"}); err == nil {
+		t.Error("endpoint without scheme should fail")
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DetectContentType 按内容判断类型，内容不能区分的文本和二进制文件再按扩展名判断。
+// 不使用上传时声明的类型，避免把 HTML 之类的内容当作图片保存后在浏览器中执行
+func DetectContentType(fileName string, data []byte) string {
+	sniffed := http.DetectContentType(data)
+	if sniffed != "application/octet-stream" && !strings.HasPrefix(sniffed, "text/plain") {
+		return sniffed
+	}
+	byExt := mime.TypeByExtension(strings.ToLower(path.Ext(fileName)))
+	if byExt == "" || strings.HasPrefix(byExt, "text/html") || strings.Contains(byExt, "javascript") || strings.HasPrefix(byExt, "image/svg") {
+		return sniffed
+	}
+	// 扩展名声明为文本时内容必须也是文本
+	if strings.HasPrefix(byExt, "text/") && sniffed == "application/octet-stream" {
+		return sniffed
+	}
+	return byExt
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object 存储中的对象信息
+type Object struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage 文件存储，本地磁盘和 S3 兼容的对象存储实现同样的接口。
+// key 是以 / 分隔的相对路径，由调用方生成，不能包含 .. 和空的路径段
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser，对象不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// List 按 key 的顺序遍历前缀下的所有对象，fn 返回错误时停止
+	List(ctx context.Context, prefix string, fn func(*Object) error) error
+	// SignedURL 生成有效期为 expires 的下载地址，不需要登录即可访问
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Options 存储配置，Driver 为 local 或 s3
+type Options struct {
+	Driver string
+	Local  LocalOptions
+	S3     S3Options
+}
+
+// New 按配置创建存储
+func New(options Options) (Storage, error) {
+	switch options.Driver {
+	case "", "local":
+		return NewLocal(options.Local)
+	case "s3":
+		return NewS3(options.S3)
+	}
+	return nil, fmt.Errorf("unsupported storage driver: %s", options.Driver)
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package appclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnauthorized API令牌无效或已过期
+var ErrUnauthorized = errors.New("api token unauthorized")
+
+// 令牌校验结果的缓存时间，避免每个MCP消息都请求一次后端
+const verifyCacheTTL = time.Minute
+
+// Agent 后端开放接口返回的agent信息，只保留需要的字段
+type Agent struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type invokeResponse struct {
+	AgentId string `json:"agentId"`
+	Answer  string `json:"answer"`
+}
+
+type result struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Client 调用 FaberAI 后端的开放接口，请求使用MCP客户端传入的API令牌
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	mu         sync.Mutex
+	verified   map[string]time.Time
+}
+
+func New(baseUrl string) *Client {
+	return &Client{
+		baseUrl: baseUrl,
+		// agent 调用可能很慢，超时由调用方的 context 控制
+		httpClient: &http.Client{},
+		verified:   make(map[string]time.Time),
+	}
+}
+
+// Verify 校验API令牌是否有效
+func (c *Client) Verify(ctx context.Context, token string) error {
+	c.mu.Lock()
+	expireAt, ok := c.verified[token]
+	c.mu.Unlock()
+	if ok && time.Now().Before(expireAt) {
+		return nil
+	}
+	if _, err := c.ListAgents(ctx, token); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, v := range c.verified {
+		if now.After(v) {
+			delete(c.verified, k)
+		}
+	}
+	c.verified[token] = now.Add(verifyCacheTTL)
+	return nil
+}
+
+// ListAgents 获取令牌所属用户可以调用的agent
+func (c *Client) ListAgents(ctx context.Context, token string) ([]*Agent, error) {
+	var agents []*Agent
+	if err := c.do(ctx, token, http.MethodGet, "/api/v1/open/agents", nil, &agents); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// InvokeAgent 调用agent并返回最终回答
+func (c *Client) InvokeAgent(ctx context.Context, token string, agentId string, message string) (string, error) {
+	body := map[string]string{"message": message}
+	var resp invokeResponse
+	if err := c.do(ctx, token, http.MethodPost, "/api/v1/open/agents/"+agentId+"/invoke", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Answer, nil
+}
+
+func (c *Client) do(ctx context.Context, token string, method string, path string, body any, data any) error {
+	var reader io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s: status %d", path, resp.StatusCode)
+	}
+	var r result
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	if r.Code != http.StatusOK {
+		return fmt.Errorf("request %s: %d %s", path, r.Code, r.Msg)
+	}
+	if data == nil || len(r.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Data, data)
+}
@@ -0,0 +1,28 @@
+package appclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type tokenKey struct{}
+
+// WithToken 把MCP客户端的API令牌放到context中，工具调用时转发给后端
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// TokenFromRequest 从 Authorization 请求头中取出令牌
+func TokenFromRequest(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && strings.ToLower(token[:7]) == "bearer " {
+		token = token[7:]
+	}
+	return strings.TrimSpace(token)
+}
@@ -8,5 +8,10 @@ import (
 )
 
 func Init(s *server.Server, conf *config.Config) {
-	s.RegisterRouters(&router.Event{}, &router.McpRouter{})
+	closeFuncs := s.RegisterRouters(&router.Event{}, &router.McpRouter{})
+	s.Close = func() {
+		for _, closeFunc := range closeFuncs {
+			_ = closeFunc()
+		}
+	}
 }
@@ -1,36 +1,152 @@
 package router
 
 import (
+	"common/configs"
+	"context"
 	"core/ai/tools"
+	"errors"
+	"mcp-server/internal/appclient"
 	"mcp-server/internal/tool"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/mszlu521/thunder/logs"
 )
 
+// 重新读取工具启用配置的间隔
+const toolsReloadInterval = 10 * time.Second
+
 type McpRouter struct {
+	cancel context.CancelFunc
 }
 
 func (u *McpRouter) Register(engine *gin.Engine) {
-	//需要两个 /sse， /message
-	//这里需要使用mcp-go 创建mcp服务
+	appClient := appclient.New(configs.GetConfig().McpServer.GetAppUrl())
+	agentTools := tool.NewAgentTools(appClient)
+	// 用户的agent作为会话级工具，初始化和每次获取工具列表时按令牌重新加载
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		logs.Infof("mcp session %s initialized, client: %s %s", sessionId(ctx),
+			message.Params.ClientInfo.Name, message.Params.ClientInfo.Version)
+		agentTools.Sync(ctx)
+	})
+	hooks.AddBeforeListTools(func(ctx context.Context, id any, message *mcp.ListToolsRequest) {
+		agentTools.Sync(ctx)
+	})
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		logs.Infof("mcp session %s connected", session.SessionID())
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		logs.Infof("mcp session %s disconnected", session.SessionID())
+	})
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
+		logs.Infof("mcp session %s call tool: %s", sessionId(ctx), message.Params.Name)
+	})
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		logs.Warnf("mcp session %s %s error: %v", sessionId(ctx), method, err)
+	})
 	mcpServer := server.NewMCPServer(
 		"faberAI mcp server",
 		mcp.LATEST_PROTOCOL_VERSION,
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, true),
 		server.WithPromptCapabilities(true),
+		server.WithHooks(hooks),
 	)
-	weather := tool.NewWeatherTool(tools.ApiKey)
-	mcpServer.AddTool(weather.Build(), weather.Invoke)
+	// core/ai/tools 中的系统工具全部登记，是否暴露由配置中的禁用列表决定
+	registry := tool.NewRegistry(mcpServer)
+	for _, t := range tools.BuiltinTools() {
+		registry.Register(tool.NewSystemTool(t))
+	}
+	registry.Apply(configs.GetConfig().McpServer.GetDisabledTools())
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancel = cancel
+	go registry.Watch(ctx, toolsReloadInterval, func() []string {
+		conf, err := configs.Reload()
+		if err != nil {
+			logs.Warnf("reload mcp server config error: %v", err)
+		}
+		return conf.McpServer.GetDisabledTools()
+	})
+	// 每个请求都带上令牌，工具调用时转发给后端
+	withToken := func(ctx context.Context, r *http.Request) context.Context {
+		return appclient.WithToken(ctx, appclient.TokenFromRequest(r))
+	}
+	baseUrl := configs.GetConfig().McpServer.GetBaseUrl()
+	// 旧版 SSE 传输：/sse 建立事件流，/message 发送消息
 	sseServer := server.NewSSEServer(
 		mcpServer,
-		server.WithBaseURL("http://localhost:7777"),
+		server.WithBaseURL(baseUrl),
 		server.WithSSEEndpoint("/sse"),
 		server.WithMessageEndpoint("/message"),
 		server.WithKeepAlive(true),
+		server.WithSSEContextFunc(withToken),
+	)
+	// streamable HTTP 传输：所有请求都走 /mcp
+	httpServer := server.NewStreamableHTTPServer(
+		mcpServer,
+		server.WithEndpointPath("/mcp"),
+		server.WithHTTPContextFunc(withToken),
+		server.WithHeartbeatInterval(30*time.Second),
 	)
-	engine.GET("/sse", gin.WrapH(sseServer.SSEHandler()))
-	engine.POST("/message", gin.WrapH(sseServer.MessageHandler()))
+	logs.Infof("mcp server endpoints: %s/sse, %s/mcp", baseUrl, baseUrl)
+	auth := tokenAuth(appClient)
+	engine.GET("/sse", noWriteDeadline, auth, gin.WrapH(sseServer.SSEHandler()))
+	engine.POST("/message", auth, gin.WrapH(sseServer.MessageHandler()))
+	mcpHandler := gin.WrapH(httpServer)
+	engine.GET("/mcp", noWriteDeadline, auth, mcpHandler)
+	engine.POST("/mcp", noWriteDeadline, auth, mcpHandler)
+	engine.DELETE("/mcp", auth, mcpHandler)
+}
+
+// Close 停止监听工具配置
+func (u *McpRouter) Close() error {
+	if u.cancel != nil {
+		u.cancel()
+	}
+	return nil
+}
+
+// tokenAuth 使用 FaberAI 的API令牌认证MCP客户端
+func tokenAuth(client *appclient.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := appclient.TokenFromRequest(c.Request)
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
+			c.Abort()
+			return
+		}
+		err := client.Verify(c.Request.Context(), token)
+		if errors.Is(err, appclient.ErrUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logs.Errorf("verify api token error: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "verify token failed"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// noWriteDeadline 事件流和调用agent的请求持续时间远超全局的写超时，需要单独取消
+func noWriteDeadline(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logs.Warn("Failed to set write deadline", "err", err)
+	}
+	c.Next()
+}
+
+func sessionId(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return "-"
 }
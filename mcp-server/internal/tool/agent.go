@@ -0,0 +1,99 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcp-server/internal/appclient"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// MCP 客户端普遍要求工具名满足 ^[a-zA-Z0-9_-]{1,64}$
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// AgentTools 把用户可以调用的agent发布为当前会话的MCP工具，
+// 不同用户看到的工具不同，所以使用会话级别的工具而不是全局工具
+type AgentTools struct {
+	client *appclient.Client
+}
+
+func NewAgentTools(client *appclient.Client) *AgentTools {
+	return &AgentTools{client: client}
+}
+
+// Sync 根据会话的API令牌重新加载agent工具
+func (a *AgentTools) Sync(ctx context.Context) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithTools)
+	if !ok {
+		return
+	}
+	token := appclient.TokenFromContext(ctx)
+	if token == "" {
+		return
+	}
+	agents, err := a.client.ListAgents(ctx, token)
+	if err != nil {
+		logs.Warnf("list agents for mcp session %s error: %v", session.SessionID(), err)
+		return
+	}
+	tools := make(map[string]server.ServerTool, len(agents))
+	for _, agent := range agents {
+		serverTool := a.build(agent)
+		tools[serverTool.Tool.Name] = serverTool
+	}
+	// 直接替换会话工具，不发送 list_changed 通知，避免客户端收到通知后再次 list 形成循环
+	session.SetSessionTools(tools)
+}
+
+func (a *AgentTools) build(agent *appclient.Agent) server.ServerTool {
+	desc := agent.Description
+	if desc == "" {
+		desc = agent.Name
+	}
+	mcpTool := mcp.NewTool(agentToolName(agent),
+		mcp.WithTitleAnnotation(agent.Name),
+		mcp.WithDescription(fmt.Sprintf("调用 FaberAI 智能体「%s」：%s", agent.Name, desc)),
+		mcp.WithString("message", mcp.Required(), mcp.Description("发送给智能体的消息")),
+	)
+	return server.ServerTool{Tool: mcpTool, Handler: a.invoke(agent.ID)}
+}
+
+func (a *AgentTools) invoke(agentId string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		message, err := request.RequireString("message")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		answer, err := a.client.InvokeAgent(ctx, appclient.TokenFromContext(ctx), agentId, message)
+		if errors.Is(err, appclient.ErrUnauthorized) {
+			return mcp.NewToolResultError("API令牌无效或已过期"), nil
+		}
+		if err != nil {
+			logs.Errorf("invoke agent %s error: %v", agentId, err)
+			return mcp.NewToolResultError("智能体调用失败"), nil
+		}
+		return mcp.NewToolResultText(answer), nil
+	}
+}
+
+// agentToolName 名称中不合法的字符被去掉，再加上ID前缀保证唯一
+func agentToolName(agent *appclient.Agent) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(agent.Name, "_"), "_")
+	id := strings.ReplaceAll(agent.ID, "-", "")
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	toolName := "agent_" + id
+	if name != "" {
+		toolName += "_" + name
+	}
+	if len(toolName) > 64 {
+		toolName = toolName[:64]
+	}
+	return toolName
+}
@@ -0,0 +1,93 @@
+package tool
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mszlu521/thunder/logs"
+)
+
+// Registry 管理MCP服务对外暴露的工具，按配置启用或禁用，
+// 工具集合变化时 mcp-go 会向所有客户端发送 notifications/tools/list_changed
+type Registry struct {
+	server  *server.MCPServer
+	mu      sync.Mutex
+	tools   map[string]MCPTool
+	enabled map[string]bool
+}
+
+func NewRegistry(s *server.MCPServer) *Registry {
+	return &Registry{
+		server:  s,
+		tools:   make(map[string]MCPTool),
+		enabled: make(map[string]bool),
+	}
+}
+
+// Register 登记可以暴露的工具，登记后需要调用 Apply 才会真正添加到MCP服务中
+func (r *Registry) Register(tools ...MCPTool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range tools {
+		r.tools[t.Build().Name] = t
+	}
+}
+
+// Apply 按禁用列表更新MCP服务中的工具，只有集合发生变化时才会修改
+func (r *Registry) Apply(disabled []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var added []server.ServerTool
+	var removed []string
+	for name, t := range r.tools {
+		enable := !slices.Contains(disabled, name)
+		if enable == r.enabled[name] {
+			continue
+		}
+		if enable {
+			added = append(added, server.ServerTool{Tool: t.Build(), Handler: t.Invoke})
+		} else {
+			removed = append(removed, name)
+		}
+		r.enabled[name] = enable
+	}
+	if len(added) > 0 {
+		r.server.AddTools(added...)
+	}
+	if len(removed) > 0 {
+		r.server.DeleteTools(removed...)
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		logs.Infof("mcp tools changed, enabled: %v", r.enabledNames())
+	}
+}
+
+// Watch 定时读取禁用列表并更新工具，ctx 结束后退出
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, disabled func() []string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Apply(disabled())
+		}
+	}
+}
+
+// enabledNames 调用方需要持有 r.mu
+func (r *Registry) enabledNames() []string {
+	var names []string
+	for name, enable := range r.enabled {
+		if enable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
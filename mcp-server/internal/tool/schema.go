@@ -0,0 +1,87 @@
+package tool
+
+import (
+	"sort"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func ToMCPOptions(params map[string]*schema.ParameterInfo, desc string) []mcp.ToolOption {
+	//先处理描述
+	var options []mcp.ToolOption
+	options = append(options, mcp.WithDescription(desc))
+	//处理参数
+	for k, v := range params {
+		var propertyOptions []mcp.PropertyOption
+		if v.Required {
+			propertyOptions = append(propertyOptions, mcp.Required())
+		}
+		propertyOptions = append(propertyOptions, mcp.Description(v.Desc))
+		if len(v.Enum) > 0 {
+			propertyOptions = append(propertyOptions, mcp.Enum(v.Enum...))
+		}
+		//这里要判断不同的参数类型
+		switch v.Type {
+		case schema.String:
+			options = append(options, mcp.WithString(k, propertyOptions...))
+		case schema.Number:
+			options = append(options, mcp.WithNumber(k, propertyOptions...))
+		case schema.Boolean:
+			options = append(options, mcp.WithBoolean(k, propertyOptions...))
+		case schema.Integer:
+			options = append(options, mcp.WithNumber(k, propertyOptions...))
+		case schema.Array, schema.Object:
+			// 数组元素和对象字段可能多层嵌套，直接生成完整的 JSON Schema
+			options = append(options, withSchemaProperty(k, v))
+		}
+	}
+	return options
+}
+
+// toJSONSchema 把嵌套的参数（数组元素、对象字段）转换为 JSON Schema
+func toJSONSchema(param *schema.ParameterInfo) map[string]any {
+	js := map[string]any{
+		"type": string(param.Type),
+	}
+	if param.Desc != "" {
+		js["description"] = param.Desc
+	}
+	if len(param.Enum) > 0 {
+		js["enum"] = param.Enum
+	}
+	if param.ElemInfo != nil {
+		js["items"] = toJSONSchema(param.ElemInfo)
+	}
+	if len(param.SubParams) > 0 {
+		properties, required := toJSONSchemaProperties(param.SubParams)
+		js["properties"] = properties
+		if len(required) > 0 {
+			js["required"] = required
+		}
+	}
+	return js
+}
+
+func toJSONSchemaProperties(params map[string]*schema.ParameterInfo) (map[string]any, []string) {
+	properties := make(map[string]any, len(params))
+	var required []string
+	for k, v := range params {
+		properties[k] = toJSONSchema(v)
+		if v.Required {
+			required = append(required, k)
+		}
+	}
+	sort.Strings(required)
+	return properties, required
+}
+
+// withSchemaProperty 添加一个使用完整 JSON Schema 描述的参数
+func withSchemaProperty(name string, param *schema.ParameterInfo) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		t.InputSchema.Properties[name] = toJSONSchema(param)
+		if param.Required {
+			t.InputSchema.Required = append(t.InputSchema.Required, name)
+		}
+	}
+}
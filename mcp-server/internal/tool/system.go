@@ -0,0 +1,38 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mszlu521/thunder/ai/einos"
+)
+
+// SystemTool 把 core/ai/tools 中的系统工具包装为MCP工具
+type SystemTool struct {
+	tool einos.InvokeParamTool
+}
+
+func NewSystemTool(tool einos.InvokeParamTool) *SystemTool {
+	return &SystemTool{tool: tool}
+}
+
+func (s *SystemTool) Build() mcp.Tool {
+	//转换为mcp tool
+	info, _ := s.tool.Info(context.Background())
+	//做一个转换把 eino的转换为mcp 的，描述和参数都要带上
+	options := ToMCPOptions(s.tool.Params(), info.Desc)
+	return mcp.NewTool(info.Name, options...)
+}
+
+func (s *SystemTool) Invoke(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params, err := json.Marshal(request.GetArguments())
+	if err != nil {
+		return nil, err
+	}
+	invokableRun, err := s.tool.InvokableRun(ctx, string(params))
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(invokableRun), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"common/configs"
 	"mcp-server/internal/inits"
 
 	"github.com/mszlu521/thunder/config"
@@ -10,8 +11,10 @@ import (
 
 func main() {
 	//1. 加载配置  默认是 etc/config.yml
-	config.Init()
+	v := config.Init()
 	conf := config.GetConfig()
+	// 业务相关的配置
+	configs.Init(v)
 	//2. 加载日志
 	logs.Init(conf.Log)
 	//3. 初始化Gin服务
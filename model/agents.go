@@ -1,6 +1,10 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +20,52 @@ var (
 
 type AgentVisibility string
 
+// PromptFormat 系统提示词的模板格式
+type PromptFormat string
+
+const (
+	PromptFormatPlain      PromptFormat = "plain"
+	PromptFormatGoTemplate PromptFormat = "go_template"
+	PromptFormatJinja2     PromptFormat = "jinja2"
+)
+
+// 提示词变量的类型
+const (
+	VariableTypeString  = "string"
+	VariableTypeNumber  = "number"
+	VariableTypeBoolean = "boolean"
+)
+
+// PromptVariable agent定义的提示词变量，对话时由调用方传入
+type PromptVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Default 没有传入时使用的值，为空且 Required 时必须传入
+	Default  any  `json:"default,omitempty"`
+	Required bool `json:"required"`
+}
+
+type PromptVariables []PromptVariable
+
+func (v PromptVariables) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *PromptVariables) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, v)
+}
+
 var (
 	Private  AgentVisibility = "private"
 	Public                   = "public"
@@ -35,6 +85,14 @@ type Agent struct {
 	Icon string `json:"icon" gorm:"column:icon;type:varchar(512)"`
 	// SystemPrompt 系统提示词，用于指导AI行为
 	SystemPrompt string `json:"systemPrompt" gorm:"column:system_prompt;type:text"`
+	// PromptFormat 系统提示词的模板格式，plain 表示不作为模板渲染
+	PromptFormat PromptFormat `json:"promptFormat" gorm:"column:prompt_format;type:varchar(20);not null;default:'plain'"`
+	// Variables 对话时需要传入的提示词变量
+	Variables PromptVariables `json:"variables" gorm:"column:variables;type:jsonb"`
+	// OutputSchema 最终回答的 JSON Schema，为空表示自由文本
+	OutputSchema JSON `json:"outputSchema" gorm:"column:output_schema;type:jsonb"`
+	// OutputMaxAttempts 回答不符合 OutputSchema 时最多尝试的次数（包括第一次），为 0 时使用默认值
+	OutputMaxAttempts int `json:"outputMaxAttempts" gorm:"column:output_max_attempts;type:int;not null;default:0"`
 	// ModelProvider 模型提供商（例如openai）
 	ModelProvider string `json:"modelProvider" gorm:"column:model_provider;type:varchar(50);not null;default:'openai'"`
 	// ModelName 使用的具体模型名称
@@ -45,12 +103,15 @@ type Agent struct {
 	OpeningDialogue string `json:"openingDialogue" gorm:"column:opening_dialogue;type:text"`
 	// SuggestedQuestions 建议问题列表
 	SuggestedQuestions JSON `json:"suggestedQuestions" gorm:"column:suggested_questions;type:jsonb"`
-	// Version 版本号
-	Version uint `json:"version" gorm:"column:version;type:int;not null;default:1"`
+	// Version 当前线上使用的发布版本号，对应 agent_versions 中的快照，0 表示还没有发布。
+	// 不设置数据库默认值，创建时总是写入 0，否则 gorm 会省略零值而使用默认值
+	Version uint `json:"version" gorm:"column:version;type:int;not null"`
 	// Status 状态（草稿、发布、归档）
 	Status AgentStatus `json:"status" gorm:"column:status;type:varchar(20);not null;default:'draft'"`
 	// Visibility 可见性（私有、公开、仅链接）
 	Visibility AgentVisibility `json:"visibility" gorm:"column:visibility;type:varchar(20);not null;default:'private'"`
+	// GuestTools 访客对话时是否可以调用创建者配置了凭证的工具（MCP、HTTP、数据库），默认只能使用系统工具
+	GuestTools bool `json:"guestTools" gorm:"column:guest_tools;type:boolean;not null;default:false"`
 	// InvocationCount 调用次数统计
 	InvocationCount uint64 `json:"invocationCount" gorm:"column:invocation_count;type:bigint;not null;default:0"`
 	// PublishedAt 发布时间戳
@@ -151,12 +212,14 @@ func DefaultAgent(userId uuid.UUID, name string, description string, status Agen
 		SuggestedQuestions: JSON{},
 		OpeningDialogue:    "",
 		SystemPrompt:       "",
+		PromptFormat:       PromptFormatPlain,
 		ModelProvider:      "",
 		ModelName:          "",
 		ModelParameters:    JSON{},
-		Version:            1,
-		Visibility:         Private,
-		InvocationCount:    0,
+		// 发布后才有线上版本
+		Version:         0,
+		Visibility:      Private,
+		InvocationCount: 0,
 	}
 }
 
@@ -199,3 +262,146 @@ func (AgentTool) TableName() string {
 //func (AgentKnowledgeBase) TableName() string {
 //	return "agent_knowledge_bases"
 //}
+
+// AgentVersion 发布时保存的agent配置快照，创建后不再修改
+type AgentVersion struct {
+	BaseModel
+	AgentID   uuid.UUID `json:"agentId" gorm:"type:uuid;not null;uniqueIndex:idx_agent_version"`
+	Version   uint      `json:"version" gorm:"not null;uniqueIndex:idx_agent_version"`
+	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;not null"`
+	// Note 发布说明
+	Note     string        `json:"note" gorm:"type:text"`
+	Snapshot AgentSnapshot `json:"snapshot" gorm:"type:jsonb;not null"`
+}
+
+func (AgentVersion) TableName() string {
+	return "agent_versions"
+}
+
+// AgentSnapshot 运行agent需要的全部配置，工具只记录ID，工具本身的配置和凭证仍然由工具维护
+type AgentSnapshot struct {
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	Icon               string          `json:"icon"`
+	SystemPrompt       string          `json:"systemPrompt"`
+	PromptFormat       PromptFormat    `json:"promptFormat"`
+	Variables          PromptVariables `json:"variables"`
+	OutputSchema       JSON            `json:"outputSchema,omitempty"`
+	OutputMaxAttempts  int             `json:"outputMaxAttempts,omitempty"`
+	ModelProvider      string          `json:"modelProvider"`
+	ModelName          string          `json:"modelName"`
+	ModelParameters    JSON            `json:"modelParameters"`
+	OpeningDialogue    string          `json:"openingDialogue"`
+	SuggestedQuestions JSON            `json:"suggestedQuestions"`
+	ToolIds            []uuid.UUID     `json:"toolIds"`
+}
+
+// NewAgentSnapshot 从当前的草稿生成快照，需要预加载 Tools
+func NewAgentSnapshot(agent *Agent) AgentSnapshot {
+	toolIds := make([]uuid.UUID, 0, len(agent.Tools))
+	for _, t := range agent.Tools {
+		toolIds = append(toolIds, t.ID)
+	}
+	return AgentSnapshot{
+		Name:               agent.Name,
+		Description:        agent.Description,
+		Icon:               agent.Icon,
+		SystemPrompt:       agent.SystemPrompt,
+		PromptFormat:       agent.PromptFormat,
+		Variables:          agent.Variables,
+		OutputSchema:       agent.OutputSchema,
+		OutputMaxAttempts:  agent.OutputMaxAttempts,
+		ModelProvider:      agent.ModelProvider,
+		ModelName:          agent.ModelName,
+		ModelParameters:    agent.ModelParameters,
+		OpeningDialogue:    agent.OpeningDialogue,
+		SuggestedQuestions: agent.SuggestedQuestions,
+		ToolIds:            toolIds,
+	}
+}
+
+// Apply 把快照中的配置写回agent，不包括工具
+func (s AgentSnapshot) Apply(agent *Agent) {
+	agent.Name = s.Name
+	agent.Description = s.Description
+	agent.Icon = s.Icon
+	agent.SystemPrompt = s.SystemPrompt
+	agent.PromptFormat = s.PromptFormat
+	agent.Variables = s.Variables
+	agent.OutputSchema = s.OutputSchema
+	agent.OutputMaxAttempts = s.OutputMaxAttempts
+	agent.ModelProvider = s.ModelProvider
+	agent.ModelName = s.ModelName
+	agent.ModelParameters = s.ModelParameters
+	agent.OpeningDialogue = s.OpeningDialogue
+	agent.SuggestedQuestions = s.SuggestedQuestions
+}
+
+func (s AgentSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *AgentSnapshot) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// AgentShare agent的分享链接，访客不需要登录就可以通过令牌和agent对话
+type AgentShare struct {
+	BaseModel
+	AgentID   uuid.UUID `json:"agentId" gorm:"type:uuid;not null;index"`
+	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;not null;index"`
+	// Name 方便创建者区分不同渠道的链接
+	Name string `json:"name" gorm:"size:255"`
+	// Token 链接中的令牌，撤销后失效
+	Token     string     `json:"token" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	// MessageCount 通过该链接发送的消息数
+	MessageCount int64 `json:"messageCount" gorm:"not null;default:0"`
+}
+
+func (AgentShare) TableName() string {
+	return "agent_shares"
+}
+
+// Active 链接没有撤销也没有过期
+func (s *AgentShare) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+// AgentEmbed 嵌入网站的聊天组件使用的密钥，Key 会出现在网页中，安全性依赖来源白名单
+type AgentEmbed struct {
+	BaseModel
+	AgentID   uuid.UUID `json:"agentId" gorm:"type:uuid;not null;index"`
+	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"size:255"`
+	Key       string    `json:"key" gorm:"size:64;not null;uniqueIndex"`
+	// AllowedOrigins 允许嵌入的网站来源，如 https://www.example.com、*.example.com
+	AllowedOrigins StringList `json:"allowedOrigins" gorm:"type:jsonb"`
+	RevokedAt      *time.Time `json:"revokedAt"`
+}
+
+func (AgentEmbed) TableName() string {
+	return "agent_embeds"
+}
+
+// AgentTemplate 用户保存的agent模板，内置模板定义在代码中不入库
+type AgentTemplate struct {
+	BaseModel
+	CreatorID   uuid.UUID `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	Category    string    `json:"category" gorm:"size:50"`
+	// Snapshot 模板的配置，和发布快照使用同样的结构
+	Snapshot AgentSnapshot `json:"snapshot" gorm:"type:jsonb;not null"`
+	// BuiltIn 内置模板，所有用户可见且不能删除
+	BuiltIn bool `json:"builtIn" gorm:"-"`
+}
+
+func (AgentTemplate) TableName() string {
+	return "agent_templates"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/schema"
+)
+
+// 新建的agent还没有发布，版本号为 0，数据库默认值不能把它改成其他值
+func TestDefaultAgentIsUnpublished(t *testing.T) {
+	agent := DefaultAgent(uuid.New(), "agent", "", Draft)
+	if agent.Version != 0 || agent.Status != Draft || agent.Visibility != Private {
+		t.Fatalf("agent = %+v", agent)
+	}
+	s, err := schema.Parse(&Agent{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := s.LookUpField("Version")
+	if field == nil {
+		t.Fatal("version field not found")
+	}
+	// 有默认值时 gorm 创建记录会省略零值
+	if field.HasDefaultValue {
+		t.Errorf("version should not have a default value, got %q", field.DefaultValue)
+	}
+}
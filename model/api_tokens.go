@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApiToken 用户的API令牌，给MCP客户端等非浏览器场景使用，数据库中只保存令牌的哈希
+type ApiToken struct {
+	BaseModel
+	// UserID 令牌所属用户
+	UserID uuid.UUID `json:"userId" gorm:"column:user_id;type:uuid;not null;index"`
+	// Name 令牌名称，方便用户区分用途
+	Name string `json:"name" gorm:"column:name;type:varchar(100);not null"`
+	// TokenHash 令牌的 sha256 哈希
+	TokenHash string `json:"-" gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex"`
+	// Prefix 令牌前几位，用于展示
+	Prefix string `json:"prefix" gorm:"column:prefix;type:varchar(20);not null"`
+	// LastUsedAt 最后使用时间
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at;type:timestamptz"`
+	// ExpiresAt 过期时间，为空表示永不过期
+	ExpiresAt *time.Time `json:"expiresAt" gorm:"column:expires_at;type:timestamptz"`
+}
+
+func (ApiToken) TableName() string {
+	return "api_tokens"
+}
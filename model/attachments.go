@@ -0,0 +1,38 @@
+package model
+
+import "github.com/google/uuid"
+
+// AttachmentKind 附件类型
+type AttachmentKind string
+
+const (
+	AttachmentImage AttachmentKind = "image"
+	// AttachmentFile 会话中上传的文档，解析出的文本用于问答，会话删除时一起删除
+	AttachmentFile AttachmentKind = "file"
+)
+
+// Attachment 对话中上传的附件，图片可以通过上传或者 URL 添加，URL 的图片下载后保存
+type Attachment struct {
+	BaseModel
+	CreatorID uuid.UUID      `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Kind      AttachmentKind `json:"kind" gorm:"size:20;not null"`
+	// SessionID 文件所属的会话，图片为空
+	SessionID   *uuid.UUID `json:"sessionId,omitempty" gorm:"type:uuid;index"`
+	FileName    string     `json:"fileName" gorm:"size:255"`
+	ContentType string     `json:"contentType" gorm:"size:100;not null"`
+	Size        int64      `json:"size"`
+	// SourceURL 通过 URL 添加时的原始地址
+	SourceURL string `json:"sourceUrl,omitempty" gorm:"type:text"`
+	// BlobID 文件内容在文件存储中的对象
+	BlobID *uuid.UUID `json:"-" gorm:"type:uuid"`
+	// Data 文件内容，不在接口中返回。保存在数据库中只用于接入文件存储之前上传的附件，新附件为空，读取时从存储中填充
+	Data []byte `json:"-" gorm:"type:bytea"`
+	// Text 文件解析出的文本
+	Text string `json:"-" gorm:"type:text"`
+	// TextLength 文本的字符数，决定对话时直接放入上下文还是检索
+	TextLength int `json:"textLength"`
+}
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
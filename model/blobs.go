@@ -0,0 +1,38 @@
+package model
+
+import "github.com/google/uuid"
+
+// BlobKind 存储对象的用途，决定对象能否公开访问以及何时成为孤立对象
+type BlobKind string
+
+const (
+	BlobAvatar     BlobKind = "avatar"
+	BlobAgentIcon  BlobKind = "agent_icon"
+	BlobAttachment BlobKind = "attachment"
+)
+
+// BlobPathPrefix 头像和图标字段保存的地址前缀，访问时跳转到签名下载地址
+const BlobPathPrefix = "/api/v1/blobs/"
+
+// Blob 保存在文件存储中的对象。存储中没有记录的对象和不再被引用的记录都会被定期清理
+type Blob struct {
+	BaseModel
+	OwnerID uuid.UUID `json:"ownerId" gorm:"type:uuid;not null;index"`
+	Kind    BlobKind  `json:"kind" gorm:"size:20;not null"`
+	// RefID 创建时引用它的记录：头像是用户，图标是agent，附件是附件本身
+	RefID uuid.UUID `json:"refId" gorm:"type:uuid;not null;index"`
+	// Key 对象在存储中的路径
+	Key         string `json:"-" gorm:"size:255;not null;uniqueIndex"`
+	FileName    string `json:"fileName" gorm:"size:255"`
+	ContentType string `json:"contentType" gorm:"size:100;not null"`
+	Size        int64  `json:"size"`
+}
+
+func (Blob) TableName() string {
+	return "blobs"
+}
+
+// Path 头像和图标使用的地址，不会过期
+func (b *Blob) Path() string {
+	return BlobPathPrefix + b.ID.String()
+}
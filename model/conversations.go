@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 会话的来源渠道
+const (
+	ConversationChannelWidget = "widget"
+	// ConversationChannelChat 登录用户在平台中和自己的agent对话
+	ConversationChannelChat = "chat"
+)
+
+// 消息的角色
+const (
+	MessageRoleUser      = "user"
+	MessageRoleAssistant = "assistant"
+)
+
+// Conversation 访客或用户和agent的会话，归属于agent，由agent的创建者查看
+type Conversation struct {
+	BaseModel
+	AgentID uuid.UUID `json:"agentId" gorm:"type:uuid;not null;index"`
+	Channel string    `json:"channel" gorm:"size:20;not null"`
+	// UserID 平台内对话的用户，访客的会话为空
+	UserID *uuid.UUID `json:"userId,omitempty" gorm:"type:uuid;index"`
+	// EmbedID 通过哪个嵌入密钥产生的会话
+	EmbedID *uuid.UUID `json:"embedId" gorm:"type:uuid;index"`
+	// VisitorID 访客标识，由组件保存在浏览器中
+	VisitorID     string     `json:"visitorId" gorm:"size:64;not null;index"`
+	MessageCount  int64      `json:"messageCount" gorm:"not null;default:0"`
+	LastMessageAt *time.Time `json:"lastMessageAt"`
+}
+
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+type ConversationMessage struct {
+	BaseModel
+	ConversationID uuid.UUID `json:"conversationId" gorm:"type:uuid;not null;index"`
+	Role           string    `json:"role" gorm:"size:20;not null"`
+	Content        string    `json:"content" gorm:"type:text"`
+}
+
+func (ConversationMessage) TableName() string {
+	return "conversation_messages"
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// 数据源类型
+const (
+	DataSourcePostgres = "postgres"
+	DataSourceMysql    = "mysql"
+)
+
+// DataSource 用户登记的外部数据库，database 类型的工具通过它执行只读查询
+type DataSource struct {
+	BaseModel
+	CreatorID   uuid.UUID `json:"creatorId" gorm:"type:uuid;index;not null"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	// Type 数据库类型：postgres、mysql
+	Type string `json:"type" gorm:"size:50;not null"`
+	// Dsn 加密后的连接串，不能返回给前端
+	Dsn string `json:"-" gorm:"type:text;not null"`
+	// Tables 暴露给模型的表，为空表示全部；真正的权限控制请使用只读的数据库账号
+	Tables StringList `json:"tables" gorm:"type:jsonb"`
+	// MaxRows 单次查询返回的最大行数，0 使用默认值
+	MaxRows int `json:"maxRows"`
+	// TimeoutSeconds 单次查询的超时时间，0 使用默认值
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+func (DataSource) TableName() string {
+	return "data_sources"
+}
+
+// StringList 以 jsonb 保存的字符串列表
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(l))
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, l)
+}
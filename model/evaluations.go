@@ -0,0 +1,177 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EvalDataset 评测数据集，一组问题和期望的回答
+type EvalDataset struct {
+	BaseModel
+	CreatorID   uuid.UUID `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+}
+
+func (EvalDataset) TableName() string {
+	return "eval_datasets"
+}
+
+// EvalCase 评测用例
+type EvalCase struct {
+	BaseModel
+	DatasetID      uuid.UUID `json:"datasetId" gorm:"type:uuid;not null;index"`
+	Question       string    `json:"question" gorm:"type:text;not null"`
+	ExpectedAnswer string    `json:"expectedAnswer" gorm:"type:text"`
+	// ExpectedTools 期望调用的工具名称，和顺序无关
+	ExpectedTools StringList `json:"expectedTools" gorm:"type:jsonb"`
+	// Variables 运行agent时的提示词变量
+	Variables JSON `json:"variables" gorm:"type:jsonb"`
+}
+
+func (EvalCase) TableName() string {
+	return "eval_cases"
+}
+
+// EvalScorerType 评分方式
+type EvalScorerType string
+
+const (
+	// ScorerExactMatch 回答和期望回答完全一致，忽略首尾空白
+	ScorerExactMatch EvalScorerType = "exact_match"
+	// ScorerRegex 回答匹配正则，Pattern 为空时使用用例的期望回答作为正则
+	ScorerRegex EvalScorerType = "regex"
+	// ScorerJSONSchema 回答是符合 Schema 的 JSON
+	ScorerJSONSchema EvalScorerType = "json_schema"
+	// ScorerLLMJudge 由模型按评分标准给出 0 到 1 的分数
+	ScorerLLMJudge EvalScorerType = "llm_judge"
+	// ScorerToolCalls 调用了所有期望的工具，分数是调用到的比例
+	ScorerToolCalls EvalScorerType = "tool_calls"
+)
+
+// EvalScorer 一个评分器，只有和 Type 对应的字段有效
+type EvalScorer struct {
+	Type       EvalScorerType `json:"type"`
+	IgnoreCase bool           `json:"ignoreCase,omitempty"`
+	Pattern    string         `json:"pattern,omitempty"`
+	Schema     JSON           `json:"schema,omitempty"`
+	// 评分模型和评分标准
+	ModelProvider   string `json:"modelProvider,omitempty"`
+	ModelName       string `json:"modelName,omitempty"`
+	ModelParameters JSON   `json:"modelParameters,omitempty"`
+	Rubric          string `json:"rubric,omitempty"`
+	// Threshold 分数达到这个值算通过，为 0 时使用 0.5
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+type EvalScorers []*EvalScorer
+
+func (s EvalScorers) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]*EvalScorer{})
+	}
+	return json.Marshal([]*EvalScorer(s))
+}
+
+func (s *EvalScorers) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// EvalRunStatus 评测运行的状态
+type EvalRunStatus string
+
+const (
+	EvalRunPending   EvalRunStatus = "pending"
+	EvalRunRunning   EvalRunStatus = "running"
+	EvalRunCompleted EvalRunStatus = "completed"
+	EvalRunFailed    EvalRunStatus = "failed"
+	EvalRunCanceled  EvalRunStatus = "canceled"
+)
+
+// EvalRun 用数据集评测agent的某个版本
+type EvalRun struct {
+	BaseModel
+	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;not null;index"`
+	DatasetID uuid.UUID `json:"datasetId" gorm:"type:uuid;not null;index"`
+	AgentID   uuid.UUID `json:"agentId" gorm:"type:uuid;not null;index"`
+	// AgentVersion 评测的版本，为 0 表示评测时的草稿配置
+	AgentVersion uint          `json:"agentVersion" gorm:"not null;default:0"`
+	Scorers      EvalScorers   `json:"scorers" gorm:"type:jsonb"`
+	Concurrency  int           `json:"concurrency" gorm:"not null;default:1"`
+	Status       EvalRunStatus `json:"status" gorm:"size:20;not null;index"`
+	// 汇总结果，每完成一个用例更新一次
+	Total        int     `json:"total"`
+	Completed    int     `json:"completed"`
+	Passed       int     `json:"passed"`
+	AvgScore     float64 `json:"avgScore"`
+	AvgLatencyMs int64   `json:"avgLatencyMs"`
+	// Error 整个评测失败的原因，单个用例的错误保存在结果中
+	Error      string     `json:"error" gorm:"type:text"`
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+func (EvalRun) TableName() string {
+	return "eval_runs"
+}
+
+// EvalScore 一个评分器给出的分数
+type EvalScore struct {
+	Type   EvalScorerType `json:"type"`
+	Score  float64        `json:"score"`
+	Passed bool           `json:"passed"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+type EvalScores []*EvalScore
+
+func (s EvalScores) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]*EvalScore{})
+	}
+	return json.Marshal([]*EvalScore(s))
+}
+
+func (s *EvalScores) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// EvalResult 一个用例的评测结果，保存了用例当时的内容，用例修改后结果仍然可以对比
+type EvalResult struct {
+	BaseModel
+	RunID          uuid.UUID  `json:"runId" gorm:"type:uuid;not null;index"`
+	CaseID         uuid.UUID  `json:"caseId" gorm:"type:uuid;not null;index"`
+	Question       string     `json:"question" gorm:"type:text"`
+	ExpectedAnswer string     `json:"expectedAnswer" gorm:"type:text"`
+	Output         string     `json:"output" gorm:"type:text"`
+	ToolCalls      StringList `json:"toolCalls" gorm:"type:jsonb"`
+	Scores         EvalScores `json:"scores" gorm:"type:jsonb"`
+	// Score 所有评分器的平均分，Passed 表示所有评分器都通过
+	Score     float64 `json:"score"`
+	Passed    bool    `json:"passed"`
+	Error     string  `json:"error" gorm:"type:text"`
+	LatencyMs int64   `json:"latencyMs"`
+}
+
+func (EvalResult) TableName() string {
+	return "eval_results"
+}
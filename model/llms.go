@@ -12,6 +12,8 @@ var (
 	OllamaProvider = "Ollama"
 	OpenAIProvider = "openai"
 	QwenProvider   = "qwen"
+	// FakeProvider 内置的确定性模型，不需要配置厂商，用于评测和测试
+	FakeProvider = "fake"
 )
 
 // LLMStatus 定义了模型状态
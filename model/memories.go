@@ -0,0 +1,50 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Memory agent在对话中记住的关于用户的信息，例如偏好和正在进行的项目。
+// 按用户和agent保存，用户可以查看、修改和删除
+type Memory struct {
+	BaseModel
+	UserID  uuid.UUID `json:"userId" gorm:"type:uuid;not null;index:idx_memories_user_agent"`
+	AgentID uuid.UUID `json:"agentId" gorm:"type:uuid;not null;index:idx_memories_user_agent"`
+	Content string    `json:"content" gorm:"type:text;not null"`
+	// ConversationID 从哪个会话中提取，不在会话中的对话为空
+	ConversationID *uuid.UUID `json:"conversationId,omitempty" gorm:"type:uuid"`
+	// Embedding 内容的向量，用户没有配置向量模型时为空，检索时按关键词匹配
+	Embedding Vector `json:"-" gorm:"type:jsonb"`
+	// EmbeddingModel 生成向量的模型，换了模型后旧的向量需要重新生成
+	EmbeddingModel string `json:"-" gorm:"size:200"`
+}
+
+func (Memory) TableName() string {
+	return "memories"
+}
+
+// Vector 以 jsonb 保存的向量
+type Vector []float64
+
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal([]float64(v))
+}
+
+func (v *Vector) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, v)
+}
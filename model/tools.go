@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
@@ -13,17 +14,28 @@ import (
 type ToolType string
 
 const (
-	McpToolType    ToolType = "mcp"
-	SystemToolType          = "system"
+	McpToolType      ToolType = "mcp"
+	SystemToolType            = "system"
+	HttpToolType              = "http"
+	DatabaseToolType          = "database"
+)
+
+// ToolVisibility 工具的可见范围，公开和组织内可见的工具可以被其他用户安装
+type ToolVisibility string
+
+const (
+	ToolPrivate ToolVisibility = "private"
+	ToolPublic  ToolVisibility = "public"
+	ToolOrg     ToolVisibility = "org"
 )
 
 // Tool 定义了工具的模型
 type Tool struct {
 	BaseModel
 	// 添加索引，通常查询工具会根据创建者查询
-	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;index;not null"`
-	// 名称通常需要唯一性或者作为查找条件，限制长度
-	Name        string   `json:"name" gorm:"size:255;not null;index"`
+	CreatorID uuid.UUID `json:"creatorId" gorm:"type:uuid;index;not null;uniqueIndex:idx_tools_creator_name,priority:1,where:deleted_at IS NULL"`
+	// 名称在同一个用户下唯一，不同用户可以同名，删除后可以再次使用
+	Name        string   `json:"name" gorm:"size:255;not null;index;uniqueIndex:idx_tools_creator_name,priority:2"`
 	Description string   `json:"description" gorm:"type:text"`
 	ToolType    ToolType `json:"toolType" gorm:"size:50;not null"`
 	IsEnable    bool     `json:"isEnable" gorm:"default:true"`
@@ -31,6 +43,17 @@ type Tool struct {
 	ParametersSchema ParametersSchema `json:"parametersSchema" gorm:"type:jsonb"`
 	// 指针类型允许存 NULL
 	McpConfig *McpConfig `json:"mcpConfig" gorm:"type:jsonb"`
+	// HttpConfig http 类型工具的请求配置
+	HttpConfig *HttpConfig `json:"httpConfig" gorm:"type:jsonb"`
+	// DataSourceID database 类型工具查询的数据源
+	DataSourceID *uuid.UUID  `json:"dataSourceId" gorm:"type:uuid;index"`
+	DataSource   *DataSource `json:"dataSource,omitempty" gorm:"foreignKey:DataSourceID"`
+	// Visibility 可见范围，默认只有创建者可以使用
+	Visibility ToolVisibility `json:"visibility" gorm:"size:20;not null;default:'private';index"`
+	// OrgID 设置为组织内可见时记录创建者所在的组织
+	OrgID *uuid.UUID `json:"orgId" gorm:"type:uuid;index"`
+	// InstallCount 被其他用户安装的次数
+	InstallCount int64 `json:"installCount" gorm:"not null;default:0"`
 	// 关联关系
 	// 注意：如果你需要在 agent_tools 中存储额外字段（如 Status），
 	// 在 GORM 代码逻辑中可能需要使用 SetupJoinTable，或者将 Many2Many 改为 HasMany AgentTools
@@ -42,15 +65,70 @@ func (Tool) TableName() string {
 	return "tools"
 }
 
+// ToolInstall 用户安装的其他人共享的工具，只记录关联，配置和凭证仍然保存在原工具上
+type ToolInstall struct {
+	UserID    uuid.UUID `json:"userId" gorm:"type:uuid;primaryKey"`
+	ToolID    uuid.UUID `json:"toolId" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+}
+
+func (ToolInstall) TableName() string {
+	return "tool_installs"
+}
+
+// MCP 传输方式
+const (
+	McpTypeSSE            = "sse"
+	McpTypeStreamableHTTP = "streamable_http"
+	McpTypeStdio          = "stdio"
+)
+
+// MCP 凭证类型
+const (
+	McpCredentialBearer = "bearer" // Authorization: Bearer <credential>
+	McpCredentialHeader = "header" // <headerName>: <credential>
+	McpCredentialOAuth2 = "oauth2" // client credentials 模式，credential 为 client secret
+)
+
 type McpConfig struct {
-	// sse 等
+	// 传输方式：sse、streamable_http、stdio
 	Type string `json:"type,omitempty"`
 
 	Url string `json:"url,omitempty"`
 
+	// stdio 方式启动的命令、参数以及环境变量
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
 	AuthenticationRequired bool `json:"authenticationRequired,omitempty"`
 
+	// 凭证类型：bearer、header、oauth2
 	CredentialType string `json:"credentialType,omitempty"`
+	// HeaderName 自定义请求头名称，CredentialType 为 header 时使用
+	HeaderName string `json:"headerName,omitempty"`
+	// OAuth2 client credentials 配置，CredentialType 为 oauth2 时使用
+	OAuth2 *McpOAuth2Config `json:"oauth2,omitempty"`
+	// Credential 加密后的凭证，不能直接返回给前端
+	Credential string `json:"credential,omitempty"`
+}
+
+type McpOAuth2Config struct {
+	TokenUrl string   `json:"tokenUrl"`
+	ClientId string   `json:"clientId"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// Masked 返回隐藏了凭证的副本，用于接口返回
+func (c *McpConfig) Masked() *McpConfig {
+	if c == nil {
+		return nil
+	}
+	masked := *c
+	if masked.Credential != "" {
+		masked.Credential = "******"
+	}
+	return &masked
 }
 
 // Value - 实现 driver.Valuer 接口
@@ -82,6 +160,77 @@ func (c *McpConfig) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, c)
 }
 
+// MaskCredentials 隐藏工具配置中的凭证，用于接口返回
+func (t *Tool) MaskCredentials() {
+	t.McpConfig = t.McpConfig.Masked()
+	t.HttpConfig = t.HttpConfig.Masked()
+}
+
+// HideConfig 隐藏全部配置，返回给安装者时使用，地址、请求头和环境变量中也可能带有敏感信息
+func (t *Tool) HideConfig() {
+	t.McpConfig = nil
+	t.HttpConfig = nil
+	t.DataSource = nil
+	t.DataSourceID = nil
+}
+
+// HTTP 工具的认证方式
+const (
+	HttpAuthBearer = "bearer" // Authorization: Bearer <credential>
+	HttpAuthHeader = "header" // <headerName>: <credential>
+	HttpAuthBasic  = "basic"  // credential 为 username:password
+)
+
+// HttpConfig 用户自定义的HTTP接口，可以手动填写也可以从 OpenAPI 文档导入
+type HttpConfig struct {
+	Method string `json:"method"`
+	// Url 请求地址，路径参数使用 {name} 占位
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// ParamLocations 每个参数所在的位置：path、query、header、body，未指定的放在 body 中
+	ParamLocations map[string]string `json:"paramLocations,omitempty"`
+	// BodyTemplate 请求体模板，{{name}} 会被替换为参数的 JSON 值
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+	// ResponsePath 从 JSON 响应中提取结果的 JSONPath，如 $.data.items
+	ResponsePath string `json:"responsePath,omitempty"`
+	// TimeoutSeconds 请求超时时间，0 使用默认值
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// OperationId 从 OpenAPI 导入时对应的 operationId
+	OperationId string `json:"operationId,omitempty"`
+	// 认证方式：bearer、header、basic
+	AuthType   string `json:"authType,omitempty"`
+	HeaderName string `json:"headerName,omitempty"`
+	// Credential 加密后的凭证，不能直接返回给前端
+	Credential string `json:"credential,omitempty"`
+}
+
+// Masked 返回隐藏了凭证的副本，用于接口返回
+func (c *HttpConfig) Masked() *HttpConfig {
+	if c == nil {
+		return nil
+	}
+	masked := *c
+	if masked.Credential != "" {
+		masked.Credential = "******"
+	}
+	return &masked
+}
+
+func (c HttpConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *HttpConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, c)
+}
+
 type ParametersSchema map[string]*schema.ParameterInfo
 
 // Value - 实现 driver.Valuer 接口，用于将 JSONSchema 存入数据库
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TriggerType 触发方式
+type TriggerType string
+
+const (
+	// TriggerSchedule 按 cron 表达式定时运行
+	TriggerSchedule TriggerType = "schedule"
+	// TriggerWebhook 外部系统调用 webhook 地址运行，需要携带密钥
+	TriggerWebhook TriggerType = "webhook"
+	// TriggerManual 只能手动运行，可以一次提交多条输入批量运行
+	TriggerManual TriggerType = "manual"
+)
+
+// TriggerTargetType 触发后运行的对象
+type TriggerTargetType string
+
+const (
+	TriggerTargetAgent    TriggerTargetType = "agent"
+	TriggerTargetWorkflow TriggerTargetType = "workflow"
+)
+
+// DeliveryType 运行结果的投递方式
+type DeliveryType string
+
+const (
+	DeliveryNone    DeliveryType = ""
+	DeliveryWebhook DeliveryType = "webhook"
+	// DeliveryEmail 发送到触发器创建者的邮箱
+	DeliveryEmail DeliveryType = "email"
+)
+
+// Trigger 触发器，在没有人对话的时候运行agent或工作流
+type Trigger struct {
+	BaseModel
+	CreatorID  uuid.UUID         `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Name       string            `json:"name" gorm:"size:255;not null"`
+	Type       TriggerType       `json:"type" gorm:"size:20;not null"`
+	TargetType TriggerTargetType `json:"targetType" gorm:"size:20;not null"`
+	TargetID   uuid.UUID         `json:"targetId" gorm:"type:uuid;not null"`
+	// InputTemplate 输入模板（Go 模板），可以引用 {{.payload}}、{{.date}}、{{.now}}；
+	// agent 渲染结果作为用户消息，工作流渲染结果必须是 JSON 对象，为空时直接使用 payload
+	InputTemplate string `json:"inputTemplate" gorm:"type:text"`
+	// Cron 定时触发的 cron 表达式，Timezone 为空时使用 UTC
+	Cron     string `json:"cron" gorm:"size:100"`
+	Timezone string `json:"timezone" gorm:"size:64"`
+	// WebhookSecret 加密保存的 webhook 密钥，只在创建和重置时返回明文
+	WebhookSecret string `json:"-" gorm:"type:text"`
+	Enabled       bool   `json:"enabled" gorm:"not null;default:true"`
+	// MaxRetries 运行失败后最多重试的次数
+	MaxRetries   int          `json:"maxRetries" gorm:"not null;default:0"`
+	DeliveryType DeliveryType `json:"deliveryType" gorm:"size:20"`
+	DeliveryUrl  string       `json:"deliveryUrl" gorm:"type:text"`
+	// NextRunAt 定时触发器下一次运行的时间，调度器按这个字段抢占任务
+	NextRunAt *time.Time `json:"nextRunAt" gorm:"index"`
+	LastRunAt *time.Time `json:"lastRunAt"`
+}
+
+func (Trigger) TableName() string {
+	return "triggers"
+}
+
+// TriggerRunStatus 触发运行的状态
+type TriggerRunStatus string
+
+const (
+	TriggerRunPending   TriggerRunStatus = "pending"
+	TriggerRunRunning   TriggerRunStatus = "running"
+	TriggerRunSucceeded TriggerRunStatus = "succeeded"
+	// TriggerRunRetrying 失败后等待重试
+	TriggerRunRetrying TriggerRunStatus = "retrying"
+	TriggerRunFailed   TriggerRunStatus = "failed"
+)
+
+// TriggerRun 触发器的一次运行
+type TriggerRun struct {
+	BaseModel
+	TriggerID uuid.UUID        `json:"triggerId" gorm:"type:uuid;not null;index"`
+	CreatorID uuid.UUID        `json:"creatorId" gorm:"type:uuid;not null"`
+	Source    TriggerType      `json:"source" gorm:"size:20;not null"`
+	Status    TriggerRunStatus `json:"status" gorm:"size:20;not null;index"`
+	// Payload webhook 的请求体或批量运行的单条输入
+	Payload JSON `json:"payload" gorm:"type:jsonb"`
+	// Input 渲染后的输入
+	Input  string `json:"input" gorm:"type:text"`
+	Output string `json:"output" gorm:"type:text"`
+	Error  string `json:"error" gorm:"type:text"`
+	// WorkflowRunID 目标是工作流时对应的工作流运行记录
+	WorkflowRunID *uuid.UUID `json:"workflowRunId" gorm:"type:uuid"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextRetryAt   *time.Time `json:"nextRetryAt" gorm:"index"`
+	// DeliveryError 投递运行结果失败的原因，投递失败不影响运行状态
+	DeliveryError string     `json:"deliveryError" gorm:"type:text"`
+	StartedAt     *time.Time `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt"`
+	// LeaseUntil 执行中的运行的租约到期时间，执行期间定期续约，过期说明执行的实例已经退出
+	LeaseUntil *time.Time `json:"-" gorm:"index"`
+}
+
+func (TriggerRun) TableName() string {
+	return "trigger_runs"
+}
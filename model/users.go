@@ -20,6 +20,8 @@ type User struct {
 	CurrentPlan   SubscriptionPlan `json:"currentPlan" gorm:"type:varchar(20);default:'free'"`
 	Email         string           `json:"email" gorm:"type:varchar(100);uniqueIndex;not null"`
 	EmailVerified bool             `json:"emailVerified" gorm:"type:boolean;default:false"`
+	// OrgID 所属组织，组织内可见的工具只对同一组织的用户开放
+	OrgID *uuid.UUID `json:"orgId" gorm:"type:uuid;index"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,242 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkflowNodeType 工作流节点类型
+type WorkflowNodeType string
+
+const (
+	WorkflowNodeStart     WorkflowNodeType = "start"
+	WorkflowNodeEnd       WorkflowNodeType = "end"
+	WorkflowNodeLLM       WorkflowNodeType = "llm"
+	WorkflowNodeAgent     WorkflowNodeType = "agent"
+	WorkflowNodeTool      WorkflowNodeType = "tool"
+	WorkflowNodeKnowledge WorkflowNodeType = "knowledge"
+	WorkflowNodeCondition WorkflowNodeType = "condition"
+	WorkflowNodeCode      WorkflowNodeType = "code"
+	WorkflowNodeHttp      WorkflowNodeType = "http"
+)
+
+// 工作流变量除了提示词变量的类型之外，还支持对象和数组
+const (
+	VariableTypeObject = "object"
+	VariableTypeArray  = "array"
+)
+
+// Workflow 用户编排的工作流，定义是一个有向无环图
+type Workflow struct {
+	BaseModel
+	CreatorID   uuid.UUID          `json:"creatorId" gorm:"type:uuid;not null;index"`
+	Name        string             `json:"name" gorm:"size:255;not null"`
+	Description string             `json:"description" gorm:"type:text"`
+	Definition  WorkflowDefinition `json:"definition" gorm:"type:jsonb;not null"`
+}
+
+func (Workflow) TableName() string {
+	return "workflows"
+}
+
+// WorkflowDefinition 工作流的节点和连线
+type WorkflowDefinition struct {
+	Nodes []*WorkflowNode `json:"nodes"`
+	Edges []*WorkflowEdge `json:"edges"`
+}
+
+func (d WorkflowDefinition) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *WorkflowDefinition) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// WorkflowNode 工作流中的一个节点，只有和 Type 对应的配置有效
+type WorkflowNode struct {
+	ID   string           `json:"id"`
+	Type WorkflowNodeType `json:"type"`
+	Name string           `json:"name"`
+	// Position 画布上的位置，只给前端使用
+	Position *WorkflowPosition `json:"position,omitempty"`
+	// Inputs 节点的输入，start 节点没有输入
+	Inputs []*WorkflowInput `json:"inputs,omitempty"`
+	// Outputs start 节点定义工作流的参数，code 节点定义脚本的输出，其他节点的输出是固定的
+	Outputs []*WorkflowVariable `json:"outputs,omitempty"`
+
+	LLM       *LLMNodeConfig       `json:"llm,omitempty"`
+	Agent     *AgentNodeConfig     `json:"agent,omitempty"`
+	Tool      *ToolNodeConfig      `json:"tool,omitempty"`
+	Knowledge *KnowledgeNodeConfig `json:"knowledge,omitempty"`
+	Condition *ConditionNodeConfig `json:"condition,omitempty"`
+	Code      *CodeNodeConfig      `json:"code,omitempty"`
+	Http      *HttpNodeConfig      `json:"http,omitempty"`
+}
+
+type WorkflowPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// WorkflowVariable 带类型的变量定义
+type WorkflowVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// WorkflowInput 节点的输入，Ref 引用上游节点的输出（格式为 节点ID.输出名），为空时使用 Value
+type WorkflowInput struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Ref   string `json:"ref,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// WorkflowEdge 节点之间的连线，从条件节点出发的连线需要指定分支
+type WorkflowEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// LLMNodeConfig 直接调用模型，提示词使用 Go 模板引用节点的输入，如 {{.question}}
+type LLMNodeConfig struct {
+	ModelProvider   string `json:"modelProvider"`
+	ModelName       string `json:"modelName"`
+	ModelParameters JSON   `json:"modelParameters,omitempty"`
+	SystemPrompt    string `json:"systemPrompt,omitempty"`
+	Prompt          string `json:"prompt"`
+}
+
+// AgentNodeConfig 调用已发布的agent，输入 message 作为用户消息，其余输入作为提示词变量
+type AgentNodeConfig struct {
+	AgentID uuid.UUID `json:"agentId"`
+}
+
+// ToolNodeConfig 调用工具，节点的输入作为工具参数；MCP工具需要指定服务中的工具名
+type ToolNodeConfig struct {
+	ToolID   uuid.UUID `json:"toolId"`
+	ToolName string    `json:"toolName,omitempty"`
+}
+
+// KnowledgeNodeConfig 知识库检索
+type KnowledgeNodeConfig struct {
+	KnowledgeBaseID uuid.UUID `json:"knowledgeBaseId"`
+	TopK            int       `json:"topK,omitempty"`
+}
+
+// WorkflowBranchElse 条件都不满足时走的分支
+const WorkflowBranchElse = "else"
+
+// ConditionNodeConfig 按顺序判断分支，第一个满足的分支生效，都不满足时走 else
+type ConditionNodeConfig struct {
+	Branches []*ConditionBranch `json:"branches"`
+}
+
+// 多个条件的组合方式
+const (
+	ConditionLogicAnd = "and"
+	ConditionLogicOr  = "or"
+)
+
+type ConditionBranch struct {
+	ID         string               `json:"id"`
+	Logic      string               `json:"logic,omitempty"`
+	Conditions []*WorkflowCondition `json:"conditions"`
+}
+
+// WorkflowCondition Left 是条件节点的输入名，Right 是比较的值
+type WorkflowCondition struct {
+	Left     string `json:"left"`
+	Operator string `json:"operator"`
+	Right    any    `json:"right,omitempty"`
+}
+
+// CodeNodeConfig 在沙箱中执行代码，节点的输入会作为同名变量注入
+type CodeNodeConfig struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// HttpNodeConfig 发送HTTP请求，节点的输入按 ParamLocations 放在路径、查询、请求头或请求体中；
+// 需要认证的接口请创建HTTP工具，凭证由工具加密保存
+type HttpNodeConfig struct {
+	Method         string            `json:"method"`
+	Url            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	ParamLocations map[string]string `json:"paramLocations,omitempty"`
+	BodyTemplate   string            `json:"bodyTemplate,omitempty"`
+	ResponsePath   string            `json:"responsePath,omitempty"`
+	TimeoutSeconds int               `json:"timeoutSeconds,omitempty"`
+}
+
+type WorkflowRunStatus string
+
+const (
+	WorkflowRunRunning   WorkflowRunStatus = "running"
+	WorkflowRunSucceeded WorkflowRunStatus = "succeeded"
+	WorkflowRunFailed    WorkflowRunStatus = "failed"
+)
+
+// WorkflowRun 一次运行的记录
+type WorkflowRun struct {
+	BaseModel
+	WorkflowID uuid.UUID         `json:"workflowId" gorm:"type:uuid;not null;index"`
+	CreatorID  uuid.UUID         `json:"creatorId" gorm:"type:uuid;not null"`
+	Status     WorkflowRunStatus `json:"status" gorm:"size:20;not null"`
+	Inputs     JSON              `json:"inputs" gorm:"type:jsonb"`
+	Outputs    JSON              `json:"outputs" gorm:"type:jsonb"`
+	Error      string            `json:"error" gorm:"type:text"`
+	// Nodes 每个节点的执行结果，按开始时间排序
+	Nodes      WorkflowNodeRuns `json:"nodes" gorm:"type:jsonb"`
+	FinishedAt *time.Time       `json:"finishedAt"`
+}
+
+func (WorkflowRun) TableName() string {
+	return "workflow_runs"
+}
+
+type WorkflowNodeRun struct {
+	NodeID    string            `json:"nodeId"`
+	NodeType  WorkflowNodeType  `json:"nodeType"`
+	Status    WorkflowRunStatus `json:"status"`
+	Outputs   map[string]any    `json:"outputs,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	StartedAt time.Time         `json:"startedAt"`
+	ElapsedMs int64             `json:"elapsedMs"`
+}
+
+type WorkflowNodeRuns []*WorkflowNodeRun
+
+func (r WorkflowNodeRuns) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *WorkflowNodeRuns) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+	}
+	return json.Unmarshal(bytes, r)
+}